@@ -74,10 +74,77 @@ git)
 		git commit --quiet -m changed
 	)
 
+	checkout_origin "$origin_directory"
+	;;
+definition)
+	initialize_origin
+	(
+		cd "$origin_directory"
+		sed 's/echo unrelated/echo changed/' pkg/BUILD.yaml >pkg/BUILD.yaml.new
+		mv pkg/BUILD.yaml.new pkg/BUILD.yaml
+		git add .
+		git commit --quiet -m definition
+	)
+
+	checkout_origin "$origin_directory"
+	;;
+starlark)
+	# Only the targets of the BUILD file that loads the edited module are
+	# affected, not the other targets of the workspace
+	mkdir -p "$origin_directory/macros"
+	cat >"$origin_directory/macros/defs.star" <<'STAR'
+def echo_target(name):
+    target(name = name, command = "echo " + name)
+STAR
+	cat >"$origin_directory/macros/BUILD.star" <<'STAR'
+load("//macros/defs.star", "echo_target")
+
+echo_target("generated")
+STAR
+	initialize_origin
+	(
+		cd "$origin_directory"
+		sed 's/"echo "/"echo changed "/' macros/defs.star >macros/defs.star.new
+		mv macros/defs.star.new macros/defs.star
+		git add .
+		git commit --quiet -m starlark
+	)
+
+	checkout_origin "$origin_directory"
+	;;
+environment)
+	# The base revision is loaded with the environment variables of its own
+	# grog.toml, so changing a value reports the targets that read it
+	printf '[environment_variables]\nGREETING = "hello"\n' >>"$origin_directory/grog.toml"
+	mkdir -p "$origin_directory/env"
+	cat >"$origin_directory/env/BUILD.star" <<'STAR'
+target(name = "greet", command = "echo " + GREETING)
+target(name = "static", command = "echo static")
+STAR
+	initialize_origin
+	(
+		cd "$origin_directory"
+		sed 's/"hello"/"bye"/' grog.toml >grog.toml.new
+		mv grog.toml.new grog.toml
+		git add .
+		git commit --quiet -m environment
+	)
+
+	checkout_origin "$origin_directory"
+	;;
+config)
+	initialize_origin
+	(
+		cd "$origin_directory"
+		printf 'fail_fast = true\n' >>grog.toml
+		git add .
+		git commit --quiet -m config
+	)
+
 	checkout_origin "$origin_directory"
 	;;
 *)
-	printf 'usage: %s jj|dirty|git\n' "$0" >&2
+	printf 'usage: %s jj|dirty|git|definition|starlark|environment|config\n' "$0" >&2
 	exit 2
 	;;
 esac
@@ -0,0 +1,19 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+# Creates a grog root at the given path whose trace history cannot be read
+grog_root="${1:-}"
+if [[ -z "$grog_root" ]]; then
+	printf 'usage: %s <grog root>\n' "$0" >&2
+	exit 2
+fi
+if [[ -z "${GROGTEST_CLEANUP_FILE:-}" ]]; then
+	printf 'GROGTEST_CLEANUP_FILE must be set\n' >&2
+	exit 2
+fi
+
+echo "$grog_root" >"$GROGTEST_CLEANUP_FILE"
+for kind in builds spans; do
+	mkdir -p "$grog_root/cache/traces/$kind/date=2026-01-01"
+	printf 'not a parquet file\n' >"$grog_root/cache/traces/$kind/date=2026-01-01/corrupt.parquet"
+done
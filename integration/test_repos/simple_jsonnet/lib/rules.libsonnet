@@ -0,0 +1,8 @@
+{
+  // echo_target writes a greeting into an output file named after the target.
+  echo_target(name, extra={}):: {
+    name: name,
+    command: "echo 'hello from " + name + "' > " + name + ".txt",
+    outputs: [name + ".txt"],
+  } + extra,
+}
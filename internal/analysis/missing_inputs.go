@@ -0,0 +1,103 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"grog/internal/config"
+	"grog/internal/console"
+	"grog/internal/dag"
+	"grog/internal/model"
+	"grog/internal/output/handlers"
+)
+
+// CheckMissingInputs reports literal input paths that do not exist in the
+// workspace. Globs that match nothing are not reported and neither are paths
+// that are declared as an output of some target since those only exist once the
+// producer has run.
+// The severity is determined per target (see Target.GetMissingInputsSeverity):
+// warnings are logged and errors are returned.
+func CheckMissingInputs(logger *console.Logger, graph *dag.DirectedTargetGraph) (errs []error) {
+	var targets []*model.Target
+	fileOutputs := make(map[string]bool)
+	var dirOutputs []string
+	for _, node := range graph.GetNodes().NodesAlphabetically() {
+		target, ok := node.(*model.Target)
+		if !ok {
+			continue
+		}
+		targets = append(targets, target)
+
+		for _, output := range target.AllOutputs() {
+			switch output.Type {
+			case string(handlers.OCIHandler):
+				continue
+			case string(handlers.DirHandler):
+				dirOutputs = append(dirOutputs, cleanOutputPath(target, output.Identifier))
+			default:
+				fileOutputs[cleanOutputPath(target, output.Identifier)] = true
+			}
+		}
+	}
+
+	isDeclaredOutput := func(inputPath string) bool {
+		if fileOutputs[inputPath] {
+			return true
+		}
+		for _, dir := range dirOutputs {
+			if pathWithin(inputPath, dir) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, target := range targets {
+		severity := target.GetMissingInputsSeverity()
+		if severity == config.SeverityIgnore {
+			continue
+		}
+
+		for _, input := range target.UnresolvedInputs {
+			if strings.ContainsAny(input, "*?[{") {
+				continue
+			}
+
+			inputPath := filepath.Clean(filepath.Join(target.Label.Package, input))
+			if isDeclaredOutput(inputPath) {
+				continue
+			}
+
+			_, err := os.Stat(config.GetPathAbsoluteToWorkspaceRoot(inputPath))
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("target %s: could not check input %q: %w", target.Label, input, err))
+				continue
+			}
+
+			message := fmt.Sprintf("target %s (%s) declares input %q which does not exist", target.Label, displaySourceFile(target), input)
+			if severity == config.SeverityError {
+				errs = append(errs, errors.New(message))
+			} else {
+				logger.Warnf("%s", message)
+			}
+		}
+	}
+
+	return errs
+}
+
+// displaySourceFile returns the BUILD file that defines the target relative to
+// the workspace root.
+func displaySourceFile(target *model.Target) string {
+	relativePath, err := config.GetPathRelativeToWorkspaceRoot(target.SourceFilePath)
+	if err != nil {
+		return target.SourceFilePath
+	}
+	return relativePath
+}
@@ -0,0 +1,67 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"grog/internal/config"
+	"grog/internal/console"
+	"grog/internal/label"
+	"grog/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCheckMissingInputs(t *testing.T) {
+	workspaceRoot, cleanup := setupWorkspaceRoot(t)
+	defer cleanup()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(workspaceRoot, "pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(workspaceRoot, "pkg", "main.go"), []byte("package main"), 0644))
+
+	nodes := model.BuildNodeMap{
+		label.TL("pkg", "build"): &model.Target{
+			Label:            label.TL("pkg", "build"),
+			SourceFilePath:   filepath.Join(workspaceRoot, "pkg", "BUILD.yaml"),
+			UnresolvedInputs: []string{"main.go", "mian.go", "**/*.txt", "gen/api.go", "dist/bundle.js"},
+		},
+		label.TL("pkg", "strict"): &model.Target{
+			Label:            label.TL("pkg", "strict"),
+			SourceFilePath:   filepath.Join(workspaceRoot, "pkg", "BUILD.yaml"),
+			UnresolvedInputs: []string{"missing.go"},
+			MissingInputs:    "error",
+		},
+		label.TL("pkg", "lenient"): &model.Target{
+			Label:            label.TL("pkg", "lenient"),
+			UnresolvedInputs: []string{"missing.go"},
+			MissingInputs:    "ignore",
+		},
+		label.TL("pkg", "gen"): &model.Target{
+			Label:   label.TL("pkg", "gen"),
+			Outputs: mustParseOutputs([]string{"gen/api.go", "dir::dist"}),
+		},
+	}
+	graph, err := BuildGraph(nodes)
+	require.NoError(t, err)
+
+	observedZapCore, observedLogs := observer.New(zap.WarnLevel)
+	logger := console.NewFromSugared(zap.New(observedZapCore).Sugar(), zapcore.WarnLevel)
+
+	errs := CheckMissingInputs(logger, graph)
+	require.Len(t, errs, 1)
+	assert.Equal(t, `target //pkg:strict (pkg/BUILD.yaml) declares input "missing.go" which does not exist`, errs[0].Error())
+
+	require.Equal(t, 1, observedLogs.Len())
+	assert.Equal(t, `target //pkg:build (pkg/BUILD.yaml) declares input "mian.go" which does not exist`, observedLogs.All()[0].Message)
+
+	config.Global.MissingInputs = "ignore"
+	defer func() { config.Global.MissingInputs = "" }()
+	errs = CheckMissingInputs(logger, graph)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, 1, observedLogs.Len())
+}
@@ -0,0 +1,161 @@
+package analysis
+
+import (
+	"fmt"
+
+	"grog/internal/config"
+	"grog/internal/label"
+	"grog/internal/model"
+	"grog/internal/selection"
+)
+
+// PolicyViolation is a dependency edge that breaks a workspace policy.
+type PolicyViolation struct {
+	Policy     config.PolicyConfig
+	Dependant  label.TargetLabel
+	Dependency label.TargetLabel
+	// DeclaredAs is the label the dependency was declared as, which differs
+	// from Dependency when it was declared through an alias.
+	DeclaredAs label.TargetLabel
+}
+
+func (v PolicyViolation) Error() string {
+	message := fmt.Sprintf("%s depends on %s%s which violates policy %s",
+		v.Dependant, v.Dependency, v.via(), v.Policy.Name)
+	if v.Policy.Description != "" {
+		message += ": " + v.Policy.Description
+	}
+	return message
+}
+
+// Edge describes the violating dependency, e.g. "//libs/a:a -> //apps/b:b".
+func (v PolicyViolation) Edge() string {
+	return fmt.Sprintf("%s -> %s%s", v.Dependant, v.Dependency, v.via())
+}
+
+func (v PolicyViolation) via() string {
+	if v.DeclaredAs == v.Dependency {
+		return ""
+	}
+	return fmt.Sprintf(" (via alias %s)", v.DeclaredAs)
+}
+
+type policy struct {
+	config config.PolicyConfig
+	from   *selection.Selector
+	to     *selection.Selector
+}
+
+// violatedBy reports whether the edge from dependant to dependency breaks
+// the policy. Allow policies never apply to edges within the protected set
+// so that e.g. third party targets may still depend on each other.
+func (p policy) violatedBy(dependant model.BuildNode, dependency model.BuildNode) bool {
+	if !policyMatches(p.to, dependency) {
+		return false
+	}
+	if p.config.Effect == config.PolicyEffectAllow {
+		return !policyMatches(p.from, dependant) && !policyMatches(p.to, dependant)
+	}
+	return policyMatches(p.from, dependant)
+}
+
+// policyMatches reports whether node belongs to the side of a policy. Only
+// targets have tags and a type, so other nodes such as resources never match
+// a side that filters on them.
+func policyMatches(selector *selection.Selector, node model.BuildNode) bool {
+	if _, isTarget := node.(*model.Target); !isTarget &&
+		(len(selector.Tags) > 0 || len(selector.ExcludeTags) > 0 || selector.TargetType != selection.AllTargets) {
+		return false
+	}
+	return selector.MatchFilters(node)
+}
+
+func newPolicySelector(matcher config.PolicyMatcherConfig) (*selection.Selector, error) {
+	patterns, err := label.ParsePatterns("", matcher.Patterns)
+	if err != nil {
+		return nil, err
+	}
+	targetType := selection.AllTargets
+	if matcher.Type != "" {
+		targetType, err = selection.StringToTargetTypeSelection(matcher.Type)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return selection.New(patterns, matcher.Tags, matcher.ExcludeTags, targetType), nil
+}
+
+// CheckPolicies evaluates the policies over every dependency edge of the
+// graph and returns the violating edges in alphabetical order of the
+// dependant. Dependencies on aliases are checked against the target the
+// alias resolves to.
+func CheckPolicies(nodeMap model.BuildNodeMap, policyConfigs []config.PolicyConfig) ([]PolicyViolation, error) {
+	if err := config.ValidatePolicies(policyConfigs); err != nil {
+		return nil, err
+	}
+
+	policies := make([]policy, 0, len(policyConfigs))
+	for _, policyConfig := range policyConfigs {
+		from, err := newPolicySelector(policyConfig.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy %s: from: %w", policyConfig.Name, err)
+		}
+		to, err := newPolicySelector(policyConfig.To)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy %s: to: %w", policyConfig.Name, err)
+		}
+		policies = append(policies, policy{config: policyConfig, from: from, to: to})
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	var violations []PolicyViolation
+	for _, node := range nodeMap.NodesAlphabetically() {
+		if node.GetType() == model.AliasNode {
+			continue
+		}
+
+		for _, dependencyLabel := range node.GetDependencies() {
+			var dependency model.BuildNode = nodeMap[dependencyLabel]
+			if dependency == nil {
+				continue
+			}
+			if dependency.GetType() == model.AliasNode {
+				dependencyTarget := resolveDependencyTarget(nodeMap, dependencyLabel)
+				if dependencyTarget == nil {
+					continue
+				}
+				dependency = dependencyTarget
+			}
+
+			for _, p := range policies {
+				if p.violatedBy(node, dependency) {
+					violations = append(violations, PolicyViolation{
+						Policy:     p.config,
+						Dependant:  node.GetLabel(),
+						Dependency: dependency.GetLabel(),
+						DeclaredAs: dependencyLabel,
+					})
+				}
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// checkPolicies reports every edge that violates a policy of the workspace
+// config.
+func checkPolicies(nodeMap model.BuildNodeMap) []error {
+	violations, err := CheckPolicies(nodeMap, config.Global.Policies)
+	if err != nil {
+		return []error{err}
+	}
+
+	errs := make([]error, 0, len(violations))
+	for _, violation := range violations {
+		errs = append(errs, violation)
+	}
+	return errs
+}
@@ -0,0 +1,174 @@
+package analysis
+
+import (
+	"testing"
+
+	"grog/internal/config"
+	"grog/internal/label"
+	"grog/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPolicies(t *testing.T) {
+	libsMustNotDependOnApps := config.PolicyConfig{
+		Name:        "layering",
+		Description: "libraries must not depend on apps",
+		Effect:      config.PolicyEffectDeny,
+		From:        config.PolicyMatcherConfig{Patterns: []string{"//libs/..."}},
+		To:          config.PolicyMatcherConfig{Patterns: []string{"//apps/..."}},
+	}
+	onlyToolsUseThirdParty := config.PolicyConfig{
+		Name:   "third_party",
+		Effect: config.PolicyEffectAllow,
+		From:   config.PolicyMatcherConfig{Patterns: []string{"//tools/..."}},
+		To:     config.PolicyMatcherConfig{Patterns: []string{"//third_party/..."}},
+	}
+	noTestDependencies := config.PolicyConfig{
+		Name:   "no_test_dependencies",
+		Effect: config.PolicyEffectDeny,
+		From:   config.PolicyMatcherConfig{Type: "no_test"},
+		To:     config.PolicyMatcherConfig{Type: "test"},
+	}
+	noDeprecated := config.PolicyConfig{
+		Name:   "deprecated",
+		Effect: config.PolicyEffectDeny,
+		From:   config.PolicyMatcherConfig{ExcludeTags: []string{"legacy"}},
+		To:     config.PolicyMatcherConfig{Tags: []string{"deprecated"}},
+	}
+
+	app := &model.Target{Label: label.TL("apps/web", "web")}
+	appTest := &model.Target{Label: label.TL("apps/web", "web_test")}
+	vendored := &model.Target{Label: label.TL("third_party/zlib", "zlib")}
+	vendoredHeaders := &model.Target{Label: label.TL("third_party/zlib", "headers")}
+	old := &model.Target{Label: label.TL("libs/old", "old"), Tags: []string{"deprecated"}}
+	alias := &model.Alias{Label: label.TL("apps", "web"), Actual: app.Label}
+	db := &model.Resource{Label: label.TL("infra", "db")}
+	onlyToolsUseThirdPartyTags := config.PolicyConfig{
+		Name:   "third_party_tags",
+		Effect: config.PolicyEffectAllow,
+		From:   config.PolicyMatcherConfig{Patterns: []string{"//tools/..."}},
+		To:     config.PolicyMatcherConfig{Tags: []string{"third_party"}},
+	}
+
+	tests := []struct {
+		name               string
+		policies           []config.PolicyConfig
+		dependant          model.BuildNode
+		expectedViolations []string
+	}{
+		{
+			name:      "deny",
+			policies:  []config.PolicyConfig{libsMustNotDependOnApps},
+			dependant: &model.Target{Label: label.TL("libs/core", "core"), Dependencies: []label.TargetLabel{app.Label, vendored.Label}},
+			expectedViolations: []string{
+				"//libs/core:core depends on //apps/web:web which violates policy layering: libraries must not depend on apps",
+			},
+		},
+		{
+			name:      "deny resolves aliases",
+			policies:  []config.PolicyConfig{libsMustNotDependOnApps},
+			dependant: &model.Target{Label: label.TL("libs/core", "core"), Dependencies: []label.TargetLabel{alias.Label}},
+			expectedViolations: []string{
+				"//libs/core:core depends on //apps/web:web (via alias //apps:web) which violates policy layering",
+			},
+		},
+		{
+			name:      "allow permits matching dependants",
+			policies:  []config.PolicyConfig{onlyToolsUseThirdParty},
+			dependant: &model.Target{Label: label.TL("tools/compress", "compress"), Dependencies: []label.TargetLabel{vendored.Label}},
+		},
+		{
+			name:      "allow permits edges within the protected set",
+			policies:  []config.PolicyConfig{onlyToolsUseThirdParty},
+			dependant: &model.Target{Label: label.TL("third_party/png", "png"), Dependencies: []label.TargetLabel{vendored.Label}},
+		},
+		{
+			name:      "allow reports every other dependant",
+			policies:  []config.PolicyConfig{onlyToolsUseThirdParty},
+			dependant: &model.Resource{Label: label.TL("apps/web", "db"), Dependencies: []label.TargetLabel{vendored.Label, vendoredHeaders.Label}},
+			expectedViolations: []string{
+				"//apps/web:db depends on //third_party/zlib:zlib which violates policy third_party",
+				"//apps/web:db depends on //third_party/zlib:headers which violates policy third_party",
+			},
+		},
+		{
+			name:      "target types",
+			policies:  []config.PolicyConfig{noTestDependencies},
+			dependant: &model.Target{Label: label.TL("apps/web", "bundle"), Dependencies: []label.TargetLabel{app.Label, appTest.Label}},
+			expectedViolations: []string{
+				"//apps/web:bundle depends on //apps/web:web_test which violates policy no_test_dependencies",
+			},
+		},
+		{
+			name:      "tests may depend on tests",
+			policies:  []config.PolicyConfig{noTestDependencies},
+			dependant: &model.Target{Label: label.TL("apps/web", "e2e_test"), Dependencies: []label.TargetLabel{appTest.Label}},
+		},
+		{
+			name:      "tags",
+			policies:  []config.PolicyConfig{noDeprecated},
+			dependant: &model.Target{Label: label.TL("apps/web", "bundle"), Dependencies: []label.TargetLabel{old.Label}},
+			expectedViolations: []string{
+				"//apps/web:bundle depends on //libs/old:old which violates policy deprecated",
+			},
+		},
+		{
+			name:      "excluded tags",
+			policies:  []config.PolicyConfig{noDeprecated},
+			dependant: &model.Target{Label: label.TL("apps/web", "bundle"), Tags: []string{"legacy"}, Dependencies: []label.TargetLabel{old.Label}},
+		},
+		{
+			name:      "resources never match tags or types",
+			policies:  []config.PolicyConfig{noTestDependencies, noDeprecated, onlyToolsUseThirdPartyTags},
+			dependant: &model.Target{Label: label.TL("libs/core", "core"), Dependencies: []label.TargetLabel{db.Label}},
+		},
+		{
+			name:      "every violated policy is reported",
+			policies:  []config.PolicyConfig{libsMustNotDependOnApps, noTestDependencies},
+			dependant: &model.Target{Label: label.TL("libs/core", "core"), Dependencies: []label.TargetLabel{appTest.Label}},
+			expectedViolations: []string{
+				"//libs/core:core depends on //apps/web:web_test which violates policy layering",
+				"//libs/core:core depends on //apps/web:web_test which violates policy no_test_dependencies",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeMap := model.BuildNodeMap{
+				app.Label:               app,
+				appTest.Label:           appTest,
+				vendored.Label:          vendored,
+				vendoredHeaders.Label:   vendoredHeaders,
+				old.Label:               old,
+				alias.Label:             alias,
+				db.Label:                db,
+				tt.dependant.GetLabel(): tt.dependant,
+			}
+
+			violations, err := CheckPolicies(nodeMap, tt.policies)
+			require.NoError(t, err)
+			require.Len(t, violations, len(tt.expectedViolations), "%v", violations)
+			for i, expected := range tt.expectedViolations {
+				assert.Contains(t, violations[i].Error(), expected)
+			}
+		})
+	}
+}
+
+func TestCheckPoliciesRejectsDuplicateNames(t *testing.T) {
+	policy := config.PolicyConfig{Name: "layering", Effect: config.PolicyEffectDeny}
+	_, err := CheckPolicies(model.BuildNodeMap{}, []config.PolicyConfig{policy, policy})
+	require.ErrorContains(t, err, "invalid policy layering: name is not unique")
+}
+
+func TestPolicyViolationEdge(t *testing.T) {
+	violation := PolicyViolation{
+		Dependant:  label.TL("libs/core", "core"),
+		Dependency: label.TL("apps/web", "web"),
+		DeclaredAs: label.TL("apps", "web"),
+	}
+	assert.Equal(t, "//libs/core:core -> //apps/web:web (via alias //apps:web)", violation.Edge())
+}
@@ -18,8 +18,9 @@ import (
 We need to check the following four constraints for the paths defined by each target:
 1. all inputs must be relative to the package path
 2. all outputs point to files within the repository
-TODO 3. warn if a target's inputs intersect with another target's outputs without them explicitly depending on each other
-TODO 4. error if a target's inputs intersect with its own outputs?
+3. warn if a target's inputs intersect with another target's outputs without them explicitly depending on each other
+4. warn if a target's inputs intersect with its own outputs
+(3. and 4. are implemented in CheckUndeclaredDependencies and their severity is configurable)
 
 TODO: We don't yet check that a parent package does not include inputs from children. Should we?
 */
@@ -58,6 +59,12 @@ func CheckTargetConstraints(logger *console.Logger, nodeMap model.BuildNodeMap)
 	resourceConstraintErrors := checkResourceConstraints(nodeMap)
 	errs = append(errs, resourceConstraintErrors...)
 
+	visibilityErrors := checkVisibility(nodeMap)
+	errs = append(errs, visibilityErrors...)
+
+	policyErrors := checkPolicies(nodeMap)
+	errs = append(errs, policyErrors...)
+
 	return errs
 }
 
@@ -0,0 +1,253 @@
+package analysis
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"grog/internal/config"
+	"grog/internal/console"
+	"grog/internal/dag"
+	"grog/internal/label"
+	"grog/internal/model"
+	"grog/internal/output/handlers"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+type producerConsumerPair struct {
+	consumer label.TargetLabel
+	producer label.TargetLabel
+}
+
+// CheckUndeclaredDependencies reports targets whose inputs intersect with the
+// outputs of another target that is not among their (transitive) dependencies,
+// as well as targets that read their own outputs.
+// Both literal and globbed inputs are matched against file and dir:: outputs.
+// Depending on the severity the findings are logged as warnings or returned as errors.
+func CheckUndeclaredDependencies(
+	logger *console.Logger,
+	graph *dag.DirectedTargetGraph,
+	severity config.Severity,
+) []error {
+	if severity == config.SeverityIgnore {
+		return nil
+	}
+
+	var fileOutputs []outputRecord
+	var dirOutputs []outputRecord
+	var targets []*model.Target
+	for _, node := range graph.GetNodes().NodesAlphabetically() {
+		target, ok := node.(*model.Target)
+		if !ok {
+			continue
+		}
+		targets = append(targets, target)
+
+		for _, output := range target.AllOutputs() {
+			record := outputRecord{
+				target: target,
+				output: output,
+				path:   cleanOutputPath(target, output.Identifier),
+			}
+			switch output.Type {
+			case string(handlers.OCIHandler):
+				// Images are not files and cannot be read as inputs
+				continue
+			case string(handlers.DirHandler):
+				dirOutputs = append(dirOutputs, record)
+			default:
+				fileOutputs = append(fileOutputs, record)
+			}
+		}
+	}
+
+	// Literal inputs are looked up by path and globs only scan the outputs
+	// below their base directory so that large graphs stay fast
+	fileOutputsByPath := make(map[string][]outputRecord, len(fileOutputs))
+	for _, record := range fileOutputs {
+		fileOutputsByPath[record.path] = append(fileOutputsByPath[record.path], record)
+	}
+	sort.SliceStable(fileOutputs, func(i, j int) bool {
+		return filepath.ToSlash(fileOutputs[i].path) < filepath.ToSlash(fileOutputs[j].path)
+	})
+
+	reported := make(map[producerConsumerPair]bool)
+	ancestorCache := make(map[label.TargetLabel]map[label.TargetLabel]struct{})
+	var violations []string
+
+	report := func(consumer *model.Target, producer outputRecord, input string) {
+		pair := producerConsumerPair{consumer: consumer.Label, producer: producer.target.Label}
+		if reported[pair] {
+			return
+		}
+
+		if consumer.Label == producer.target.Label {
+			// Declaring an existing source file as an output (e.g. bin_output: tool.sh)
+			// passes it through rather than reading something the target produced.
+			if producer.output.Type != string(handlers.DirHandler) && declaresLiteralInput(consumer, producer.path) {
+				return
+			}
+			reported[pair] = true
+			violations = append(violations, fmt.Sprintf("target %s reads its own output %q via input %q", consumer.Label, producer.path, input))
+			return
+		}
+
+		if _, isAncestor := getAncestorSet(graph, consumer, ancestorCache)[producer.target.Label]; isAncestor {
+			return
+		}
+		reported[pair] = true
+		violations = append(violations, fmt.Sprintf("target %s reads output %q of %s via input %q but does not depend on it", consumer.Label, producer.path, producer.target.Label, input))
+	}
+
+	for _, target := range targets {
+		for _, input := range target.Inputs {
+			inputPath := filepath.Clean(filepath.Join(target.Label.Package, input))
+			for _, record := range fileOutputsByPath[inputPath] {
+				report(target, record, input)
+			}
+			for _, record := range dirOutputs {
+				if pathWithin(inputPath, record.path) {
+					report(target, record, input)
+				}
+			}
+		}
+
+		// Outputs usually do not exist at load time so the resolved inputs
+		// alone would miss globs that only match once the producer has run.
+		for _, input := range target.UnresolvedInputs {
+			if !strings.ContainsAny(input, "*?[{") {
+				continue
+			}
+			pattern := filepath.ToSlash(filepath.Join(target.Label.Package, input))
+			for _, record := range outputsUnder(fileOutputs, pattern) {
+				if matched, _ := doublestar.Match(pattern, filepath.ToSlash(record.path)); matched && !isExcludedInput(target, record.path, false) {
+					report(target, record, input)
+				}
+			}
+			for _, record := range dirOutputs {
+				if globMayMatchWithin(pattern, filepath.ToSlash(record.path)) && !isExcludedInput(target, record.path, true) {
+					report(target, record, input)
+				}
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	sort.Strings(violations)
+
+	if severity == config.SeverityWarn {
+		for _, violation := range violations {
+			logger.Warnf("%s", violation)
+		}
+		return nil
+	}
+
+	errs := make([]error, 0, len(violations))
+	for _, violation := range violations {
+		errs = append(errs, fmt.Errorf("%s", violation))
+	}
+	return errs
+}
+
+// outputsUnder returns the records that can match the glob pattern, i.e. the
+// ones below its base directory. records must be sorted by slash separated path.
+func outputsUnder(records []outputRecord, pattern string) []outputRecord {
+	base, _ := doublestar.SplitPattern(pattern)
+	if base == "." || base == "" {
+		return records
+	}
+	prefix := base + "/"
+	start := sort.Search(len(records), func(i int) bool {
+		return filepath.ToSlash(records[i].path) >= prefix
+	})
+	end := start
+	for end < len(records) && strings.HasPrefix(filepath.ToSlash(records[end].path), prefix) {
+		end++
+	}
+	return records[start:end]
+}
+
+// declaresLiteralInput returns true if the target lists the workspace relative
+// path as a non-glob input.
+func declaresLiteralInput(target *model.Target, path string) bool {
+	for _, input := range target.UnresolvedInputs {
+		if strings.ContainsAny(input, "*?[{") {
+			continue
+		}
+		if filepath.Clean(filepath.Join(target.Label.Package, input)) == path {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcludedInput returns true if the exclude_inputs of the target exclude the
+// workspace relative output path. Directories only count as excluded if a
+// pattern ending in ** covers everything inside them.
+func isExcludedInput(target *model.Target, path string, isDir bool) bool {
+	path = filepath.ToSlash(path)
+	for _, exclude := range target.ExcludeInputs {
+		pattern := filepath.ToSlash(filepath.Join(target.Label.Package, exclude))
+		if !isDir {
+			if matched, _ := doublestar.Match(pattern, path); matched {
+				return true
+			}
+			continue
+		}
+		if !strings.HasSuffix(pattern, "**") {
+			continue
+		}
+		if matched, _ := doublestar.Match(pattern, path+"/file"); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// globMayMatchWithin returns true if the glob pattern can match a file inside dir.
+func globMayMatchWithin(pattern, dir string) bool {
+	base, rest := doublestar.SplitPattern(pattern)
+	if base == "." {
+		base = ""
+	}
+
+	// The whole glob lives inside the directory
+	if base != "" && pathWithin(base, dir) {
+		return true
+	}
+
+	var relativeDir string
+	switch {
+	case base == "":
+		relativeDir = dir
+	case pathWithin(dir, base):
+		relativeDir = strings.TrimPrefix(strings.TrimPrefix(dir, base), "/")
+	default:
+		return false
+	}
+
+	// Walk the directory components alongside the pattern components: the glob
+	// reaches into dir if every component matches and either a ** is hit or
+	// the pattern has components left to match files below dir.
+	patternSegments := strings.Split(rest, "/")
+	var dirSegments []string
+	if relativeDir != "" {
+		dirSegments = strings.Split(relativeDir, "/")
+	}
+	for i, dirSegment := range dirSegments {
+		if i >= len(patternSegments) {
+			return false
+		}
+		if patternSegments[i] == "**" {
+			return true
+		}
+		if matched, _ := doublestar.Match(patternSegments[i], dirSegment); !matched {
+			return false
+		}
+	}
+	return len(dirSegments) < len(patternSegments)
+}
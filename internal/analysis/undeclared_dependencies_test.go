@@ -0,0 +1,169 @@
+package analysis
+
+import (
+	"testing"
+
+	"grog/internal/config"
+	"grog/internal/console"
+	"grog/internal/label"
+	"grog/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCheckUndeclaredDependencies(t *testing.T) {
+	tests := []struct {
+		name          string
+		targets       []*model.Target
+		expectedCount int
+		expectedText  string
+	}{
+		{
+			name: "literal input of undeclared producer",
+			targets: []*model.Target{
+				{Label: label.TL("gen", "proto"), Outputs: mustParseOutputs([]string{"out/api.pb.go"})},
+				{Label: label.TL("", "build"), Inputs: []string{"gen/out/api.pb.go"}},
+			},
+			expectedCount: 1,
+			expectedText:  "target //:build reads output \"gen/out/api.pb.go\" of //gen:proto",
+		},
+		{
+			name: "declared dependency is fine",
+			targets: []*model.Target{
+				{Label: label.TL("", "gen"), Outputs: mustParseOutputs([]string{"gen/api.go"})},
+				{Label: label.TL("", "mid"), Dependencies: []label.TargetLabel{label.TL("", "gen")}},
+				{
+					Label:            label.TL("", "build"),
+					Inputs:           []string{"gen/api.go"},
+					UnresolvedInputs: []string{"gen/**/*.go"},
+					Dependencies:     []label.TargetLabel{label.TL("", "mid")},
+				},
+			},
+			expectedCount: 0,
+		},
+		{
+			name: "glob matches output that does not exist yet",
+			targets: []*model.Target{
+				{Label: label.TL("", "gen"), Outputs: mustParseOutputs([]string{"src/generated.go"})},
+				{Label: label.TL("", "build"), UnresolvedInputs: []string{"src/*.go"}},
+			},
+			expectedCount: 1,
+			expectedText:  "via input \"src/*.go\"",
+		},
+		{
+			name: "glob only matches outputs below its base directory",
+			targets: []*model.Target{
+				{Label: label.TL("", "gen"), Outputs: mustParseOutputs([]string{"src-gen/a.go", "src/a.go", "srcx/b.go", "z/c.go"})},
+				{Label: label.TL("", "build"), UnresolvedInputs: []string{"src/**/*.go"}},
+			},
+			expectedCount: 1,
+			expectedText:  "reads output \"src/a.go\" of //:gen",
+		},
+		{
+			name: "literal input matches outputs of several producers",
+			targets: []*model.Target{
+				{Label: label.TL("", "gen_a"), Outputs: mustParseOutputs([]string{"out/api.go"})},
+				{
+					Label:        label.TL("", "gen_b"),
+					Outputs:      mustParseOutputs([]string{"out/api.go"}),
+					Dependencies: []label.TargetLabel{label.TL("", "gen_a")},
+				},
+				{Label: label.TL("", "build"), Inputs: []string{"out/api.go"}},
+			},
+			expectedCount: 2,
+		},
+		{
+			name: "glob reaches into dir output",
+			targets: []*model.Target{
+				{Label: label.TL("", "gen"), Outputs: mustParseOutputs([]string{"dir::dist/assets"})},
+				{Label: label.TL("", "build"), UnresolvedInputs: []string{"dist/**/*.css"}},
+			},
+			expectedCount: 1,
+		},
+		{
+			name: "glob does not reach into unrelated dir output",
+			targets: []*model.Target{
+				{Label: label.TL("", "gen"), Outputs: mustParseOutputs([]string{"dir::dist/assets"})},
+				{Label: label.TL("", "build"), UnresolvedInputs: []string{"dist/*.css", "src/**"}},
+			},
+			expectedCount: 0,
+		},
+		{
+			name: "target reads its own output",
+			targets: []*model.Target{
+				{
+					Label:            label.TL("", "build"),
+					Inputs:           []string{"main.go"},
+					UnresolvedInputs: []string{"**/*.go"},
+					Outputs:          mustParseOutputs([]string{"dir::gen"}),
+				},
+			},
+			expectedCount: 1,
+			expectedText:  "target //:build reads its own output \"gen\"",
+		},
+		{
+			name: "excluded outputs are not read",
+			targets: []*model.Target{
+				{
+					Label:            label.TL("web", "build"),
+					UnresolvedInputs: []string{"**/*"},
+					ExcludeInputs:    []string{"dist/**", "tsconfig.tsbuildinfo"},
+					Outputs:          mustParseOutputs([]string{"dir::dist", "tsconfig.tsbuildinfo"}),
+				},
+			},
+			expectedCount: 0,
+		},
+		{
+			name: "declared source file passed through as output",
+			targets: []*model.Target{
+				{
+					Label:            label.TL("", "tool"),
+					Inputs:           []string{"tool.sh"},
+					UnresolvedInputs: []string{"tool.sh", "*.sh"},
+					BinOutput:        mustParseOutputs([]string{"tool.sh"})[0],
+				},
+			},
+			expectedCount: 0,
+		},
+		{
+			name: "violations are reported once per producer and consumer",
+			targets: []*model.Target{
+				{Label: label.TL("", "gen"), Outputs: mustParseOutputs([]string{"a.txt", "b.txt"})},
+				{Label: label.TL("", "build"), Inputs: []string{"a.txt", "b.txt"}, UnresolvedInputs: []string{"*.txt"}},
+			},
+			expectedCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := make(model.BuildNodeMap)
+			for _, target := range tt.targets {
+				nodes[target.Label] = target
+			}
+			graph, err := BuildGraph(nodes)
+			require.NoError(t, err)
+
+			observedZapCore, observedLogs := observer.New(zap.WarnLevel)
+			logger := console.NewFromSugared(zap.New(observedZapCore).Sugar(), zapcore.WarnLevel)
+
+			errs := CheckUndeclaredDependencies(logger, graph, config.SeverityError)
+			require.Len(t, errs, tt.expectedCount)
+			if tt.expectedText != "" {
+				assert.Contains(t, errs[0].Error(), tt.expectedText)
+			}
+
+			errs = CheckUndeclaredDependencies(logger, graph, config.SeverityWarn)
+			assert.Empty(t, errs)
+			assert.Equal(t, tt.expectedCount, observedLogs.Len())
+
+			errs = CheckUndeclaredDependencies(logger, graph, config.SeverityIgnore)
+			assert.Empty(t, errs)
+			assert.Equal(t, tt.expectedCount, observedLogs.Len())
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"grog/internal/model"
+)
+
+// checkVisibility checks that every dependency of a target or resource is
+// visible to it. Dependencies on aliases are checked against the target the
+// alias resolves to so that an alias cannot widen the visibility of a target.
+func checkVisibility(nodeMap model.BuildNodeMap) (errs []error) {
+	for _, node := range nodeMap.NodesAlphabetically() {
+		if node.GetType() == model.AliasNode {
+			continue
+		}
+
+		for _, dependencyLabel := range node.GetDependencies() {
+			dependencyTarget := resolveDependencyTarget(nodeMap, dependencyLabel)
+			if dependencyTarget == nil || dependencyTarget.IsVisibleTo(node.GetLabel()) {
+				continue
+			}
+
+			via := ""
+			if dependencyLabel != dependencyTarget.Label {
+				via = fmt.Sprintf(" (via alias %s)", dependencyLabel)
+			}
+			errs = append(errs, fmt.Errorf("%s depends on %s%s which is not visible to it: %s",
+				node.GetLabel(),
+				dependencyTarget.Label,
+				via,
+				describeVisibility(dependencyTarget),
+			))
+		}
+	}
+
+	return errs
+}
+
+func describeVisibility(target *model.Target) string {
+	if len(target.Visibility) == 0 {
+		return fmt.Sprintf("it is private to package //%s", target.Label.Package)
+	}
+
+	patterns := make([]string, 0, len(target.Visibility))
+	for _, pattern := range target.Visibility {
+		patterns = append(patterns, pattern.String())
+	}
+	return fmt.Sprintf("it is only visible to %s", strings.Join(patterns, ", "))
+}
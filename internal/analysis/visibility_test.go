@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"testing"
+
+	"grog/internal/label"
+	"grog/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParsePatterns(t *testing.T, patterns ...string) []label.TargetPattern {
+	t.Helper()
+	parsed := []label.TargetPattern{}
+	for _, pattern := range patterns {
+		p, err := label.ParseTargetPattern("", pattern)
+		require.NoError(t, err)
+		parsed = append(parsed, p)
+	}
+	return parsed
+}
+
+func TestCheckVisibility(t *testing.T) {
+	internal := &model.Target{
+		Label:      label.TL("services/payments", "internal"),
+		Visibility: mustParsePatterns(t, "//services/payments/...", "//billing:api"),
+	}
+	private := &model.Target{
+		Label:      label.TL("services/payments", "secret"),
+		Visibility: mustParsePatterns(t),
+	}
+	public := &model.Target{Label: label.TL("lib", "public")}
+	alias := &model.Alias{Label: label.TL("services/payments", "internal_alias"), Actual: internal.Label}
+
+	tests := []struct {
+		name           string
+		dependant      model.BuildNode
+		expectedErrors []string
+	}{
+		{
+			name:      "public target",
+			dependant: &model.Target{Label: label.TL("app", "server"), Dependencies: []label.TargetLabel{public.Label}},
+		},
+		{
+			name:      "same package is always visible",
+			dependant: &model.Target{Label: label.TL("services/payments", "api"), Dependencies: []label.TargetLabel{private.Label}},
+		},
+		{
+			name:      "package pattern includes sub packages",
+			dependant: &model.Target{Label: label.TL("services/payments/refunds", "api"), Dependencies: []label.TargetLabel{internal.Label}},
+		},
+		{
+			name:      "explicit label",
+			dependant: &model.Target{Label: label.TL("billing", "api"), Dependencies: []label.TargetLabel{internal.Label}},
+		},
+		{
+			name: "all violations are listed",
+			dependant: &model.Target{
+				Label:        label.TL("billing", "worker"),
+				Dependencies: []label.TargetLabel{internal.Label, private.Label, public.Label},
+			},
+			expectedErrors: []string{
+				"//billing:worker depends on //services/payments:internal which is not visible to it: it is only visible to //services/payments/..., //billing:api",
+				"//billing:worker depends on //services/payments:secret which is not visible to it: it is private to package //services/payments",
+			},
+		},
+		{
+			name:      "aliases are resolved",
+			dependant: &model.Target{Label: label.TL("app", "server"), Dependencies: []label.TargetLabel{alias.Label}},
+			expectedErrors: []string{
+				"//app:server depends on //services/payments:internal (via alias //services/payments:internal_alias) which is not visible to it",
+			},
+		},
+		{
+			name:      "resources",
+			dependant: &model.Resource{Label: label.TL("app", "db"), Dependencies: []label.TargetLabel{private.Label}},
+			expectedErrors: []string{
+				"//app:db depends on //services/payments:secret which is not visible to it",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeMap := model.BuildNodeMap{
+				internal.Label:          internal,
+				private.Label:           private,
+				public.Label:            public,
+				alias.Label:             alias,
+				tt.dependant.GetLabel(): tt.dependant,
+			}
+
+			errs := checkVisibility(nodeMap)
+			require.Len(t, errs, len(tt.expectedErrors), "%v", errs)
+			for i, expected := range tt.expectedErrors {
+				assert.Contains(t, errs[i].Error(), expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package buildfile
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"grog/internal/label"
+	"grog/internal/output/handlers"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a BUILD file opened for programmatic editing.
+// Edits only touch the targets they address so that comments and the layout
+// of the remaining file are kept where the format allows it.
+// Target definitions are exchanged as YAML mapping nodes which lets targets
+// move between files of different formats.
+type Document interface {
+	// HasTarget reports whether the file defines the target with a literal
+	// definition that can be edited.
+	HasTarget(name string) bool
+	// AddDependency adds the dependency to a target unless it is already present.
+	AddDependency(targetName string, dependency label.TargetLabel) (bool, error)
+	// RemoveDependency removes the dependency from a target if present.
+	RemoveDependency(targetName string, dependency label.TargetLabel) (bool, error)
+	// SetAttribute sets a target attribute to the given value (see ParseAttributeValue).
+	SetAttribute(targetName string, key string, value *yaml.Node) error
+	// RenameTarget changes the name of a target.
+	// References to the target are not updated (see RewriteLabels).
+	RenameTarget(oldName string, newName string) error
+	// RewriteLabels replaces all dependency and alias labels for which rewrite
+	// returns true and reports how many labels were rewritten.
+	RewriteLabels(rewrite func(label.TargetLabel) (label.TargetLabel, bool)) (int, error)
+	// RemoveTarget removes a target from the file and returns its definition.
+	RemoveTarget(name string) (*yaml.Node, error)
+	// AppendTarget adds a target definition to the end of the file.
+	AppendTarget(definition *yaml.Node) error
+	// Bytes returns the edited file content.
+	Bytes() ([]byte, error)
+}
+
+// IsEditable reports whether grog edit can modify the given BUILD file name.
+func IsEditable(fileName string) bool {
+	return IsFormattable(fileName)
+}
+
+// Open parses a BUILD file for editing.
+// packagePath is the path of the file's package relative to the workspace root.
+func Open(filePath string, content []byte, packagePath string) (Document, error) {
+	if packagePath == "." {
+		packagePath = ""
+	}
+
+	switch filepath.Base(filePath) {
+	case "BUILD.json":
+		return openNodeDocument(content, packagePath, true)
+	case "BUILD.yaml", "BUILD.yml":
+		return openNodeDocument(content, packagePath, false)
+	case "BUILD.star", "BUILD.bzl":
+		return openStarlarkDocument(filePath, content, packagePath)
+	}
+	return nil, fmt.Errorf("grog edit does not support %s", filepath.Base(filePath))
+}
+
+// ParseAttributeValue parses a command line value for a target attribute.
+// The value uses YAML syntax (e.g. `5m`, `true` or `[a, b]`) and is checked
+// against the type of the attribute. Values of string attributes are always
+// read as strings so that `set-attr command true` does what one would expect.
+func ParseAttributeValue(key string, rawValue string) (*yaml.Node, error) {
+	targetSchema := packageSchema.children["targets"]
+	fieldType, ok := targetSchema.fieldTypes[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown target attribute %q", key)
+	}
+	if key == "name" {
+		return nil, fmt.Errorf("use grog edit rename to change the name of a target")
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(rawValue), &document); err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+
+	var value *yaml.Node
+	if len(document.Content) == 0 {
+		value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: ""}
+	} else {
+		value = document.Content[0]
+	}
+
+	if fieldType.Kind() == reflect.String && value.Kind == yaml.ScalarNode {
+		value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: rawValue}
+	}
+
+	if err := value.Decode(reflect.New(fieldType).Interface()); err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	clearPositions(value)
+	return value, nil
+}
+
+// RebaseDefinition prepares a target definition that is moved from one
+// package to another: relative dependency labels are rewritten so that they
+// keep pointing to the same targets.
+// File paths (inputs, outputs, ...) are relative to the package and can only be
+// rebased when moving to a parent package. The returned bool reports whether
+// all paths could be rebased.
+func RebaseDefinition(definition *yaml.Node, fromPackage string, toPackage string) (bool, error) {
+	rebased := true
+	relativeDirectory, err := filepath.Rel(toPackage, fromPackage)
+	if err != nil {
+		return false, err
+	}
+	relativeDirectory = filepath.ToSlash(relativeDirectory)
+	canRebasePaths := relativeDirectory != ".." && !strings.HasPrefix(relativeDirectory, "../")
+
+	for index := 0; index+1 < len(definition.Content); index += 2 {
+		key := definition.Content[index].Value
+		value := definition.Content[index+1]
+
+		if key == "dependencies" {
+			for _, item := range value.Content {
+				parsed, err := label.ParseTargetLabel(fromPackage, item.Value)
+				if err != nil {
+					return false, err
+				}
+				item.Value = labelSpelling(toPackage, parsed)
+			}
+			continue
+		}
+
+		if !pathKeys[key] || relativeDirectory == "." {
+			continue
+		}
+		for _, item := range scalarItems(value) {
+			if !canRebasePaths {
+				rebased = false
+				continue
+			}
+			item.Value = rebaseOutputPath(item.Value, relativeDirectory)
+		}
+	}
+	return rebased, nil
+}
+
+// pathKeys are target attributes holding paths relative to the package.
+var pathKeys = map[string]bool{
+	"inputs":         true,
+	"exclude_inputs": true,
+	"outputs":        true,
+	"bin_output":     true,
+}
+
+// rebaseOutputPath prefixes a (possibly typed, e.g. "dir::dist") path with the
+// given directory. Docker image outputs are not paths and stay unchanged.
+func rebaseOutputPath(value string, directory string) string {
+	outputType, identifier, hasType := strings.Cut(value, "::")
+	if !hasType {
+		return path.Join(directory, value)
+	}
+	if outputType != string(handlers.DirHandler) && outputType != string(handlers.FileHandler) {
+		return value
+	}
+	return outputType + "::" + path.Join(directory, identifier)
+}
+
+// labelSpelling returns how a label is written in a BUILD file of the given
+// package: ":name" for targets in the same package and the full label otherwise.
+func labelSpelling(packagePath string, targetLabel label.TargetLabel) string {
+	if targetLabel.Package == packagePath {
+		return ":" + targetLabel.Name
+	}
+	return targetLabel.String()
+}
+
+func scalarItems(node *yaml.Node) []*yaml.Node {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []*yaml.Node{node}
+	case yaml.SequenceNode:
+		var items []*yaml.Node
+		for _, item := range node.Content {
+			if item.Kind == yaml.ScalarNode {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return nil
+}
+
+// clearPositions resets line information of nodes that are inserted into
+// another document so that the encoder does not try to keep their layout.
+func clearPositions(node *yaml.Node) {
+	node.Line = 0
+	node.Column = 0
+	for _, child := range node.Content {
+		clearPositions(child)
+	}
+}
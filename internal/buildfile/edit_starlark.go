@@ -0,0 +1,440 @@
+package buildfile
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"grog/internal/label"
+
+	"github.com/bazelbuild/buildtools/build"
+	"gopkg.in/yaml.v3"
+)
+
+// starlarkDocument edits top level builtin calls of a BUILD.star file.
+// Only literal arguments can be edited, targets created by macros or loops
+// are not visible to it.
+type starlarkDocument struct {
+	file        *build.File
+	packagePath string
+}
+
+func openStarlarkDocument(filePath string, content []byte, packagePath string) (*starlarkDocument, error) {
+	file, err := build.ParseBuild(filePath, content)
+	if err != nil {
+		return nil, err
+	}
+	return &starlarkDocument{file: file, packagePath: packagePath}, nil
+}
+
+// builtinCalls returns the top level calls of the given builtin together with
+// their statement index.
+func (d *starlarkDocument) builtinCalls(builtin string) map[int]*build.CallExpr {
+	calls := make(map[int]*build.CallExpr)
+	for index, statement := range d.file.Stmt {
+		call, ok := statement.(*build.CallExpr)
+		if !ok {
+			continue
+		}
+		if function, ok := call.X.(*build.Ident); ok && function.Name == builtin {
+			calls[index] = call
+		}
+	}
+	return calls
+}
+
+func (d *starlarkDocument) findTarget(name string) (int, *build.CallExpr) {
+	for index, call := range d.builtinCalls("target") {
+		if nameValue, ok := callArgument(call, "name").(*build.StringExpr); ok && nameValue.Value == name {
+			return index, call
+		}
+	}
+	return -1, nil
+}
+
+func (d *starlarkDocument) mustFindTarget(name string) (*build.CallExpr, error) {
+	_, call := d.findTarget(name)
+	if call == nil {
+		return nil, fmt.Errorf("target %q is not defined by a literal target() call in this file", name)
+	}
+	return call, nil
+}
+
+func (d *starlarkDocument) HasTarget(name string) bool {
+	_, call := d.findTarget(name)
+	return call != nil
+}
+
+func (d *starlarkDocument) AddDependency(targetName string, dependency label.TargetLabel) (bool, error) {
+	call, err := d.mustFindTarget(targetName)
+	if err != nil {
+		return false, err
+	}
+
+	value := callArgument(call, "dependencies")
+	if value == nil {
+		value = &build.ListExpr{ForceMultiLine: true}
+		setCallArgument(call, "dependencies", value)
+	}
+	list, ok := value.(*build.ListExpr)
+	if !ok {
+		return false, fmt.Errorf("dependencies of target %q is not a list literal", targetName)
+	}
+
+	for _, element := range list.List {
+		existing, err := d.parseLabelExpr(element)
+		if err != nil {
+			return false, err
+		}
+		if existing == dependency {
+			return false, nil
+		}
+	}
+
+	list.List = append(list.List, &build.StringExpr{Value: labelSpelling(d.packagePath, dependency)})
+	return true, nil
+}
+
+func (d *starlarkDocument) RemoveDependency(targetName string, dependency label.TargetLabel) (bool, error) {
+	call, err := d.mustFindTarget(targetName)
+	if err != nil {
+		return false, err
+	}
+
+	list, ok := callArgument(call, "dependencies").(*build.ListExpr)
+	if !ok {
+		return false, nil
+	}
+
+	removed := false
+	kept := list.List[:0]
+	for _, element := range list.List {
+		existing, err := d.parseLabelExpr(element)
+		if err != nil {
+			return false, err
+		}
+		if existing == dependency {
+			removed = true
+			continue
+		}
+		kept = append(kept, element)
+	}
+	list.List = kept
+
+	if len(list.List) == 0 {
+		removeCallArgument(call, "dependencies")
+	}
+	return removed, nil
+}
+
+func (d *starlarkDocument) SetAttribute(targetName string, key string, value *yaml.Node) error {
+	call, err := d.mustFindTarget(targetName)
+	if err != nil {
+		return err
+	}
+	expression, err := nodeToStarlark(value)
+	if err != nil {
+		return err
+	}
+	setCallArgument(call, key, expression)
+	return nil
+}
+
+func (d *starlarkDocument) RenameTarget(oldName string, newName string) error {
+	call, err := d.mustFindTarget(oldName)
+	if err != nil {
+		return err
+	}
+	if d.HasTarget(newName) {
+		return fmt.Errorf("target %q already exists", newName)
+	}
+	setCallArgument(call, "name", &build.StringExpr{Value: newName})
+	return nil
+}
+
+func (d *starlarkDocument) RewriteLabels(rewrite func(label.TargetLabel) (label.TargetLabel, bool)) (int, error) {
+	count := 0
+	rewriteExpr := func(expression build.Expr) error {
+		var elements []build.Expr
+		switch typed := expression.(type) {
+		case *build.StringExpr:
+			elements = []build.Expr{typed}
+		case *build.ListExpr:
+			elements = typed.List
+		}
+		for _, element := range elements {
+			stringExpr, ok := element.(*build.StringExpr)
+			if !ok {
+				continue
+			}
+			parsed, err := label.ParseTargetLabel(d.packagePath, stringExpr.Value)
+			if err != nil {
+				return err
+			}
+			if replacement, ok := rewrite(parsed); ok {
+				stringExpr.Value = labelSpelling(d.packagePath, replacement)
+				stringExpr.Token = ""
+				count++
+			}
+		}
+		return nil
+	}
+
+	for builtin, key := range map[string]string{
+		"target":      "dependencies",
+		"resource":    "dependencies",
+		"environment": "dependencies",
+		"alias":       "actual",
+	} {
+		for _, call := range d.builtinCalls(builtin) {
+			if value := callArgument(call, key); value != nil {
+				if err := rewriteExpr(value); err != nil {
+					return count, err
+				}
+			}
+		}
+	}
+	return count, nil
+}
+
+func (d *starlarkDocument) RemoveTarget(name string) (*yaml.Node, error) {
+	index, call := d.findTarget(name)
+	if call == nil {
+		return nil, fmt.Errorf("target %q is not defined by a literal target() call in this file", name)
+	}
+
+	definition := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", HeadComment: joinComments(call.Comments.Before)}
+	for _, argument := range call.List {
+		assignment, ok := argument.(*build.AssignExpr)
+		if !ok {
+			return nil, fmt.Errorf("target %q has positional arguments", name)
+		}
+		keyword, ok := assignment.LHS.(*build.Ident)
+		if !ok {
+			return nil, fmt.Errorf("target %q has an unexpected argument", name)
+		}
+		value, err := starlarkToNode(assignment.RHS)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s of target %q: %w", keyword.Name, name, err)
+		}
+		value.LineComment = joinComments(slices.Concat(assignment.Comments.Suffix, assignment.RHS.Comment().Suffix))
+		definition.Content = append(definition.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: keyword.Name},
+			value,
+		)
+	}
+
+	d.file.Stmt = append(d.file.Stmt[:index], d.file.Stmt[index+1:]...)
+	return definition, nil
+}
+
+func (d *starlarkDocument) AppendTarget(definition *yaml.Node) error {
+	_, nameNode := mappingValue(definition, "name")
+	if nameNode == nil {
+		return fmt.Errorf("target definition has no name")
+	}
+	if d.HasTarget(nameNode.Value) {
+		return fmt.Errorf("target %q already exists", nameNode.Value)
+	}
+
+	call := &build.CallExpr{X: &build.Ident{Name: "target"}, ForceMultiLine: true}
+	call.Comments.Before = splitComments(definition.HeadComment)
+	for index := 0; index+1 < len(definition.Content); index += 2 {
+		valueNode := definition.Content[index+1]
+		value, err := nodeToStarlark(valueNode)
+		if err != nil {
+			return err
+		}
+		value.Comment().Suffix = splitComments(valueNode.LineComment)
+		setCallArgument(call, definition.Content[index].Value, value)
+	}
+	d.file.Stmt = append(d.file.Stmt, call)
+	return nil
+}
+
+func (d *starlarkDocument) Bytes() ([]byte, error) {
+	return build.FormatWithoutRewriting(d.file), nil
+}
+
+func (d *starlarkDocument) parseLabelExpr(expression build.Expr) (label.TargetLabel, error) {
+	stringExpr, ok := expression.(*build.StringExpr)
+	if !ok {
+		return label.TargetLabel{}, fmt.Errorf("dependencies must be string literals to be edited")
+	}
+	return label.ParseTargetLabel(d.packagePath, stringExpr.Value)
+}
+
+// joinComments converts Starlark comments to the text of a YAML comment.
+func joinComments(comments []build.Comment) string {
+	lines := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		lines = append(lines, comment.Token)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitComments converts the text of a YAML comment to Starlark comments.
+func splitComments(text string) []build.Comment {
+	if text == "" {
+		return nil
+	}
+	var comments []build.Comment
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") {
+			line = "# " + line
+		}
+		comments = append(comments, build.Comment{Token: line})
+	}
+	return comments
+}
+
+func callArgument(call *build.CallExpr, key string) build.Expr {
+	for _, argument := range call.List {
+		if assignment, ok := argument.(*build.AssignExpr); ok {
+			if keyword, ok := assignment.LHS.(*build.Ident); ok && keyword.Name == key {
+				return assignment.RHS
+			}
+		}
+	}
+	return nil
+}
+
+// setCallArgument replaces the value of a keyword argument or adds it at the
+// canonical position of the key.
+func setCallArgument(call *build.CallExpr, key string, value build.Expr) {
+	targetSchema := packageSchema.children["targets"]
+	insertAt := len(call.List)
+	for index, argument := range call.List {
+		assignment, ok := argument.(*build.AssignExpr)
+		if !ok {
+			continue
+		}
+		keyword, ok := assignment.LHS.(*build.Ident)
+		if !ok {
+			continue
+		}
+		if keyword.Name == key {
+			assignment.RHS = value
+			return
+		}
+		if insertAt == len(call.List) && targetSchema.keyRank(keyword.Name) > targetSchema.keyRank(key) {
+			insertAt = index
+		}
+	}
+
+	assignment := &build.AssignExpr{LHS: &build.Ident{Name: key}, Op: "=", RHS: value}
+	call.List = append(call.List[:insertAt], append([]build.Expr{assignment}, call.List[insertAt:]...)...)
+}
+
+func removeCallArgument(call *build.CallExpr, key string) {
+	for index, argument := range call.List {
+		if assignment, ok := argument.(*build.AssignExpr); ok {
+			if keyword, ok := assignment.LHS.(*build.Ident); ok && keyword.Name == key {
+				call.List = append(call.List[:index], call.List[index+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// nodeToStarlark converts a YAML value into the equivalent Starlark literal.
+func nodeToStarlark(node *yaml.Node) (build.Expr, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		switch node.Tag {
+		case "!!bool":
+			parsed, err := strconv.ParseBool(node.Value)
+			if err != nil {
+				return nil, err
+			}
+			if parsed {
+				return &build.Ident{Name: "True"}, nil
+			}
+			return &build.Ident{Name: "False"}, nil
+		case "!!int", "!!float":
+			return &build.LiteralExpr{Token: node.Value}, nil
+		case "!!null":
+			return &build.Ident{Name: "None"}, nil
+		}
+		return &build.StringExpr{Value: node.Value}, nil
+	case yaml.SequenceNode:
+		list := &build.ListExpr{ForceMultiLine: len(node.Content) > 1}
+		for _, item := range node.Content {
+			element, err := nodeToStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			list.List = append(list.List, element)
+		}
+		return list, nil
+	case yaml.MappingNode:
+		dict := &build.DictExpr{ForceMultiLine: len(node.Content) > 2}
+		for index := 0; index+1 < len(node.Content); index += 2 {
+			value, err := nodeToStarlark(node.Content[index+1])
+			if err != nil {
+				return nil, err
+			}
+			dict.List = append(dict.List, &build.KeyValueExpr{
+				Key:   &build.StringExpr{Value: node.Content[index].Value},
+				Value: value,
+			})
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("unsupported value at line %d", node.Line)
+}
+
+// starlarkToNode converts a Starlark literal into the equivalent YAML value.
+// Non-literal expressions such as function calls or variables cannot be
+// converted.
+func starlarkToNode(expression build.Expr) (*yaml.Node, error) {
+	switch typed := expression.(type) {
+	case *build.StringExpr:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: typed.Value}, nil
+	case *build.LiteralExpr:
+		tag := "!!int"
+		if _, err := strconv.ParseInt(typed.Token, 0, 64); err != nil {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: typed.Token}, nil
+	case *build.Ident:
+		switch typed.Name {
+		case "True":
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"}, nil
+		case "False":
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"}, nil
+		case "None":
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+		}
+	case *build.ListExpr:
+		sequence := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, element := range typed.List {
+			item, err := starlarkToNode(element)
+			if err != nil {
+				return nil, err
+			}
+			sequence.Content = append(sequence.Content, item)
+		}
+		return sequence, nil
+	case *build.DictExpr:
+		mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, entry := range typed.List {
+			key, ok := entry.Key.(*build.StringExpr)
+			if !ok {
+				return nil, fmt.Errorf("dictionary keys must be string literals")
+			}
+			value, err := starlarkToNode(entry.Value)
+			if err != nil {
+				return nil, err
+			}
+			mapping.Content = append(mapping.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.Value},
+				value,
+			)
+		}
+		return mapping, nil
+	}
+	return nil, fmt.Errorf("%s is not a literal", build.FormatString(expression))
+}
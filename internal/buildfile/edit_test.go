@@ -0,0 +1,223 @@
+package buildfile
+
+import (
+	"testing"
+
+	"grog/internal/label"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEdit_Yaml(t *testing.T) {
+	input := `# Package comment
+targets:
+  # builds the api
+  - name: build
+    command: go build ./...
+    dependencies:
+      - :gen # generated code
+      - //libs/log:lib
+
+  - name: gen
+    command: go generate ./...
+
+aliases:
+  - name: default
+    actual: :build
+`
+	document, err := Open("pkg/api/BUILD.yaml", []byte(input), "pkg/api")
+	require.NoError(t, err)
+
+	added, err := document.AddDependency("gen", label.TL("pkg/api", "tools"))
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = document.AddDependency("build", label.TL("pkg/api", "gen"))
+	require.NoError(t, err)
+	assert.False(t, added, "dependency is already present")
+
+	removed, err := document.RemoveDependency("build", label.TL("libs/log", "lib"))
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	timeout, err := ParseAttributeValue("timeout", "5m")
+	require.NoError(t, err)
+	require.NoError(t, document.SetAttribute("build", "timeout", timeout))
+
+	require.NoError(t, document.RenameTarget("gen", "generate"))
+	count, err := document.RewriteLabels(func(existing label.TargetLabel) (label.TargetLabel, bool) {
+		return label.TL("pkg/api", "generate"), existing == label.TL("pkg/api", "gen")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	expected := `# Package comment
+targets:
+  # builds the api
+  - name: build
+    command: go build ./...
+    dependencies:
+      - :generate # generated code
+    timeout: 5m
+
+  - name: generate
+    command: go generate ./...
+    dependencies:
+      - :tools
+
+aliases:
+  - name: default
+    actual: :build
+`
+	edited, err := document.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(edited))
+}
+
+func TestEdit_Json(t *testing.T) {
+	input := `{
+  "targets": [
+    {"name": "foo", "command": "echo foo"}
+  ]
+}`
+	document, err := Open("BUILD.json", []byte(input), ".")
+	require.NoError(t, err)
+
+	_, err = document.AddDependency("foo", label.TL("other", "bar"))
+	require.NoError(t, err)
+
+	tags, err := ParseAttributeValue("tags", "[no-cache]")
+	require.NoError(t, err)
+	require.NoError(t, document.SetAttribute("foo", "tags", tags))
+
+	expected := `{
+  "targets": [
+    {
+      "name": "foo",
+      "command": "echo foo",
+      "dependencies": ["//other:bar"],
+      "tags": ["no-cache"]
+    }
+  ]
+}
+`
+	edited, err := document.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(edited))
+}
+
+func TestEdit_Starlark(t *testing.T) {
+	input := `load("//rules.star", "macro")
+
+# the web app
+target(
+    name = "build",
+    command = "npm run build",
+    dependencies = [":lint"],  # keep lint first
+)
+
+macro(name = "generated")
+`
+	document, err := Open("web/BUILD.star", []byte(input), "web")
+	require.NoError(t, err)
+
+	assert.True(t, document.HasTarget("build"))
+	assert.False(t, document.HasTarget("generated"), "targets created by macros cannot be edited")
+
+	_, err = document.AddDependency("build", label.TL("lib", "ui"))
+	require.NoError(t, err)
+
+	enabled, err := ParseAttributeValue("binary_requires_push", "true")
+	require.NoError(t, err)
+	require.NoError(t, document.SetAttribute("build", "binary_requires_push", enabled))
+
+	require.NoError(t, document.RenameTarget("build", "bundle"))
+
+	expected := `load("//rules.star", "macro")
+
+# the web app
+target(
+    name = "bundle",
+    command = "npm run build",
+    dependencies = [
+        ":lint",
+        "//lib:ui",
+    ],  # keep lint first
+    binary_requires_push = True,
+)
+
+macro(name = "generated")
+`
+	edited, err := document.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(edited))
+}
+
+func TestEdit_MoveBetweenFormats(t *testing.T) {
+	source, err := Open("services/api/BUILD.star", []byte(`# generates the client
+target(
+    name = "client",
+    command = "./gen.sh",
+    dependencies = [":spec"],
+    inputs = ["gen.sh"],
+    outputs = ["dir::client"],
+)
+`), "services/api")
+	require.NoError(t, err)
+
+	destination, err := Open("services/BUILD.yaml", []byte("targets:\n  - name: lint\n    command: lint\n"), "services")
+	require.NoError(t, err)
+
+	definition, err := source.RemoveTarget("client")
+	require.NoError(t, err)
+
+	rebased, err := RebaseDefinition(definition, "services/api", "services")
+	require.NoError(t, err)
+	assert.True(t, rebased)
+	require.NoError(t, destination.AppendTarget(definition))
+
+	expected := `targets:
+  - name: lint
+    command: lint
+
+  # generates the client
+  - name: client
+    command: ./gen.sh
+    dependencies:
+      - //services/api:spec
+    inputs:
+      - api/gen.sh
+    outputs:
+      - dir::api/client
+`
+	edited, err := destination.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(edited))
+
+	remaining, err := source.Bytes()
+	require.NoError(t, err)
+	assert.Empty(t, string(remaining))
+
+	unrelated, err := Open("other/BUILD.yaml", nil, "other")
+	require.NoError(t, err)
+	rebased, err = RebaseDefinition(definition, "services", "other")
+	require.NoError(t, err)
+	assert.False(t, rebased, "paths cannot be rebased into a sibling package")
+	require.NoError(t, unrelated.AppendTarget(definition))
+}
+
+func TestParseAttributeValue(t *testing.T) {
+	value, err := ParseAttributeValue("command", "true")
+	require.NoError(t, err)
+	assert.Equal(t, "!!str", value.Tag)
+
+	_, err = ParseAttributeValue("tags", "{a: b}")
+	assert.Error(t, err)
+
+	_, err = ParseAttributeValue("colour", "red")
+	assert.Error(t, err)
+
+	_, err = ParseAttributeValue("name", "other")
+	assert.Error(t, err)
+}
@@ -0,0 +1,314 @@
+package buildfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"grog/internal/label"
+
+	"gopkg.in/yaml.v3"
+)
+
+// nodeDocument edits BUILD.yaml and BUILD.json files on the YAML node tree
+// which keeps comments and the order of all untouched keys.
+type nodeDocument struct {
+	document    yaml.Node
+	packagePath string
+	isJson      bool
+}
+
+func openNodeDocument(content []byte, packagePath string, isJson bool) (*nodeDocument, error) {
+	if isJson && len(bytes.TrimSpace(content)) > 0 && !json.Valid(content) {
+		var discard any
+		if err := json.Unmarshal(content, &discard); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &nodeDocument{packagePath: packagePath, isJson: isJson}
+	if err := yaml.Unmarshal(content, &result.document); err != nil {
+		return nil, err
+	}
+	if len(result.document.Content) == 0 {
+		result.document = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+	if result.root().Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected an object at the top level of the BUILD file")
+	}
+	return result, nil
+}
+
+func (d *nodeDocument) root() *yaml.Node {
+	return d.document.Content[0]
+}
+
+// section returns the list node of a top level key such as "targets".
+func (d *nodeDocument) section(key string) *yaml.Node {
+	_, value := mappingValue(d.root(), key)
+	if value == nil || value.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return value
+}
+
+func (d *nodeDocument) findTarget(name string) (int, *yaml.Node) {
+	targets := d.section("targets")
+	if targets == nil {
+		return -1, nil
+	}
+	for index, item := range targets.Content {
+		if _, nameNode := mappingValue(item, "name"); nameNode != nil && nameNode.Value == name {
+			return index, item
+		}
+	}
+	return -1, nil
+}
+
+func (d *nodeDocument) mustFindTarget(name string) (*yaml.Node, error) {
+	_, target := d.findTarget(name)
+	if target == nil {
+		return nil, fmt.Errorf("target %q is not defined in this file", name)
+	}
+	return target, nil
+}
+
+func (d *nodeDocument) HasTarget(name string) bool {
+	_, target := d.findTarget(name)
+	return target != nil
+}
+
+func (d *nodeDocument) AddDependency(targetName string, dependency label.TargetLabel) (bool, error) {
+	target, err := d.mustFindTarget(targetName)
+	if err != nil {
+		return false, err
+	}
+
+	_, dependencies := mappingValue(target, "dependencies")
+	if dependencies == nil {
+		dependencies = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		setMappingValue(target, "dependencies", dependencies)
+	}
+	if dependencies.Kind != yaml.SequenceNode {
+		return false, fmt.Errorf("dependencies of target %q is not a list", targetName)
+	}
+
+	for _, item := range dependencies.Content {
+		existing, err := label.ParseTargetLabel(d.packagePath, item.Value)
+		if err != nil {
+			return false, err
+		}
+		if existing == dependency {
+			return false, nil
+		}
+	}
+
+	dependencies.Content = append(dependencies.Content, &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   "!!str",
+		Value: labelSpelling(d.packagePath, dependency),
+	})
+	return true, nil
+}
+
+func (d *nodeDocument) RemoveDependency(targetName string, dependency label.TargetLabel) (bool, error) {
+	target, err := d.mustFindTarget(targetName)
+	if err != nil {
+		return false, err
+	}
+
+	keyIndex, dependencies := mappingValue(target, "dependencies")
+	if dependencies == nil || dependencies.Kind != yaml.SequenceNode {
+		return false, nil
+	}
+
+	removed := false
+	kept := dependencies.Content[:0]
+	for _, item := range dependencies.Content {
+		existing, err := label.ParseTargetLabel(d.packagePath, item.Value)
+		if err != nil {
+			return false, err
+		}
+		if existing == dependency {
+			removed = true
+			continue
+		}
+		kept = append(kept, item)
+	}
+	dependencies.Content = kept
+
+	if len(dependencies.Content) == 0 {
+		target.Content = append(target.Content[:keyIndex], target.Content[keyIndex+2:]...)
+	}
+	return removed, nil
+}
+
+func (d *nodeDocument) SetAttribute(targetName string, key string, value *yaml.Node) error {
+	target, err := d.mustFindTarget(targetName)
+	if err != nil {
+		return err
+	}
+	setMappingValue(target, key, value)
+	return nil
+}
+
+func (d *nodeDocument) RenameTarget(oldName string, newName string) error {
+	target, err := d.mustFindTarget(oldName)
+	if err != nil {
+		return err
+	}
+	if d.HasTarget(newName) {
+		return fmt.Errorf("target %q already exists", newName)
+	}
+	_, nameNode := mappingValue(target, "name")
+	nameNode.Value = newName
+	return nil
+}
+
+func (d *nodeDocument) RewriteLabels(rewrite func(label.TargetLabel) (label.TargetLabel, bool)) (int, error) {
+	count := 0
+	rewriteNode := func(node *yaml.Node) error {
+		for _, item := range scalarItems(node) {
+			parsed, err := label.ParseTargetLabel(d.packagePath, item.Value)
+			if err != nil {
+				return err
+			}
+			if replacement, ok := rewrite(parsed); ok {
+				item.Value = labelSpelling(d.packagePath, replacement)
+				count++
+			}
+		}
+		return nil
+	}
+
+	for _, sectionKey := range []string{"targets", "resources", "environments"} {
+		section := d.section(sectionKey)
+		if section == nil {
+			continue
+		}
+		for _, item := range section.Content {
+			if _, dependencies := mappingValue(item, "dependencies"); dependencies != nil {
+				if err := rewriteNode(dependencies); err != nil {
+					return count, err
+				}
+			}
+		}
+	}
+
+	if aliases := d.section("aliases"); aliases != nil {
+		for _, item := range aliases.Content {
+			if _, actual := mappingValue(item, "actual"); actual != nil {
+				if err := rewriteNode(actual); err != nil {
+					return count, err
+				}
+			}
+		}
+	}
+	return count, nil
+}
+
+func (d *nodeDocument) RemoveTarget(name string) (*yaml.Node, error) {
+	index, target := d.findTarget(name)
+	if target == nil {
+		return nil, fmt.Errorf("target %q is not defined in this file", name)
+	}
+
+	targets := d.section("targets")
+	targets.Content = append(targets.Content[:index], targets.Content[index+1:]...)
+	return target, nil
+}
+
+func (d *nodeDocument) AppendTarget(definition *yaml.Node) error {
+	_, nameNode := mappingValue(definition, "name")
+	if nameNode == nil {
+		return fmt.Errorf("target definition has no name")
+	}
+	if d.HasTarget(nameNode.Value) {
+		return fmt.Errorf("target %q already exists", nameNode.Value)
+	}
+
+	targets := d.section("targets")
+	if targets == nil {
+		targets = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		setMappingValue(d.root(), "targets", targets)
+	}
+	clearPositions(definition)
+	if !d.isJson {
+		// Definitions moved from JSON or Starlark files use flow style
+		clearFlowStyles(definition)
+	}
+	targets.Content = append(targets.Content, definition)
+	return nil
+}
+
+func (d *nodeDocument) Bytes() ([]byte, error) {
+	var buffer bytes.Buffer
+	if d.isJson {
+		if err := writeJsonNode(&buffer, d.root(), 0, 0); err != nil {
+			return nil, err
+		}
+		buffer.WriteString("\n")
+		return buffer.Bytes(), nil
+	}
+
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&d.document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return separateTopLevelBlocks(buffer.Bytes()), nil
+}
+
+// mappingValue returns the index of the key and the value node of a key in
+// a mapping node or (-1, nil) if the key is not present.
+func mappingValue(mapping *yaml.Node, key string) (int, *yaml.Node) {
+	if mapping.Kind != yaml.MappingNode {
+		return -1, nil
+	}
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		if mapping.Content[index].Value == key {
+			return index, mapping.Content[index+1]
+		}
+	}
+	return -1, nil
+}
+
+// setMappingValue replaces the value of a key or inserts the key at its
+// canonical position (see schema) if it does not exist yet.
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	if index, existing := mappingValue(mapping, key); existing != nil {
+		// Keep comments attached to the previous value
+		value.HeadComment = existing.HeadComment
+		value.LineComment = existing.LineComment
+		mapping.Content[index+1] = value
+		return
+	}
+
+	nodeSchema := packageSchema
+	if mappingHasKey(mapping, "name") {
+		nodeSchema = packageSchema.children["targets"]
+	}
+	rank := nodeSchema.keyRank(key)
+
+	insertAt := len(mapping.Content)
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		if nodeSchema.keyRank(mapping.Content[index].Value) > rank {
+			insertAt = index
+			break
+		}
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	mapping.Content = append(mapping.Content[:insertAt], append([]*yaml.Node{keyNode, value}, mapping.Content[insertAt:]...)...)
+}
+
+func mappingHasKey(mapping *yaml.Node, key string) bool {
+	_, value := mappingValue(mapping, key)
+	return value != nil
+}
@@ -0,0 +1,227 @@
+package buildfile
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"grog/internal/label"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IsFormattable reports whether grog fmt knows how to format the given BUILD file name.
+func IsFormattable(fileName string) bool {
+	switch fileName {
+	case "BUILD.json", "BUILD.yaml", "BUILD.yml", "BUILD.star", "BUILD.bzl":
+		return true
+	}
+	return false
+}
+
+// Format returns the canonical representation of a BUILD file.
+// packagePath is the path of the file's package relative to the workspace root
+// and is used to shorten labels pointing into the same package to ":name".
+func Format(filePath string, content []byte, packagePath string) ([]byte, error) {
+	switch filepath.Base(filePath) {
+	case "BUILD.json":
+		return formatJson(content, packagePath)
+	case "BUILD.yaml", "BUILD.yml":
+		return formatYaml(content, packagePath)
+	case "BUILD.star", "BUILD.bzl":
+		return formatStarlark(filePath, content, packagePath)
+	}
+	return nil, fmt.Errorf("no formatter for %s", filePath)
+}
+
+func formatYaml(content []byte, packagePath string) ([]byte, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		// Empty or comment-only files are already canonical.
+		return content, nil
+	}
+
+	root := document.Content[0]
+	if err := canonicalizeNode(root, packageSchema, packagePath); err != nil {
+		return nil, err
+	}
+	clearFlowStyles(root)
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return separateTopLevelBlocks(buffer.Bytes()), nil
+}
+
+// canonicalizeNode sorts the keys of a mapping node according to the schema,
+// normalises labels and sorts unordered lists. It recurses into known children.
+func canonicalizeNode(node *yaml.Node, nodeSchema *schema, packagePath string) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := canonicalizeNode(item, nodeSchema, packagePath); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.MappingNode:
+	default:
+		return nil
+	}
+
+	type keyValue struct {
+		key   *yaml.Node
+		value *yaml.Node
+	}
+	pairs := make([]keyValue, 0, len(node.Content)/2)
+	for index := 0; index+1 < len(node.Content); index += 2 {
+		pairs = append(pairs, keyValue{key: node.Content[index], value: node.Content[index+1]})
+	}
+
+	for _, pair := range pairs {
+		key := pair.key.Value
+		if labelKeys[key] {
+			if err := normalizeLabels(pair.value, packagePath); err != nil {
+				return err
+			}
+		}
+		if sortedListKeys[key] && pair.value.Kind == yaml.SequenceNode {
+			sortScalarSequence(pair.value)
+		}
+		if childSchema, ok := nodeSchema.children[key]; ok {
+			if err := canonicalizeNode(pair.value, childSchema, packagePath); err != nil {
+				return err
+			}
+		}
+	}
+
+	slices.SortStableFunc(pairs, func(a, b keyValue) int {
+		return nodeSchema.keyRank(a.key.Value) - nodeSchema.keyRank(b.key.Value)
+	})
+
+	node.Content = node.Content[:0]
+	for _, pair := range pairs {
+		node.Content = append(node.Content, pair.key, pair.value)
+	}
+	return nil
+}
+
+// normalizeLabels rewrites labels in a scalar or sequence node so that labels
+// within the same package use the relative ":name" form.
+func normalizeLabels(node *yaml.Node, packagePath string) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		normalized, err := NormalizeLabel(packagePath, node.Value)
+		if err != nil {
+			return err
+		}
+		node.Value = normalized
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := normalizeLabels(item, packagePath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// NormalizeLabel returns the canonical spelling of a label as written in a
+// BUILD file of the given package: ":name" for targets in the same package and
+// the unchanged label otherwise.
+func NormalizeLabel(packagePath string, rawLabel string) (string, error) {
+	if packagePath == "." {
+		packagePath = ""
+	}
+	parsed, err := label.ParseTargetLabel(packagePath, rawLabel)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Package == packagePath {
+		return ":" + parsed.Name, nil
+	}
+	return rawLabel, nil
+}
+
+func sortScalarSequence(node *yaml.Node) {
+	for _, item := range node.Content {
+		if item.Kind != yaml.ScalarNode {
+			return
+		}
+	}
+	slices.SortStableFunc(node.Content, func(a, b *yaml.Node) int {
+		return strings.Compare(a.Value, b.Value)
+	})
+}
+
+// clearFlowStyles switches flow collections to block style and drops
+// redundant quoting so that equivalent files are rendered the same way.
+// Literal and folded block scalars are kept since they are deliberate.
+func clearFlowStyles(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Style != yaml.LiteralStyle && node.Style != yaml.FoldedStyle {
+			node.Style = 0
+		}
+	case yaml.MappingNode, yaml.SequenceNode:
+		node.Style &^= yaml.FlowStyle
+	}
+	for _, child := range node.Content {
+		clearFlowStyles(child)
+	}
+}
+
+var (
+	topLevelKeyPattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*:`)
+	topLevelItemPattern = regexp.MustCompile(`^  - [A-Za-z_][A-Za-z0-9_]*:( |$)`)
+)
+
+// separateTopLevelBlocks inserts a blank line between top level keys and
+// between the objects listed in a top level section (e.g. each target).
+// Comments directly above a block stay attached to it.
+func separateTopLevelBlocks(content []byte) []byte {
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+
+	var result []string
+	for _, line := range lines {
+		isKey := topLevelKeyPattern.MatchString(line)
+		isItem := topLevelItemPattern.MatchString(line)
+		if !isKey && !isItem {
+			result = append(result, line)
+			continue
+		}
+
+		// Move the separator above any comment lines attached to the block.
+		commentPrefix := "#"
+		if isItem {
+			commentPrefix = "  #"
+		}
+		insertAt := len(result)
+		for insertAt > 0 && strings.HasPrefix(result[insertAt-1], commentPrefix) {
+			insertAt--
+		}
+
+		if insertAt > 0 {
+			previous := result[insertAt-1]
+			// The first item of a section directly follows its key.
+			firstItem := isItem && topLevelKeyPattern.MatchString(previous)
+			if previous != "" && !firstItem {
+				result = slices.Insert(result, insertAt, "")
+			}
+		}
+		result = append(result, line)
+	}
+	return []byte(strings.Join(result, "\n") + "\n")
+}
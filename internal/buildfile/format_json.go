@@ -0,0 +1,138 @@
+package buildfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonLineWidth is the width up to which lists of scalars are kept on one line.
+const jsonLineWidth = 80
+
+func formatJson(content []byte, packagePath string) ([]byte, error) {
+	if !json.Valid(content) {
+		// Surface the error from the json decoder rather than the yaml parser
+		var discard any
+		if err := json.Unmarshal(content, &discard); err != nil {
+			return nil, err
+		}
+	}
+
+	// JSON is a subset of YAML so we can reuse the node based canonicalization
+	// which also preserves the key order of unknown keys.
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return content, nil
+	}
+
+	root := document.Content[0]
+	if err := canonicalizeNode(root, packageSchema, packagePath); err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	if err := writeJsonNode(&buffer, root, 0, 0); err != nil {
+		return nil, err
+	}
+	buffer.WriteString("\n")
+	return buffer.Bytes(), nil
+}
+
+// writeJsonNode writes a node using two space indentation. column is the
+// column at which the value starts and is used to decide whether a list of
+// scalars fits on a single line.
+func writeJsonNode(buffer *bytes.Buffer, node *yaml.Node, indentation int, column int) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			buffer.WriteString("{}")
+			return nil
+		}
+		buffer.WriteString("{\n")
+		for index := 0; index+1 < len(node.Content); index += 2 {
+			key, err := jsonScalar(node.Content[index])
+			if err != nil {
+				return err
+			}
+			prefix := strings.Repeat("  ", indentation+1) + key + ": "
+			buffer.WriteString(prefix)
+			if err := writeJsonNode(buffer, node.Content[index+1], indentation+1, len(prefix)); err != nil {
+				return err
+			}
+			if index+2 < len(node.Content) {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(strings.Repeat("  ", indentation) + "}")
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			buffer.WriteString("[]")
+			return nil
+		}
+		if inline, ok := inlineJsonSequence(node); ok && column+len(inline)+1 <= jsonLineWidth {
+			buffer.WriteString(inline)
+			return nil
+		}
+		buffer.WriteString("[\n")
+		for index, item := range node.Content {
+			prefix := strings.Repeat("  ", indentation+1)
+			buffer.WriteString(prefix)
+			if err := writeJsonNode(buffer, item, indentation+1, len(prefix)); err != nil {
+				return err
+			}
+			if index+1 < len(node.Content) {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(strings.Repeat("  ", indentation) + "]")
+	case yaml.ScalarNode:
+		value, err := jsonScalar(node)
+		if err != nil {
+			return err
+		}
+		buffer.WriteString(value)
+	default:
+		return fmt.Errorf("unsupported json value at line %d", node.Line)
+	}
+	return nil
+}
+
+// inlineJsonSequence renders a list of scalars on a single line.
+func inlineJsonSequence(node *yaml.Node) (string, bool) {
+	values := make([]string, 0, len(node.Content))
+	for _, item := range node.Content {
+		if item.Kind != yaml.ScalarNode {
+			return "", false
+		}
+		value, err := jsonScalar(item)
+		if err != nil {
+			return "", false
+		}
+		values = append(values, value)
+	}
+	return "[" + strings.Join(values, ", ") + "]", true
+}
+
+func jsonScalar(node *yaml.Node) (string, error) {
+	switch node.Tag {
+	case "!!int", "!!float", "!!bool", "!!null":
+		return node.Value, nil
+	}
+
+	// Do not escape <, > and & which are common in shell commands.
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(node.Value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
@@ -0,0 +1,106 @@
+package buildfile
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// starlarkBuiltins are the grog builtins whose literal arguments are
+// canonicalized in the same way as the keys of JSON and YAML BUILD files.
+var starlarkBuiltins = map[string]bool{
+	"target":      true,
+	"alias":       true,
+	"resource":    true,
+	"environment": true,
+}
+
+// formatStarlark formats a Starlark BUILD file with the buildifier layout.
+// We deliberately skip buildifier's Bazel specific rewrites and instead apply
+// grog's own sorting and label normalization to literal builtin arguments.
+func formatStarlark(filePath string, content []byte, packagePath string) ([]byte, error) {
+	file, err := build.ParseBuild(filePath, content)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, statement := range file.Stmt {
+		call, ok := statement.(*build.CallExpr)
+		if !ok {
+			continue
+		}
+		if err := canonicalizeStarlarkCall(call, packagePath); err != nil {
+			return nil, err
+		}
+	}
+
+	return build.FormatWithoutRewriting(file), nil
+}
+
+func canonicalizeStarlarkCall(call *build.CallExpr, packagePath string) error {
+	function, ok := call.X.(*build.Ident)
+	if !ok || !starlarkBuiltins[function.Name] {
+		return nil
+	}
+
+	for _, argument := range call.List {
+		assignment, ok := argument.(*build.AssignExpr)
+		if !ok {
+			continue
+		}
+		keyword, ok := assignment.LHS.(*build.Ident)
+		if !ok {
+			continue
+		}
+
+		if labelKeys[keyword.Name] {
+			if err := normalizeStarlarkLabels(assignment.RHS, packagePath); err != nil {
+				return err
+			}
+		}
+		if sortedListKeys[keyword.Name] {
+			sortStarlarkStringList(assignment.RHS)
+		}
+	}
+	return nil
+}
+
+func normalizeStarlarkLabels(expression build.Expr, packagePath string) error {
+	switch typed := expression.(type) {
+	case *build.StringExpr:
+		normalized, err := NormalizeLabel(packagePath, typed.Value)
+		if err != nil {
+			return err
+		}
+		if normalized != typed.Value {
+			typed.Value = normalized
+			// Token caches the original spelling and would take precedence.
+			typed.Token = ""
+		}
+	case *build.ListExpr:
+		for _, element := range typed.List {
+			if err := normalizeStarlarkLabels(element, packagePath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortStarlarkStringList sorts list literals that only contain string
+// literals. Lists built from expressions are left alone.
+func sortStarlarkStringList(expression build.Expr) {
+	list, ok := expression.(*build.ListExpr)
+	if !ok {
+		return
+	}
+	for _, element := range list.List {
+		if _, isString := element.(*build.StringExpr); !isString {
+			return
+		}
+	}
+	slices.SortStableFunc(list.List, func(a, b build.Expr) int {
+		return strings.Compare(a.(*build.StringExpr).Value, b.(*build.StringExpr).Value)
+	})
+}
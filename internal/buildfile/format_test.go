@@ -0,0 +1,148 @@
+package buildfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat_Yaml(t *testing.T) {
+	input := `# Package comment
+targets:
+  - command: go build ./...
+    tags: [z, a]
+    name: build
+    dependencies: ["//pkg/web:gen", "//pkg/api:lib", ":aaa"]
+    # inputs are globbed
+    inputs:
+      - "src/**/*.go"
+      - "go.mod"
+  - name: test
+    command: |
+      go test ./...
+      echo done
+aliases:
+  - actual: //pkg/api:build
+    name: default
+`
+	expected := `# Package comment
+targets:
+  - name: build
+    command: go build ./...
+    dependencies:
+      - //pkg/web:gen
+      - :aaa
+      - :lib
+    # inputs are globbed
+    inputs:
+      - go.mod
+      - src/**/*.go
+    tags:
+      - a
+      - z
+
+  - name: test
+    command: |
+      go test ./...
+      echo done
+
+aliases:
+  - name: default
+    actual: :build
+`
+	formatted, err := Format("pkg/api/BUILD.yaml", []byte(input), "pkg/api")
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(formatted))
+
+	// Formatting must be idempotent
+	again, err := Format("pkg/api/BUILD.yaml", formatted, "pkg/api")
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(again))
+}
+
+func TestFormat_Json(t *testing.T) {
+	input := `{"targets": [{"outputs": ["out.txt"], "name": "foo", "command": "cat a > out.txt && echo '<done>'",
+  "dependencies": ["//:bar", "//other:baz"], "binary_requires_push": false, "timeout": "1m"}],
+  "default_platforms": ["linux/amd64"]}`
+	expected := `{
+  "targets": [
+    {
+      "name": "foo",
+      "command": "cat a > out.txt && echo '<done>'",
+      "dependencies": ["//other:baz", ":bar"],
+      "outputs": ["out.txt"],
+      "binary_requires_push": false,
+      "timeout": "1m"
+    }
+  ],
+  "default_platforms": ["linux/amd64"]
+}
+`
+	formatted, err := Format("BUILD.json", []byte(input), ".")
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(formatted))
+
+	again, err := Format("BUILD.json", formatted, ".")
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(again))
+}
+
+func TestFormat_JsonWrapsLongLists(t *testing.T) {
+	input := `{"targets": [{"name": "foo", "inputs": ["a/very/long/path/one.txt", "a/very/long/path/two.txt", "a/very/long/path/three.txt"]}]}`
+	expected := `{
+  "targets": [
+    {
+      "name": "foo",
+      "inputs": [
+        "a/very/long/path/one.txt",
+        "a/very/long/path/three.txt",
+        "a/very/long/path/two.txt"
+      ]
+    }
+  ]
+}
+`
+	formatted, err := Format("BUILD.json", []byte(input), "")
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(formatted))
+}
+
+func TestFormat_Starlark(t *testing.T) {
+	input := `load("//rules.star", "ts_package")
+target(name="build", command="npm run build",
+  dependencies=["//web:lint", "//lib:ui"], inputs=["src/**", "package.json"])
+ts_package(name = "example", deps = ["b", "a"])
+`
+	expected := `load("//rules.star", "ts_package")
+
+target(
+    name = "build",
+    command = "npm run build",
+    dependencies = [
+        "//lib:ui",
+        ":lint",
+    ],
+    inputs = [
+        "package.json",
+        "src/**",
+    ],
+)
+
+ts_package(
+    name = "example",
+    deps = [
+        "b",
+        "a",
+    ],
+)
+`
+	formatted, err := Format("web/BUILD.star", []byte(input), "web")
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(formatted))
+}
+
+func TestFormat_InvalidLabel(t *testing.T) {
+	_, err := Format("BUILD.yaml", []byte("targets:\n  - name: foo\n    dependencies: [foo]\n"), "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,141 @@
+package buildfile
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"grog/internal/label"
+
+	"gopkg.in/yaml.v3"
+)
+
+// keepPattern matches the `# keep` marker that protects hand-written parts
+// of a BUILD.yaml file from being changed by grog gen.
+var keepPattern = regexp.MustCompile(`#\s*keep\b`)
+
+// MergeGeneratedTarget merges a generated target definition into a BUILD.yaml
+// or BUILD.json document and reports whether the document changed.
+// New targets are appended as they are. For existing targets only the
+// generatedKeys are replaced, all other attributes are considered hand-written.
+// A `# keep` comment on the target, an attribute or a single list item
+// keeps it unchanged.
+func MergeGeneratedTarget(document Document, definition *yaml.Node, generatedKeys []string) (bool, error) {
+	nodes, ok := document.(*nodeDocument)
+	if !ok {
+		return false, fmt.Errorf("generated targets can only be merged into BUILD.yaml and BUILD.json files")
+	}
+
+	_, nameNode := mappingValue(definition, "name")
+	if nameNode == nil {
+		return false, fmt.Errorf("target definition has no name")
+	}
+
+	_, target := nodes.findTarget(nameNode.Value)
+	if target == nil {
+		return true, nodes.AppendTarget(definition)
+	}
+	_, existingName := mappingValue(target, "name")
+	if isKept(target) || isKept(existingName) {
+		return false, nil
+	}
+
+	changed := false
+	for _, key := range generatedKeys {
+		keyIndex, existing := mappingValue(target, key)
+		if existing != nil && (isKept(existing) || isKept(target.Content[keyIndex])) {
+			continue
+		}
+
+		_, generated := mappingValue(definition, key)
+		merged := nodes.mergeGeneratedValue(key, existing, generated)
+
+		if merged == nil {
+			if existing != nil {
+				target.Content = append(target.Content[:keyIndex], target.Content[keyIndex+2:]...)
+				changed = true
+			}
+			continue
+		}
+		if existing != nil && nodesEqual(existing, merged) {
+			continue
+		}
+		clearPositions(merged)
+		setMappingValue(target, key, merged)
+		changed = true
+	}
+	return changed, nil
+}
+
+// mergeGeneratedValue returns the new value of a generated attribute: the
+// generated items plus all existing items marked with `# keep`. Existing items
+// are reused so that their comments survive. Returns nil if the attribute
+// should be removed.
+func (d *nodeDocument) mergeGeneratedValue(key string, existing *yaml.Node, generated *yaml.Node) *yaml.Node {
+	if existing == nil || existing.Kind != yaml.SequenceNode || (generated != nil && generated.Kind != yaml.SequenceNode) {
+		return generated
+	}
+
+	merged := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: existing.Style}
+	var mergedValues []string
+	add := func(item *yaml.Node) {
+		value := d.normalizeItem(key, item.Value)
+		if slices.Contains(mergedValues, value) {
+			return
+		}
+		mergedValues = append(mergedValues, value)
+		merged.Content = append(merged.Content, item)
+	}
+
+	existingItems := make(map[string]*yaml.Node)
+	for _, item := range existing.Content {
+		existingItems[d.normalizeItem(key, item.Value)] = item
+	}
+
+	if generated != nil {
+		for _, item := range generated.Content {
+			if existingItem, ok := existingItems[d.normalizeItem(key, item.Value)]; ok {
+				item = existingItem
+			}
+			add(item)
+		}
+	}
+	for _, item := range existing.Content {
+		if isKept(item) {
+			add(item)
+		}
+	}
+
+	if len(merged.Content) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// normalizeItem makes differently spelled labels of the same target compare equal.
+func (d *nodeDocument) normalizeItem(key string, value string) string {
+	if key != "dependencies" {
+		return value
+	}
+	parsed, err := label.ParseTargetLabel(d.packagePath, value)
+	if err != nil {
+		return value
+	}
+	return parsed.String()
+}
+
+func isKept(node *yaml.Node) bool {
+	return node != nil && (keepPattern.MatchString(node.LineComment) || keepPattern.MatchString(node.HeadComment))
+}
+
+func nodesEqual(a *yaml.Node, b *yaml.Node) bool {
+	if a.Kind != b.Kind || a.Value != b.Value || len(a.Content) != len(b.Content) {
+		return false
+	}
+	for index := range a.Content {
+		if !nodesEqual(a.Content[index], b.Content[index]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package buildfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func mustParseDefinition(t *testing.T, raw string) *yaml.Node {
+	t.Helper()
+	var document yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(raw), &document))
+	return document.Content[0]
+}
+
+func TestMergeGeneratedTarget(t *testing.T) {
+	input := `targets:
+  - name: go
+    command: go build -o app .
+    dependencies:
+      - //tools:gen # keep
+      - //stale:dep
+      - //lib/log:go # logging
+    inputs: # keep
+      - main.go
+      - embed.txt
+    outputs:
+      - app
+
+  - name: pinned # keep
+    inputs:
+      - old.go
+`
+	document, err := Open("cmd/app/BUILD.yaml", []byte(input), "cmd/app")
+	require.NoError(t, err)
+
+	changed, err := MergeGeneratedTarget(document, mustParseDefinition(t, `
+name: go
+command: ignored
+dependencies: [//lib/log:go, //lib/http:go]
+inputs: [main.go]
+`), []string{"dependencies", "inputs"})
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	changed, err = MergeGeneratedTarget(document, mustParseDefinition(t, "{name: pinned, inputs: [new.go]}"), []string{"inputs"})
+	require.NoError(t, err)
+	assert.False(t, changed, "targets marked with keep are not changed")
+
+	changed, err = MergeGeneratedTarget(document, mustParseDefinition(t, "{name: go_test, command: go test ., inputs: [main_test.go]}"), []string{"inputs"})
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	expected := `targets:
+  - name: go
+    command: go build -o app .
+    dependencies:
+      - //lib/log:go # logging
+      - //lib/http:go
+      - //tools:gen # keep
+    inputs: # keep
+      - main.go
+      - embed.txt
+    outputs:
+      - app
+
+  - name: pinned # keep
+    inputs:
+      - old.go
+
+  - name: go_test
+    command: go test .
+    inputs:
+      - main_test.go
+`
+	edited, err := document.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(edited))
+}
+
+func TestMergeGeneratedTarget_RemovesEmptyAttributes(t *testing.T) {
+	document, err := Open("BUILD.json", []byte(`{"targets": [{"name": "py", "dependencies": ["//gone:py"], "inputs": ["a.py"]}]}`), "")
+	require.NoError(t, err)
+
+	changed, err := MergeGeneratedTarget(document, mustParseDefinition(t, "{name: py, inputs: [a.py]}"), []string{"dependencies", "inputs"})
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	changed, err = MergeGeneratedTarget(document, mustParseDefinition(t, "{name: py, inputs: [a.py]}"), []string{"dependencies", "inputs"})
+	require.NoError(t, err)
+	assert.False(t, changed, "merging is idempotent")
+
+	expected := `{
+  "targets": [
+    {
+      "name": "py",
+      "inputs": ["a.py"]
+    }
+  ]
+}
+`
+	edited, err := document.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(edited))
+
+	starlark, err := Open("BUILD.star", nil, "")
+	require.NoError(t, err)
+	_, err = MergeGeneratedTarget(starlark, mustParseDefinition(t, "{name: py}"), nil)
+	assert.Error(t, err)
+}
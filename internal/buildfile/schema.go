@@ -0,0 +1,96 @@
+package buildfile
+
+import (
+	"reflect"
+	"strings"
+
+	"grog/internal/loading"
+)
+
+// schema describes one object level of a BUILD file: the canonical order of
+// its keys (the field order of the corresponding loading DTO), the Go type of
+// each key and the schema of nested objects keyed by their parent key.
+type schema struct {
+	keyOrder   []string
+	fieldTypes map[string]reflect.Type
+	children   map[string]*schema
+}
+
+// packageSchema mirrors loading.PackageDTO so that the formatter always
+// follows the DTO field order without a second, hand maintained list.
+var packageSchema = schemaFromType(reflect.TypeOf(loading.PackageDTO{}))
+
+// sortedListKeys are list attributes whose order carries no meaning.
+var sortedListKeys = map[string]bool{
+	"dependencies": true,
+	"inputs":       true,
+	"tags":         true,
+}
+
+// labelKeys are attributes holding a single label or a list of labels that
+// should be normalised relative to the package of the BUILD file.
+var labelKeys = map[string]bool{
+	"dependencies": true,
+	"actual":       true,
+}
+
+func schemaFromType(structType reflect.Type) *schema {
+	result := &schema{
+		fieldTypes: make(map[string]reflect.Type),
+		children:   make(map[string]*schema),
+	}
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		key := yamlKey(field)
+		if key == "" {
+			continue
+		}
+		result.keyOrder = append(result.keyOrder, key)
+		result.fieldTypes[key] = field.Type
+
+		if elementType := structElementType(field.Type); elementType != nil {
+			result.children[key] = schemaFromType(elementType)
+		}
+	}
+	return result
+}
+
+// yamlKey returns the serialized key of a DTO field or "" for fields that are
+// not part of the file format.
+func yamlKey(field reflect.StructField) string {
+	tag, ok := field.Tag.Lookup("yaml")
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// structElementType unwraps pointers, slices and maps until it reaches a
+// struct type. It returns nil if the field does not contain objects.
+func structElementType(fieldType reflect.Type) reflect.Type {
+	for {
+		switch fieldType.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array:
+			fieldType = fieldType.Elem()
+		case reflect.Struct:
+			return fieldType
+		default:
+			return nil
+		}
+	}
+}
+
+// keyRank returns the canonical position of a key. Unknown keys sort after
+// all known keys.
+func (s *schema) keyRank(key string) int {
+	for index, known := range s.keyOrder {
+		if known == key {
+			return index
+		}
+	}
+	return len(s.keyOrder)
+}
@@ -25,6 +25,7 @@ import (
 	"grog/internal/locking"
 	"grog/internal/model"
 	"grog/internal/output"
+	"grog/internal/remote"
 	"grog/internal/selection"
 	"grog/internal/tracing"
 )
@@ -32,13 +33,22 @@ import (
 // GrogVersion is set by the root command during initialization.
 var GrogVersion string
 
+// buildOptions holds the selection flags shared by build, test and
+// build-and-test.
+var buildOptions = struct {
+	since string
+	shard string
+}{}
+
 var BuildCmd = &cobra.Command{
 	Use:   "build",
 	Short: "Loads the user configuration and executes build targets.",
 	Long:  `Loads the user configuration, checks which targets need to be rebuilt based on file hashes, builds the dependency graph, and executes targets.`,
 	Example: `  grog build                      # Build all targets in the current package and subpackages
   grog build //path/to/package:target  # Build a specific target
-  grog build //path/to/package/...     # Build all targets in a package and subpackages`,
+  grog build //path/to/package/...     # Build all targets in a package and subpackages
+  grog build //... --since=origin/main # Build all targets affected by changes since origin/main
+  grog build //... --shard=1/4         # Build the first of four shards planned by grog plan`,
 	Args:              cobra.ArbitraryArgs, // Optional argument for target pattern
 	ValidArgsFunction: completions.BuildTargetPatternCompletion,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -69,9 +79,68 @@ var BuildCmd = &cobra.Command{
 }
 
 func AddBuildCmd(rootCmd *cobra.Command) {
+	addSinceFlag(BuildCmd)
+	addShardFlag(BuildCmd)
 	rootCmd.AddCommand(BuildCmd)
 }
 
+// addSinceFlag registers --since which limits the selection to the targets
+// affected by changes since a revision, see `grog changes`.
+func addSinceFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(
+		&buildOptions.since,
+		"since",
+		"",
+		"Only select targets affected by changes since this Git ref or Jujutsu revision, including their transitive dependents")
+}
+
+// addShardFlag registers --shard which limits the selection to one of the
+// shards planned by `grog plan`.
+func addShardFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(
+		&buildOptions.shard,
+		"shard",
+		"",
+		"Only select the targets of shard i/N as planned by grog plan --shards=N, e.g. 2/4")
+}
+
+// mustGetShardLabels returns the labels of the targets that grog plan
+// assigns to the given shard, e.g. "2/4".
+func mustGetShardLabels(
+	ctx context.Context,
+	logger *console.Logger,
+	graph *dag.DirectedTargetGraph,
+	selector *selection.Selector,
+	shard string,
+) map[label.TargetLabel]bool {
+	index, count, err := selection.ParseShard(shard)
+	if err != nil {
+		logger.Fatalf("%v", err)
+	}
+
+	shardLabels := make(map[label.TargetLabel]bool)
+	for _, targetLabel := range mustPlanShards(ctx, logger, graph, selector, count, true)[index-1].Targets {
+		shardLabels[targetLabel] = true
+	}
+	return shardLabels
+}
+
+// mustGetAffectedLabels returns the labels of the targets affected by
+// changes since revision, including their transitive dependents.
+func mustGetAffectedLabels(logger *console.Logger, graph *dag.DirectedTargetGraph, revision string) map[label.TargetLabel]bool {
+	changedFiles, err := getChangedFiles(revision)
+	if err != nil {
+		logger.Fatalf("could not get files changed since %s: %v", revision, err)
+	}
+	logger.Debugf("Changed files: %v", changedFiles)
+
+	affectedLabels := make(map[label.TargetLabel]bool)
+	for _, target := range getAffectedTargets(graph, changedFiles, true) {
+		affectedLabels[target.Label] = true
+	}
+	return affectedLabels
+}
+
 // RunBuild runs the build/test command for the given target pattern.
 // commandOverride optionally overrides the trace command name (e.g. "run").
 // If empty, the command name is derived from the testFilter.
@@ -115,10 +184,12 @@ func RunBuildAndAfter(
 	}
 
 	var traceCollector *tracing.TraceCollector
-	if config.Global.Traces.Enabled {
+	if config.Global.Traces.Enabled || config.Global.Traces.MetricsTextfile != "" {
 		traceCollector = tracing.NewTraceCollector(commandName, targetPatterns, GrogVersion)
 	}
 	errs := analysis.CheckTargetConstraints(logger, graph.GetNodes())
+	errs = append(errs, analysis.CheckUndeclaredDependencies(logger, graph, config.Global.GetUndeclaredDependenciesSeverity())...)
+	errs = append(errs, analysis.CheckMissingInputs(logger, graph)...)
 	if len(errs) > 0 {
 		for _, err := range errs {
 			logger.Errorf(err.Error())
@@ -127,12 +198,32 @@ func RunBuildAndAfter(
 	}
 
 	selector := selection.New(targetPatterns, config.Global.Tags, config.Global.ExcludeTags, testFilter)
+	if buildOptions.since != "" {
+		selector.Labels = mustGetAffectedLabels(logger, graph, buildOptions.since)
+	}
+	if buildOptions.shard != "" {
+		selector.Labels = mustGetShardLabels(ctx, logger, graph, selector, buildOptions.shard)
+	}
 	// Select targets based on the target pattern.
 	selectedCount, skippedCount, err := selector.SelectTargetsForBuild(graph)
 	if err != nil {
 		logger.Fatalf("target selection failed: %v", err)
 	}
 
+	if selectedCount == 0 && buildOptions.since != "" {
+		// Nothing to do is a success when building only what changed
+		logger.Infof("No targets matching %s are affected by changes since %s.",
+			label.PatternSetToString(targetPatterns), buildOptions.since)
+		return
+	}
+
+	if selectedCount == 0 && buildOptions.shard != "" {
+		// Shards may be empty when there are fewer targets than shards
+		logger.Infof("No targets matching %s are assigned to shard %s.",
+			label.PatternSetToString(targetPatterns), buildOptions.shard)
+		return
+	}
+
 	if selectedCount == 0 {
 		// Fail if no targets were selected
 		errString := fmt.Sprintf("could not find any targets matching %s", label.PatternSetToString(targetPatterns))
@@ -200,6 +291,15 @@ func RunBuildAndAfter(
 	if afterBuildSuccess != nil {
 		executor.DeferAsyncWait()
 	}
+	if config.Global.RemoteExecution.Enabled() {
+		remoteClient, err := remote.Dial(config.Global.RemoteExecution)
+		if err != nil {
+			logger.Warnf("Remote execution is unavailable, running all targets locally: %v", err)
+		} else {
+			defer remoteClient.Close()
+			executor.SetRemoteClient(remoteClient)
+		}
+	}
 	completionMap, executionErr := executor.Execute(ctx)
 
 	goal := "Build"
@@ -257,24 +357,27 @@ func RunBuildAndAfter(
 	if traceCollector != nil && completionMap != nil {
 		buildTrace := traceCollector.Finalize(completionMap, graph, executor.AsyncWaitTime())
 
-		// Use the dedicated traces backend if configured, otherwise fall back to the main cache
-		traceBackend := cache
-		if config.Global.Traces.Backend != "" {
-			traceCacheConfig := config.CacheConfig{
-				Backend: config.Global.Traces.Backend,
-				GCS:     config.Global.Traces.GCS,
-				S3:      config.Global.Traces.S3,
+		if config.Global.Traces.Enabled {
+			// Use the dedicated traces backend if configured, otherwise fall back to the main cache
+			traceBackend := cache
+			if config.Global.Traces.Backend != "" {
+				if tb, err := backends.GetCacheBackend(ctx, config.Global.GetTracesCacheConfig()); err == nil {
+					traceBackend = tb
+				} else {
+					logger.Warnf("failed to instantiate traces backend, falling back to cache: %v", err)
+				}
 			}
-			if tb, err := backends.GetCacheBackend(ctx, traceCacheConfig); err == nil {
-				traceBackend = tb
-			} else {
-				logger.Warnf("failed to instantiate traces backend, falling back to cache: %v", err)
+
+			traceWriter := tracing.NewTraceWriter(traceBackend)
+			if err := traceWriter.Write(context.WithoutCancel(ctx), buildTrace); err != nil {
+				logger.Warnf("failed to write trace: %v", err)
 			}
 		}
 
-		traceWriter := tracing.NewTraceWriter(traceBackend)
-		if err := traceWriter.Write(context.WithoutCancel(ctx), buildTrace); err != nil {
-			logger.Warnf("failed to write trace: %v", err)
+		if textfile := config.Global.GetMetricsTextfilePath(); textfile != "" {
+			if err := tracing.WriteMetricsTextfile(textfile, buildTrace); err != nil {
+				logger.Warnf("failed to write metrics textfile: %v", err)
+			}
 		}
 	}
 
@@ -18,7 +18,8 @@ var BuildAndTestCmd = &cobra.Command{
 	Long:    `Loads the user configuration, checks which targets need to be rebuilt based on file hashes, builds the dependency graph, and executes both build and test targets.`,
 	Example: `  grog build-and-test                      # Build all targets and run all tests in the current package and subpackages
   grog build-and-test //path/to/package:target  # Build or test a specific target
-  grog build-and-test //path/to/package/...     # Build all targets and run all tests in a package and subpackages`,
+  grog build-and-test //path/to/package/...     # Build all targets and run all tests in a package and subpackages
+  grog build-and-test //... --since=origin/main # Build and test all targets affected by changes since origin/main`,
 	Args:              cobra.ArbitraryArgs,
 	ValidArgsFunction: completions.AllTargetPatternCompletion,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -49,5 +50,7 @@ var BuildAndTestCmd = &cobra.Command{
 }
 
 func AddBuildAndTestCmd(rootCmd *cobra.Command) {
+	addSinceFlag(BuildAndTestCmd)
+	addShardFlag(BuildAndTestCmd)
 	rootCmd.AddCommand(BuildAndTestCmd)
 }
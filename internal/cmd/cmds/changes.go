@@ -11,6 +11,7 @@ import (
 	"grog/internal/cmd/flagtypes"
 	"grog/internal/config"
 	"grog/internal/console"
+	"grog/internal/dag"
 	"grog/internal/label"
 	"grog/internal/loading"
 	"grog/internal/model"
@@ -21,6 +22,7 @@ import (
 
 var changesOptions = struct {
 	since      string
+	semantic   bool
 	dependents *flagtypes.Enum
 	targetType *flagtypes.Enum
 }{
@@ -32,10 +34,15 @@ var ChangesCmd = &cobra.Command{
 	Use:   "changes",
 	Short: "Lists targets whose inputs have been modified since a given commit.",
 	Long: `Identifies targets that need to be rebuilt due to changes in their input files since a specified Git commit or Jujutsu revision.
-Can optionally include transitive dependents of changed targets to find all affected targets.`,
+Can optionally include transitive dependents of changed targets to find all affected targets.
+
+With --semantic, the packages are also loaded at the given revision in a temporary Git worktree or Jujutsu workspace.
+Each target's definition (command, outputs, dependencies, ...) and input files are compared between both revisions
+and every target whose change hash would differ is listed together with the reasons.`,
 	Example: `  grog changes --since=HEAD~1                      # Show targets changed in the last commit
   grog changes --since=main --dependents=transitive  # Show targets changed since main branch, including dependents
-  grog changes --since=v1.0.0 --target-type=test     # Show only test targets changed since Git tag v1.0.0`,
+  grog changes --since=v1.0.0 --target-type=test     # Show only test targets changed since Git tag v1.0.0
+  grog changes --since=main --semantic               # Compare target definitions with the main branch and show why they changed`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, logger := console.SetupCommand()
@@ -69,67 +76,85 @@ Can optionally include transitive dependents of changed targets to find all affe
 			logger.Fatalf("could not build graph: %v", err)
 		}
 
-		// Find nodes that own the changed files
-		var matchingTargets []*model.Target
-		for _, target := range nodes.GetTargets() {
-			for _, inputFile := range target.Inputs {
-				// Get the absolute path of the input file
-				absInputPath := config.GetPathAbsoluteToWorkspaceRoot(filepath.Join(
-					target.Label.Package,
-					inputFile,
-				))
-
-				if containsFile(changedFiles, absInputPath) {
-					matchingTargets = append(matchingTargets, target)
-					break // Found a match, no need to check other inputs
-				}
-			}
+		targetTypeFilter, err := selection.StringToTargetTypeSelection(changesOptions.targetType.Value)
+		if err != nil {
+			logger.Fatalf(err.Error())
 		}
+		selector := selection.New(nil, config.Global.Tags, config.Global.ExcludeTags, targetTypeFilter)
 
-		// Check package definitions
-		for _, pkg := range packages {
-			for _, target := range pkg.Targets {
-				if containsFile(changedFiles, target.SourceFilePath) {
-					// Add this target if the package source file changed
-					matchingTargets = append(matchingTargets, target)
-				}
-			}
+		if changesOptions.semantic {
+			printSemanticChanges(ctx, logger, cmd, nodes, selector)
+			return
 		}
 
-		// Get dependents if requested
-		var resultTargets []*model.Target
-		if changesOptions.dependents.Value == "transitive" {
-			// Get all transitive dependents of the matching nodes
-			for _, target := range matchingTargets {
-				resultTargets = append(resultTargets, target)
-				for _, descendant := range graph.GetDescendants(target) {
-					if targetDescendant, ok := descendant.(*model.Target); ok {
-						resultTargets = append(resultTargets, targetDescendant)
-					}
-				}
-			}
-		} else {
-			resultTargets = matchingTargets
+		affectedTargets := getAffectedTargets(graph, changedFiles, changesOptions.dependents.Value == "transitive")
+		affectedNodes := make([]model.BuildNode, 0, len(affectedTargets))
+		for _, target := range affectedTargets {
+			affectedNodes = append(affectedNodes, target)
 		}
 
-		// Deduplicate nodes
-		uniqueLabels := make(map[label.TargetLabel]bool)
-		var deduplicatedTargets []model.BuildNode
-		for _, target := range resultTargets {
-			if !uniqueLabels[target.Label] {
-				uniqueLabels[target.Label] = true
-				deduplicatedTargets = append(deduplicatedTargets, target)
+		model.PrintSortedLabels(selector.FilterNodes(affectedNodes))
+	},
+}
+
+// getAffectedTargets returns the targets that own one of the changed files,
+// either as an input, as the BUILD file that defines them or as a file read
+// while loading that BUILD file (e.g. a Starlark module). A change to
+// grog.toml affects every target. With transitive set, the transitive
+// dependents of these targets are included as well.
+func getAffectedTargets(graph *dag.DirectedTargetGraph, changedFiles []string, transitive bool) []*model.Target {
+	targets := graph.GetNodes().GetTargets()
+	workspaceConfigChanged := containsFile(changedFiles, config.GetPathAbsoluteToWorkspaceRoot("grog.toml"))
+
+	// Find targets that own the changed files
+	var matchingTargets []*model.Target
+	for _, target := range targets {
+		if workspaceConfigChanged || containsFile(changedFiles, target.SourceFilePath) ||
+			slices.ContainsFunc(target.LoaderDependencies, func(dependency string) bool { return containsFile(changedFiles, dependency) }) {
+			matchingTargets = append(matchingTargets, target)
+			continue
+		}
+
+		for _, inputFile := range target.Inputs {
+			// Get the absolute path of the input file
+			absInputPath := config.GetPathAbsoluteToWorkspaceRoot(filepath.Join(
+				target.Label.Package,
+				inputFile,
+			))
+
+			if containsFile(changedFiles, absInputPath) {
+				matchingTargets = append(matchingTargets, target)
+				break // Found a match, no need to check other inputs
 			}
 		}
+	}
 
-		targetTypeFilter, err := selection.StringToTargetTypeSelection(changesOptions.targetType.Value)
-		if err != nil {
-			logger.Fatalf(err.Error())
+	// Get dependents if requested
+	var resultTargets []*model.Target
+	if transitive {
+		// Get all transitive dependents of the matching nodes
+		for _, target := range matchingTargets {
+			resultTargets = append(resultTargets, target)
+			for _, descendant := range graph.GetDescendants(target) {
+				if targetDescendant, ok := descendant.(*model.Target); ok {
+					resultTargets = append(resultTargets, targetDescendant)
+				}
+			}
 		}
-		selector := selection.New(nil, config.Global.Tags, config.Global.ExcludeTags, targetTypeFilter)
+	} else {
+		resultTargets = matchingTargets
+	}
 
-		model.PrintSortedLabels(selector.FilterNodes(deduplicatedTargets))
-	},
+	// Deduplicate targets
+	uniqueLabels := make(map[label.TargetLabel]bool)
+	var deduplicatedTargets []*model.Target
+	for _, target := range resultTargets {
+		if !uniqueLabels[target.Label] {
+			uniqueLabels[target.Label] = true
+			deduplicatedTargets = append(deduplicatedTargets, target)
+		}
+	}
+	return deduplicatedTargets
 }
 
 // getChangedFiles returns a list of files that have changed since the given revision
@@ -268,6 +293,12 @@ func AddChangesCmd(rootCmd *cobra.Command) {
 		"",
 		"Git ref or Jujutsu revision to compare against")
 
+	flags.BoolVar(
+		&changesOptions.semantic,
+		"semantic",
+		false,
+		"Compare target definitions and inputs with the revision and show the reasons for each change")
+
 	flags.Var(
 		changesOptions.dependents,
 		"dependents",
@@ -1,7 +1,9 @@
 package cmds
 
 import (
+	"fmt"
 	"grog/internal/analysis"
+	"grog/internal/config"
 	"grog/internal/console"
 	"grog/internal/loading"
 	"os"
@@ -9,18 +11,37 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var checkPolicyReport bool
+
 var CheckCmd = &cobra.Command{
-	Use:     "check",
-	Short:   "Loads the build graph and runs basic consistency checks.",
-	Long:    `Loads the build graph and performs the same consistency checks as 'grog build' without actually building anything.`,
-	Example: `  grog check  # Validate the build graph for consistency issues`,
-	Args:    cobra.NoArgs,
+	Use:   "check",
+	Short: "Loads the build graph and runs basic consistency checks.",
+	Long: `Loads the build graph and performs the same consistency checks as 'grog build' without actually building anything.
+
+With --policy-report, every policy declared in grog.toml is listed together with all dependency edges that violate it.`,
+	Example: `  grog check                  # Validate the build graph for consistency issues
+  grog check --policy-report  # List every dependency edge that violates a policy`,
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, logger := console.SetupCommand()
 
 		graph := loading.MustLoadGraphForBuild(ctx, logger)
 
+		if checkPolicyReport {
+			violations, err := analysis.CheckPolicies(graph.GetNodes(), config.Global.Policies)
+			if err != nil {
+				logger.Fatalf("could not check policies: %v", err)
+			}
+			printPolicyReport(config.Global.Policies, violations)
+			if len(violations) > 0 {
+				logger.Errorf("%d dependencies violate workspace policies", len(violations))
+				os.Exit(1)
+			}
+		}
+
 		errs := analysis.CheckTargetConstraints(logger, graph.GetNodes())
+		errs = append(errs, analysis.CheckUndeclaredDependencies(logger, graph, config.Global.GetUndeclaredDependenciesSeverity())...)
+		errs = append(errs, analysis.CheckMissingInputs(logger, graph)...)
 		if len(errs) > 0 {
 			for _, err := range errs {
 				logger.Errorf(err.Error())
@@ -31,3 +52,39 @@ var CheckCmd = &cobra.Command{
 		logger.Infof("Build graph is valid.")
 	},
 }
+
+// printPolicyReport lists each policy followed by the edges that violate it.
+func printPolicyReport(policies []config.PolicyConfig, violations []analysis.PolicyViolation) {
+	if len(policies) == 0 {
+		fmt.Println("No policies are configured in grog.toml.")
+		return
+	}
+
+	for i, policy := range policies {
+		if i > 0 {
+			fmt.Println()
+		}
+		header := fmt.Sprintf("%s (%s)", policy.Name, policy.Effect)
+		if policy.Description != "" {
+			header += ": " + policy.Description
+		}
+		fmt.Println(header)
+
+		count := 0
+		for _, violation := range violations {
+			if violation.Policy.Name != policy.Name {
+				continue
+			}
+			fmt.Printf("  %s\n", violation.Edge())
+			count++
+		}
+		if count == 0 {
+			fmt.Println("  no violations")
+		}
+	}
+}
+
+func AddCheckCmd(rootCmd *cobra.Command) {
+	CheckCmd.Flags().BoolVar(&checkPolicyReport, "policy-report", false, "List every dependency that violates a policy in grog.toml")
+	rootCmd.AddCommand(CheckCmd)
+}
@@ -0,0 +1,85 @@
+package edit
+
+import (
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"grog/internal/completions"
+	"grog/internal/console"
+	"grog/internal/loading"
+)
+
+var addDepCmd = &cobra.Command{
+	Use:   "add-dep <dependency> <target-patterns...>",
+	Short: "Adds a dependency to all matching targets.",
+	Example: `  grog edit add-dep //libs/logging:lib //services/...   # Add a dependency to every service target
+  grog edit add-dep :gen :build                         # Labels are relative to the current package`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completions.AllTargetPatternCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, logger := console.SetupCommand()
+		graph := loading.MustLoadGraphForQuery(ctx, logger)
+
+		dependency := mustParseLabel(logger, args[0])
+		if _, exists := graph.GetNodes()[dependency]; !exists {
+			logger.Fatalf("dependency %s does not exist", dependency)
+		}
+
+		workspace := newWorkspaceEdit()
+		for _, target := range mustSelectTargets(logger, graph, args[1:]) {
+			if target.Label == dependency {
+				continue
+			}
+			document, ok := workspace.literalDocument(logger, target)
+			if !ok {
+				continue
+			}
+			if _, err := document.AddDependency(target.Label.Name, dependency); err != nil {
+				logger.Fatalf("could not add dependency to %s: %v", target.Label, err)
+			}
+		}
+		workspace.mustWrite(logger)
+	},
+}
+
+var removeDepCmd = &cobra.Command{
+	Use:               "remove-dep <dependency> <target-patterns...>",
+	Short:             "Removes a dependency from all matching targets.",
+	Example:           `  grog edit remove-dep //libs/legacy:lib //...   # Drop a dependency everywhere`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completions.AllTargetPatternCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, logger := console.SetupCommand()
+		graph := loading.MustLoadGraphForQuery(ctx, logger)
+
+		dependency := mustParseLabel(logger, args[0])
+
+		workspace := newWorkspaceEdit()
+		for _, target := range mustSelectTargets(logger, graph, args[1:]) {
+			if !slices.Contains(target.Dependencies, dependency) {
+				continue
+			}
+			document, ok := workspace.literalDocument(logger, target)
+			if !ok {
+				continue
+			}
+			removed, err := document.RemoveDependency(target.Label.Name, dependency)
+			if err != nil {
+				logger.Fatalf("could not remove dependency from %s: %v", target.Label, err)
+			}
+			if !removed {
+				logger.Warnf("dependency of %s on %s is not literal and must be removed manually", target.Label, dependency)
+			}
+		}
+		workspace.mustWrite(logger)
+	},
+}
+
+func registerAddDepCmd() {
+	Cmd.AddCommand(addDepCmd)
+}
+
+func registerRemoveDepCmd() {
+	Cmd.AddCommand(removeDepCmd)
+}
@@ -0,0 +1,263 @@
+package edit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"grog/internal/buildfile"
+	"grog/internal/config"
+	"grog/internal/console"
+	"grog/internal/dag"
+	"grog/internal/label"
+	"grog/internal/model"
+)
+
+var dryRun bool
+
+var Cmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Programmatically edits BUILD files.",
+	Long: `Applies refactorings to BUILD files, similar to buildozer.
+Targets are selected with target patterns and edited in place in the BUILD.json, BUILD.yaml or BUILD.star file that defines them.
+Comments and the layout of unrelated parts of a file are preserved where possible.
+In Starlark files only targets defined by a top level target() call with literal arguments can be edited.`,
+}
+
+func AddCmd(rootCmd *cobra.Command) {
+	Cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "List the files that would be changed without writing them")
+	registerAddDepCmd()
+	registerRemoveDepCmd()
+	registerSetAttrCmd()
+	registerRenameCmd()
+	registerMoveCmd()
+	rootCmd.AddCommand(Cmd)
+}
+
+// workspaceEdit collects the BUILD files touched by an edit command so that
+// each file is parsed once and all changes are written together at the end.
+type workspaceEdit struct {
+	documents map[string]buildfile.Document
+	original  map[string][]byte
+}
+
+func newWorkspaceEdit() *workspaceEdit {
+	return &workspaceEdit{
+		documents: make(map[string]buildfile.Document),
+		original:  make(map[string][]byte),
+	}
+}
+
+// document opens the BUILD file at the absolute path. Files that do not exist
+// yet are opened empty and created when the edit is written.
+func (w *workspaceEdit) document(filePath string) (buildfile.Document, error) {
+	if document, ok := w.documents[filePath]; ok {
+		return document, nil
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	packagePath, err := config.GetPackagePath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := buildfile.Open(filePath, content, packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", displayPath(filePath), err)
+	}
+	w.documents[filePath] = document
+	w.original[filePath] = content
+	return document, nil
+}
+
+// write stores all changed documents and returns the number of changed files.
+func (w *workspaceEdit) write(logger *console.Logger) (int, error) {
+	changed := 0
+	for _, filePath := range sortedKeys(w.documents) {
+		content, err := w.documents[filePath].Bytes()
+		if err != nil {
+			return changed, fmt.Errorf("%s: %w", displayPath(filePath), err)
+		}
+		if bytes.Equal(content, w.original[filePath]) {
+			continue
+		}
+		changed++
+
+		if dryRun {
+			fmt.Println(displayPath(filePath))
+			continue
+		}
+
+		mode := os.FileMode(0644)
+		if info, err := os.Stat(filePath); err == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := os.WriteFile(filePath, content, mode); err != nil {
+			return changed, err
+		}
+		logger.Debugf("wrote %s", displayPath(filePath))
+	}
+	return changed, nil
+}
+
+// mustWrite writes the edit and logs a summary.
+func (w *workspaceEdit) mustWrite(logger *console.Logger) {
+	changed, err := w.write(logger)
+	if err != nil {
+		logger.Fatalf("could not write BUILD files: %v", err)
+	}
+	if dryRun {
+		logger.Infof("%d BUILD file(s) would be changed.", changed)
+		return
+	}
+	logger.Infof("Changed %d BUILD file(s).", changed)
+}
+
+// mustSelectTargets returns the targets matching the patterns in alphabetical order.
+func mustSelectTargets(logger *console.Logger, graph *dag.DirectedTargetGraph, patterns []string) []*model.Target {
+	currentPackage, err := config.Global.GetCurrentPackage()
+	if err != nil {
+		logger.Fatalf("could not get current package: %v", err)
+	}
+
+	targetPatterns, err := label.ParsePatterns(currentPackage, patterns)
+	if err != nil {
+		logger.Fatalf("could not parse target pattern: %v", err)
+	}
+
+	var targets []*model.Target
+	for _, node := range graph.GetNodes().NodesAlphabetically() {
+		target, ok := node.(*model.Target)
+		if !ok {
+			continue
+		}
+		for _, pattern := range targetPatterns {
+			if pattern.Matches(target.Label) {
+				targets = append(targets, target)
+				break
+			}
+		}
+	}
+
+	if len(targets) == 0 {
+		logger.Fatalf("no targets matched %s", label.PatternSetToString(targetPatterns))
+	}
+	return targets
+}
+
+// mustParseLabel parses a label relative to the current package.
+func mustParseLabel(logger *console.Logger, rawLabel string) label.TargetLabel {
+	currentPackage, err := config.Global.GetCurrentPackage()
+	if err != nil {
+		logger.Fatalf("could not get current package: %v", err)
+	}
+
+	parsed, err := label.ParseTargetLabel(currentPackage, rawLabel)
+	if err != nil {
+		logger.Fatalf("could not parse label %s: %v", rawLabel, err)
+	}
+	return parsed
+}
+
+// literalDocument opens the file defining the target and reports whether the
+// target can be edited in it. Targets created by macros are skipped with a warning.
+func (w *workspaceEdit) literalDocument(logger *console.Logger, target *model.Target) (buildfile.Document, bool) {
+	if !buildfile.IsEditable(filepath.Base(target.SourceFilePath)) {
+		logger.Warnf("skipping %s: %s cannot be edited", target.Label, displayPath(target.SourceFilePath))
+		return nil, false
+	}
+
+	document, err := w.document(target.SourceFilePath)
+	if err != nil {
+		logger.Fatalf("%v", err)
+	}
+	if !document.HasTarget(target.Label.Name) {
+		logger.Warnf("skipping %s: it is not defined literally in %s", target.Label, displayPath(target.SourceFilePath))
+		return nil, false
+	}
+	return document, true
+}
+
+// rewriteReferences replaces references to oldLabel with newLabel in the
+// files that define the dependants of the node as well as in extraFiles.
+func (w *workspaceEdit) rewriteReferences(
+	logger *console.Logger,
+	graph *dag.DirectedTargetGraph,
+	node model.BuildNode,
+	newLabel label.TargetLabel,
+	extraFiles ...string,
+) {
+	oldLabel := node.GetLabel()
+	dependantFiles := make(map[string]bool)
+	for _, dependant := range graph.GetDependants(node) {
+		dependantFiles[sourceFilePath(dependant)] = true
+	}
+
+	filePaths := append([]string{}, extraFiles...)
+	filePaths = append(filePaths, sortedKeys(dependantFiles)...)
+
+	seen := make(map[string]bool)
+	for _, filePath := range filePaths {
+		if filePath == "" || seen[filePath] {
+			continue
+		}
+		seen[filePath] = true
+
+		if !buildfile.IsEditable(filepath.Base(filePath)) {
+			logger.Warnf("references to %s in %s must be updated manually", oldLabel, displayPath(filePath))
+			continue
+		}
+
+		document, err := w.document(filePath)
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+		count, err := document.RewriteLabels(func(existing label.TargetLabel) (label.TargetLabel, bool) {
+			return newLabel, existing == oldLabel
+		})
+		if err != nil {
+			logger.Fatalf("%s: %v", displayPath(filePath), err)
+		}
+		if count == 0 && dependantFiles[filePath] {
+			logger.Warnf("references to %s in %s are not literal and must be updated manually", oldLabel, displayPath(filePath))
+		}
+	}
+}
+
+func sourceFilePath(node model.BuildNode) string {
+	switch typed := node.(type) {
+	case *model.Target:
+		return typed.SourceFilePath
+	case *model.Alias:
+		return typed.SourceFilePath
+	case *model.Resource:
+		return typed.SourceFilePath
+	}
+	return ""
+}
+
+func displayPath(filePath string) string {
+	relativePath, err := config.GetPathRelativeToWorkspaceRoot(filePath)
+	if err != nil {
+		return filePath
+	}
+	return relativePath
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
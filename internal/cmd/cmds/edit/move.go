@@ -0,0 +1,137 @@
+package edit
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"grog/internal/buildfile"
+	"grog/internal/completions"
+	"grog/internal/config"
+	"grog/internal/console"
+	"grog/internal/label"
+	"grog/internal/loading"
+	"grog/internal/model"
+)
+
+// editableBuildFileNames are tried in order when looking for the BUILD file
+// of the destination package.
+var editableBuildFileNames = []string{"BUILD.yaml", "BUILD.yml", "BUILD.json", "BUILD.star", "BUILD.bzl"}
+
+var moveCmd = &cobra.Command{
+	Use:   "move <target> <package>",
+	Short: "Moves a target to another package and updates all references to it.",
+	Long: `Moves a target definition into the BUILD file of another package and rewrites all dependants to the new label.
+The target is appended to an existing BUILD file of the destination package or a new BUILD file of the same format is created.
+Dependencies of the moved target keep pointing to the same targets.
+Input and output paths are relative to the package: they are rebased when moving to a parent package and are otherwise left unchanged, so move the files along with the target.`,
+	Example: `  grog edit move //services/api:proto //proto        # //services/api:proto becomes //proto:proto
+  grog edit move :lint ..                            # Packages may be given relative to the current package`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completions.AllTargetPatternCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, logger := console.SetupCommand()
+		graph := loading.MustLoadGraphForQuery(ctx, logger)
+
+		oldLabel := mustParseLabel(logger, args[0])
+		node, exists := graph.GetNodes()[oldLabel]
+		if !exists {
+			logger.Fatalf("could not find target %s", oldLabel)
+		}
+		target, ok := node.(*model.Target)
+		if !ok {
+			logger.Fatalf("%s is not a target", oldLabel)
+		}
+
+		currentPackage, err := config.Global.GetCurrentPackage()
+		if err != nil {
+			logger.Fatalf("could not get current package: %v", err)
+		}
+		newLabel, err := label.ParseTargetLabel("", "//"+resolvePackagePath(currentPackage, args[1])+":"+oldLabel.Name)
+		if err != nil {
+			logger.Fatalf("invalid package %s: %v", args[1], err)
+		}
+		if newLabel.Package == oldLabel.Package {
+			logger.Fatalf("%s is already in package %s", oldLabel, args[1])
+		}
+		if _, exists := graph.GetNodes()[newLabel]; exists {
+			logger.Fatalf("%s already exists", newLabel)
+		}
+
+		destinationFile, err := destinationBuildFile(newLabel.Package, filepath.Base(target.SourceFilePath))
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+
+		workspace := newWorkspaceEdit()
+		sourceDocument, ok := workspace.literalDocument(logger, target)
+		if !ok {
+			logger.Fatalf("could not move %s", oldLabel)
+		}
+		definition, err := sourceDocument.RemoveTarget(oldLabel.Name)
+		if err != nil {
+			logger.Fatalf("could not move %s: %v", oldLabel, err)
+		}
+
+		rebased, err := buildfile.RebaseDefinition(definition, oldLabel.Package, newLabel.Package)
+		if err != nil {
+			logger.Fatalf("could not move %s: %v", oldLabel, err)
+		}
+		if !rebased {
+			logger.Warnf("input and output paths of %s are relative to its package and were left unchanged: move the files to %s as well", newLabel, displayPath(filepath.Dir(destinationFile)))
+		}
+
+		destinationDocument, err := workspace.document(destinationFile)
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+		if err := destinationDocument.AppendTarget(definition); err != nil {
+			logger.Fatalf("could not move %s: %v", oldLabel, err)
+		}
+
+		workspace.rewriteReferences(logger, graph, target, newLabel, target.SourceFilePath, destinationFile)
+		workspace.mustWrite(logger)
+	},
+}
+
+func registerMoveCmd() {
+	Cmd.AddCommand(moveCmd)
+}
+
+// resolvePackagePath resolves an absolute ("//pkg") or relative package path.
+func resolvePackagePath(currentPackage string, rawPackage string) string {
+	if strings.HasPrefix(rawPackage, "//") {
+		return strings.Trim(strings.TrimPrefix(rawPackage, "//"), "/")
+	}
+	resolved := path.Join(currentPackage, rawPackage)
+	if resolved == "." {
+		return ""
+	}
+	return resolved
+}
+
+// destinationBuildFile returns the BUILD file a moved target is written to:
+// the existing file of the same format, any other editable BUILD file or a new
+// file of the same format as the source.
+func destinationBuildFile(packagePath string, sourceFileName string) (string, error) {
+	directory := config.GetPathAbsoluteToWorkspaceRoot(packagePath)
+	info, err := os.Stat(directory)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", errors.New(directory + " is not a directory")
+	}
+
+	for _, fileName := range append([]string{sourceFileName}, editableBuildFileNames...) {
+		candidate := filepath.Join(directory, fileName)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return filepath.Join(directory, sourceFileName), nil
+}
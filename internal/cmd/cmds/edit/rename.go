@@ -0,0 +1,60 @@
+package edit
+
+import (
+	"github.com/spf13/cobra"
+
+	"grog/internal/completions"
+	"grog/internal/console"
+	"grog/internal/label"
+	"grog/internal/loading"
+	"grog/internal/model"
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <target> <new-name>",
+	Short: "Renames a target and updates all references to it.",
+	Long: `Renames a target within its package.
+All dependants found in the build graph (targets, aliases and resources) are rewritten to use the new label.
+Use grog edit move to move a target to a different package.`,
+	Example:           `  grog edit rename //services/api:build compile   # //services/api:build becomes //services/api:compile`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completions.AllTargetPatternCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, logger := console.SetupCommand()
+		graph := loading.MustLoadGraphForQuery(ctx, logger)
+
+		oldLabel := mustParseLabel(logger, args[0])
+		node, exists := graph.GetNodes()[oldLabel]
+		if !exists {
+			logger.Fatalf("could not find target %s", oldLabel)
+		}
+		target, ok := node.(*model.Target)
+		if !ok {
+			logger.Fatalf("%s is not a target", oldLabel)
+		}
+
+		newLabel, err := label.ParseTargetLabel(oldLabel.Package, ":"+args[1])
+		if err != nil {
+			logger.Fatalf("invalid target name %s: %v", args[1], err)
+		}
+		if _, exists := graph.GetNodes()[newLabel]; exists {
+			logger.Fatalf("%s already exists", newLabel)
+		}
+
+		workspace := newWorkspaceEdit()
+		document, ok := workspace.literalDocument(logger, target)
+		if !ok {
+			logger.Fatalf("could not rename %s", oldLabel)
+		}
+		if err := document.RenameTarget(oldLabel.Name, newLabel.Name); err != nil {
+			logger.Fatalf("could not rename %s: %v", oldLabel, err)
+		}
+
+		workspace.rewriteReferences(logger, graph, target, newLabel, target.SourceFilePath)
+		workspace.mustWrite(logger)
+	},
+}
+
+func registerRenameCmd() {
+	Cmd.AddCommand(renameCmd)
+}
@@ -0,0 +1,49 @@
+package edit
+
+import (
+	"github.com/spf13/cobra"
+
+	"grog/internal/buildfile"
+	"grog/internal/console"
+	"grog/internal/loading"
+)
+
+var setAttrCmd = &cobra.Command{
+	Use:   "set-attr <attribute> <value> <target-patterns...>",
+	Short: "Sets an attribute on all matching targets.",
+	Long: `Sets an attribute on all matching targets, replacing any previous value.
+The value is parsed as YAML so lists and maps can be written inline.
+Values of string attributes such as command or timeout are always taken verbatim.`,
+	Example: `  grog edit set-attr timeout 10m //integration/...        # Set a timeout on all integration targets
+  grog edit set-attr tags "[no-cache, slow]" //e2e:test   # Replace the tags of a target
+  grog edit set-attr missing_inputs error //...            # Attributes are checked against the target schema`,
+	Args: cobra.MinimumNArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, logger := console.SetupCommand()
+
+		attribute := args[0]
+		if _, err := buildfile.ParseAttributeValue(attribute, args[1]); err != nil {
+			logger.Fatalf("%v", err)
+		}
+
+		graph := loading.MustLoadGraphForQuery(ctx, logger)
+
+		workspace := newWorkspaceEdit()
+		for _, target := range mustSelectTargets(logger, graph, args[2:]) {
+			document, ok := workspace.literalDocument(logger, target)
+			if !ok {
+				continue
+			}
+			// Every target gets its own value node
+			value, _ := buildfile.ParseAttributeValue(attribute, args[1])
+			if err := document.SetAttribute(target.Label.Name, attribute, value); err != nil {
+				logger.Fatalf("could not set %s on %s: %v", attribute, target.Label, err)
+			}
+		}
+		workspace.mustWrite(logger)
+	},
+}
+
+func registerSetAttrCmd() {
+	Cmd.AddCommand(setAttrCmd)
+}
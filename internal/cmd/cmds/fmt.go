@@ -0,0 +1,164 @@
+package cmds
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"grog/internal/buildfile"
+	"grog/internal/config"
+	"grog/internal/console"
+
+	"github.com/boyter/gocodewalker"
+	"github.com/spf13/cobra"
+)
+
+var fmtCheck bool
+
+var FmtCmd = &cobra.Command{
+	Use:   "fmt [paths...]",
+	Short: "Formats BUILD files into their canonical form.",
+	Long: `Rewrites BUILD.json, BUILD.yaml and BUILD.star files into a canonical form.
+JSON and YAML files are ordered by the target configuration fields, the dependencies, inputs and tags lists are sorted and labels within the same package are shortened to ":name".
+Starlark files are laid out like buildifier does and the same list rules apply to literal arguments of target(), resource(), alias() and environment().
+Paths may be BUILD files or directories, which are searched recursively. Defaults to the current directory.`,
+	Example: `  grog fmt                 # Format all BUILD files in the current directory and below
+  grog fmt services/api    # Format the BUILD files of a directory tree
+  grog fmt --check         # Exit non-zero if any BUILD file is not formatted (for CI)`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, logger := console.SetupCommand()
+
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+
+		filePaths, err := collectBuildFiles(args)
+		if err != nil {
+			logger.Fatalf("could not collect BUILD files: %v", err)
+		}
+
+		var unformatted []string
+		var failed bool
+		for _, filePath := range filePaths {
+			changed, err := formatBuildFile(filePath, !fmtCheck)
+			if err != nil {
+				logger.Errorf("%s: %v", filePath, err)
+				failed = true
+				continue
+			}
+			if changed {
+				unformatted = append(unformatted, filePath)
+			}
+		}
+
+		for _, filePath := range unformatted {
+			displayPath, err := config.GetPathRelativeToWorkspaceRoot(filePath)
+			if err != nil {
+				displayPath = filePath
+			}
+			if fmtCheck {
+				fmt.Println(displayPath)
+			} else {
+				logger.Infof("Formatted %s", displayPath)
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+		if fmtCheck && len(unformatted) > 0 {
+			logger.Errorf("%d BUILD file(s) are not formatted. Run grog fmt to fix them.", len(unformatted))
+			os.Exit(1)
+		}
+	},
+}
+
+// collectBuildFiles resolves the given files and directories to a sorted list
+// of absolute BUILD file paths. Directories are walked the same way the
+// package loader walks the workspace (respecting ignore files).
+func collectBuildFiles(paths []string) ([]string, error) {
+	unique := make(map[string]bool)
+	for _, path := range paths {
+		absolutePath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := os.Stat(absolutePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			if !buildfile.IsFormattable(filepath.Base(absolutePath)) {
+				return nil, fmt.Errorf("%s is not a BUILD file that grog fmt supports", path)
+			}
+			unique[absolutePath] = true
+			continue
+		}
+
+		fileListQueue := make(chan *gocodewalker.File, 100)
+		fileWalker := gocodewalker.NewFileWalker(absolutePath, fileListQueue)
+		fileWalker.IncludeHidden = config.Global.IncludeHidden
+
+		walkErrors := make(chan error, 1)
+		go func() {
+			walkErrors <- fileWalker.Start()
+		}()
+		for fileEntry := range fileListQueue {
+			if buildfile.IsFormattable(fileEntry.Filename) {
+				unique[fileEntry.Location] = true
+			}
+		}
+		if walkErr := <-walkErrors; walkErr != nil {
+			return nil, walkErr
+		}
+	}
+
+	filePaths := make([]string, 0, len(unique))
+	for filePath := range unique {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+	return filePaths, nil
+}
+
+// formatBuildFile formats a single BUILD file and reports whether its content
+// differed from the canonical form. The file is only rewritten if write is set.
+func formatBuildFile(filePath string, write bool) (bool, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return false, err
+	}
+
+	packagePath, err := config.GetPackagePath(filePath)
+	if err != nil {
+		return false, err
+	}
+
+	formatted, err := buildfile.Format(filePath, content, packagePath)
+	if err != nil {
+		return false, err
+	}
+
+	if bytes.Equal(content, formatted) {
+		return false, nil
+	}
+	if write {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(filePath, formatted, info.Mode().Perm()); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func AddFmtCmd(rootCmd *cobra.Command) {
+	FmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "Do not write files; list unformatted files and exit non-zero if there are any")
+	rootCmd.AddCommand(FmtCmd)
+}
@@ -0,0 +1,222 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"grog/internal/config"
+	"grog/internal/console"
+	"grog/internal/gen"
+	"grog/internal/loading"
+	"grog/internal/model"
+
+	"github.com/boyter/gocodewalker"
+	"github.com/spf13/cobra"
+)
+
+var (
+	genCheck     bool
+	genLanguages []string
+)
+
+var GenCmd = &cobra.Command{
+	Use:   "gen [paths...]",
+	Short: "Generates BUILD targets from source file imports.",
+	Long: `Creates or updates targets in BUILD.yaml and BUILD.json files based on the source files of each directory, similar to Gazelle.
+Each language extension generates a library and a test target per directory and turns the imports of the source files into dependencies on the targets that own the imported files (see grog owners).
+
+Languages:
+  go      "go" (non-test files) and "go_test" (go test .) targets; package imports are resolved using the go.mod files of the workspace.
+  python  "py" and "py_test" (pytest) targets; imports are resolved to first-party modules below the workspace root, project roots (pyproject.toml, setup.py) and their src directories.
+
+Only the inputs and dependencies of existing targets are updated; all other attributes are left alone.
+Add a "# keep" comment to a target, an attribute or a list item to protect it from being changed or removed.
+Targets defined in other BUILD file formats are skipped.
+Paths are directories which are searched recursively. Defaults to the current directory.`,
+	Example: `  grog gen                     # Generate targets for the current directory and below
+  grog gen --lang=go services  # Only run the Go extension for a directory tree
+  grog gen --check             # Exit non-zero if any BUILD file is out of date (for CI)`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, logger := console.SetupCommand()
+
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+
+		languages, err := selectLanguages(genLanguages)
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+
+		workspace, err := collectWorkspaceFiles()
+		if err != nil {
+			logger.Fatalf("could not list workspace files: %v", err)
+		}
+
+		directories, err := selectGenDirectories(workspace, args)
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+
+		// The graph is not built since stale dependencies are what grog gen fixes
+		packages, err := loading.LoadAllPackages(ctx)
+		if err != nil {
+			logger.Fatalf("could not load packages: %v", err)
+		}
+		nodes, err := model.BuildNodeMapFromPackages(packages)
+		if err != nil {
+			logger.Fatalf("could not create target map: %v", err)
+		}
+
+		generator := gen.NewGenerator(logger, workspace, languages, nodes.GetTargets())
+		changed, err := generator.Generate(directories)
+		if err != nil {
+			logger.Fatalf("could not generate targets: %v", err)
+		}
+
+		filePaths := make([]string, 0, len(changed))
+		for filePath := range changed {
+			filePaths = append(filePaths, filePath)
+		}
+		slices.Sort(filePaths)
+
+		for _, filePath := range filePaths {
+			displayPath, err := config.GetPathRelativeToWorkspaceRoot(filePath)
+			if err != nil {
+				displayPath = filePath
+			}
+			if genCheck {
+				fmt.Println(displayPath)
+				continue
+			}
+
+			mode := os.FileMode(0644)
+			if info, err := os.Stat(filePath); err == nil {
+				mode = info.Mode().Perm()
+			}
+			if err := os.WriteFile(filePath, changed[filePath], mode); err != nil {
+				logger.Fatalf("could not write %s: %v", displayPath, err)
+			}
+			logger.Infof("Updated %s", displayPath)
+		}
+
+		if genCheck && len(filePaths) > 0 {
+			logger.Errorf("%d BUILD file(s) are out of date. Run grog gen to update them.", len(filePaths))
+			os.Exit(1)
+		}
+	},
+}
+
+func selectLanguages(names []string) ([]gen.Language, error) {
+	available := gen.Languages()
+	if len(names) == 0 {
+		return available, nil
+	}
+
+	var selected []gen.Language
+	for _, name := range names {
+		index := slices.IndexFunc(available, func(language gen.Language) bool {
+			return language.Name() == name
+		})
+		if index < 0 {
+			var availableNames []string
+			for _, language := range available {
+				availableNames = append(availableNames, language.Name())
+			}
+			return nil, fmt.Errorf("unknown language %q (available: %s)", name, strings.Join(availableNames, ", "))
+		}
+		selected = append(selected, available[index])
+	}
+	return selected, nil
+}
+
+// collectWorkspaceFiles lists all files of the workspace by directory.
+// The whole workspace is needed to resolve imports into other directories.
+func collectWorkspaceFiles() (*gen.Workspace, error) {
+	workspace := &gen.Workspace{
+		Root:  config.Global.WorkspaceRoot,
+		Files: make(map[string][]string),
+	}
+
+	fileListQueue := make(chan *gocodewalker.File, 100)
+	fileWalker := gocodewalker.NewFileWalker(config.Global.WorkspaceRoot, fileListQueue)
+	fileWalker.IncludeHidden = config.Global.IncludeHidden
+
+	walkErrors := make(chan error, 1)
+	go func() {
+		walkErrors <- fileWalker.Start()
+	}()
+	for fileEntry := range fileListQueue {
+		directory, err := genDirectory(filepath.Dir(fileEntry.Location))
+		if err != nil {
+			continue
+		}
+		workspace.Files[directory] = append(workspace.Files[directory], fileEntry.Filename)
+	}
+	if walkErr := <-walkErrors; walkErr != nil {
+		return nil, walkErr
+	}
+
+	for _, fileNames := range workspace.Files {
+		slices.Sort(fileNames)
+	}
+	return workspace, nil
+}
+
+// selectGenDirectories returns the sorted workspace directories at or below the given paths.
+func selectGenDirectories(workspace *gen.Workspace, paths []string) ([]string, error) {
+	var directories []string
+	for _, argument := range paths {
+		absolutePath, err := filepath.Abs(argument)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(absolutePath)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", argument)
+		}
+
+		prefix, err := genDirectory(absolutePath)
+		if err != nil {
+			return nil, err
+		}
+		for directory := range workspace.Files {
+			if prefix == "" || directory == prefix || strings.HasPrefix(directory, prefix+"/") {
+				if !slices.Contains(directories, directory) {
+					directories = append(directories, directory)
+				}
+			}
+		}
+	}
+	slices.Sort(directories)
+	return directories, nil
+}
+
+// genDirectory converts an absolute directory to the workspace relative form
+// used by grog gen ("" for the workspace root).
+func genDirectory(absolutePath string) (string, error) {
+	relativePath, err := filepath.Rel(config.Global.WorkspaceRoot, absolutePath)
+	if err != nil {
+		return "", err
+	}
+	relativePath = filepath.ToSlash(relativePath)
+	if relativePath == "." {
+		return "", nil
+	}
+	if relativePath == ".." || strings.HasPrefix(relativePath, "../") {
+		return "", fmt.Errorf("%s is outside of the workspace", absolutePath)
+	}
+	return relativePath, nil
+}
+
+func AddGenCmd(rootCmd *cobra.Command) {
+	GenCmd.Flags().BoolVar(&genCheck, "check", false, "Do not write files; list out of date BUILD files and exit non-zero if there are any")
+	GenCmd.Flags().StringSliceVar(&genLanguages, "lang", nil, "Language extensions to run (go, python); defaults to all")
+	rootCmd.AddCommand(GenCmd)
+}
@@ -0,0 +1,160 @@
+package cmds
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"grog/internal/buildfile"
+	"grog/internal/config"
+	"grog/internal/console"
+	"grog/internal/importer"
+
+	"github.com/spf13/cobra"
+)
+
+var importDryRun bool
+
+var ImportCmd = &cobra.Command{
+	Use:   "import <tool>",
+	Short: "Generates BUILD files from the configuration of another build tool.",
+	Long: `Translates the task configuration of a JavaScript monorepo tool into BUILD.yaml targets.
+
+Tools:
+  turbo  Reads the npm, yarn or pnpm workspace packages and creates a target for each package.json script that has a task in turbo.json.
+         dependsOn, inputs, outputs and cache are translated; package turbo.json files override the root configuration.
+  nx     Reads project.json files and the package.json scripts nx infers targets from, applying targetDefaults and namedInputs from nx.json.
+         run-commands and run-script executors become commands; other executors are run through nx.
+
+Cross-package dependencies such as "^build" become labels of the targets in the dependency packages.
+Outputs become dir:: or file:: outputs and the outputs of a target are excluded from its inputs.
+Targets are appended to the existing BUILD.yaml or BUILD.json file of a package or written to a new BUILD.yaml file.
+Targets that already exist are left unchanged.
+Constructs that could not be translated (environment variables, persistent tasks, plugins, ...) are listed in a report at the end.`,
+	Example: `  grog import turbo            # Translate turbo.json tasks
+  grog import nx --dry-run     # List the BUILD files that would be written and print the report`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"turbo", "nx"},
+	Run: func(cmd *cobra.Command, args []string) {
+		_, logger := console.SetupCommand()
+
+		importTool, ok := importer.Tools[args[0]]
+		if !ok {
+			logger.Fatalf("unknown tool %q (available: turbo, nx)", args[0])
+		}
+
+		result, err := importTool(config.Global.WorkspaceRoot)
+		if err != nil {
+			logger.Fatalf("could not import %s configuration: %v", args[0], err)
+		}
+
+		written, targetCount, err := writeImportedPackages(result)
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+
+		for _, filePath := range written {
+			if importDryRun {
+				fmt.Println(filePath)
+			} else {
+				logger.Infof("Wrote %s", filePath)
+			}
+		}
+		if importDryRun {
+			logger.Infof("%s would be imported into %d BUILD file(s).", console.FCountTargets(targetCount), len(written))
+		} else {
+			logger.Infof("Imported %s into %d BUILD file(s).", console.FCountTargets(targetCount), len(written))
+		}
+
+		if len(result.Issues) > 0 {
+			logger.Warnf("%d construct(s) could not be translated:", len(result.Issues))
+			for _, issue := range result.Issues {
+				fmt.Println("  " + issue.String())
+			}
+		}
+	},
+}
+
+// writeImportedPackages appends the imported targets to the BUILD files of
+// their packages. Targets that already exist are reported and skipped.
+// Returns the workspace relative paths of the changed files and the number of
+// imported targets.
+func writeImportedPackages(result *importer.Result) ([]string, int, error) {
+	var written []string
+	targetCount := 0
+
+	packagePaths := make([]string, 0, len(result.Packages))
+	for packagePath := range result.Packages {
+		packagePaths = append(packagePaths, packagePath)
+	}
+	slices.Sort(packagePaths)
+
+	for _, packagePath := range packagePaths {
+		filePath := importBuildFilePath(packagePath)
+		displayPath, err := config.GetPathRelativeToWorkspaceRoot(filePath)
+		if err != nil {
+			displayPath = filePath
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, 0, err
+		}
+		document, err := buildfile.Open(filePath, content, packagePath)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", displayPath, err)
+		}
+
+		for _, target := range result.Packages[packagePath] {
+			if document.HasTarget(target.Name) {
+				result.Issues = append(result.Issues, importer.Issue{
+					Package: packagePath,
+					Task:    target.Name,
+					Message: fmt.Sprintf("already defined in %s and not imported", displayPath),
+				})
+				continue
+			}
+			if err := document.AppendTarget(importer.Definition(packagePath, target)); err != nil {
+				return nil, 0, fmt.Errorf("%s: %w", displayPath, err)
+			}
+			targetCount++
+		}
+
+		updated, err := document.Bytes()
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", displayPath, err)
+		}
+		if bytes.Equal(updated, content) {
+			continue
+		}
+		written = append(written, displayPath)
+		if importDryRun {
+			continue
+		}
+		if err := os.WriteFile(filePath, updated, 0644); err != nil {
+			return nil, 0, err
+		}
+	}
+	return written, targetCount, nil
+}
+
+// importBuildFilePath returns the BUILD.yaml or BUILD.json file of a package,
+// defaulting to a new BUILD.yaml file.
+func importBuildFilePath(packagePath string) string {
+	directory := config.GetPathAbsoluteToWorkspaceRoot(packagePath)
+	for _, fileName := range []string{"BUILD.yaml", "BUILD.yml", "BUILD.json"} {
+		filePath := filepath.Join(directory, fileName)
+		if _, err := os.Stat(filePath); err == nil {
+			return filePath
+		}
+	}
+	return filepath.Join(directory, "BUILD.yaml")
+}
+
+func AddImportCmd(rootCmd *cobra.Command) {
+	ImportCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "List the BUILD files that would be written without writing them")
+	rootCmd.AddCommand(ImportCmd)
+}
@@ -0,0 +1,33 @@
+package cmds
+
+import (
+	"os"
+
+	"grog/internal/console"
+	"grog/internal/lsp"
+
+	"github.com/spf13/cobra"
+)
+
+var LspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Runs a language server for BUILD files over stdio.",
+	Long: `Runs a Language Server Protocol server for BUILD.yaml, BUILD.json and BUILD.star files over stdin/stdout.
+It completes target labels in dependencies, jumps from a label to the BUILD file that defines it,
+shows the inputs, outputs and dependants of a target on hover and reports the same consistency
+problems as 'grog check' (e.g. dependency cycles) as diagnostics whenever a BUILD file is opened or saved.
+Diagnostics are computed from the files on disk, so unsaved changes are only checked once saved.`,
+	Example: `  grog lsp  # Start the language server (usually launched by your editor)`,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// The protocol owns stdout, so logs and anything else that would be
+		// printed while loading packages go to stderr instead.
+		protocolOutput := os.Stdout
+		os.Stdout = os.Stderr
+
+		ctx, logger := console.SetupCommand()
+		if err := lsp.NewServer(ctx, os.Stdin, protocolOutput).Run(); err != nil {
+			logger.Fatalf("language server failed: %v", err)
+		}
+	},
+}
@@ -0,0 +1,184 @@
+package cmds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"grog/internal/cmd/cmds/traces"
+	"grog/internal/cmd/flagtypes"
+	"grog/internal/completions"
+	"grog/internal/config"
+	"grog/internal/console"
+	"grog/internal/dag"
+	"grog/internal/label"
+	"grog/internal/loading"
+	"grog/internal/model"
+	"grog/internal/selection"
+	"grog/internal/tracing"
+
+	"github.com/spf13/cobra"
+)
+
+// planTraceLimit is the number of recent builds used to estimate target costs.
+const planTraceLimit = 50
+
+var planOptions = struct {
+	shards     int
+	format     *flagtypes.Enum
+	targetType *flagtypes.Enum
+}{
+	format:     flagtypes.NewEnum("text", "json"),
+	targetType: flagtypes.NewEnum("all", "test", "no_test", "bin_output"),
+}
+
+type planJSONShard struct {
+	Index           int      `json:"index"`
+	Targets         []string `json:"targets"`
+	EstimatedMillis float64  `json:"estimated_millis"`
+}
+
+var PlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Splits the selected targets into balanced shards for parallel CI jobs.",
+	Long: `Estimates the cost of every selected target from the local trace history and partitions them into shards of similar cost.
+The cost of a target is its average command duration on cache misses weighted by its cache miss rate.
+Targets without history are assumed to cost as much as the average known target.
+
+Targets that share dependencies are kept in the same shard where possible so that each shard builds fewer dependencies.
+The plan only depends on the targets and the trace history, so run "grog traces pull" in every job to get the same plan.
+Instead of passing the printed labels on, each job can also select its shard directly with "grog build --shard=i/N" or "grog test --shard=i/N".`,
+	Example: `  grog plan //... --shards=4                    # Print the targets of 4 shards
+  grog plan //... --shards=4 --target-type=test # Plan the same shards as grog test --shard=i/4
+  grog plan //... --shards=4 --format=json      # Output the shards as JSON`,
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: completions.AllTargetPatternCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, logger := console.SetupCommand()
+
+		if planOptions.shards < 1 {
+			logger.Fatalf("--shards must be at least 1")
+		}
+
+		currentPackagePath, err := config.Global.GetCurrentPackage()
+		if err != nil {
+			logger.Fatalf("could not get current package: %v", err)
+		}
+
+		targetPatterns, err := label.ParsePatternsOrMatchCurrentPackageAndSubpackages(currentPackagePath, args)
+		if err != nil {
+			logger.Fatalf("could not parse target pattern: %v", err)
+		}
+
+		targetType, err := selection.StringToTargetTypeSelection(planOptions.targetType.Value)
+		if err != nil {
+			logger.Fatalf(err.Error())
+		}
+
+		graph := loading.MustLoadGraphForBuild(ctx, logger)
+
+		selector := selection.New(targetPatterns, config.Global.Tags, config.Global.ExcludeTags, targetType)
+		if buildOptions.since != "" {
+			selector.Labels = mustGetAffectedLabels(logger, graph, buildOptions.since)
+		}
+
+		shards := mustPlanShards(ctx, logger, graph, selector, planOptions.shards, false)
+
+		if planOptions.format.Value == "json" {
+			jsonShards := make([]planJSONShard, 0, len(shards))
+			for _, shard := range shards {
+				targets := make([]string, 0, len(shard.Targets))
+				for _, targetLabel := range shard.Targets {
+					targets = append(targets, targetLabel.String())
+				}
+				jsonShards = append(jsonShards, planJSONShard{
+					Index:           shard.Index,
+					Targets:         targets,
+					EstimatedMillis: shard.EstimatedMillis,
+				})
+			}
+			jsonData, err := json.MarshalIndent(jsonShards, "", "  ")
+			if err != nil {
+				logger.Fatalf("could not marshal shards to json: %v", err)
+			}
+			fmt.Println(string(jsonData))
+			return
+		}
+
+		for _, shard := range shards {
+			fmt.Printf("# shard %d/%d: %s, estimated %s\n",
+				shard.Index, len(shards), console.FCountTargets(len(shard.Targets)), formatEstimate(shard.EstimatedMillis))
+			for _, targetLabel := range shard.Targets {
+				fmt.Println(targetLabel)
+			}
+		}
+	},
+}
+
+func AddPlanCmd(rootCmd *cobra.Command) {
+	PlanCmd.Flags().IntVar(&planOptions.shards, "shards", 1, "Number of shards to split the selected targets into")
+	PlanCmd.Flags().Var(planOptions.format, "format", "Output format. One of: text, json.")
+	PlanCmd.Flags().Var(planOptions.targetType, "target-type", "Filter targets by type (all, test, no_test, bin_output)")
+	addSinceFlag(PlanCmd)
+	rootCmd.AddCommand(PlanCmd)
+}
+
+// mustPlanShards partitions the targets matching selector into count shards
+// using the costs recorded in the local trace history.
+// If the history cannot be read the targets are assumed to cost the same,
+// unless requireCosts is set. Every --shard job plans on its own, so a job
+// that falls back would select its targets from a different plan than the
+// others and targets would be skipped or built twice.
+func mustPlanShards(
+	ctx context.Context,
+	logger *console.Logger,
+	graph *dag.DirectedTargetGraph,
+	selector *selection.Selector,
+	count int,
+	requireCosts bool,
+) []selection.Shard {
+	store := traces.GetStore(ctx, logger)
+	defer store.Close()
+
+	costs, err := store.TargetCosts(ctx, tracing.StatsOptions{Limit: planTraceLimit})
+	if err != nil {
+		if requireCosts {
+			logger.Fatalf("could not load target costs from traces: %v", err)
+		}
+		logger.Warnf("could not load target costs from traces, assuming equal costs: %v", err)
+		costs = nil
+	}
+
+	targets := selector.MatchingTargets(graph)
+	return selection.PlanShards(graph, targets, count, targetCostFunc(targets, costs))
+}
+
+// targetCostFunc returns the expected cost of a target in milliseconds.
+// Targets without history cost the average of the known targets and every
+// target costs at least a millisecond so that fully cached targets are still
+// spread evenly across shards.
+func targetCostFunc(targets []*model.Target, costs map[string]tracing.TargetCost) func(*model.Target) float64 {
+	defaultCost, known := 0.0, 0
+	for _, target := range targets {
+		if cost, ok := costs[target.Label.String()]; ok {
+			defaultCost += cost.ExpectedMillis()
+			known++
+		}
+	}
+	if known > 0 {
+		defaultCost /= float64(known)
+	}
+
+	return func(target *model.Target) float64 {
+		cost, ok := costs[target.Label.String()]
+		if !ok {
+			return max(defaultCost, 1)
+		}
+		return max(cost.ExpectedMillis(), 1)
+	}
+}
+
+func formatEstimate(millis float64) string {
+	return (time.Duration(millis) * time.Millisecond).String()
+}
@@ -0,0 +1,29 @@
+package cmds
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"grog/internal/console"
+	"grog/internal/loading"
+
+	"github.com/spf13/cobra"
+)
+
+var SchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of BUILD.json and BUILD.yaml files.",
+	Long: `Prints a JSON Schema (draft 2020-12) describing BUILD.json and BUILD.yaml files.
+Reference it from a BUILD.json file ("$schema" key) or a BUILD.yaml file
+("# yaml-language-server: $schema=..." comment) to get completion and validation in your editor.
+Does not require a grog workspace.`,
+	Example: `  grog schema > build.schema.json  # Write the schema to a file that BUILD files can reference`,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		schema, err := json.MarshalIndent(loading.BuildFileSchema(), "", "  ")
+		if err != nil {
+			console.InitLogger().Fatalf("could not encode schema: %v", err)
+		}
+		fmt.Println(string(schema))
+	},
+}
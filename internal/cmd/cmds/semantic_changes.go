@@ -0,0 +1,154 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"grog/internal/config"
+	"grog/internal/console"
+	"grog/internal/hashing"
+	"grog/internal/loading"
+	"grog/internal/model"
+	"grog/internal/selection"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// printSemanticChanges lists the targets whose change hash differs from the
+// one at changesOptions.since together with the reasons. The base revision is
+// loaded with the global flags of cmd.
+func printSemanticChanges(ctx context.Context, logger *console.Logger, cmd *cobra.Command, nodes model.BuildNodeMap, selector *selection.Selector) {
+	baseTree, cleanup, err := loadTargetTreeAtRevision(ctx, changesOptions.since, cmd.Root().PersistentFlags())
+	if err != nil {
+		logger.Fatalf("could not load packages at %s: %v", changesOptions.since, err)
+	}
+	defer cleanup()
+
+	changes, err := hashing.DiffTargets(baseTree, hashing.TargetTree{Nodes: nodes, WorkspaceRoot: config.Global.WorkspaceRoot})
+	if err != nil {
+		logger.Fatalf("could not compare targets: %v", err)
+	}
+
+	var selected []hashing.TargetChange
+	width := 0
+	for _, change := range changes {
+		if !change.Direct && changesOptions.dependents.Value != "transitive" {
+			continue
+		}
+		if !selector.Match(nodes[change.Label]) {
+			continue
+		}
+		selected = append(selected, change)
+		width = max(width, len(change.Label.String()))
+	}
+
+	for _, change := range selected {
+		fmt.Printf("%-*s  %s\n", width, change.Label, strings.Join(change.Reasons, ", "))
+	}
+}
+
+// loadTargetTreeAtRevision checks out revision in a temporary Git worktree or
+// Jujutsu workspace and loads its packages. The returned cleanup function
+// removes the checkout again.
+func loadTargetTreeAtRevision(ctx context.Context, revision string, flags *pflag.FlagSet) (hashing.TargetTree, func(), error) {
+	gitRoot, err := getGitRoot()
+	if err != nil {
+		return hashing.TargetTree{}, nil, err
+	}
+
+	// The grog workspace may be a sub directory of the repository
+	workspaceRoot, err := filepath.EvalSymlinks(config.Global.WorkspaceRoot)
+	if err != nil {
+		return hashing.TargetTree{}, nil, err
+	}
+	relativeWorkspaceRoot, err := filepath.Rel(gitRoot, workspaceRoot)
+	if err != nil {
+		return hashing.TargetTree{}, nil, err
+	}
+
+	tempDirectory, err := os.MkdirTemp("", "grog-changes-")
+	if err != nil {
+		return hashing.TargetTree{}, nil, err
+	}
+	checkoutPath := filepath.Join(tempDirectory, "checkout")
+
+	var addCommand, removeCommand *exec.Cmd
+	if vcsIsJJ(gitRoot) {
+		workspaceName := filepath.Base(tempDirectory)
+		addCommand = exec.Command("jj", "--no-pager", "workspace", "add", "--quiet", "--name", workspaceName, "--revision", revision, checkoutPath)
+		removeCommand = exec.Command("jj", "--no-pager", "workspace", "forget", "--quiet", workspaceName)
+	} else {
+		addCommand = exec.Command("git", "worktree", "add", "--quiet", "--detach", checkoutPath, revision)
+		removeCommand = exec.Command("git", "worktree", "remove", "--force", checkoutPath)
+	}
+	addCommand.Dir = gitRoot
+	removeCommand.Dir = gitRoot
+
+	cleanup := func() {
+		logger := console.GetLogger(ctx)
+		if output, err := removeCommand.CombinedOutput(); err != nil {
+			logger.Warnf("could not remove checkout %s: %v\n%s", checkoutPath, err, output)
+		}
+		if err := os.RemoveAll(tempDirectory); err != nil {
+			logger.Warnf("could not remove %s: %v", tempDirectory, err)
+		}
+	}
+
+	if output, err := addCommand.CombinedOutput(); err != nil {
+		_ = os.RemoveAll(tempDirectory)
+		return hashing.TargetTree{}, nil, fmt.Errorf("could not check out %s: %w\n%s", revision, err, output)
+	}
+
+	baseWorkspace, err := loadWorkspaceConfigAt(filepath.Join(checkoutPath, relativeWorkspaceRoot), flags)
+	if err != nil {
+		cleanup()
+		return hashing.TargetTree{}, nil, err
+	}
+	packages, err := loading.LoadWorkspacePackages(ctx, baseWorkspace)
+	if err != nil {
+		cleanup()
+		return hashing.TargetTree{}, nil, err
+	}
+	nodes, err := model.BuildNodeMapFromPackages(packages)
+	if err != nil {
+		cleanup()
+		return hashing.TargetTree{}, nil, err
+	}
+	return hashing.TargetTree{Nodes: nodes, WorkspaceRoot: baseWorkspace.WorkspaceRoot}, cleanup, nil
+}
+
+// loadWorkspaceConfigAt reads the config file of the checkout at
+// workspaceRoot the same way the root command reads the current one. Only
+// the flags and GROG_ environment variables of the current invocation carry
+// over so that both revisions are loaded the same way.
+func loadWorkspaceConfigAt(workspaceRoot string, flags *pflag.FlagSet) (*config.WorkspaceConfig, error) {
+	configFile := viper.ConfigFileUsed()
+	// Config files outside of the workspace, e.g. in ~/.grog, are not versioned
+	if relativeConfigFile, err := config.GetPathRelativeToWorkspaceRoot(configFile); err == nil {
+		configFile = filepath.Join(workspaceRoot, relativeConfigFile)
+	}
+
+	baseViper := viper.New()
+	baseViper.SetConfigFile(configFile)
+	config.BindEnv(baseViper)
+	config.SetDefaults(baseViper)
+	flags.VisitAll(func(flag *pflag.Flag) {
+		_ = baseViper.BindPFlag(strings.ReplaceAll(flag.Name, "-", "_"), flag)
+	})
+	baseViper.Set("workspace_root", workspaceRoot)
+	if err := baseViper.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", configFile, err)
+	}
+
+	var workspace config.WorkspaceConfig
+	if err := config.Unmarshal(baseViper, &workspace); err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+	return &workspace, nil
+}
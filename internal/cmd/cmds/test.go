@@ -21,7 +21,9 @@ Use "--" to separate the list of targets from additional arguments passed to the
 	Example: `  grog test                                          # Run all tests in the current package and subpackages
   grog test //path/to/package:test                   # Run a specific test
   grog test //path/to/package/...                    # Run all tests in a package and subpackages
-  grog test //path/to/package:test -- -k test_foo    # Pass extra arguments to the test command`,
+  grog test //path/to/package:test -- -k test_foo    # Pass extra arguments to the test command
+  grog test //... --since=origin/main                # Run all tests affected by changes since origin/main
+  grog test //... --shard=1/4                        # Run the first of four shards planned by grog plan --target-type=test`,
 	Args:              cobra.ArbitraryArgs, // Optional argument for target pattern
 	ValidArgsFunction: completions.TestTargetPatternCompletion,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -71,5 +73,7 @@ Use "--" to separate the list of targets from additional arguments passed to the
 }
 
 func AddTestCmd(rootCmd *cobra.Command) {
+	addSinceFlag(TestCmd)
+	addShardFlag(TestCmd)
 	rootCmd.AddCommand(TestCmd)
 }
@@ -0,0 +1,136 @@
+package traces
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"grog/internal/cmd/flagtypes"
+	"grog/internal/console"
+	"grog/internal/tracing"
+)
+
+var (
+	diffFormat    = flagtypes.NewEnum("table", "json")
+	diffThreshold float64
+	diffMinDelta  time.Duration
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <base-trace-id> <head-trace-id>",
+	Short: "Compare two traces target by target.",
+	Long: `Compares a head trace with a base trace, e.g. a slow CI build with the previous one.
+Shows the build duration, critical path and async cache wait deltas, targets that switched from cache hit to miss,
+targets whose command duration regressed by more than the threshold and targets that were added or removed.`,
+	Example: `  grog traces diff a1b2c3d4 e5f6g7h8
+  grog traces diff a1b2c3d4 e5f6g7h8 --threshold 50 --min-delta 5s
+  grog traces diff a1b2c3d4 e5f6g7h8 --format json`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, logger := console.SetupCommand()
+		store := GetStore(ctx, logger)
+		defer store.Close()
+
+		base, err := store.FindAndLoad(ctx, args[0])
+		if err != nil {
+			logger.Fatalf("failed to load trace %s: %v", args[0], err)
+		}
+		head, err := store.FindAndLoad(ctx, args[1])
+		if err != nil {
+			logger.Fatalf("failed to load trace %s: %v", args[1], err)
+		}
+
+		diff := tracing.DiffTraces(base, head, tracing.DiffOptions{
+			Threshold:      diffThreshold / 100,
+			MinDeltaMillis: diffMinDelta.Milliseconds(),
+		})
+
+		if diffFormat.Value == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(diff); err != nil {
+				logger.Fatalf("failed to encode diff: %v", err)
+			}
+			return
+		}
+		printTraceDiff(diff)
+	},
+}
+
+func registerDiffCmd() {
+	diffCmd.Flags().Var(diffFormat, "format", "Output format: table or json")
+	diffCmd.Flags().Float64Var(&diffThreshold, "threshold", 20, "Minimum command duration increase in percent to report a regression")
+	diffCmd.Flags().DurationVar(&diffMinDelta, "min-delta", time.Second, "Minimum command duration increase to report a regression")
+	Cmd.AddCommand(diffCmd)
+}
+
+func printTraceDiff(diff *tracing.TraceDiff) {
+	fmt.Printf("Base: %s\n", renderDim(diff.BaseTraceID))
+	fmt.Printf("Head: %s\n\n", renderDim(diff.HeadTraceID))
+
+	metricRow := func(name string, metric tracing.MetricDelta) []string {
+		return []string{name, formatMillis(metric.Base), formatMillis(metric.Head), formatDeltaMillis(metric.Delta)}
+	}
+	printTable([]string{"METRIC", "BASE", "HEAD", "DELTA"}, [][]string{
+		metricRow("Duration", diff.TotalDuration),
+		metricRow("Critical path exec", diff.CriticalPathExec),
+		metricRow("Critical path cache", diff.CriticalPathCache),
+		metricRow("Async cache wait", diff.AsyncCacheWait),
+		{"Cache hits", fmt.Sprintf("%d", diff.CacheHits.Base), fmt.Sprintf("%d", diff.CacheHits.Head), fmt.Sprintf("%+d", diff.CacheHits.Delta)},
+	})
+
+	if len(diff.CacheMisses) > 0 {
+		fmt.Println(renderSection(fmt.Sprintf("Cache hit → miss (%d)", len(diff.CacheMisses))))
+		var rows [][]string
+		for _, miss := range diff.CacheMisses {
+			reason := "cache entry missing"
+			if miss.ChangeHashChanged() {
+				reason = fmt.Sprintf("%s → %s", shortHash(miss.BaseChangeHash), shortHash(miss.HeadChangeHash))
+			}
+			rows = append(rows, []string{renderLabel(miss.Label), formatMillis(miss.HeadCommandMillis), reason})
+		}
+		printTable([]string{"TARGET", "CMD", "CHANGE HASH"}, rows)
+	}
+
+	if len(diff.Regressions) > 0 {
+		fmt.Println(renderSection(fmt.Sprintf("Command regressions (%d)", len(diff.Regressions))))
+		var rows [][]string
+		for _, regression := range diff.Regressions {
+			rows = append(rows, []string{
+				renderLabel(regression.Label),
+				formatMillis(regression.BaseMillis),
+				formatMillis(regression.HeadMillis),
+				formatDeltaMillis(regression.Delta),
+				fmt.Sprintf("%.1fx", regression.Ratio),
+			})
+		}
+		printTable([]string{"TARGET", "BASE", "HEAD", "DELTA", "RATIO"}, rows)
+	}
+
+	printLabelSection("Added targets", diff.Added)
+	printLabelSection("Removed targets", diff.Removed)
+
+	if len(diff.CacheMisses) == 0 && len(diff.Regressions) == 0 && len(diff.Added) == 0 && len(diff.Removed) == 0 {
+		fmt.Println(renderHint("No target level differences."))
+	}
+}
+
+func printLabelSection(title string, labels []string) {
+	if len(labels) == 0 {
+		return
+	}
+	fmt.Println(renderSection(fmt.Sprintf("%s (%d)", title, len(labels))))
+	for _, label := range labels {
+		fmt.Printf("  %s\n", renderLabel(label))
+	}
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
@@ -13,7 +13,7 @@ import (
 )
 
 var (
-	exportFormat = flagtypes.NewEnum("jsonl", "otel")
+	exportFormat = flagtypes.NewEnum("jsonl", "otel", "chrome", "openmetrics")
 	exportOutput string
 	exportLimit  int
 	exportSince  string
@@ -23,11 +23,13 @@ var exportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "Export traces for dashboard integration.",
 	Example: `  grog traces export --format=jsonl
-  grog traces export --format=otel --output traces.json`,
+  grog traces export --format=otel --output traces.json
+  grog traces export --format=chrome --limit 1 --output build.json  # Open in ui.perfetto.dev
+  grog traces export --format=openmetrics --since 2026-03-01 --output metrics.txt`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, logger := console.SetupCommand()
-		store := getStore(ctx, logger)
+		store := GetStore(ctx, logger)
 		defer store.Close()
 
 		opts := tracing.ListOptions{Limit: exportLimit}
@@ -68,12 +70,20 @@ var exportCmd = &cobra.Command{
 			if err := tracing.ExportOTLP(ctx, store, entries, w); err != nil {
 				logger.Fatalf("export failed: %v", err)
 			}
+		case "chrome":
+			if err := tracing.ExportChrome(ctx, store, entries, w); err != nil {
+				logger.Fatalf("export failed: %v", err)
+			}
+		case "openmetrics":
+			if err := tracing.ExportOpenMetrics(ctx, store, entries, w); err != nil {
+				logger.Fatalf("export failed: %v", err)
+			}
 		}
 	},
 }
 
 func registerExportCmd() {
-	exportCmd.Flags().Var(exportFormat, "format", "Export format: jsonl or otel")
+	exportCmd.Flags().Var(exportFormat, "format", "Export format: jsonl, otel, chrome (Trace Event Format) or openmetrics")
 	exportCmd.Flags().StringVar(&exportOutput, "output", "", "Output file (default: stdout)")
 	exportCmd.Flags().IntVar(&exportLimit, "limit", 0, "Maximum number of traces to export (0 = all)")
 	exportCmd.Flags().StringVar(&exportSince, "since", "", "Only export traces after this date (YYYY-MM-DD)")
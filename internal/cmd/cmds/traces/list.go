@@ -33,7 +33,7 @@ var listCmd = &cobra.Command{
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, logger := console.SetupCommand()
-		store := getStore(ctx, logger)
+		store := GetStore(ctx, logger)
 		defer store.Close()
 
 		command, err := normalizeCommand(listCommand.Value)
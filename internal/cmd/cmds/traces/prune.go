@@ -19,7 +19,7 @@ var pruneCmd = &cobra.Command{
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, logger := console.SetupCommand()
-		store := getStore(ctx, logger)
+		store := GetStore(ctx, logger)
 		defer store.Close()
 
 		duration, err := parseDuration(pruneOlderThan)
@@ -17,7 +17,7 @@ var pullCmd = &cobra.Command{
 	Args:    cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, logger := console.SetupCommand()
-		store := getStore(ctx, logger)
+		store := GetStore(ctx, logger)
 		defer store.Close()
 
 		var onProgress tracing.PullProgress
@@ -0,0 +1,178 @@
+package traces
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"grog/internal/cmd/flagtypes"
+	"grog/internal/console"
+	"grog/internal/tracing"
+)
+
+var (
+	querySince  string
+	queryUntil  string
+	queryFormat = flagtypes.NewEnum("table", "csv", "json")
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query <sql>",
+	Short: "Run an ad-hoc SQL query against the traces.",
+	Long: `Runs a DuckDB SQL query against the local traces which are exposed as the views builds and spans.
+The views have the same columns as the Parquet files, see the tracing docs for the schema.
+--since and --until restrict both views to builds started in the date range and skip the trace files of other days.
+Run grog traces pull first to include traces of other machines.`,
+	Example: `  grog traces query 'SELECT command, count(*) FROM builds GROUP BY ALL'
+  grog traces query --since 2026-03-01 --until 2026-03-31 --format csv "SELECT label, count(*) AS misses
+    FROM spans JOIN builds USING (trace_id)
+    WHERE git_branch = 'main' AND cache_result = 'CACHE_MISS'
+    GROUP BY label ORDER BY misses DESC LIMIT 10"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, logger := console.SetupCommand()
+		store := GetStore(ctx, logger)
+		defer store.Close()
+
+		var opts tracing.QueryOptions
+		if querySince != "" {
+			since, err := time.Parse("2006-01-02", querySince)
+			if err != nil {
+				logger.Fatalf("invalid --since date: %v (use YYYY-MM-DD format)", err)
+			}
+			opts.Since = since
+		}
+		if queryUntil != "" {
+			until, err := time.Parse("2006-01-02", queryUntil)
+			if err != nil {
+				logger.Fatalf("invalid --until date: %v (use YYYY-MM-DD format)", err)
+			}
+			// The until date is inclusive
+			opts.Until = until.AddDate(0, 0, 1)
+		}
+
+		result, err := store.Query(ctx, args[0], opts)
+		if err != nil {
+			logger.Fatalf("query failed: %v", err)
+		}
+		if result == nil {
+			logger.Info("No traces found.")
+			return
+		}
+
+		switch queryFormat.Value {
+		case "csv":
+			err = writeQueryCSV(os.Stdout, result)
+		case "json":
+			err = writeQueryJSON(os.Stdout, result)
+		default:
+			printQueryTable(result)
+		}
+		if err != nil {
+			logger.Fatalf("failed to write query result: %v", err)
+		}
+	},
+}
+
+func registerQueryCmd() {
+	queryCmd.Flags().StringVar(&querySince, "since", "", "Only query traces on or after this date (YYYY-MM-DD)")
+	queryCmd.Flags().StringVar(&queryUntil, "until", "", "Only query traces on or before this date (YYYY-MM-DD)")
+	queryCmd.Flags().Var(queryFormat, "format", "Output format: table, csv or json")
+	Cmd.AddCommand(queryCmd)
+}
+
+func printQueryTable(result *tracing.QueryResult) {
+	var rows [][]string
+	for _, row := range result.Rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			if value == nil {
+				cells[i] = renderDim("NULL")
+			} else {
+				cells[i] = formatQueryValue(value)
+			}
+		}
+		rows = append(rows, cells)
+	}
+	printTable(result.Columns, rows)
+	if len(result.Rows) == 1 {
+		fmt.Println(renderHint("1 row"))
+	} else {
+		fmt.Println(renderHint(fmt.Sprintf("%d rows", len(result.Rows))))
+	}
+}
+
+func writeQueryCSV(w io.Writer, result *tracing.QueryResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(result.Columns); err != nil {
+		return err
+	}
+	for _, row := range result.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if value != nil {
+				record[i] = formatQueryValue(value)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeQueryJSON writes the rows as an array of objects whose keys keep the
+// column order of the query.
+func writeQueryJSON(w io.Writer, result *tracing.QueryResult) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("[")
+	for i, row := range result.Rows {
+		if i > 0 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n  {")
+		for j, value := range row {
+			key, err := json.Marshal(result.Columns[j])
+			if err != nil {
+				return err
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("encode column %s: %w", result.Columns[j], err)
+			}
+			if j > 0 {
+				bw.WriteString(", ")
+			}
+			bw.Write(key)
+			bw.WriteString(": ")
+			bw.Write(encoded)
+		}
+		bw.WriteString("}")
+	}
+	if len(result.Rows) > 0 {
+		bw.WriteString("\n")
+	}
+	bw.WriteString("]\n")
+	return bw.Flush()
+}
+
+func formatQueryValue(value any) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,149 @@
+package traces
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"grog/internal/cmd/flagtypes"
+	"grog/internal/console"
+	"grog/internal/tracing"
+)
+
+var (
+	regressionsBaseline    string
+	regressionsWindow      string
+	regressionsCommandType = flagtypes.NewEnum("all", "build", "test")
+	regressionsCI          = flagtypes.NewEnum("all", "true", "false")
+	regressionsThreshold   float64
+	regressionsMinDelta    time.Duration
+	regressionsHitRateDrop float64
+	regressionsMinSamples  int
+	regressionsFormat      = flagtypes.NewEnum("table", "json")
+)
+
+var regressionsCmd = &cobra.Command{
+	Use:   "regressions",
+	Short: "Detect targets that got slower or lost cache hits over time.",
+	Long: `Compares the targets of the builds in a recent window with the builds of the baseline period before it.
+A target regressed if its median or p90 command duration of successful cache misses increased by more than the threshold
+or if its cache hit rate dropped by more than --hit-rate-drop percentage points.
+Regressions are ranked by the increase of the expected time spent on the target per build.
+Exits with code 1 if there is at least one regression so that it can gate a nightly job.`,
+	Example: `  grog traces regressions
+  grog traces regressions --baseline 30d --window 3d --threshold 30
+  grog traces regressions --ci true --command-type test --format json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, logger := console.SetupCommand()
+		store := GetStore(ctx, logger)
+		defer store.Close()
+
+		baseline, err := parseDuration(regressionsBaseline)
+		if err != nil {
+			logger.Fatalf("invalid --baseline value: %v", err)
+		}
+		window, err := parseDuration(regressionsWindow)
+		if err != nil {
+			logger.Fatalf("invalid --window value: %v", err)
+		}
+		command, err := normalizeStatsCommandType(regressionsCommandType.Value)
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+		isCI, err := normalizeStatsCI(regressionsCI.Value)
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+
+		end := time.Now()
+		periods := tracing.RegressionPeriods{
+			BaselineStart: end.Add(-window - baseline),
+			WindowStart:   end.Add(-window),
+			End:           end,
+		}
+		report, err := store.Regressions(ctx, periods,
+			tracing.StatsOptions{Command: command, IsCI: isCI},
+			tracing.RegressionOptions{
+				Threshold:      regressionsThreshold / 100,
+				MinDeltaMillis: regressionsMinDelta.Milliseconds(),
+				HitRateDrop:    regressionsHitRateDrop / 100,
+				MinSamples:     regressionsMinSamples,
+			})
+		if err != nil {
+			logger.Fatalf("failed to detect regressions: %v", err)
+		}
+
+		if regressionsFormat.Value == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				logger.Fatalf("failed to encode regressions: %v", err)
+			}
+		} else {
+			printRegressionReport(report)
+		}
+
+		if len(report.Regressions) > 0 {
+			logger.Errorf("%d targets regressed", len(report.Regressions))
+			os.Exit(1)
+		}
+	},
+}
+
+func registerRegressionsCmd() {
+	regressionsCmd.Flags().StringVar(&regressionsBaseline, "baseline", "30d", "Length of the baseline period before the window (e.g. 30d, 72h)")
+	regressionsCmd.Flags().StringVar(&regressionsWindow, "window", "3d", "Length of the recent window to check for regressions (e.g. 3d, 24h)")
+	regressionsCmd.Flags().Var(regressionsCommandType, "command-type", "Filter by build command type (build, test, all)")
+	regressionsCmd.Flags().Var(regressionsCI, "ci", "Filter by CI origin (true, false, all)")
+	regressionsCmd.Flags().Float64Var(&regressionsThreshold, "threshold", 20, "Minimum median or p90 command duration increase in percent to report a regression")
+	regressionsCmd.Flags().DurationVar(&regressionsMinDelta, "min-delta", time.Second, "Minimum median or p90 command duration increase to report a regression")
+	regressionsCmd.Flags().Float64Var(&regressionsHitRateDrop, "hit-rate-drop", 20, "Minimum cache hit rate decrease in percentage points to report a regression")
+	regressionsCmd.Flags().IntVar(&regressionsMinSamples, "min-samples", 3, "Minimum number of runs of a target in both periods to compare it")
+	regressionsCmd.Flags().Var(regressionsFormat, "format", "Output format: table or json")
+	Cmd.AddCommand(regressionsCmd)
+}
+
+func printRegressionReport(report *tracing.RegressionReport) {
+	const dateFormat = "2006-01-02 15:04"
+	fmt.Printf("Baseline: %s – %s (%d builds)\n",
+		time.UnixMilli(report.BaselineStartUnixMillis).Format(dateFormat),
+		time.UnixMilli(report.WindowStartUnixMillis).Format(dateFormat),
+		report.BaselineBuilds)
+	fmt.Printf("Window:   %s – %s (%d builds)\n",
+		time.UnixMilli(report.WindowStartUnixMillis).Format(dateFormat),
+		time.UnixMilli(report.EndUnixMillis).Format(dateFormat),
+		report.WindowBuilds)
+
+	if len(report.Regressions) == 0 {
+		fmt.Println(renderHint("No regressions."))
+		return
+	}
+
+	fmt.Println(renderSection(fmt.Sprintf("Regressions (%d)", len(report.Regressions))))
+	var rows [][]string
+	for _, r := range report.Regressions {
+		rows = append(rows, []string{
+			renderImpact(r.ImpactMillis),
+			renderLabel(r.Label),
+			formatChange(r.Baseline.MedianMillis, r.Window.MedianMillis, r.DurationRegressed),
+			formatChange(r.Baseline.P90Millis, r.Window.P90Millis, r.DurationRegressed),
+			fmt.Sprintf("%.0f%% → %.0f%%", r.Baseline.CacheHitRate*100, r.Window.CacheHitRate*100),
+			fmt.Sprintf("%d/%d", r.Baseline.Runs, r.Window.Runs),
+		})
+	}
+	printTable([]string{"IMPACT", "TARGET", "MEDIAN", "P90", "HIT RATE", "N"}, rows)
+}
+
+// formatChange formats the command durations of both periods with the
+// relative change if the duration regressed.
+func formatChange(baseMillis, headMillis float64, regressed bool) string {
+	change := fmt.Sprintf("%s → %s", formatMillis(int64(baseMillis)), formatMillis(int64(headMillis)))
+	if regressed && baseMillis > 0 {
+		change += fmt.Sprintf(" (%+.0f%%)", (headMillis/baseMillis-1)*100)
+	}
+	return change
+}
@@ -2,9 +2,13 @@ package traces
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
 
 	"grog/internal/console"
 )
@@ -83,3 +87,63 @@ func formatDuration(d time.Duration) string {
 func formatMillis(ms int64) string {
 	return formatDuration(time.Duration(ms) * time.Millisecond)
 }
+
+// formatDeltaMillis formats a duration difference with an explicit sign.
+func formatDeltaMillis(ms int64) string {
+	if ms < 0 {
+		return "-" + formatMillis(-ms)
+	}
+	return "+" + formatMillis(ms)
+}
+
+// formatBytes renders a human-readable byte count.
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit && exp < len("KMGTPE")-1; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// formatResource formats a resource usage value or "-" if it was not
+// recorded, e.g. for cache hits or traces from older versions.
+func formatResource(value int64, format func(int64) string) string {
+	if value == 0 {
+		return "-"
+	}
+	return format(value)
+}
+
+// printTable prints rows as a bordered table when styled and as aligned
+// columns otherwise.
+func printTable(headers []string, rows [][]string) {
+	if styled() {
+		t := table.New().
+			Headers(headers...).
+			Rows(rows...).
+			Border(lipgloss.NormalBorder()).
+			BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("238"))).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return headerStyle
+				}
+				return lipgloss.NewStyle()
+			})
+		fmt.Println(t.Render())
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
@@ -0,0 +1,109 @@
+package traces
+
+import (
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"grog/internal/console"
+	"grog/internal/label"
+	"grog/internal/logs"
+	"grog/internal/model"
+	"grog/internal/tracing"
+)
+
+// reportLogSlack is how long after the end of a build a log file may have
+// been written to still belong to it.
+const reportLogSlack = time.Minute
+
+var (
+	reportOutput   string
+	reportLogLines int
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report <trace-id>",
+	Short: "Generate a self-contained HTML report of a trace.",
+	Long: `Generates a single HTML file that works offline with the build metadata, a Gantt chart of all targets with the critical path highlighted,
+a per-phase breakdown and the failing targets.
+Log excerpts of failing targets are included if their log files from the traced build are still in the local workspace.`,
+	Example: `  grog traces report a1b2c3d4 -o report.html
+  grog traces report a1b2c3d4 --log-lines 200 -o report.html`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, logger := console.SetupCommand()
+		store := GetStore(ctx, logger)
+		defer store.Close()
+
+		trace, err := store.FindAndLoad(ctx, args[0])
+		if err != nil {
+			logger.Fatalf("failed to load trace: %v", err)
+		}
+
+		logExcerpts := make(map[string]string)
+		for _, span := range trace.Spans {
+			if span.Status != "FAILURE" {
+				continue
+			}
+			if excerpt, ok := readLogExcerpt(&trace.Build, span.Label, reportLogLines); ok {
+				logExcerpts[span.Label] = excerpt
+			}
+		}
+
+		var w io.Writer = os.Stdout
+		if reportOutput != "" {
+			f, openErr := os.Create(reportOutput)
+			if openErr != nil {
+				logger.Fatalf("failed to create output file: %v", openErr)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if err := tracing.WriteHTMLReport(w, trace, logExcerpts); err != nil {
+			logger.Fatalf("failed to write report: %v", err)
+		}
+		if reportOutput != "" {
+			logger.Infof("Wrote report to %s.", reportOutput)
+		}
+	},
+}
+
+func registerReportCmd() {
+	reportCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "Output file (default: stdout)")
+	reportCmd.Flags().IntVar(&reportLogLines, "log-lines", 50, "Number of trailing log lines to include for each failing target")
+	Cmd.AddCommand(reportCmd)
+}
+
+// readLogExcerpt returns the last lines of the local log file of a target if
+// the file was written during the given build.
+func readLogExcerpt(build *tracing.BuildRow, targetLabel string, lines int) (string, bool) {
+	parsedLabel, err := label.ParseTargetLabel("", targetLabel)
+	if err != nil {
+		return "", false
+	}
+	logPath := logs.NewTargetLogFile(model.Target{Label: parsedLabel}).Path()
+
+	info, err := os.Stat(logPath)
+	if err != nil {
+		return "", false
+	}
+	buildStart := time.UnixMilli(build.StartTimeUnixMillis)
+	buildEnd := buildStart.Add(time.Duration(build.TotalDurationMillis)*time.Millisecond + reportLogSlack)
+	if info.ModTime().Before(buildStart) || info.ModTime().After(buildEnd) {
+		return "", false
+	}
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		return "", false
+	}
+	logLines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	if lines > 0 && len(logLines) > lines {
+		logLines = logLines[len(logLines)-lines:]
+	}
+	return strings.Join(logLines, "\n"), true
+}
@@ -2,14 +2,10 @@ package traces
 
 import (
 	"fmt"
-	"os"
 	"sort"
 	"strings"
-	"text/tabwriter"
 	"time"
 
-	"github.com/charmbracelet/lipgloss"
-	"github.com/charmbracelet/lipgloss/table"
 	"github.com/spf13/cobra"
 
 	"grog/internal/cmd/flagtypes"
@@ -18,7 +14,7 @@ import (
 )
 
 var (
-	showSortBy = flagtypes.NewEnum("total", "command", "queue", "hash")
+	showSortBy = flagtypes.NewEnum("total", "command", "queue", "hash", "cpu", "memory")
 	showTop    int
 )
 
@@ -26,11 +22,12 @@ var showCmd = &cobra.Command{
 	Use:   "show <trace-id>",
 	Short: "Show details of a specific trace.",
 	Example: `  grog traces show a1b2c3d4
-  grog traces show a1b2c3d4 --sort-by command --top 10`,
+  grog traces show a1b2c3d4 --sort-by command --top 10
+  grog traces show a1b2c3d4 --sort-by memory`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, logger := console.SetupCommand()
-		store := getStore(ctx, logger)
+		store := GetStore(ctx, logger)
 		defer store.Close()
 
 		trace, err := store.FindAndLoad(ctx, args[0])
@@ -46,7 +43,7 @@ var showCmd = &cobra.Command{
 }
 
 func registerShowCmd() {
-	showCmd.Flags().Var(showSortBy, "sort-by", "Sort targets by: total, command, queue, hash")
+	showCmd.Flags().Var(showSortBy, "sort-by", "Sort targets by: total, command, queue, hash, cpu, memory")
 	showCmd.Flags().IntVar(&showTop, "top", 0, "Show only the N slowest targets (0 = all)")
 	Cmd.AddCommand(showCmd)
 }
@@ -65,6 +62,14 @@ func sortSpans(spans []tracing.SpanRow, sortBy string) {
 		sort.Slice(spans, func(i, j int) bool {
 			return spans[i].HashDurationMillis > spans[j].HashDurationMillis
 		})
+	case "cpu":
+		sort.Slice(spans, func(i, j int) bool {
+			return spans[i].CPUMillis() > spans[j].CPUMillis()
+		})
+	case "memory":
+		sort.Slice(spans, func(i, j int) bool {
+			return spans[i].MaxRSSBytes > spans[j].MaxRSSBytes
+		})
 	default: // "total"
 		sort.Slice(spans, func(i, j int) bool {
 			return spans[i].TotalDurationMillis > spans[j].TotalDurationMillis
@@ -146,7 +151,7 @@ func printSpanTable(spans []tracing.SpanRow) {
 		displaySpans = displaySpans[:showTop]
 	}
 
-	headers := []string{"TARGET", "STATUS", "CACHE", "TOTAL", "CMD", "HASH", "I/O", "QUEUE"}
+	headers := []string{"TARGET", "STATUS", "CACHE", "TOTAL", "CMD", "HASH", "I/O", "QUEUE", "CPU", "PEAK RSS"}
 
 	var rows [][]string
 	for _, s := range displaySpans {
@@ -175,6 +180,8 @@ func printSpanTable(spans []tracing.SpanRow) {
 			formatMillis(s.HashDurationMillis),
 			formatMillis(ioMillis),
 			formatMillis(s.QueueWaitMillis),
+			formatResource(s.CPUMillis(), formatMillis),
+			formatResource(s.MaxRSSBytes, formatBytes),
 		}
 
 		if styled() {
@@ -190,25 +197,5 @@ func printSpanTable(spans []tracing.SpanRow) {
 		rows = append(rows, row)
 	}
 
-	if styled() {
-		t := table.New().
-			Headers(headers...).
-			Rows(rows...).
-			Border(lipgloss.NormalBorder()).
-			BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("238"))).
-			StyleFunc(func(row, col int) lipgloss.Style {
-				if row == table.HeaderRow {
-					return headerStyle
-				}
-				return lipgloss.NewStyle()
-			})
-		fmt.Println(t.Render())
-	} else {
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, strings.Join(headers, "\t"))
-		for _, row := range rows {
-			fmt.Fprintln(w, strings.Join(row, "\t"))
-		}
-		w.Flush()
-	}
+	printTable(headers, rows)
 }
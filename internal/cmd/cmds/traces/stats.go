@@ -30,7 +30,7 @@ var statsCmd = &cobra.Command{
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, logger := console.SetupCommand()
-		store := getStore(ctx, logger)
+		store := GetStore(ctx, logger)
 		defer store.Close()
 
 		command, err := normalizeStatsCommandType(statsCommandType.Value)
@@ -65,6 +65,7 @@ var statsCmd = &cobra.Command{
 				logger.Fatalf("failed to compute bottleneck analysis: %v", err)
 			}
 			printBottleneckReport(report)
+			printResourceReport(report)
 		}
 	},
 }
@@ -231,6 +232,29 @@ func printBottleneckReport(r *tracing.BottleneckReport) {
 	}
 }
 
+func printResourceReport(r *tracing.BottleneckReport) {
+	if len(r.ResourceHeavy) == 0 {
+		return
+	}
+	fmt.Println(renderSection("Resource usage (avg CPU time of cache misses, peak memory):"))
+	if styled() {
+		printBottleneckTable(r.ResourceHeavy, func(t tracing.TargetBottleneck) []string {
+			return []string{
+				formatResource(int64(t.AvgCPU), formatMillis),
+				renderLabel(t.Label),
+				formatResource(t.PeakRSS, formatBytes),
+				fmt.Sprintf("%d", t.Count),
+			}
+		}, []string{"AVG CPU", "TARGET", "PEAK RSS", "N"})
+	} else {
+		for _, t := range r.ResourceHeavy {
+			fmt.Printf("  %s  %s (peak rss: %s, n=%d)\n",
+				formatResource(int64(t.AvgCPU), formatMillis), t.Label,
+				formatResource(t.PeakRSS, formatBytes), t.Count)
+		}
+	}
+}
+
 func printBottleneckTable(items []tracing.TargetBottleneck, rowFn func(tracing.TargetBottleneck) []string, headers []string) {
 	var rows [][]string
 	for _, item := range items {
@@ -22,23 +22,20 @@ func AddCmd(rootCmd *cobra.Command) {
 	registerListCmd()
 	registerShowCmd()
 	registerStatsCmd()
+	registerQueryCmd()
+	registerRegressionsCmd()
 	registerPullCmd()
 	registerExportCmd()
+	registerDiffCmd()
+	registerReportCmd()
 	registerPruneCmd()
 	rootCmd.AddCommand(Cmd)
 }
 
-func getStore(ctx context.Context, logger *console.Logger) *tracing.TraceStore {
-	cacheConfig := config.Global.Cache
-	if config.Global.Traces.Backend != "" {
-		cacheConfig = config.CacheConfig{
-			Backend: config.Global.Traces.Backend,
-			GCS:     config.Global.Traces.GCS,
-			S3:      config.Global.Traces.S3,
-		}
-	}
-
-	cache, err := backends.GetCacheBackend(ctx, cacheConfig)
+// GetStore opens the local trace store using the traces backend if configured
+// and the cache backend otherwise.
+func GetStore(ctx context.Context, logger *console.Logger) *tracing.TraceStore {
+	cache, err := backends.GetCacheBackend(ctx, config.Global.GetTracesCacheConfig())
 	if err != nil {
 		logger.Fatalf("could not instantiate cache backend for traces: %v", err)
 	}
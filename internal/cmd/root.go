@@ -4,20 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"grog/internal/cmd/cmds"
+	"grog/internal/cmd/cmds/edit"
 	"grog/internal/cmd/cmds/traces"
 	"grog/internal/cmd/flagtypes"
 	"grog/internal/config"
 	"grog/internal/console"
-	"maps"
 	"os"
-	"path/filepath"
-	"runtime"
-	"strings"
 
-	"github.com/pelletier/go-toml/v2"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"github.com/subosito/gotenv"
 )
 
 var Version string
@@ -27,7 +22,7 @@ var RootCmd = &cobra.Command{
 	// PersistentPreRunE runs before any subcommand's Run, after flags are parsed.
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if cmd.Flags().Changed("help") || cmd.Flags().Changed("version") ||
-			cmd.Name() == "help" || isCompletionCmd(cmd) {
+			cmd.Name() == "help" || isCompletionCmd(cmd) || cmd == cmds.SchemaCmd {
 			return nil
 		}
 
@@ -90,13 +85,8 @@ func configureRoot() bool {
 
 	// Set up Viper
 	viper.SetConfigType("toml")
-	viper.SetEnvPrefix("GROG")
-	viper.AddConfigPath("$HOME/.grog")                     // optionally look for config in the home directory
-	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_")) // allow FLAG-NAME to map to ENV VAR_NAME
-	viper.AutomaticEnv()                                   // read in environment variables that match
-
-	// Set default global root directory
-	viper.SetDefault("root", filepath.Join(os.Getenv("HOME"), ".grog"))
+	viper.AddConfigPath("$HOME/.grog") // optionally look for config in the home directory
+	config.BindEnv(viper.GetViper())
 
 	// Options:
 	// color
@@ -192,8 +182,10 @@ func configureRoot() bool {
 	RootCmd.AddCommand(cmds.VersionCmd)
 	RootCmd.AddCommand(cmds.ListCmd)
 	RootCmd.AddCommand(cmds.InfoCmd)
-	RootCmd.AddCommand(cmds.CheckCmd)
+	cmds.AddCheckCmd(RootCmd)
 	RootCmd.AddCommand(cmds.TaintCmd)
+	RootCmd.AddCommand(cmds.SchemaCmd)
+	RootCmd.AddCommand(cmds.LspCmd)
 	cmds.AddRunCmd(RootCmd)
 	cmds.AddGraphCmd(RootCmd)
 	cmds.AddCleanCmd(RootCmd)
@@ -204,26 +196,19 @@ func configureRoot() bool {
 	cmds.AddRDepsCmd(RootCmd)
 	cmds.AddOwnersCmd(RootCmd)
 	cmds.AddChangesCmd(RootCmd)
+	cmds.AddPlanCmd(RootCmd)
 	cmds.AddExplainChangesCmd(RootCmd)
 	cmds.AddListCmd(RootCmd)
+	cmds.AddFmtCmd(RootCmd)
+	cmds.AddGenCmd(RootCmd)
+	cmds.AddImportCmd(RootCmd)
+	edit.AddCmd(RootCmd)
 	traces.AddCmd(RootCmd)
 	return true
 }
 
 func initConfig(cmd *cobra.Command) error {
-	// Set defaults here
-	viper.SetDefault("log_level", "info")
-	viper.SetDefault("load_outputs", "all")
-	viper.SetDefault("disable_non_deterministic_logging", false)
-	viper.SetDefault("os", runtime.GOOS)
-	viper.SetDefault("arch", runtime.GOARCH)
-	viper.SetDefault("cache.gcs.shared_cache", true)
-	viper.SetDefault("cache.s3.shared_cache", true)
-	viper.SetDefault("cache.azure.shared_cache", true)
-	viper.SetDefault("hash_algorithm", config.HashAlgorithmXXH3)
-	viper.SetDefault("include_hidden", false)
-	viper.SetDefault("environment_variables", make(map[string]string))
-	viper.SetDefault("traces.enabled", false)
+	config.SetDefaults(viper.GetViper())
 
 	names := []string{"grog"}
 	if os.Getenv("CI") == "1" {
@@ -276,83 +261,12 @@ func initConfig(cmd *cobra.Command) error {
 	}
 
 	// Merge all config sources into the global
-	if err := viper.Unmarshal(&config.Global); err != nil {
-		return fmt.Errorf("failed to parse config: %w", err)
+	if err := config.Unmarshal(viper.GetViper(), &config.Global); err != nil {
+		return err
 	}
 
-	config.Global.HashAlgorithm = strings.ToLower(config.Global.HashAlgorithm)
-
 	logger.Debugf("Using config file: %s", viper.ConfigFileUsed())
 	logger.Debugf("Running on %s", config.Global.GetPlatform())
 
-	platform := viper.GetString("platform")
-	if config.Global.AllPlatforms && platform != "" {
-		return fmt.Errorf("--platform cannot be used with --all-platforms")
-	}
-	if platform != "" {
-		parts := strings.SplitN(platform, "/", 2)
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid platform %s, expected os/arch", platform)
-		}
-		config.Global.OS = parts[0]
-		config.Global.Arch = parts[1]
-	}
-
-	if err := readInEnvironmentVariablesConfig(); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// Viper always normalizes all configuration keys to be lower-case
-// but users should be able to specify upper case environment_variables
-// So as a workaround we load the section here a second time _if_ there are env vars.
-//
-// When environment_variables_file is set, variables are loaded from the file
-// first, then inline environment_variables from grog.toml are merged on top
-// (inline values take precedence over file values).
-func readInEnvironmentVariablesConfig() error {
-	merged := make(map[string]string)
-
-	// Phase 1: Load variables from file if configured.
-	if config.Global.EnvironmentVariablesFile != "" {
-		envFilePath := config.Global.EnvironmentVariablesFile
-		if !filepath.IsAbs(envFilePath) {
-			envFilePath = filepath.Join(config.Global.WorkspaceRoot, envFilePath)
-		}
-
-		f, err := os.Open(envFilePath)
-		if err != nil {
-			return fmt.Errorf("failed to open environment_variables_file %q: %w", envFilePath, err)
-		}
-		defer f.Close()
-
-		fileEnv := gotenv.Parse(f)
-		maps.Copy(merged, fileEnv)
-	}
-
-	// Phase 2: Load inline environment_variables from grog.toml (preserving case).
-	if len(config.Global.EnvironmentVariables) > 0 {
-		raw, err := os.ReadFile(viper.ConfigFileUsed())
-		if err != nil {
-			return err
-		}
-
-		var helper EnvVarsHelper
-		err = toml.Unmarshal(raw, &helper)
-		if err != nil {
-			return err
-		}
-
-		// Inline values override file-loaded values.
-		maps.Copy(merged, helper.EnvironmentVariables)
-	}
-
-	config.Global.EnvironmentVariables = merged
 	return nil
 }
-
-type EnvVarsHelper struct {
-	EnvironmentVariables map[string]string `toml:"environment_variables"`
-}
@@ -1,6 +1,7 @@
 package completions
 
 import (
+	"context"
 	"fmt"
 	"grog/internal/config"
 	"grog/internal/console"
@@ -16,7 +17,7 @@ import (
 )
 
 func TargetPatternCompletion(command *cobra.Command, _ []string, toComplete string, targetType selection.TargetTypeSelection) ([]string, cobra.ShellCompDirective) {
-	context, _ := console.SetupCommand()
+	ctx, _ := console.SetupCommand()
 	currentPackage, err := config.Global.GetCurrentPackage()
 	debugToFile(fmt.Sprintf("err: %s\n", err))
 
@@ -24,6 +25,20 @@ func TargetPatternCompletion(command *cobra.Command, _ []string, toComplete stri
 		return nil, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveError
 	}
 
+	completions, isComplete, err := TargetLabelCompletions(ctx, currentPackage, toComplete, targetType)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveError
+	}
+	if isComplete {
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// TargetLabelCompletions returns the completions of a partially typed target
+// pattern relative to currentPackage. isComplete is true when the only
+// completion is a fully qualified target label that needs no further input.
+func TargetLabelCompletions(ctx context.Context, currentPackage string, toComplete string, targetType selection.TargetTypeSelection) (completions []string, isComplete bool, err error) {
 	// Completion input states.
 	// Absolute: starts with "//", intended to resolve from workspace root.
 	// Relative target: starts with ":", intended to resolve within current package only.
@@ -62,9 +77,9 @@ func TargetPatternCompletion(command *cobra.Command, _ []string, toComplete stri
 	// Load packages from the search directory, which is either the current package,
 	// the workspace root, or the parent directory for partial prefixes.
 	absoluteSearchDirectory := config.GetPathAbsoluteToWorkspaceRoot(searchDirectory)
-	packages, err := loading.LoadPackages(context, absoluteSearchDirectory)
+	packages, err := loading.LoadPackages(ctx, absoluteSearchDirectory)
 	if err != nil {
-		return nil, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveError
+		return nil, false, err
 	}
 
 	// For partial prefixes we also need packages for the original prefix so that we can
@@ -72,7 +87,7 @@ func TargetPatternCompletion(command *cobra.Command, _ []string, toComplete stri
 	packagesForOriginalPrefix := packages
 	if isPrefixPartial && originalPrefix != "" && originalPrefix != searchDirectory {
 		absoluteOriginalDir := config.GetPathAbsoluteToWorkspaceRoot(originalPrefix)
-		packagesForOriginalPrefix, err = loading.LoadPackages(context, absoluteOriginalDir)
+		packagesForOriginalPrefix, err = loading.LoadPackages(ctx, absoluteOriginalDir)
 		if err != nil {
 			packagesForOriginalPrefix = nil
 		}
@@ -118,7 +133,6 @@ func TargetPatternCompletion(command *cobra.Command, _ []string, toComplete stri
 		}
 	}
 
-	var completions []string
 	wildcardDirectorySuggestions := make(map[string]bool)
 	for fullPath, hasChildren := range directorySuggestions {
 		completion := "//" + fullPath
@@ -181,14 +195,14 @@ func TargetPatternCompletion(command *cobra.Command, _ []string, toComplete stri
 
 	// If there is only a single target and no directory completions just offer that
 	if len(completions) == 0 && len(targets) == 1 {
-		return []string{targets[0]}, cobra.ShellCompDirectiveNoFileComp
+		return []string{targets[0]}, true, nil
 	}
 
 	completions = append(completions, targets...)
 	sort.Strings(completions)
 	debugToFile(fmt.Sprintf("completions: %s", completions))
 
-	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	return completions, false, nil
 }
 
 func TestTargetPatternCompletion(command *cobra.Command, arguments []string, toComplete string) ([]string, cobra.ShellCompDirective) {
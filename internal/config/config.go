@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
 	semver "github.com/blang/semver/v4"
 )
@@ -74,6 +75,31 @@ type WorkspaceConfig struct {
 	// 1 (fully serialized).
 	ConcurrencyGroups map[string]int `mapstructure:"concurrency_groups"`
 
+	// UndeclaredDependencies controls how targets that read another target's
+	// outputs without depending on it (or that read their own outputs) are
+	// reported: "ignore", "warn" (default) or "error".
+	UndeclaredDependencies string `mapstructure:"undeclared_dependencies"`
+	// MissingInputs controls how literal input paths that do not exist are
+	// reported: "ignore", "warn" (default) or "error". Globs that match
+	// nothing are never reported. Can be overridden per target.
+	MissingInputs string `mapstructure:"missing_inputs"`
+	// BuildFileValidation controls how unknown keys in BUILD.yaml and
+	// BUILD.json files are reported: "ignore", "warn" (default) or "error"
+	// (strict mode). Type mismatches are always errors.
+	BuildFileValidation string `mapstructure:"build_file_validation"`
+
+	// ExecutableBuildFiles lists the file names of executable BUILD files.
+	// They are run in their package directory and print the package
+	// definition as JSON or YAML to stdout. Defaults to ["BUILD.gen"].
+	ExecutableBuildFiles []string `mapstructure:"executable_build_files"`
+	// ExecutableBuildFileTimeout bounds how long a single executable BUILD
+	// file may run. Defaults to one minute.
+	ExecutableBuildFileTimeout time.Duration `mapstructure:"executable_build_file_timeout"`
+
+	// Policies are workspace wide dependency rules that are checked on every
+	// edge of the build graph, e.g. to keep libraries from depending on apps.
+	Policies []PolicyConfig `mapstructure:"policies"`
+
 	// Logging
 	LogLevel      string `mapstructure:"log_level"`
 	LogOutputPath string `mapstructure:"log_output_path"`
@@ -97,6 +123,9 @@ type WorkspaceConfig struct {
 	// Tracing
 	Traces TracesConfig `mapstructure:"traces"`
 
+	// Remote execution
+	RemoteExecution RemoteExecutionConfig `mapstructure:"remote_execution"`
+
 	// OCI
 	OCI OCIConfig `mapstructure:"oci"`
 
@@ -154,6 +183,34 @@ func (w WorkspaceConfig) GetCasDirectory() string {
 	return filepath.Join(w.Root, "cas")
 }
 
+// GetMetricsTextfilePath returns the absolute path of the metrics textfile
+// or an empty string if it is not configured. Relative paths are resolved
+// against the workspace root.
+func (w WorkspaceConfig) GetMetricsTextfilePath() string {
+	path := w.Traces.MetricsTextfile
+	if path == "" {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(w.WorkspaceRoot, path)
+	}
+	return filepath.Clean(path)
+}
+
+// GetTracesCacheConfig returns the cache config of the dedicated traces
+// backend or the main cache config if traces.backend is not set.
+func (w WorkspaceConfig) GetTracesCacheConfig() CacheConfig {
+	if w.Traces.Backend == "" {
+		return w.Cache
+	}
+	return CacheConfig{
+		Backend: w.Traces.Backend,
+		GCS:     w.Traces.GCS,
+		S3:      w.Traces.S3,
+		Azure:   w.Traces.Azure,
+	}
+}
+
 func (w WorkspaceConfig) IsDebug() bool {
 	return w.LogLevel == "debug"
 }
@@ -212,6 +269,32 @@ func (w WorkspaceConfig) Validate() error {
 		return err
 	}
 
+	if _, err := ParseSeverity("undeclared_dependencies", w.UndeclaredDependencies, SeverityWarn); err != nil {
+		return err
+	}
+
+	if _, err := ParseSeverity("missing_inputs", w.MissingInputs, SeverityWarn); err != nil {
+		return err
+	}
+
+	if _, err := ParseSeverity("build_file_validation", w.BuildFileValidation, SeverityWarn); err != nil {
+		return err
+	}
+
+	for _, fileName := range w.ExecutableBuildFiles {
+		if fileName == "" || strings.ContainsAny(fileName, `/\`) {
+			return fmt.Errorf("invalid executable_build_files entry %q: must be a file name", fileName)
+		}
+	}
+
+	if err := ValidatePolicies(w.Policies); err != nil {
+		return err
+	}
+
+	if err := w.RemoteExecution.validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -250,6 +333,57 @@ func (w WorkspaceConfig) GetLoadOutputsMode() LoadOutputsMode {
 	return mode
 }
 
+// GetUndeclaredDependenciesSeverity returns the severity of the undeclared
+// producer/consumer check. Defaults to SeverityWarn.
+func (w WorkspaceConfig) GetUndeclaredDependenciesSeverity() Severity {
+	severity, err := ParseSeverity("undeclared_dependencies", w.UndeclaredDependencies, SeverityWarn)
+	if err != nil {
+		// Validated in Validate()
+		return SeverityWarn
+	}
+	return severity
+}
+
+// GetMissingInputsSeverity returns the workspace wide severity for reporting
+// missing input files. Defaults to SeverityWarn.
+func (w WorkspaceConfig) GetMissingInputsSeverity() Severity {
+	severity, err := ParseSeverity("missing_inputs", w.MissingInputs, SeverityWarn)
+	if err != nil {
+		// Validated in Validate()
+		return SeverityWarn
+	}
+	return severity
+}
+
+// GetBuildFileValidationSeverity returns the severity for reporting unknown
+// keys in BUILD files. Defaults to SeverityWarn.
+func (w WorkspaceConfig) GetBuildFileValidationSeverity() Severity {
+	severity, err := ParseSeverity("build_file_validation", w.BuildFileValidation, SeverityWarn)
+	if err != nil {
+		// Validated in Validate()
+		return SeverityWarn
+	}
+	return severity
+}
+
+// GetExecutableBuildFiles returns the file names that are loaded by running
+// them. Defaults to BUILD.gen.
+func (w WorkspaceConfig) GetExecutableBuildFiles() []string {
+	if len(w.ExecutableBuildFiles) == 0 {
+		return []string{"BUILD.gen"}
+	}
+	return w.ExecutableBuildFiles
+}
+
+// GetExecutableBuildFileTimeout returns the maximum run time of an executable
+// BUILD file. Defaults to one minute.
+func (w WorkspaceConfig) GetExecutableBuildFileTimeout() time.Duration {
+	if w.ExecutableBuildFileTimeout <= 0 {
+		return time.Minute
+	}
+	return w.ExecutableBuildFileTimeout
+}
+
 func (w WorkspaceConfig) GetOutputMode() OutputMode {
 	mode, err := ParseOutputMode(w.OutputMode)
 	if err != nil {
@@ -299,10 +433,15 @@ type AzureCacheConfig struct {
 }
 
 type TracesConfig struct {
-	Enabled bool           `mapstructure:"enabled"`
-	Backend CacheBackend   `mapstructure:"backend"`
-	GCS     GCSCacheConfig `mapstructure:"gcs"`
-	S3      S3CacheConfig  `mapstructure:"s3"`
+	Enabled bool             `mapstructure:"enabled"`
+	Backend CacheBackend     `mapstructure:"backend"`
+	GCS     GCSCacheConfig   `mapstructure:"gcs"`
+	S3      S3CacheConfig    `mapstructure:"s3"`
+	Azure   AzureCacheConfig `mapstructure:"azure"`
+
+	// MetricsTextfile is the path of a file for the node exporter textfile
+	// collector that is replaced with the metrics of every build.
+	MetricsTextfile string `mapstructure:"metrics_textfile"`
 }
 
 const (
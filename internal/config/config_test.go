@@ -0,0 +1,77 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestGetTracesCacheConfig(t *testing.T) {
+	readConfig := func(t *testing.T, content string) WorkspaceConfig {
+		t.Helper()
+		v := viper.New()
+		v.SetConfigType("toml")
+		if err := v.ReadConfig(strings.NewReader(content)); err != nil {
+			t.Fatalf("ReadConfig returned error: %v", err)
+		}
+		var w WorkspaceConfig
+		if err := v.Unmarshal(&w); err != nil {
+			t.Fatalf("Unmarshal returned error: %v", err)
+		}
+		return w
+	}
+
+	t.Run("azure", func(t *testing.T) {
+		w := readConfig(t, `
+[cache]
+backend = "s3"
+
+[cache.s3]
+bucket = "build-cache"
+
+[traces]
+backend = "azure"
+
+[traces.azure]
+account_url = "https://account.blob.core.windows.net"
+container = "traces"
+prefix = "ci"
+`)
+		cacheConfig := w.GetTracesCacheConfig()
+		if cacheConfig.Backend != AzureCacheBackend {
+			t.Errorf("expected the azure backend, got %q", cacheConfig.Backend)
+		}
+		expected := AzureCacheConfig{
+			AccountURL: "https://account.blob.core.windows.net",
+			Container:  "traces",
+			Prefix:     "ci",
+		}
+		if cacheConfig.Azure != expected {
+			t.Errorf("expected azure config %+v, got %+v", expected, cacheConfig.Azure)
+		}
+		if cacheConfig.S3 != (S3CacheConfig{}) {
+			t.Errorf("expected the main cache config not to leak into traces, got %+v", cacheConfig.S3)
+		}
+	})
+
+	t.Run("falls back to the main cache", func(t *testing.T) {
+		w := readConfig(t, `
+[cache]
+backend = "azure"
+
+[cache.azure]
+container = "build-cache"
+
+[traces]
+enabled = true
+`)
+		cacheConfig := w.GetTracesCacheConfig()
+		if cacheConfig != w.Cache {
+			t.Errorf("expected the main cache config %+v, got %+v", w.Cache, cacheConfig)
+		}
+		if cacheConfig.Azure.Container != "build-cache" {
+			t.Errorf("expected the container of the main cache, got %q", cacheConfig.Azure.Container)
+		}
+	})
+}
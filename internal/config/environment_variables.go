@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/subosito/gotenv"
+)
+
+// ReadEnvironmentVariables returns the environment variables of w.
+//
+// Viper always normalizes all configuration keys to be lower-case
+// but users should be able to specify upper case environment_variables
+// So as a workaround we load the section of configFile a second time _if_
+// there are env vars.
+//
+// When environment_variables_file is set, variables are loaded from the file
+// first, then inline environment_variables from grog.toml are merged on top
+// (inline values take precedence over file values).
+func (w WorkspaceConfig) ReadEnvironmentVariables(configFile string) (map[string]string, error) {
+	merged := make(map[string]string)
+
+	// Phase 1: Load variables from file if configured.
+	if w.EnvironmentVariablesFile != "" {
+		envFilePath := w.EnvironmentVariablesFile
+		if !filepath.IsAbs(envFilePath) {
+			envFilePath = filepath.Join(w.WorkspaceRoot, envFilePath)
+		}
+
+		f, err := os.Open(envFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open environment_variables_file %q: %w", envFilePath, err)
+		}
+		defer f.Close()
+
+		fileEnv := gotenv.Parse(f)
+		maps.Copy(merged, fileEnv)
+	}
+
+	// Phase 2: Load inline environment_variables from grog.toml (preserving case).
+	if len(w.EnvironmentVariables) > 0 {
+		raw, err := os.ReadFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+
+		var helper envVarsHelper
+		err = toml.Unmarshal(raw, &helper)
+		if err != nil {
+			return nil, err
+		}
+
+		// Inline values override file-loaded values.
+		maps.Copy(merged, helper.EnvironmentVariables)
+	}
+
+	return merged, nil
+}
+
+type envVarsHelper struct {
+	EnvironmentVariables map[string]string `toml:"environment_variables"`
+}
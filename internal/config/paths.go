@@ -56,7 +56,20 @@ func MustFindWorkspaceRoot() string {
 }
 
 func GetPathRelativeToWorkspaceRoot(path string) (string, error) {
-	workspaceRoot := Global.WorkspaceRoot
+	return Global.GetPathRelativeToWorkspaceRoot(path)
+}
+
+func GetPathAbsoluteToWorkspaceRoot(path string) string {
+	return Global.GetPathAbsoluteToWorkspaceRoot(path)
+}
+
+func GetPackagePath(path string) (string, error) {
+	return Global.GetPackagePath(path)
+}
+
+// GetPathRelativeToWorkspaceRoot returns path relative to the root of w.
+func (w WorkspaceConfig) GetPathRelativeToWorkspaceRoot(path string) (string, error) {
+	workspaceRoot := w.WorkspaceRoot
 	// error if path is not under workspace root
 	if !strings.HasPrefix(path, workspaceRoot) {
 		return "", fmt.Errorf("path %s is not under workspace root %s", path, workspaceRoot)
@@ -65,13 +78,14 @@ func GetPathRelativeToWorkspaceRoot(path string) (string, error) {
 	return path[len(workspaceRoot)+1:], nil
 }
 
-func GetPathAbsoluteToWorkspaceRoot(path string) string {
-	workspaceRoot := Global.WorkspaceRoot
-	return filepath.Join(workspaceRoot, path)
+// GetPathAbsoluteToWorkspaceRoot joins the workspace relative path to the root of w.
+func (w WorkspaceConfig) GetPathAbsoluteToWorkspaceRoot(path string) string {
+	return filepath.Join(w.WorkspaceRoot, path)
 }
 
-func GetPackagePath(path string) (string, error) {
-	relativePath, err := GetPathRelativeToWorkspaceRoot(path)
+// GetPackagePath returns the package of the file at path within w.
+func (w WorkspaceConfig) GetPackagePath(path string) (string, error) {
+	relativePath, err := w.GetPathRelativeToWorkspaceRoot(path)
 	if err != nil {
 		return "", err
 	}
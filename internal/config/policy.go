@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+
+	"grog/internal/label"
+)
+
+const (
+	// PolicyEffectDeny reports every dependency from a node matching From
+	// on a node matching To.
+	PolicyEffectDeny = "deny"
+	// PolicyEffectAllow reports every dependency on a node matching To
+	// unless the dependant matches From.
+	PolicyEffectAllow = "allow"
+)
+
+// PolicyConfig is a workspace wide dependency rule declared with a
+// [[policies]] table in grog.toml.
+type PolicyConfig struct {
+	Name        string `mapstructure:"name"`
+	Description string `mapstructure:"description"`
+	// Effect is either "deny" or "allow".
+	Effect string              `mapstructure:"effect"`
+	From   PolicyMatcherConfig `mapstructure:"from"`
+	To     PolicyMatcherConfig `mapstructure:"to"`
+}
+
+// PolicyMatcherConfig selects one side of a dependency edge using the same
+// filters as target selection on the command line. Empty filters match
+// everything.
+type PolicyMatcherConfig struct {
+	Patterns    []string `mapstructure:"patterns"`
+	Tags        []string `mapstructure:"tags"`
+	ExcludeTags []string `mapstructure:"exclude_tags"`
+	// Type is one of "all" (default), "test", "no_test" or "bin_output".
+	Type string `mapstructure:"type"`
+}
+
+// ValidatePolicies validates every policy and checks that the names are
+// unique since violations are reported by policy name.
+func ValidatePolicies(policies []PolicyConfig) error {
+	names := make(map[string]bool, len(policies))
+	for _, policy := range policies {
+		if err := policy.validate(); err != nil {
+			return err
+		}
+		if names[policy.Name] {
+			return fmt.Errorf("invalid policy %s: name is not unique", policy.Name)
+		}
+		names[policy.Name] = true
+	}
+	return nil
+}
+
+func (p PolicyConfig) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("invalid policy: name is required")
+	}
+	if p.Effect != PolicyEffectDeny && p.Effect != PolicyEffectAllow {
+		return fmt.Errorf("invalid policy %s: effect '%s' must be either '%s' or '%s'",
+			p.Name, p.Effect, PolicyEffectDeny, PolicyEffectAllow)
+	}
+	if err := p.From.validate(); err != nil {
+		return fmt.Errorf("invalid policy %s: from: %w", p.Name, err)
+	}
+	if err := p.To.validate(); err != nil {
+		return fmt.Errorf("invalid policy %s: to: %w", p.Name, err)
+	}
+	return nil
+}
+
+func (m PolicyMatcherConfig) validate() error {
+	for _, pattern := range m.Patterns {
+		if _, err := label.ParseTargetPattern("", pattern); err != nil {
+			return err
+		}
+	}
+	if !slices.Contains([]string{"", "all", "test", "no_test", "bin_output"}, m.Type) {
+		return fmt.Errorf("invalid type '%s'. Must be one of 'all', 'test', 'no_test' or 'bin_output'", m.Type)
+	}
+	return nil
+}
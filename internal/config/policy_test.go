@@ -0,0 +1,43 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePolicies(t *testing.T) {
+	valid := PolicyConfig{
+		Name:   "layering",
+		Effect: PolicyEffectDeny,
+		From:   PolicyMatcherConfig{Patterns: []string{"//libs/..."}},
+		To:     PolicyMatcherConfig{Patterns: []string{"//apps/..."}, Type: "no_test"},
+	}
+
+	tests := []struct {
+		name     string
+		policies []PolicyConfig
+		wantErr  string
+	}{
+		{name: "valid", policies: []PolicyConfig{valid}},
+		{name: "missing name", policies: []PolicyConfig{{Effect: PolicyEffectAllow}}, wantErr: "name is required"},
+		{name: "invalid effect", policies: []PolicyConfig{{Name: "a", Effect: "forbid"}}, wantErr: "effect 'forbid'"},
+		{name: "invalid pattern", policies: []PolicyConfig{{Name: "a", Effect: PolicyEffectDeny, From: PolicyMatcherConfig{Patterns: []string{"//a/...x"}}}}, wantErr: "invalid policy a: from:"},
+		{name: "invalid type", policies: []PolicyConfig{{Name: "a", Effect: PolicyEffectDeny, To: PolicyMatcherConfig{Type: "tests"}}}, wantErr: "invalid policy a: to: invalid type 'tests'"},
+		{name: "duplicate name", policies: []PolicyConfig{valid, valid}, wantErr: "name is not unique"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := WorkspaceConfig{LoadOutputs: "all", Policies: tt.policies}.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
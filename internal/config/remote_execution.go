@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RemoteExecutionConfig configures running targets tagged "remote" on a
+// server implementing the Remote Execution API (REAPI).
+type RemoteExecutionConfig struct {
+	// Address of the server, e.g. "grpcs://remote.example.com" or
+	// "grpc://localhost:8980" for plain text. Remote execution is disabled
+	// if empty.
+	Address      string `mapstructure:"address"`
+	InstanceName string `mapstructure:"instance_name"`
+	// Platform lists the platform properties used to pick a worker as
+	// "name=value" pairs, e.g. "OSFamily=Linux". A list instead of a table
+	// keeps the case of the property names.
+	Platform []string `mapstructure:"platform"`
+	// Headers are sent with every request, e.g. for authentication.
+	Headers map[string]string `mapstructure:"headers"`
+}
+
+// Enabled reports whether a remote execution server is configured.
+func (r RemoteExecutionConfig) Enabled() bool {
+	return r.Address != ""
+}
+
+// GetPlatformProperties returns the platform properties by name.
+func (r RemoteExecutionConfig) GetPlatformProperties() map[string]string {
+	properties := make(map[string]string, len(r.Platform))
+	for _, property := range r.Platform {
+		name, value, _ := strings.Cut(property, "=")
+		properties[name] = value
+	}
+	return properties
+}
+
+func (r RemoteExecutionConfig) validate() error {
+	if r.Address != "" && !strings.HasPrefix(r.Address, "grpc://") && !strings.HasPrefix(r.Address, "grpcs://") {
+		return fmt.Errorf("invalid remote_execution.address %q: must start with grpc:// or grpcs://", r.Address)
+	}
+	for _, property := range r.Platform {
+		if name, _, found := strings.Cut(property, "="); !found || name == "" {
+			return fmt.Errorf("invalid remote_execution.platform entry %q: must be of the form name=value", property)
+		}
+	}
+	return nil
+}
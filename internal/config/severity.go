@@ -0,0 +1,32 @@
+package config
+
+import "fmt"
+
+// Severity determines how a consistency check reports its findings.
+type Severity int
+
+const (
+	// SeverityIgnore skips the check entirely.
+	SeverityIgnore Severity = iota
+	// SeverityWarn logs findings as warnings without failing.
+	SeverityWarn
+	// SeverityError fails the command on any finding.
+	SeverityError
+)
+
+// ParseSeverity converts a string to a Severity. An empty string maps to the
+// given default. Returns an error for any other unrecognized value.
+func ParseSeverity(key string, s string, defaultSeverity Severity) (Severity, error) {
+	switch s {
+	case "":
+		return defaultSeverity, nil
+	case "ignore":
+		return SeverityIgnore, nil
+	case "warn":
+		return SeverityWarn, nil
+	case "error":
+		return SeverityError, nil
+	default:
+		return defaultSeverity, fmt.Errorf("invalid %s: '%s'. Must be one of 'ignore', 'warn' or 'error'", key, s)
+	}
+}
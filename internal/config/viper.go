@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// BindEnv lets GROG_ prefixed environment variables override the config
+// keys of v.
+func BindEnv(v *viper.Viper) {
+	v.SetEnvPrefix("GROG")
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_")) // allow FLAG-NAME to map to ENV VAR_NAME
+	v.AutomaticEnv()                                   // read in environment variables that match
+}
+
+// SetDefaults registers the default value of every config key on v.
+func SetDefaults(v *viper.Viper) {
+	v.SetDefault("root", filepath.Join(os.Getenv("HOME"), ".grog"))
+	v.SetDefault("log_level", "info")
+	v.SetDefault("load_outputs", "all")
+	v.SetDefault("disable_non_deterministic_logging", false)
+	v.SetDefault("os", runtime.GOOS)
+	v.SetDefault("arch", runtime.GOARCH)
+	v.SetDefault("cache.gcs.shared_cache", true)
+	v.SetDefault("cache.s3.shared_cache", true)
+	v.SetDefault("cache.azure.shared_cache", true)
+	v.SetDefault("hash_algorithm", HashAlgorithmXXH3)
+	v.SetDefault("include_hidden", false)
+	v.SetDefault("undeclared_dependencies", "warn")
+	v.SetDefault("missing_inputs", "warn")
+	v.SetDefault("build_file_validation", "warn")
+	v.SetDefault("executable_build_files", []string{"BUILD.gen"})
+	v.SetDefault("executable_build_file_timeout", "1m")
+	v.SetDefault("environment_variables", make(map[string]string))
+	v.SetDefault("traces.enabled", false)
+}
+
+// Unmarshal merges all config sources of v (flags, environment, config file
+// and defaults) into w.
+func Unmarshal(v *viper.Viper, w *WorkspaceConfig) error {
+	if err := v.Unmarshal(w); err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	w.HashAlgorithm = strings.ToLower(w.HashAlgorithm)
+
+	platform := v.GetString("platform")
+	if w.AllPlatforms && platform != "" {
+		return fmt.Errorf("--platform cannot be used with --all-platforms")
+	}
+	if platform != "" {
+		parts := strings.SplitN(platform, "/", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid platform %s, expected os/arch", platform)
+		}
+		w.OS = parts[0]
+		w.Arch = parts[1]
+	}
+
+	environmentVariables, err := w.ReadEnvironmentVariables(v.ConfigFileUsed())
+	if err != nil {
+		return err
+	}
+	w.EnvironmentVariables = environmentVariables
+	return nil
+}
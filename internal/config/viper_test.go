@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestUnmarshal(t *testing.T) {
+	workspaceRoot := t.TempDir()
+	configFile := filepath.Join(workspaceRoot, "grog.toml")
+	content := `
+include_hidden = true
+executable_build_file_timeout = "10s"
+
+[environment_variables]
+GREETING = "hello"
+`
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	v := viper.New()
+	v.SetConfigFile(configFile)
+	SetDefaults(v)
+	v.Set("workspace_root", workspaceRoot)
+	v.Set("platform", "linux/arm64")
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatalf("ReadInConfig returned error: %v", err)
+	}
+
+	var w WorkspaceConfig
+	if err := Unmarshal(v, &w); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+
+	if w.WorkspaceRoot != workspaceRoot || !w.IncludeHidden || w.ExecutableBuildFileTimeout != 10*time.Second {
+		t.Errorf("expected the values of the config file, got %+v", w)
+	}
+	if w.BuildFileValidation != "warn" || len(w.ExecutableBuildFiles) != 1 || w.ExecutableBuildFiles[0] != "BUILD.gen" {
+		t.Errorf("expected defaults for unset keys, got %q and %v", w.BuildFileValidation, w.ExecutableBuildFiles)
+	}
+	if w.OS != "linux" || w.Arch != "arm64" {
+		t.Errorf("expected the platform to override os and arch, got %s/%s", w.OS, w.Arch)
+	}
+	if w.EnvironmentVariables["GREETING"] != "hello" {
+		t.Errorf("expected upper case environment variables, got %v", w.EnvironmentVariables)
+	}
+}
@@ -0,0 +1,21 @@
+package config
+
+import "context"
+
+type workspaceKey struct{}
+
+// WithWorkspace returns a context in which packages are loaded from
+// workspace instead of Global, e.g. to load a checkout of another revision
+// without touching the configuration of the running command.
+func WithWorkspace(ctx context.Context, workspace *WorkspaceConfig) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, workspace)
+}
+
+// WorkspaceFromContext returns the workspace set by WithWorkspace or Global
+// if there is none.
+func WorkspaceFromContext(ctx context.Context) *WorkspaceConfig {
+	if workspace, ok := ctx.Value(workspaceKey{}).(*WorkspaceConfig); ok {
+		return workspace
+	}
+	return &Global
+}
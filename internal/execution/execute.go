@@ -14,6 +14,7 @@ import (
 	"grog/internal/output"
 	"grog/internal/output/handlers"
 	"grog/internal/proto/gen"
+	"grog/internal/remote"
 	"grog/internal/worker"
 	"path/filepath"
 	"time"
@@ -51,6 +52,7 @@ type Executor struct {
 	asyncDrained     bool
 	rerunGroup       singleflight.Group
 	resourceManager  *ResourceManager
+	remoteClient     *remote.Client
 }
 
 func NewExecutor(
@@ -517,9 +519,18 @@ func (e *Executor) executeTarget(
 	if target.Command != "" {
 		resourceEnvironment, err = e.resourceManager.EnsureResourcesStarted(ctx, e.graph, target, update)
 		if err == nil {
-			update(worker.Status(fmt.Sprintf("%s: running \"%s\"", target.Label, target.CommandEllipsis())))
-			logger.Debugf("running target %s: %s", target.Label, target.CommandEllipsis())
-			err = executeTarget(ctx, target, binToolPaths, outputIdentifiers, transitiveOutputs, taggedOutputs, resourceEnvironment, e.streamLogsToggle.Enabled())
+			ranRemotely := false
+			// Resources run on this machine so their dependants do as well
+			if e.remoteClient != nil && target.IsRemote() && len(resourceEnvironment) == 0 {
+				update(worker.Status(fmt.Sprintf("%s: running remotely \"%s\"", target.Label, target.CommandEllipsis())))
+				logger.Debugf("running target %s remotely: %s", target.Label, target.CommandEllipsis())
+				ranRemotely, err = e.executeRemoteTarget(ctx, target, binToolPaths, outputIdentifiers, transitiveOutputs, taggedOutputs, isTainted)
+			}
+			if !ranRemotely {
+				update(worker.Status(fmt.Sprintf("%s: running \"%s\"", target.Label, target.CommandEllipsis())))
+				logger.Debugf("running target %s: %s", target.Label, target.CommandEllipsis())
+				err = executeTarget(ctx, target, binToolPaths, outputIdentifiers, transitiveOutputs, taggedOutputs, resourceEnvironment, e.streamLogsToggle.Enabled())
+			}
 		}
 	} else {
 		logger.Debugf("skipped target %s due to no command", target.Label)
@@ -80,7 +80,8 @@ func executeTarget(
 		defer cancel()
 	}
 
-	cmdOut, err := runTargetCommand(ctx, target, binToolPaths, outputIdentifiers, transitiveOutputs, taggedOutputs, resourceEnvironment, target.Command, streamLogs)
+	cmdOut, usage, err := runTargetCommand(ctx, target, binToolPaths, outputIdentifiers, transitiveOutputs, taggedOutputs, resourceEnvironment, target.Command, streamLogs)
+	target.ResourceUsage = usage
 
 	if err != nil {
 		if ctx.Err() != nil {
@@ -105,6 +106,7 @@ func executeTarget(
 }
 
 // runTargetCommand runs a single shell command in the context of a target.
+// It returns the combined output and the resource usage of the command.
 func runTargetCommand(
 	ctx context.Context,
 	target *model.Target,
@@ -115,11 +117,11 @@ func runTargetCommand(
 	resourceEnvironment []string,
 	command string,
 	streamLogs bool,
-) ([]byte, error) {
+) ([]byte, model.ResourceUsage, error) {
 	executionPath := config.GetPathAbsoluteToWorkspaceRoot(target.Label.Package)
 	templatedCommand, err := getCommand(binToolPaths, outputIdentifiers, transitiveOutputs, taggedOutputs, command)
 	if err != nil {
-		return nil, err
+		return nil, model.ResourceUsage{}, err
 	}
 
 	// Execute the rendered script as a file rather than `sh -c "<script>"`: a
@@ -128,7 +130,7 @@ func runTargetCommand(
 	// `sh` reads the file as data, so it needs no execute bit.
 	scriptPath, cleanup, err := writeCommandScript(templatedCommand)
 	if err != nil {
-		return nil, err
+		return nil, model.ResourceUsage{}, err
 	}
 	defer cleanup()
 
@@ -145,7 +147,7 @@ func runTargetCommand(
 	targetLogs := logs.NewTargetLogFile(*target)
 	logWriter, err := targetLogs.Open()
 	if err != nil {
-		return nil, err
+		return nil, model.ResourceUsage{}, err
 	}
 	defer logWriter.Close()
 
@@ -181,10 +183,11 @@ func runTargetCommand(
 	if teaWriter != nil {
 		teaWriter.Flush()
 	}
+	usage := processResourceUsage(cmd.ProcessState)
 	if cmdErr != nil {
-		return buffer.Bytes(), cmdErr
+		return buffer.Bytes(), usage, cmdErr
 	}
-	return buffer.Bytes(), nil
+	return buffer.Bytes(), usage, nil
 }
 
 // writeCommandScript writes the rendered shell script to a temp file and returns
@@ -3,6 +3,7 @@ package execution
 import (
 	"context"
 	"os"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -233,7 +234,7 @@ func TestRunTargetCommandForwardsExtraArgs(t *testing.T) {
 	ctx = WithExtraArgs(ctx, []string{"-k", "test_foo", "-x"})
 
 	// The command uses $@ which should expand to the extra args
-	output, err := runTargetCommand(ctx, target, nil, nil, nil, nil, nil, `echo "ARGS:$@"`, false)
+	output, _, err := runTargetCommand(ctx, target, nil, nil, nil, nil, nil, `echo "ARGS:$@"`, false)
 	if err != nil {
 		t.Fatalf("expected no error, got %v\noutput: %s", err, string(output))
 	}
@@ -270,7 +271,7 @@ func TestRunTargetCommandHandlesLargeScript(t *testing.T) {
 	// this overflows execve; as a script file it runs fine.
 	command := "# " + strings.Repeat("x", 256*1024) + "\necho OK"
 
-	output, err := runTargetCommand(context.Background(), target, nil, nil, nil, nil, nil, command, false)
+	output, _, err := runTargetCommand(context.Background(), target, nil, nil, nil, nil, nil, command, false)
 	if err != nil {
 		t.Fatalf("expected large script to execute, got %v\noutput: %s", err, string(output))
 	}
@@ -301,7 +302,7 @@ func TestRunTargetCommandWithoutExtraArgs(t *testing.T) {
 
 	ctx := context.Background()
 
-	output, err := runTargetCommand(ctx, target, nil, nil, nil, nil, nil, `echo "ARGS:$@"`, false)
+	output, _, err := runTargetCommand(ctx, target, nil, nil, nil, nil, nil, `echo "ARGS:$@"`, false)
 	if err != nil {
 		t.Fatalf("expected no error, got %v\noutput: %s", err, string(output))
 	}
@@ -316,3 +317,38 @@ func TestRunTargetCommandWithoutExtraArgs(t *testing.T) {
 		t.Errorf("expected $@ to be empty (output 'ARGS:'), got: %q", trimmed)
 	}
 }
+
+// TestExecuteTargetRecordsResourceUsage verifies that the rusage of the target
+// command is stored on the target for trace collection.
+func TestExecuteTargetRecordsResourceUsage(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("resource usage is only collected on linux and darwin")
+	}
+	tmpDir := t.TempDir()
+
+	prev := config.Global
+	config.Global = config.WorkspaceConfig{
+		Root:                     tmpDir,
+		WorkspaceRoot:            tmpDir,
+		DisableDefaultShellFlags: true,
+	}
+	t.Cleanup(func() { config.Global = prev })
+
+	os.MkdirAll(tmpDir+"/pkg", 0755)
+	os.MkdirAll(tmpDir+"/logs/pkg", 0755)
+
+	target := &model.Target{
+		Label:   label.TargetLabel{Package: "pkg", Name: "test"},
+		Command: "i=0; while [ $i -lt 20000 ]; do i=$((i+1)); done",
+	}
+
+	if err := executeTarget(context.Background(), target, nil, nil, nil, nil, nil, false); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if target.ResourceUsage.MaxRSSBytes == 0 {
+		t.Errorf("expected peak rss to be recorded, got %+v", target.ResourceUsage)
+	}
+	if target.ResourceUsage.UserCPU+target.ResourceUsage.SystemCPU == 0 {
+		t.Errorf("expected cpu time to be recorded, got %+v", target.ResourceUsage)
+	}
+}
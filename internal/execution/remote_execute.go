@@ -0,0 +1,232 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"grog/internal/config"
+	"grog/internal/console"
+	"grog/internal/logs"
+	"grog/internal/model"
+	"grog/internal/output/handlers"
+	"grog/internal/remote"
+	"path/filepath"
+	"strings"
+)
+
+// remoteScriptPath is the workspace relative path of the rendered command
+// script in the remote input root.
+const remoteScriptPath = ".grog-remote/command.sh"
+
+// errRemoteUnsupported is returned for targets that cannot run remotely and
+// therefore always run locally.
+var errRemoteUnsupported = errors.New("target cannot run remotely")
+
+// SetRemoteClient enables remote execution of targets tagged "remote".
+func (e *Executor) SetRemoteClient(client *remote.Client) {
+	e.remoteClient = client
+}
+
+// executeRemoteTarget runs the command of target on the remote execution
+// server. It returns false if the target should run locally instead because
+// it cannot run remotely or the server could not be reached. Errors of the
+// command itself are returned the same way as for local execution.
+func (e *Executor) executeRemoteTarget(
+	ctx context.Context,
+	target *model.Target,
+	binToolPaths BinToolMap,
+	outputIdentifiers OutputIdentifierMap,
+	transitiveOutputs []string,
+	taggedOutputs TransitiveTaggedOutputs,
+	isTainted bool,
+) (bool, error) {
+	logger := console.GetLogger(ctx)
+
+	action, err := newRemoteAction(ctx, target, binToolPaths, outputIdentifiers, transitiveOutputs, taggedOutputs)
+	if err != nil {
+		logger.Debugf("%s: running locally: %v", target.Label, err)
+		return false, nil
+	}
+	action.SkipCacheLookup = isTainted
+
+	result, err := e.remoteClient.Execute(ctx, action)
+	if err != nil {
+		if ctx.Err() != nil {
+			return true, ctx.Err()
+		}
+		logger.Warnf("%s: remote execution failed, running locally: %v", target.Label, err)
+		return false, nil
+	}
+	if result.Cached {
+		logger.Debugf("%s: remote action cache hit", target.Label)
+	}
+
+	cmdOut := append(result.Stdout, result.Stderr...)
+	if err := writeRemoteLogs(ctx, target, cmdOut, e.streamLogsToggle.Enabled()); err != nil {
+		return true, err
+	}
+
+	if result.TimedOut {
+		return true, fmt.Errorf("timeout after %s", target.Timeout)
+	}
+	if result.ExitCode != 0 {
+		return true, &CommandError{
+			TargetLabel: target.Label,
+			ExitCode:    result.ExitCode,
+			Output:      string(cmdOut),
+		}
+	}
+	return true, nil
+}
+
+// workspaceRelative returns path relative to the workspace root if it is an
+// absolute path within the workspace.
+func workspaceRelative(path string) (string, bool) {
+	if !filepath.IsAbs(path) {
+		return "", false
+	}
+	relativePath, err := filepath.Rel(config.Global.WorkspaceRoot, path)
+	if err != nil || !filepath.IsLocal(relativePath) {
+		return "", false
+	}
+	return relativePath, true
+}
+
+// newRemoteAction creates the remote action for target. The workspace root
+// is the input root of the action, so absolute paths to dependency outputs
+// and tools are rewritten relative to the target's package.
+func newRemoteAction(
+	ctx context.Context,
+	target *model.Target,
+	binToolPaths BinToolMap,
+	outputIdentifiers OutputIdentifierMap,
+	transitiveOutputs []string,
+	taggedOutputs TransitiveTaggedOutputs,
+) (remote.Action, error) {
+	var outputPaths []string
+	for _, targetOutput := range target.AllOutputs() {
+		if !targetOutput.IsSet() {
+			continue
+		}
+		if targetOutput.Type != string(handlers.FileHandler) && targetOutput.Type != string(handlers.DirHandler) {
+			return remote.Action{}, fmt.Errorf("%w: %s outputs are not supported", errRemoteUnsupported, targetOutput.Type)
+		}
+		outputPaths = append(outputPaths, targetOutput.Identifier)
+	}
+
+	packagePath := target.Label.Package
+	toPackageRelative := func(path string) string {
+		if _, ok := workspaceRelative(path); !ok {
+			return path
+		}
+		relativePath, err := filepath.Rel(config.GetPathAbsoluteToWorkspaceRoot(packagePath), path)
+		if err != nil {
+			return path
+		}
+		return relativePath
+	}
+
+	remoteBinToolPaths := make(BinToolMap, len(binToolPaths))
+	for key, path := range binToolPaths {
+		remoteBinToolPaths[key] = toPackageRelative(path)
+	}
+	remoteOutputIdentifiers := make(OutputIdentifierMap, len(outputIdentifiers))
+	for key, identifiers := range outputIdentifiers {
+		for _, identifier := range identifiers {
+			remoteOutputIdentifiers[key] = append(remoteOutputIdentifiers[key], toPackageRelative(identifier))
+		}
+	}
+	remoteTaggedOutputs := make(TransitiveTaggedOutputs, len(taggedOutputs))
+	for tag, identifiers := range taggedOutputs {
+		for _, identifier := range identifiers {
+			remoteTaggedOutputs[tag] = append(remoteTaggedOutputs[tag], toPackageRelative(identifier))
+		}
+	}
+	var remoteTransitiveOutputs []string
+	for _, identifier := range transitiveOutputs {
+		remoteTransitiveOutputs = append(remoteTransitiveOutputs, toPackageRelative(identifier))
+	}
+
+	script, err := getCommand(remoteBinToolPaths, remoteOutputIdentifiers, remoteTransitiveOutputs, remoteTaggedOutputs, target.Command)
+	if err != nil {
+		return remote.Action{}, err
+	}
+
+	// Inputs are relative to the package while dependency outputs (which
+	// include bin outputs) are absolute
+	var inputs []string
+	for _, input := range target.Inputs {
+		inputs = append(inputs, filepath.Join(packagePath, input))
+	}
+	for _, identifier := range transitiveOutputs {
+		if relativePath, ok := workspaceRelative(identifier); ok {
+			inputs = append(inputs, relativePath)
+		}
+	}
+
+	workspaceRoot, err := filepath.Rel(config.GetPathAbsoluteToWorkspaceRoot(packagePath), config.Global.WorkspaceRoot)
+	if err != nil {
+		return remote.Action{}, err
+	}
+	scriptPath, err := filepath.Rel(config.GetPathAbsoluteToWorkspaceRoot(packagePath), config.GetPathAbsoluteToWorkspaceRoot(remoteScriptPath))
+	if err != nil {
+		return remote.Action{}, err
+	}
+
+	// The host environment is not forwarded since it would make the action
+	// depend on the local machine. GROG_GIT_HASH is left out as well since it
+	// would give the action a different cache key on every commit.
+	environment := make(map[string]string)
+	for k, v := range config.Global.EnvironmentVariables {
+		environment[k] = v
+	}
+	for k, v := range target.EnvironmentVariables {
+		environment[k] = v
+	}
+	environment["GROG_TARGET"] = target.Label.String()
+	environment["GROG_OS"] = config.Global.OS
+	environment["GROG_ARCH"] = config.Global.Arch
+	environment["GROG_PLATFORM"] = config.Global.GetPlatform()
+	environment["GROG_PLATFORM_TAGS"] = strings.Join(config.Global.PlatformTags, ",")
+	environment["GROG_PACKAGE"] = packagePath
+	environment["GROG_WORKSPACE_ROOT"] = workspaceRoot
+
+	return remote.Action{
+		InputRoot:        config.Global.WorkspaceRoot,
+		Inputs:           inputs,
+		Files:            map[string][]byte{remoteScriptPath: []byte(script)},
+		WorkingDirectory: packagePath,
+		Arguments:        append([]string{"sh", scriptPath}, ExtraArgsFromContext(ctx)...),
+		Environment:      environment,
+		OutputPaths:      outputPaths,
+		Timeout:          target.Timeout,
+		DoNotCache:       target.SkipsCache(),
+	}, nil
+}
+
+// writeRemoteLogs writes the output of a remotely executed command to the
+// target log file and streams it if enabled.
+func writeRemoteLogs(ctx context.Context, target *model.Target, cmdOut []byte, streamLogs bool) error {
+	logWriter, err := logs.NewTargetLogFile(*target).Open()
+	if err != nil {
+		return err
+	}
+	defer logWriter.Close()
+	if _, err := logWriter.Write(cmdOut); err != nil {
+		return err
+	}
+
+	program := console.GetTeaProgram(ctx)
+	if program == nil {
+		return nil
+	}
+	if toggle := console.GetStreamLogsToggle(ctx); toggle != nil {
+		streamLogs = toggle.Enabled()
+	}
+	if streamLogs {
+		teaWriter := console.NewTeaWriter(program)
+		_, _ = teaWriter.Write(cmdOut)
+		teaWriter.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"grog/internal/config"
+	"grog/internal/label"
+	"grog/internal/model"
+)
+
+func TestNewRemoteAction(t *testing.T) {
+	original := config.Global
+	config.Global.WorkspaceRoot = "/workspace"
+	config.Global.EnvironmentVariables = map[string]string{"GLOBAL": "1"}
+	t.Cleanup(func() {
+		config.Global = original
+	})
+
+	target := &model.Target{
+		Label:                label.TargetLabel{Package: "apps/api", Name: "build"},
+		Command:              `$(bin //tools:gen) $(output //lib 0) $(bin //tools:sibling) > dist/api`,
+		Inputs:               []string{"main.go", "../shared/config.json"},
+		Outputs:              []model.Output{model.NewOutput("file", "dist/api")},
+		EnvironmentVariables: map[string]string{"TARGET": "2"},
+		Tags:                 []string{model.TagRemote},
+	}
+	binToolPaths := BinToolMap{"//tools:gen": "/workspace/tools/dist/gen", "//tools:sibling": "/workspace-other/bin/tool"}
+	outputIdentifiers := OutputIdentifierMap{"//lib": {"/workspace/lib/dist/lib.a"}}
+	transitiveOutputs := []string{"/workspace/lib/dist/lib.a", "/workspace/tools/dist/gen", "/workspace-other/bin/tool", "registry/image:tag"}
+
+	action, err := newRemoteAction(context.Background(), target, binToolPaths, outputIdentifiers, transitiveOutputs, nil)
+	if err != nil {
+		t.Fatalf("newRemoteAction failed: %v", err)
+	}
+
+	if action.InputRoot != "/workspace" || action.WorkingDirectory != "apps/api" {
+		t.Errorf("unexpected input root %q and working directory %q", action.InputRoot, action.WorkingDirectory)
+	}
+	expectedInputs := []string{"apps/api/main.go", "apps/shared/config.json", "lib/dist/lib.a", "tools/dist/gen"}
+	if !slices.Equal(action.Inputs, expectedInputs) {
+		t.Errorf("expected inputs %v, got %v", expectedInputs, action.Inputs)
+	}
+	if !slices.Equal(action.OutputPaths, []string{"dist/api"}) {
+		t.Errorf("unexpected output paths %v", action.OutputPaths)
+	}
+	if !slices.Equal(action.Arguments, []string{"sh", "../../" + remoteScriptPath}) {
+		t.Errorf("unexpected arguments %v", action.Arguments)
+	}
+
+	script := string(action.Files[remoteScriptPath])
+	if strings.Contains(script, "/workspace/") {
+		t.Errorf("expected no absolute workspace paths in the script, got:\n%s", script)
+	}
+	// Paths outside of the workspace, even if they share its prefix, are kept
+	for _, path := range []string{"../../tools/dist/gen", "../../lib/dist/lib.a", `"/workspace-other/bin/tool"`} {
+		if !strings.Contains(script, path) {
+			t.Errorf("expected script to contain %s, got:\n%s", path, script)
+		}
+	}
+
+	for name, value := range map[string]string{
+		"GLOBAL":              "1",
+		"TARGET":              "2",
+		"GROG_PACKAGE":        "apps/api",
+		"GROG_WORKSPACE_ROOT": "../..",
+	} {
+		if action.Environment[name] != value {
+			t.Errorf("expected %s=%s, got %q", name, value, action.Environment[name])
+		}
+	}
+	if _, ok := action.Environment["PATH"]; ok {
+		t.Errorf("expected the host environment not to be forwarded")
+	}
+}
+
+func TestNewRemoteActionRejectsUnsupportedOutputs(t *testing.T) {
+	target := &model.Target{
+		Label:   label.TargetLabel{Package: "pkg", Name: "image"},
+		Command: "docker build .",
+		Outputs: []model.Output{model.NewOutput("docker", "image:latest")},
+	}
+
+	_, err := newRemoteAction(context.Background(), target, nil, nil, nil, nil)
+	if !errors.Is(err, errRemoteUnsupported) {
+		t.Errorf("expected errRemoteUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build linux || darwin
+
+package execution
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+
+	"grog/internal/model"
+)
+
+// processResourceUsage converts the rusage of an exited process.
+// On Linux the usage includes all descendants that the process waited for.
+func processResourceUsage(state *os.ProcessState) model.ResourceUsage {
+	if state == nil {
+		return model.ResourceUsage{}
+	}
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || rusage == nil {
+		return model.ResourceUsage{}
+	}
+
+	// Linux reports the peak resident set size in kilobytes, macOS in bytes
+	maxRSS := int64(rusage.Maxrss)
+	if runtime.GOOS == "linux" {
+		maxRSS *= 1024
+	}
+
+	return model.ResourceUsage{
+		UserCPU:                    time.Duration(rusage.Utime.Nano()),
+		SystemCPU:                  time.Duration(rusage.Stime.Nano()),
+		MaxRSSBytes:                maxRSS,
+		BlockInputOps:              int64(rusage.Inblock),
+		BlockOutputOps:             int64(rusage.Oublock),
+		VoluntaryContextSwitches:   int64(rusage.Nvcsw),
+		InvoluntaryContextSwitches: int64(rusage.Nivcsw),
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package execution
+
+import (
+	"os"
+
+	"grog/internal/model"
+)
+
+// processResourceUsage is not supported on this platform.
+func processResourceUsage(_ *os.ProcessState) model.ResourceUsage {
+	return model.ResourceUsage{}
+}
@@ -0,0 +1,273 @@
+// Package gen generates BUILD file targets from the imports of source files,
+// similar to Gazelle. Languages plug in through the Language interface.
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"grog/internal/buildfile"
+	"grog/internal/console"
+	"grog/internal/label"
+	"grog/internal/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+// generatedKeys are the target attributes that grog gen owns on existing targets.
+// All other attributes (command, outputs, tags, ...) are only set when a
+// target is created and are left alone afterward.
+var generatedKeys = []string{"dependencies", "inputs"}
+
+// Language is a language extension that turns the source files of a
+// directory into targets and maps the imports of those files to files in
+// the workspace.
+type Language interface {
+	// Name identifies the language on the command line (--lang).
+	Name() string
+	// GenerateTargets returns the targets for the files of a directory
+	// (relative to the workspace root). Returns nil if the directory contains
+	// no sources of the language.
+	GenerateTargets(workspace *Workspace, directory string) []*Target
+	// ResolveImport returns the workspace relative paths of the files that
+	// provide an import of a file in the given directory.
+	// Returns nil for imports that are not provided by the workspace such as
+	// the standard library or third party packages.
+	ResolveImport(workspace *Workspace, directory string, importPath string) []string
+}
+
+// Target is a target generated by a Language.
+type Target struct {
+	Name string
+	// Command is only used when the target is created.
+	Command string
+	// Inputs are relative to the directory of the target.
+	Inputs []string
+	// Dependencies are added in addition to the resolved imports.
+	Dependencies []label.TargetLabel
+	// Imports are resolved to dependencies using ResolveImport.
+	Imports []string
+}
+
+// Languages returns all available language extensions.
+func Languages() []Language {
+	return []Language{&goLanguage{}, &pythonLanguage{}}
+}
+
+// Workspace lists the files of the workspace by directory.
+type Workspace struct {
+	// Root is the absolute path of the workspace root.
+	Root string
+	// Files maps workspace relative directories ("" for the root) to the
+	// names of the files they contain.
+	Files map[string][]string
+}
+
+// HasFile reports whether the workspace relative file exists.
+func (w *Workspace) HasFile(filePath string) bool {
+	directory := path.Dir(filePath)
+	if directory == "." {
+		directory = ""
+	}
+	return slices.Contains(w.Files[directory], path.Base(filePath))
+}
+
+// ReadFile reads a workspace relative file.
+func (w *Workspace) ReadFile(filePath string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(w.Root, filepath.FromSlash(filePath)))
+}
+
+// Generator generates targets for a set of directories and merges them
+// into the BUILD files of those directories.
+type Generator struct {
+	logger    *console.Logger
+	workspace *Workspace
+	languages []Language
+	// existing are the targets that are currently defined in the workspace.
+	existing []*model.Target
+}
+
+func NewGenerator(logger *console.Logger, workspace *Workspace, languages []Language, existing []*model.Target) *Generator {
+	return &Generator{
+		logger:    logger,
+		workspace: workspace,
+		languages: languages,
+		existing:  existing,
+	}
+}
+
+// generatedTarget is a Target placed in its package.
+type generatedTarget struct {
+	*Target
+	label     label.TargetLabel
+	language  Language
+	directory string
+}
+
+// Generate creates or updates the targets of the given workspace relative
+// directories. It returns the new content of each changed BUILD file keyed by
+// its absolute path.
+func (g *Generator) Generate(directories []string) (map[string][]byte, error) {
+	existingByLabel := make(map[label.TargetLabel]*model.Target)
+	for _, target := range g.existing {
+		existingByLabel[target.Label] = target
+	}
+
+	var generated []*generatedTarget
+	for _, directory := range directories {
+		for _, language := range g.languages {
+			for _, target := range language.GenerateTargets(g.workspace, directory) {
+				targetLabel := label.TL(directory, target.Name)
+				if existing, ok := existingByLabel[targetLabel]; ok && !isMergeable(existing.SourceFilePath) {
+					g.logger.Warnf("skipping %s: it is already defined in %s", targetLabel, g.displayPath(existing.SourceFilePath))
+					continue
+				}
+				generated = append(generated, &generatedTarget{
+					Target:    target,
+					label:     targetLabel,
+					language:  language,
+					directory: directory,
+				})
+			}
+		}
+	}
+
+	owners := newOwnerIndex(g.existing, generated)
+
+	documents := make(map[string]buildfile.Document)
+	original := make(map[string][]byte)
+	for _, target := range generated {
+		filePath := g.buildFilePath(target.directory)
+		document, ok := documents[filePath]
+		if !ok {
+			content, err := os.ReadFile(filePath)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			document, err = buildfile.Open(filePath, content, target.directory)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", g.displayPath(filePath), err)
+			}
+			documents[filePath] = document
+			original[filePath] = content
+		}
+
+		dependencies := g.resolveDependencies(owners, target)
+		if _, err := buildfile.MergeGeneratedTarget(document, definition(target, dependencies), generatedKeys); err != nil {
+			return nil, fmt.Errorf("%s: %w", g.displayPath(filePath), err)
+		}
+	}
+
+	changed := make(map[string][]byte)
+	for filePath, document := range documents {
+		content, err := document.Bytes()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", g.displayPath(filePath), err)
+		}
+		if !bytes.Equal(content, original[filePath]) {
+			changed[filePath] = content
+		}
+	}
+	return changed, nil
+}
+
+// resolveDependencies maps the imports of a target to the labels of the
+// targets that own the imported files.
+func (g *Generator) resolveDependencies(owners *ownerIndex, target *generatedTarget) []label.TargetLabel {
+	dependencies := slices.Clone(target.Dependencies)
+	for _, importPath := range target.Imports {
+		files := target.language.ResolveImport(g.workspace, target.directory, importPath)
+		if len(files) == 0 {
+			continue
+		}
+
+		owner, err := owners.resolve(files, target.label)
+		if err != nil {
+			g.logger.Warnf("%s: could not resolve import %q: %v", target.label, importPath, err)
+			continue
+		}
+		if owner != nil && !slices.Contains(dependencies, *owner) {
+			dependencies = append(dependencies, *owner)
+		}
+	}
+
+	slices.SortFunc(dependencies, func(a, b label.TargetLabel) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return dependencies
+}
+
+// buildFilePath returns the BUILD.yaml or BUILD.json file of a directory.
+// A new BUILD.yaml file is used if there is none.
+func (g *Generator) buildFilePath(directory string) string {
+	for _, fileName := range []string{"BUILD.yaml", "BUILD.yml", "BUILD.json"} {
+		if slices.Contains(g.workspace.Files[directory], fileName) {
+			return filepath.Join(g.workspace.Root, filepath.FromSlash(directory), fileName)
+		}
+	}
+	return filepath.Join(g.workspace.Root, filepath.FromSlash(directory), "BUILD.yaml")
+}
+
+func (g *Generator) displayPath(filePath string) string {
+	relativePath, err := filepath.Rel(g.workspace.Root, filePath)
+	if err != nil {
+		return filePath
+	}
+	return filepath.ToSlash(relativePath)
+}
+
+// isMergeable reports whether generated targets can be merged into the file.
+func isMergeable(filePath string) bool {
+	switch filepath.Base(filePath) {
+	case "BUILD.yaml", "BUILD.yml", "BUILD.json":
+		return true
+	}
+	return false
+}
+
+// definition renders a generated target as a BUILD.yaml target definition.
+func definition(target *generatedTarget, dependencies []label.TargetLabel) *yaml.Node {
+	mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	addScalar := func(key string, value string) {
+		mapping.Content = append(mapping.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+		)
+	}
+	addList := func(key string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, value := range values {
+			list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+		}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, list)
+	}
+
+	addScalar("name", target.Name)
+	if target.Command != "" {
+		addScalar("command", target.Command)
+	}
+
+	var spelledDependencies []string
+	for _, dependency := range dependencies {
+		if dependency.Package == target.label.Package {
+			spelledDependencies = append(spelledDependencies, ":"+dependency.Name)
+		} else {
+			spelledDependencies = append(spelledDependencies, dependency.String())
+		}
+	}
+	addList("dependencies", spelledDependencies)
+
+	inputs := slices.Clone(target.Inputs)
+	slices.Sort(inputs)
+	addList("inputs", inputs)
+	return mapping
+}
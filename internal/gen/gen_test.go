@@ -0,0 +1,151 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"grog/internal/console"
+	"grog/internal/label"
+	"grog/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+)
+
+// setupWorkspace writes the files to a temporary workspace and returns its file listing.
+func setupWorkspace(t *testing.T, files map[string]string) *Workspace {
+	t.Helper()
+	workspace := &Workspace{Root: t.TempDir(), Files: make(map[string][]string)}
+	for filePath, content := range files {
+		absolutePath := filepath.Join(workspace.Root, filepath.FromSlash(filePath))
+		require.NoError(t, os.MkdirAll(filepath.Dir(absolutePath), 0755))
+		require.NoError(t, os.WriteFile(absolutePath, []byte(content), 0644))
+
+		directory := filepath.ToSlash(filepath.Dir(filePath))
+		if directory == "." {
+			directory = ""
+		}
+		workspace.Files[directory] = append(workspace.Files[directory], filepath.Base(filePath))
+	}
+	return workspace
+}
+
+func newTestGenerator(t *testing.T, workspace *Workspace, existing ...*model.Target) *Generator {
+	logger := console.NewFromSugared(zaptest.NewLogger(t).Sugar(), zapcore.DebugLevel)
+	return NewGenerator(logger, workspace, Languages(), existing)
+}
+
+func TestGenerate_Go(t *testing.T) {
+	workspace := setupWorkspace(t, map[string]string{
+		"go.mod":              "module example.com/app\n",
+		"lib/log/log.go":      "package log\n\nimport \"fmt\"\n",
+		"lib/log/x_test.go":   "package log_test\n\nimport \"example.com/app/lib/log\"\n",
+		"cmd/app/main.go":     "package main\n\nimport (\n\t\"example.com/app/lib/log\"\n\t\"example.com/app/vendored\"\n\t\"github.com/other/module\"\n)\n",
+		"vendored/v.go":       "package vendored\n",
+		"vendored/BUILD.star": "",
+		"cmd/app/BUILD.yaml": `targets:
+  - name: go
+    command: go build -o app .
+    outputs:
+      - app
+`,
+	})
+	vendored := &model.Target{
+		Label:          label.TL("vendored", "lib"),
+		Inputs:         []string{"v.go"},
+		SourceFilePath: filepath.Join(workspace.Root, "vendored", "BUILD.star"),
+	}
+	hidden := &model.Target{
+		Label:          label.TL("vendored", "go"),
+		SourceFilePath: filepath.Join(workspace.Root, "vendored", "BUILD.star"),
+	}
+
+	changed, err := newTestGenerator(t, workspace, vendored, hidden).Generate([]string{"cmd/app", "lib/log", "vendored"})
+	require.NoError(t, err)
+	require.Len(t, changed, 2, "vendored:go is defined in a Starlark file and must be skipped")
+
+	assert.Equal(t, `targets:
+  - name: go
+    command: go build -o app .
+    dependencies:
+      - //lib/log:go
+      - //vendored:lib
+    inputs:
+      - main.go
+    outputs:
+      - app
+`, string(changed[filepath.Join(workspace.Root, "cmd", "app", "BUILD.yaml")]))
+
+	assert.Equal(t, `targets:
+  - name: go
+    inputs:
+      - log.go
+
+  - name: go_test
+    command: go test .
+    dependencies:
+      - :go
+    inputs:
+      - x_test.go
+`, string(changed[filepath.Join(workspace.Root, "lib", "log", "BUILD.yaml")]))
+}
+
+func TestGenerate_Python(t *testing.T) {
+	workspace := setupWorkspace(t, map[string]string{
+		"services/api/pyproject.toml":           "",
+		"services/api/src/api/__init__.py":      "from .handlers import handle\n",
+		"services/api/src/api/handlers.py":      "import json\nfrom shared.log import (\n    info,\n)\n",
+		"services/api/src/api/test_handlers.py": "from api import handlers\n",
+		"shared/log/__init__.py":                "",
+		"shared/BUILD.json":                     `{"targets": [{"name": "py", "inputs": ["__init__.py"]}]}`,
+		"shared/__init__.py":                    "from . import log\n",
+	})
+
+	changed, err := newTestGenerator(t, workspace).Generate([]string{"services/api/src/api", "shared", "shared/log"})
+	require.NoError(t, err)
+
+	assert.Equal(t, `targets:
+  - name: py
+    dependencies:
+      - //shared/log:py
+    inputs:
+      - __init__.py
+      - handlers.py
+
+  - name: py_test
+    command: python -m pytest
+    dependencies:
+      - :py
+    inputs:
+      - test_handlers.py
+`, string(changed[filepath.Join(workspace.Root, "services", "api", "src", "api", "BUILD.yaml")]))
+
+	assert.Equal(t, `{
+  "targets": [
+    {
+      "name": "py",
+      "dependencies": ["//shared/log:py"],
+      "inputs": ["__init__.py"]
+    }
+  ]
+}
+`, string(changed[filepath.Join(workspace.Root, "shared", "BUILD.json")]))
+}
+
+func TestPythonImports(t *testing.T) {
+	workspace := setupWorkspace(t, map[string]string{
+		"pkg/mod.py": `import os, sys as system
+import a.b.c  # comment
+from . import sibling
+from ..parent import name as alias, other
+from x.y import *
+`,
+	})
+
+	assert.Equal(t,
+		[]string{"..parent.name", "..parent.other", ".sibling", "a.b.c", "os", "sys", "x.y"},
+		pythonImports(workspace, "pkg", []string{"mod.py"}))
+}
@@ -0,0 +1,150 @@
+package gen
+
+import (
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"grog/internal/label"
+)
+
+const (
+	goLibraryName = "go"
+	goTestName    = "go_test"
+)
+
+var goModulePattern = regexp.MustCompile(`(?m)^\s*module\s+"?([^\s"]+)"?`)
+
+// goLanguage generates a library target with the non-test files of a Go
+// package and a test target that runs `go test`. Package imports are mapped
+// to the targets owning the files of the imported package's directory.
+// Imports are resolved against all go.mod files in the workspace.
+type goLanguage struct {
+	// modules maps module paths to their workspace relative directories.
+	modules map[string]string
+}
+
+func (l *goLanguage) Name() string {
+	return "go"
+}
+
+func (l *goLanguage) GenerateTargets(workspace *Workspace, directory string) []*Target {
+	var sources, tests []string
+	for _, fileName := range workspace.Files[directory] {
+		if !strings.HasSuffix(fileName, ".go") {
+			continue
+		}
+		if strings.HasSuffix(fileName, "_test.go") {
+			tests = append(tests, fileName)
+		} else {
+			sources = append(sources, fileName)
+		}
+	}
+
+	var targets []*Target
+	if len(sources) > 0 {
+		targets = append(targets, &Target{
+			Name:    goLibraryName,
+			Inputs:  sources,
+			Imports: goImports(workspace, directory, sources),
+		})
+	}
+	if len(tests) > 0 {
+		test := &Target{
+			Name:    goTestName,
+			Command: "go test .",
+			Inputs:  tests,
+			Imports: goImports(workspace, directory, tests),
+		}
+		if len(sources) > 0 {
+			test.Dependencies = []label.TargetLabel{label.TL(directory, goLibraryName)}
+		}
+		targets = append(targets, test)
+	}
+	return targets
+}
+
+func (l *goLanguage) ResolveImport(workspace *Workspace, _ string, importPath string) []string {
+	modulePath, directory := l.findModule(workspace, importPath)
+	if modulePath == "" {
+		return nil
+	}
+
+	packageDirectory := path.Join(directory, strings.TrimPrefix(strings.TrimPrefix(importPath, modulePath), "/"))
+	if packageDirectory == "." {
+		packageDirectory = ""
+	}
+
+	var files []string
+	for _, fileName := range workspace.Files[packageDirectory] {
+		if strings.HasSuffix(fileName, ".go") && !strings.HasSuffix(fileName, "_test.go") {
+			files = append(files, path.Join(packageDirectory, fileName))
+		}
+	}
+	return files
+}
+
+// findModule returns the longest module path that the import path belongs to
+// and the directory of that module.
+func (l *goLanguage) findModule(workspace *Workspace, importPath string) (string, string) {
+	if l.modules == nil {
+		l.modules = goModules(workspace)
+	}
+
+	var bestPath, bestDirectory string
+	for modulePath, directory := range l.modules {
+		if importPath != modulePath && !strings.HasPrefix(importPath, modulePath+"/") {
+			continue
+		}
+		if len(modulePath) > len(bestPath) {
+			bestPath, bestDirectory = modulePath, directory
+		}
+	}
+	return bestPath, bestDirectory
+}
+
+func goModules(workspace *Workspace) map[string]string {
+	modules := make(map[string]string)
+	for directory, fileNames := range workspace.Files {
+		if !slices.Contains(fileNames, "go.mod") {
+			continue
+		}
+		content, err := workspace.ReadFile(path.Join(directory, "go.mod"))
+		if err != nil {
+			continue
+		}
+		if match := goModulePattern.FindSubmatch(content); match != nil {
+			modules[string(match[1])] = directory
+		}
+	}
+	return modules
+}
+
+// goImports returns the sorted import paths of the given files.
+// Files that cannot be parsed are skipped since they fail to compile anyway.
+func goImports(workspace *Workspace, directory string, fileNames []string) []string {
+	var imports []string
+	fileSet := token.NewFileSet()
+	for _, fileName := range fileNames {
+		content, err := workspace.ReadFile(path.Join(directory, fileName))
+		if err != nil {
+			continue
+		}
+		file, err := parser.ParseFile(fileSet, fileName, content, parser.ImportsOnly)
+		if err != nil {
+			continue
+		}
+		for _, importSpec := range file.Imports {
+			importPath, err := strconv.Unquote(importSpec.Path.Value)
+			if err == nil && !slices.Contains(imports, importPath) {
+				imports = append(imports, importPath)
+			}
+		}
+	}
+	slices.Sort(imports)
+	return imports
+}
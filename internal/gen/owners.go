@@ -0,0 +1,88 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"slices"
+
+	"grog/internal/label"
+	"grog/internal/model"
+)
+
+// ownerIndex maps workspace relative files to the targets that declare them
+// as inputs, the same way grog owners does. Test targets are never owners
+// since nothing should depend on them.
+type ownerIndex struct {
+	owners map[string][]label.TargetLabel
+	// generated contains the labels of targets created by this run which take
+	// precedence when more than one target owns a file.
+	generated map[label.TargetLabel]bool
+}
+
+func newOwnerIndex(existing []*model.Target, generated []*generatedTarget) *ownerIndex {
+	index := &ownerIndex{
+		owners:    make(map[string][]label.TargetLabel),
+		generated: make(map[label.TargetLabel]bool),
+	}
+
+	for _, target := range generated {
+		index.generated[target.label] = true
+		if target.label.IsTest() {
+			continue
+		}
+		for _, input := range target.Inputs {
+			index.add(path.Join(target.directory, input), target.label)
+		}
+	}
+
+	for _, target := range existing {
+		// The generated version replaces the current definition
+		if index.generated[target.Label] || target.IsTest() {
+			continue
+		}
+		for _, input := range target.Inputs {
+			index.add(path.Join(target.Label.Package, input), target.Label)
+		}
+	}
+	return index
+}
+
+func (o *ownerIndex) add(filePath string, owner label.TargetLabel) {
+	if !slices.Contains(o.owners[filePath], owner) {
+		o.owners[filePath] = append(o.owners[filePath], owner)
+	}
+}
+
+// resolve returns the target that owns the files or nil if none of them is
+// owned by a target other than from.
+// If several targets own a file, the generated one wins; otherwise the
+// import is ambiguous.
+func (o *ownerIndex) resolve(files []string, from label.TargetLabel) (*label.TargetLabel, error) {
+	for _, filePath := range files {
+		var candidates []label.TargetLabel
+		for _, owner := range o.owners[filePath] {
+			if owner != from {
+				candidates = append(candidates, owner)
+			}
+		}
+
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return &candidates[0], nil
+		}
+
+		var generated []label.TargetLabel
+		for _, candidate := range candidates {
+			if o.generated[candidate] {
+				generated = append(generated, candidate)
+			}
+		}
+		if len(generated) == 1 {
+			return &generated[0], nil
+		}
+		return nil, fmt.Errorf("%s is owned by %d targets: %v", filePath, len(candidates), candidates)
+	}
+	return nil, nil
+}
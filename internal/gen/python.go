@@ -0,0 +1,213 @@
+package gen
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"grog/internal/label"
+)
+
+const (
+	pythonLibraryName = "py"
+	pythonTestName    = "py_test"
+)
+
+var (
+	pythonImportPattern     = regexp.MustCompile(`^\s*import\s+(.+)$`)
+	pythonFromImportPattern = regexp.MustCompile(`^\s*from\s+(\.*[\w.]*)\s+import\s+(.+)$`)
+	pythonIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][\w.]*$`)
+)
+
+// pythonProjectFiles mark the root of a Python project. Absolute imports are
+// resolved against the workspace root, project roots and their src/ directories.
+var pythonProjectFiles = []string{"pyproject.toml", "setup.py", "setup.cfg"}
+
+// pythonLanguage generates a library target with the non-test modules of a
+// directory and a pytest target for the test modules. Imports are resolved
+// to first-party modules (foo/bar.py or foo/bar/__init__.py).
+type pythonLanguage struct {
+	roots []string
+}
+
+func (l *pythonLanguage) Name() string {
+	return "python"
+}
+
+func (l *pythonLanguage) GenerateTargets(workspace *Workspace, directory string) []*Target {
+	var sources, tests []string
+	for _, fileName := range workspace.Files[directory] {
+		if !strings.HasSuffix(fileName, ".py") {
+			continue
+		}
+		if isPythonTest(fileName) {
+			tests = append(tests, fileName)
+		} else {
+			sources = append(sources, fileName)
+		}
+	}
+
+	var targets []*Target
+	if len(sources) > 0 {
+		targets = append(targets, &Target{
+			Name:    pythonLibraryName,
+			Inputs:  sources,
+			Imports: pythonImports(workspace, directory, sources),
+		})
+	}
+	if len(tests) > 0 {
+		test := &Target{
+			Name:    pythonTestName,
+			Command: "python -m pytest",
+			Inputs:  tests,
+			Imports: pythonImports(workspace, directory, tests),
+		}
+		if len(sources) > 0 {
+			test.Dependencies = []label.TargetLabel{label.TL(directory, pythonLibraryName)}
+		}
+		targets = append(targets, test)
+	}
+	return targets
+}
+
+func isPythonTest(fileName string) bool {
+	return fileName == "conftest.py" || strings.HasPrefix(fileName, "test_") || strings.HasSuffix(fileName, "_test.py")
+}
+
+func (l *pythonLanguage) ResolveImport(workspace *Workspace, directory string, importPath string) []string {
+	if strings.HasPrefix(importPath, ".") {
+		// Relative import: each leading dot beyond the first moves up a directory
+		module := strings.TrimLeft(importPath, ".")
+		base := directory
+		for range len(importPath) - len(module) - 1 {
+			base = path.Dir(base)
+			if base == "." {
+				base = ""
+			}
+		}
+		return resolvePythonModule(workspace, base, module)
+	}
+
+	if l.roots == nil {
+		l.roots = pythonRoots(workspace)
+	}
+	for _, root := range l.roots {
+		if files := resolvePythonModule(workspace, root, importPath); files != nil {
+			return files
+		}
+	}
+	return nil
+}
+
+// resolvePythonModule finds the file of a dotted module name below base.
+// `from a.b import c` is recorded as a.b.c since c may be a module or a name
+// defined in a/b.py, so shorter prefixes are tried as well.
+func resolvePythonModule(workspace *Workspace, base string, module string) []string {
+	if module == "" {
+		if candidate := path.Join(base, "__init__.py"); workspace.HasFile(candidate) {
+			return []string{candidate}
+		}
+		return nil
+	}
+
+	parts := strings.Split(module, ".")
+	for length := len(parts); length > 0; length-- {
+		modulePath := path.Join(append([]string{base}, parts[:length]...)...)
+		for _, candidate := range []string{modulePath + ".py", path.Join(modulePath, "__init__.py")} {
+			if workspace.HasFile(candidate) {
+				return []string{candidate}
+			}
+		}
+	}
+	return nil
+}
+
+func pythonRoots(workspace *Workspace) []string {
+	roots := []string{""}
+	var projectRoots []string
+	for directory, fileNames := range workspace.Files {
+		if directory == "" {
+			continue
+		}
+		for _, projectFile := range pythonProjectFiles {
+			if slices.Contains(fileNames, projectFile) {
+				projectRoots = append(projectRoots, directory)
+				break
+			}
+		}
+	}
+	slices.Sort(projectRoots)
+	for _, projectRoot := range projectRoots {
+		roots = append(roots, projectRoot)
+		if _, ok := workspace.Files[path.Join(projectRoot, "src")]; ok {
+			roots = append(roots, path.Join(projectRoot, "src"))
+		}
+	}
+	if _, ok := workspace.Files["src"]; ok {
+		roots = append(roots, "src")
+	}
+	return roots
+}
+
+// pythonImports returns the sorted module names imported by the given files.
+// Names imported with `from module import name` are recorded as module.name
+// and relative imports keep their leading dots.
+func pythonImports(workspace *Workspace, directory string, fileNames []string) []string {
+	var imports []string
+	add := func(module string) {
+		if module != "" && !slices.Contains(imports, module) {
+			imports = append(imports, module)
+		}
+	}
+
+	for _, fileName := range fileNames {
+		content, err := workspace.ReadFile(path.Join(directory, fileName))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+
+			if match := pythonFromImportPattern.FindStringSubmatch(line); match != nil {
+				module := match[1]
+				names := pythonImportedNames(match[2])
+				if len(names) == 0 {
+					add(module)
+				}
+				for _, name := range names {
+					if strings.HasSuffix(module, ".") {
+						add(module + name)
+					} else {
+						add(module + "." + name)
+					}
+				}
+				continue
+			}
+			if match := pythonImportPattern.FindStringSubmatch(line); match != nil {
+				for _, name := range pythonImportedNames(match[1]) {
+					add(name)
+				}
+			}
+		}
+	}
+	slices.Sort(imports)
+	return imports
+}
+
+// pythonImportedNames splits "a as b, c" into [a, c]. Wildcards and the
+// continuation lines of parenthesized lists are not returned.
+func pythonImportedNames(list string) []string {
+	list = strings.Trim(strings.TrimSpace(list), "()\\")
+	var names []string
+	for _, item := range strings.Split(list, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(item), " ")
+		if pythonIdentifierPattern.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
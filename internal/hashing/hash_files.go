@@ -38,7 +38,8 @@ func HashFiles(absolutePackagePath string, fileList []string) (string, error) {
 		if err != nil {
 			if os.IsNotExist(err) {
 				// NOTE: If a file does not exist in the package, we skip it.
-				// TODO make this a warning
+				// Missing literal inputs are reported during analysis
+				// according to the missing_inputs setting.
 				continue
 			}
 			return "", fmt.Errorf("failed opening input file for hashing: %w", err)
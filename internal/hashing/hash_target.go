@@ -49,6 +49,12 @@ func hashTargetDefinition(target model.Target, dependencyHashes []string, extraA
 	if _, err := hasher.WriteString(sortedKeyValue(target.Fingerprint)); err != nil {
 		return "", err
 	}
+	// Only hashed if set so that the hashes of other targets stay stable
+	if len(target.EnvironmentVariables) > 0 {
+		if _, err := hasher.WriteString(sortedKeyValue(target.EnvironmentVariables)); err != nil {
+			return "", err
+		}
+	}
 
 	// Include extra command-line arguments (e.g. from "grog test -- -k foo")
 	// so that different invocations with different flags bust the cache.
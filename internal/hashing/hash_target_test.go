@@ -32,6 +32,34 @@ func TestHashTargetDefinition_FingerprintAffectsHash(t *testing.T) {
 	}
 }
 
+func TestHashTargetDefinition_EnvironmentVariablesAffectHash(t *testing.T) {
+	target := model.Target{
+		Label:   label.TL("pkg", "target"),
+		Command: "echo $MODE",
+	}
+
+	hashWithoutEnv, err := hashTargetDefinition(target, nil, nil)
+	if err != nil {
+		t.Fatalf("hashTargetDefinition returned error: %v", err)
+	}
+
+	target.EnvironmentVariables = map[string]string{"MODE": "debug"}
+	hashWithDebug, err := hashTargetDefinition(target, nil, nil)
+	if err != nil {
+		t.Fatalf("hashTargetDefinition returned error: %v", err)
+	}
+
+	target.EnvironmentVariables["MODE"] = "release"
+	hashWithRelease, err := hashTargetDefinition(target, nil, nil)
+	if err != nil {
+		t.Fatalf("hashTargetDefinition returned error: %v", err)
+	}
+
+	if hashWithoutEnv == hashWithDebug || hashWithDebug == hashWithRelease {
+		t.Fatalf("expected hash to change with the environment variables: %s, %s, %s", hashWithoutEnv, hashWithDebug, hashWithRelease)
+	}
+}
+
 func TestHashTargetDefinition_DockerBackendAffectsHashForDockerTargets(t *testing.T) {
 	dockerTarget := model.Target{
 		Label:   label.TL("pkg", "target"),
@@ -0,0 +1,227 @@
+package hashing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"grog/internal/label"
+	"grog/internal/model"
+)
+
+// TargetChange is a target whose change hash differs between two revisions
+// of the workspace together with the reasons why.
+type TargetChange struct {
+	Label   label.TargetLabel
+	Reasons []string
+	// Direct is false if the target only changed because of its dependencies.
+	Direct bool
+}
+
+// TargetTree is the set of nodes of one revision of the workspace together
+// with the workspace root that their inputs are relative to.
+type TargetTree struct {
+	Nodes         model.BuildNodeMap
+	WorkspaceRoot string
+}
+
+// DiffTargets compares every target of current with the target of the same
+// label in base and returns the targets whose change hash would differ in
+// alphabetical order. Instead of dependency output hashes, which are only
+// known after a build, a target is considered changed if one of its target
+// dependencies changed.
+func DiffTargets(base TargetTree, current TargetTree) ([]TargetChange, error) {
+	differ := &targetDiffer{base: base, current: current, changes: make(map[label.TargetLabel]*TargetChange)}
+
+	var result []TargetChange
+	for _, node := range current.Nodes.NodesAlphabetically() {
+		target, ok := node.(*model.Target)
+		if !ok {
+			continue
+		}
+		change, err := differ.diff(target, nil)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			result = append(result, *change)
+		}
+	}
+	return result, nil
+}
+
+type targetDiffer struct {
+	base    TargetTree
+	current TargetTree
+	// changes memoizes the result for each target, nil if unchanged.
+	changes map[label.TargetLabel]*TargetChange
+}
+
+func (d *targetDiffer) diff(target *model.Target, visiting []label.TargetLabel) (*TargetChange, error) {
+	if change, done := d.changes[target.Label]; done {
+		return change, nil
+	}
+	if slices.Contains(visiting, target.Label) {
+		return nil, fmt.Errorf("cycle detected at %s", target.Label)
+	}
+	visiting = append(visiting, target.Label)
+
+	reasons, err := d.definitionReasons(target)
+	if err != nil {
+		return nil, err
+	}
+	change := &TargetChange{Label: target.Label, Reasons: reasons, Direct: len(reasons) > 0}
+
+	for _, dependencyLabel := range target.Dependencies {
+		dependency := resolveTarget(d.current.Nodes, dependencyLabel)
+		if dependency == nil {
+			continue
+		}
+		dependencyChange, err := d.diff(dependency, visiting)
+		if err != nil {
+			return nil, err
+		}
+		if dependencyChange != nil {
+			change.Reasons = append(change.Reasons, fmt.Sprintf("dependency %s changed", dependency.Label))
+		}
+	}
+
+	if len(change.Reasons) == 0 {
+		change = nil
+	}
+	d.changes[target.Label] = change
+	return change, nil
+}
+
+// definitionReasons compares the parts of the target that make up its
+// definition hash and its input files with the base revision.
+func (d *targetDiffer) definitionReasons(target *model.Target) ([]string, error) {
+	baseTarget, ok := d.base.Nodes[target.Label].(*model.Target)
+	if !ok {
+		return []string{"added"}, nil
+	}
+
+	var reasons []string
+	if baseTarget.Command != target.Command {
+		reasons = append(reasons, "command changed")
+	}
+	if sorted(slices.Clone(baseTarget.OutputDefinitions())) != sorted(slices.Clone(target.OutputDefinitions())) {
+		reasons = append(reasons, "outputs changed")
+	}
+	if sortedKeyValue(baseTarget.Fingerprint) != sortedKeyValue(target.Fingerprint) {
+		reasons = append(reasons, "fingerprint changed")
+	}
+	if sortedKeyValue(baseTarget.EnvironmentVariables) != sortedKeyValue(target.EnvironmentVariables) {
+		reasons = append(reasons, "environment_variables changed")
+	}
+	if baseTarget.IsMultiplatformCache() != target.IsMultiplatformCache() {
+		reasons = append(reasons, "multiplatform-cache tag changed")
+	}
+	if resolvedDependencies(d.base.Nodes, baseTarget) != resolvedDependencies(d.current.Nodes, target) {
+		reasons = append(reasons, "dependencies changed")
+	}
+
+	inputReasons, err := d.inputReasons(baseTarget, target)
+	if err != nil {
+		return nil, err
+	}
+	reasons = append(reasons, inputReasons...)
+
+	if len(reasons) == 0 {
+		// Catch anything else that is part of the definition hash
+		baseHash, err := hashTargetDefinition(*baseTarget, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		currentHash, err := hashTargetDefinition(*target, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if baseHash != currentHash {
+			reasons = append(reasons, "definition changed")
+		}
+	}
+	return reasons, nil
+}
+
+// inputReasons lists the input files that were added, removed or modified.
+func (d *targetDiffer) inputReasons(baseTarget *model.Target, target *model.Target) ([]string, error) {
+	baseHashes, err := hashInputs(d.base.WorkspaceRoot, baseTarget)
+	if err != nil {
+		return nil, err
+	}
+	currentHashes, err := hashInputs(d.current.WorkspaceRoot, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var reasons []string
+	for _, input := range sortedInputs(target) {
+		baseHash, existed := baseHashes[input]
+		switch {
+		case !existed:
+			reasons = append(reasons, fmt.Sprintf("input %s added", input))
+		case baseHash != currentHashes[input]:
+			reasons = append(reasons, fmt.Sprintf("input %s modified", input))
+		}
+	}
+	for _, input := range sortedInputs(baseTarget) {
+		if _, exists := currentHashes[input]; !exists {
+			reasons = append(reasons, fmt.Sprintf("input %s removed", input))
+		}
+	}
+	return reasons, nil
+}
+
+// hashInputs hashes each input file of target. Inputs that do not exist
+// hash to an empty string like they are skipped by HashFiles.
+func hashInputs(workspaceRoot string, target *model.Target) (map[string]string, error) {
+	hashes := make(map[string]string, len(target.Inputs))
+	for _, input := range target.Inputs {
+		hash, err := HashFile(filepath.Join(workspaceRoot, target.Label.Package, input))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed hashing input %s of target %s: %w", input, target.Label, err)
+		}
+		hashes[input] = hash
+	}
+	return hashes, nil
+}
+
+func sortedInputs(target *model.Target) []string {
+	inputs := slices.Clone(target.Inputs)
+	slices.Sort(inputs)
+	return inputs
+}
+
+// resolvedDependencies returns the sorted target dependencies of target
+// with aliases resolved, so that retargeting an alias counts as a change.
+func resolvedDependencies(nodes model.BuildNodeMap, target *model.Target) string {
+	var dependencies []string
+	for _, dependencyLabel := range target.Dependencies {
+		if dependency := resolveTarget(nodes, dependencyLabel); dependency != nil {
+			dependencies = append(dependencies, dependency.Label.String())
+		}
+	}
+	slices.Sort(dependencies)
+	return strings.Join(dependencies, ",")
+}
+
+// resolveTarget follows aliases until it reaches a target. Returns nil for
+// resources and unknown labels.
+func resolveTarget(nodes model.BuildNodeMap, nodeLabel label.TargetLabel) *model.Target {
+	visited := make(map[label.TargetLabel]bool)
+	for !visited[nodeLabel] {
+		visited[nodeLabel] = true
+		switch node := nodes[nodeLabel].(type) {
+		case *model.Target:
+			return node
+		case *model.Alias:
+			nodeLabel = node.Actual
+		default:
+			return nil
+		}
+	}
+	return nil
+}
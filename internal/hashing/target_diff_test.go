@@ -0,0 +1,93 @@
+package hashing
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"grog/internal/label"
+	"grog/internal/model"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return root
+}
+
+func nodeMap(nodes ...model.BuildNode) model.BuildNodeMap {
+	result := make(model.BuildNodeMap)
+	for _, node := range nodes {
+		result[node.GetLabel()] = node
+	}
+	return result
+}
+
+func TestDiffTargets(t *testing.T) {
+	baseRoot := writeTree(t, map[string]string{
+		"lib/lib.go":    "package lib",
+		"lib/util.go":   "package lib",
+		"app/main.go":   "package main",
+		"docs/index.md": "# docs",
+	})
+	currentRoot := writeTree(t, map[string]string{
+		"lib/lib.go":    "package lib // changed",
+		"lib/extra.go":  "package lib",
+		"app/main.go":   "package main",
+		"docs/index.md": "# docs",
+	})
+
+	base := nodeMap(
+		&model.Target{Label: label.TL("lib", "lib"), Command: "go build", Inputs: []string{"lib.go", "util.go"}},
+		&model.Target{Label: label.TL("app", "app"), Command: "go build", Inputs: []string{"main.go"}, Dependencies: []label.TargetLabel{label.TL("lib", "lib")}},
+		&model.Target{Label: label.TL("app", "image"), Command: "docker build", Dependencies: []label.TargetLabel{label.TL("app", "app_alias")}},
+		&model.Alias{Label: label.TL("app", "app_alias"), Actual: label.TL("app", "app")},
+		&model.Target{Label: label.TL("docs", "docs"), Command: "mkdocs", Inputs: []string{"index.md"}},
+		&model.Target{Label: label.TL("docs", "publish"), Command: "publish", Dependencies: []label.TargetLabel{label.TL("docs", "docs")}},
+		&model.Target{Label: label.TL("docs", "lint"), Command: "lint", Inputs: []string{"index.md"}},
+		&model.Target{Label: label.TL("docs", "check"), Command: "check", EnvironmentVariables: map[string]string{"STRICT": "0"}},
+	)
+	current := nodeMap(
+		&model.Target{Label: label.TL("lib", "lib"), Command: "go build", Inputs: []string{"extra.go", "lib.go"}},
+		&model.Target{Label: label.TL("app", "app"), Command: "go build", Inputs: []string{"main.go"}, Dependencies: []label.TargetLabel{label.TL("lib", "lib")}},
+		&model.Target{Label: label.TL("app", "image"), Command: "docker build", Dependencies: []label.TargetLabel{label.TL("app", "app_alias")}},
+		&model.Alias{Label: label.TL("app", "app_alias"), Actual: label.TL("app", "app")},
+		&model.Target{Label: label.TL("docs", "docs"), Command: "mkdocs build", Inputs: []string{"index.md"}},
+		// Retargets the dependency without changing the inputs
+		&model.Target{Label: label.TL("docs", "publish"), Command: "publish", Dependencies: []label.TargetLabel{label.TL("docs", "lint")}},
+		&model.Target{Label: label.TL("docs", "lint"), Command: "lint", Inputs: []string{"index.md"}},
+		&model.Target{Label: label.TL("docs", "serve"), Command: "serve"},
+		&model.Target{Label: label.TL("docs", "check"), Command: "check", EnvironmentVariables: map[string]string{"STRICT": "1"}},
+	)
+
+	changes, err := DiffTargets(
+		TargetTree{Nodes: base, WorkspaceRoot: baseRoot},
+		TargetTree{Nodes: current, WorkspaceRoot: currentRoot},
+	)
+	if err != nil {
+		t.Fatalf("DiffTargets returned unexpected error: %v", err)
+	}
+
+	expected := []TargetChange{
+		{Label: label.TL("app", "app"), Reasons: []string{"dependency //lib:lib changed"}},
+		{Label: label.TL("app", "image"), Reasons: []string{"dependency //app:app changed"}},
+		{Label: label.TL("docs", "check"), Reasons: []string{"environment_variables changed"}, Direct: true},
+		{Label: label.TL("docs", "docs"), Reasons: []string{"command changed"}, Direct: true},
+		{Label: label.TL("docs", "publish"), Reasons: []string{"dependencies changed"}, Direct: true},
+		{Label: label.TL("docs", "serve"), Reasons: []string{"added"}, Direct: true},
+		{Label: label.TL("lib", "lib"), Reasons: []string{"input extra.go added", "input lib.go modified", "input util.go removed"}, Direct: true},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("unexpected changes:\n got: %+v\nwant: %+v", changes, expected)
+	}
+}
@@ -0,0 +1,245 @@
+// Package importer translates the task configuration of JavaScript monorepo
+// tools (turbo, nx) into grog targets.
+package importer
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"grog/internal/label"
+	"grog/internal/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tools maps the tool names accepted by grog import to their importers.
+// Each importer reads the configuration below the workspace root.
+var Tools = map[string]func(workspaceRoot string) (*Result, error){
+	"turbo": ImportTurbo,
+	"nx":    ImportNx,
+}
+
+// Result holds the translated targets by package and everything that could
+// not be translated.
+type Result struct {
+	// Packages maps package paths ("" for the workspace root) to their targets.
+	Packages map[string][]*Target
+	Issues   []Issue
+}
+
+// Target is a translated target.
+type Target struct {
+	Name          string
+	Command       string
+	Dependencies  []label.TargetLabel
+	Inputs        []string
+	ExcludeInputs []string
+	Outputs       []string
+	Tags          []string
+}
+
+// Issue describes a construct that was not (fully) translated.
+type Issue struct {
+	// Package is the package path of the affected target ("" for the
+	// workspace root or for workspace wide configuration).
+	Package string
+	// Task is the name of the affected task, empty for package or
+	// workspace wide configuration.
+	Task    string
+	Message string
+}
+
+func (i Issue) String() string {
+	location := "//" + i.Package
+	if i.Task != "" {
+		location += ":" + i.Task
+	}
+	return location + ": " + i.Message
+}
+
+func newResult() *Result {
+	return &Result{Packages: make(map[string][]*Target)}
+}
+
+func (r *Result) report(packagePath string, task string, format string, args ...any) {
+	r.Issues = append(r.Issues, Issue{Package: packagePath, Task: task, Message: fmt.Sprintf(format, args...)})
+}
+
+// sortIssues orders the issues by package and task and removes duplicates.
+func (r *Result) sortIssues() {
+	sort.SliceStable(r.Issues, func(i, j int) bool {
+		if r.Issues[i].Package != r.Issues[j].Package {
+			return r.Issues[i].Package < r.Issues[j].Package
+		}
+		return r.Issues[i].Task < r.Issues[j].Task
+	})
+	r.Issues = slices.Compact(r.Issues)
+}
+
+// Definition renders a target as a BUILD.yaml target definition in the given package.
+func Definition(packagePath string, target *Target) *yaml.Node {
+	mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	addScalar := func(key string, value string) {
+		if value == "" {
+			return
+		}
+		mapping.Content = append(mapping.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+		)
+	}
+	addList := func(key string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, value := range values {
+			list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+		}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, list)
+	}
+
+	var dependencies []string
+	for _, dependency := range target.Dependencies {
+		if dependency.Package == packagePath {
+			dependencies = append(dependencies, ":"+dependency.Name)
+		} else {
+			dependencies = append(dependencies, dependency.String())
+		}
+	}
+	slices.Sort(dependencies)
+
+	addScalar("name", target.Name)
+	addScalar("command", target.Command)
+	addList("dependencies", slices.Compact(dependencies))
+	addList("inputs", target.Inputs)
+	addList("exclude_inputs", target.ExcludeInputs)
+	addList("outputs", target.Outputs)
+	addList("tags", target.Tags)
+	return mapping
+}
+
+// targetName turns a script or task name such as "test:unit" into a valid
+// target name.
+func targetName(task string) string {
+	var builder strings.Builder
+	for _, c := range task {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '-', c == '.':
+			builder.WriteRune(c)
+		default:
+			builder.WriteRune('_')
+		}
+	}
+	return builder.String()
+}
+
+// defaultInputs are used for tasks that do not declare inputs: both turbo and
+// nx hash all files of the package by default.
+var defaultInputs = []string{"**/*"}
+
+// alwaysExcludedInputs are never useful as inputs of a JavaScript package.
+var alwaysExcludedInputs = []string{"node_modules/**", ".turbo/**", ".nx/**"}
+
+// excludeOutputs excludes the outputs of all targets of a package from the
+// broad input globs of its targets. turbo and nx only hash files tracked by git,
+// which does not include build outputs.
+func (r *Result) excludeOutputs() {
+	for _, targets := range r.Packages {
+		for _, target := range targets {
+			if !slices.Contains(target.Inputs, "**/*") {
+				continue
+			}
+			excludedInputs := slices.Clone(alwaysExcludedInputs)
+			for _, other := range append([]*Target{target}, targets...) {
+				for _, output := range other.Outputs {
+					outputType, outputPath, _ := strings.Cut(output, "::")
+					if outputType == "dir" {
+						outputPath += "/**"
+					}
+					excludedInputs = append(excludedInputs, outputPath)
+				}
+			}
+			for _, excluded := range excludedInputs {
+				if !slices.Contains(target.ExcludeInputs, excluded) {
+					target.ExcludeInputs = append(target.ExcludeInputs, excluded)
+				}
+			}
+		}
+	}
+}
+
+// excludeNestedPackages excludes the directories of packages nested in the
+// package of the target (all packages for the workspace root) from its inputs.
+func excludeNestedPackages(target *Target, packagePath string, packagePaths []string) {
+	if !slices.Contains(target.Inputs, "**/*") {
+		return
+	}
+	for _, other := range packagePaths {
+		if other == packagePath {
+			continue
+		}
+		relativePath, ok := other, packagePath == ""
+		if !ok {
+			relativePath, ok = strings.CutPrefix(other, packagePath+"/")
+		}
+		if ok && !slices.Contains(target.ExcludeInputs, relativePath+"/**") {
+			target.ExcludeInputs = append(target.ExcludeInputs, relativePath+"/**")
+		}
+	}
+}
+
+// translateOutput converts a package relative output glob into a grog output.
+// Globs ending in /** become directory outputs. Literal paths are directory
+// outputs if literalIsDirectory returns true and file outputs otherwise.
+// Returns false for globs that cannot be expressed as a grog output.
+func translateOutput(pattern string, literalIsDirectory func(string) bool) (string, bool) {
+	pattern = strings.TrimPrefix(pattern, "./")
+	for _, suffix := range []string{"/**/*", "/**", "/*"} {
+		if trimmed, ok := strings.CutSuffix(pattern, suffix); ok && !isGlob(trimmed) && trimmed != "" {
+			return "dir::" + path.Clean(trimmed), true
+		}
+	}
+	if isGlob(pattern) || pattern == "" || isOutsidePackage(pattern) {
+		return "", false
+	}
+	if literalIsDirectory(pattern) {
+		return "dir::" + path.Clean(pattern), true
+	}
+	return "file::" + path.Clean(pattern), true
+}
+
+// translateInput converts a package relative input glob. Negated globs are
+// returned as exclusions.
+func translateInput(target *Target, pattern string) bool {
+	excluded := strings.HasPrefix(pattern, "!")
+	pattern = strings.TrimPrefix(strings.TrimPrefix(pattern, "!"), "./")
+	if pattern == "" || isOutsidePackage(pattern) {
+		return false
+	}
+	if excluded {
+		target.ExcludeInputs = append(target.ExcludeInputs, pattern)
+	} else if !slices.Contains(target.Inputs, pattern) {
+		target.Inputs = append(target.Inputs, pattern)
+	}
+	return true
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[{")
+}
+
+func isOutsidePackage(pattern string) bool {
+	cleaned := path.Clean(pattern)
+	return path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../")
+}
+
+// noCache marks a target as not cacheable.
+func noCache(target *Target) {
+	if !slices.Contains(target.Tags, model.TagNoCache) {
+		target.Tags = append(target.Tags, model.TagNoCache)
+	}
+}
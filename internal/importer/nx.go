@@ -0,0 +1,754 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"grog/internal/label"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+const (
+	nxProjectRoot   = "{projectRoot}"
+	nxWorkspaceRoot = "{workspaceRoot}"
+)
+
+// nxIgnoredTargetKeys only affect how nx schedules or presents a target.
+var nxIgnoredTargetKeys = []string{"metadata", "parallelism", "syncGenerators"}
+
+// nxRunCommandsOptions are the nx:run-commands options that are translated.
+var nxRunCommandsOptions = []string{"command", "commands", "cwd", "parallel"}
+
+// nxTarget is the configuration of a target in project.json, package.json
+// or the targetDefaults of nx.json. Nil fields are not set.
+type nxTarget struct {
+	Executor   string
+	Command    string
+	Options    map[string]any
+	DependsOn  []json.RawMessage
+	Inputs     []json.RawMessage
+	Outputs    []string
+	Cache      *bool
+	Continuous *bool
+	// untranslated lists the keys that have no grog equivalent.
+	untranslated []string
+}
+
+func parseNxTarget(raw json.RawMessage) (nxTarget, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nxTarget{}, err
+	}
+
+	var target nxTarget
+	for key, value := range fields {
+		var err error
+		switch key {
+		case "executor":
+			err = json.Unmarshal(value, &target.Executor)
+		case "command":
+			err = json.Unmarshal(value, &target.Command)
+		case "options":
+			err = json.Unmarshal(value, &target.Options)
+		case "dependsOn":
+			err = json.Unmarshal(value, &target.DependsOn)
+		case "inputs":
+			err = json.Unmarshal(value, &target.Inputs)
+		case "outputs":
+			err = json.Unmarshal(value, &target.Outputs)
+		case "cache":
+			err = json.Unmarshal(value, &target.Cache)
+		case "continuous":
+			err = json.Unmarshal(value, &target.Continuous)
+		default:
+			if !slices.Contains(nxIgnoredTargetKeys, key) {
+				target.untranslated = append(target.untranslated, key)
+			}
+		}
+		if err != nil {
+			return nxTarget{}, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	slices.Sort(target.untranslated)
+	return target, nil
+}
+
+// override returns the target with the fields set in other replacing its
+// own. Options are merged key by key.
+func (t nxTarget) override(other nxTarget) nxTarget {
+	result := t
+	if other.Executor != "" || other.Command != "" {
+		result.Executor = other.Executor
+		result.Command = other.Command
+	}
+	if other.Options != nil {
+		options := make(map[string]any)
+		for key, value := range t.Options {
+			options[key] = value
+		}
+		for key, value := range other.Options {
+			options[key] = value
+		}
+		result.Options = options
+	}
+	if other.DependsOn != nil {
+		result.DependsOn = other.DependsOn
+	}
+	if other.Inputs != nil {
+		result.Inputs = other.Inputs
+	}
+	if other.Outputs != nil {
+		result.Outputs = other.Outputs
+	}
+	if other.Cache != nil {
+		result.Cache = other.Cache
+	}
+	if other.Continuous != nil {
+		result.Continuous = other.Continuous
+	}
+	result.untranslated = slices.Concat(t.untranslated, other.untranslated)
+	return result
+}
+
+// nxProjectConfig is the content of project.json or the nx key of package.json.
+type nxProjectConfig struct {
+	Name                 string                       `json:"name"`
+	Targets              map[string]json.RawMessage   `json:"targets"`
+	ImplicitDependencies []string                     `json:"implicitDependencies"`
+	NamedInputs          map[string][]json.RawMessage `json:"namedInputs"`
+	IncludedScripts      *[]string                    `json:"includedScripts"`
+}
+
+type nxProject struct {
+	name string
+	// root is the project directory relative to the workspace root.
+	root         string
+	packageName  string
+	targets      map[string]nxTarget
+	dependencies []string
+	namedInputs  map[string][]json.RawMessage
+}
+
+type nxImport struct {
+	workspace     *jsWorkspace
+	projects      []*nxProject
+	byName        map[string]*nxProject
+	byPackageName map[string]*nxProject
+	// namedInputs are the workspace wide named inputs of nx.json.
+	namedInputs         map[string][]json.RawMessage
+	targetDefaults      map[string]nxTarget
+	cacheableOperations []string
+	result              *Result
+}
+
+// ImportNx translates the targets of the project.json files (and the
+// package.json scripts nx infers targets from) of an nx workspace.
+func ImportNx(workspaceRoot string) (*Result, error) {
+	workspace, err := loadJsWorkspace(workspaceRoot)
+	if errors.Is(err, os.ErrNotExist) {
+		workspace = &jsWorkspace{root: workspaceRoot, byName: make(map[string]*jsPackage), runScript: detectRunScript(workspaceRoot, "")}
+	} else if err != nil {
+		return nil, err
+	}
+
+	importer := &nxImport{
+		workspace:      workspace,
+		byName:         make(map[string]*nxProject),
+		byPackageName:  make(map[string]*nxProject),
+		targetDefaults: make(map[string]nxTarget),
+		result:         newResult(),
+	}
+	if err := importer.readNxJson(); err != nil {
+		return nil, err
+	}
+	if err := importer.loadProjects(); err != nil {
+		return nil, err
+	}
+
+	for _, project := range importer.projects {
+		for _, name := range sortedKeys(project.targets) {
+			if target := importer.translate(project, name); target != nil {
+				importer.result.Packages[project.root] = append(importer.result.Packages[project.root], target)
+			}
+		}
+	}
+
+	importer.result.excludeOutputs()
+	importer.result.sortIssues()
+	return importer.result, nil
+}
+
+func (n *nxImport) readNxJson() error {
+	var fields map[string]json.RawMessage
+	err := readJson(filepath.Join(n.workspace.root, "nx.json"), &fields)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(fields) {
+		value := fields[key]
+		switch key {
+		case "namedInputs":
+			if err := json.Unmarshal(value, &n.namedInputs); err != nil {
+				return fmt.Errorf("nx.json: namedInputs: %w", err)
+			}
+		case "targetDefaults":
+			var defaults map[string]json.RawMessage
+			if err := json.Unmarshal(value, &defaults); err != nil {
+				return fmt.Errorf("nx.json: targetDefaults: %w", err)
+			}
+			for name, rawDefault := range defaults {
+				target, err := parseNxTarget(rawDefault)
+				if err != nil {
+					return fmt.Errorf("nx.json: targetDefaults %s: %w", name, err)
+				}
+				n.targetDefaults[name] = target
+			}
+		case "tasksRunnerOptions":
+			var runners map[string]struct {
+				Options struct {
+					CacheableOperations []string `json:"cacheableOperations"`
+				} `json:"options"`
+			}
+			if err := json.Unmarshal(value, &runners); err == nil {
+				n.cacheableOperations = runners["default"].Options.CacheableOperations
+			}
+		case "plugins":
+			n.result.report("", "", "targets inferred by nx plugins are not translated")
+		case "release":
+			n.result.report("", "", "nx.json key %q is not translated", key)
+		}
+	}
+	return nil
+}
+
+// loadProjects finds all project.json files and the packages of the
+// JavaScript workspace, which nx treats as projects as well.
+func (n *nxImport) loadProjects() error {
+	projectRoots := make(map[string]bool)
+	for _, jsPackage := range n.workspace.packages {
+		projectRoots[jsPackage.Directory] = true
+	}
+
+	err := fs.WalkDir(os.DirFS(n.workspace.root), ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && filePath != "." && (entry.Name() == "node_modules" || strings.HasPrefix(entry.Name(), ".")) {
+			return fs.SkipDir
+		}
+		if !entry.IsDir() && entry.Name() == "project.json" {
+			directory := path.Dir(filePath)
+			if directory == "." {
+				directory = ""
+			}
+			projectRoots[directory] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, root := range sortedKeys(projectRoots) {
+		project, err := n.loadProject(root)
+		if err != nil {
+			return err
+		}
+		if existing := n.byName[project.name]; existing != nil {
+			return fmt.Errorf("nx project %q is defined in both %q and %q", project.name, existing.root, project.root)
+		}
+		n.projects = append(n.projects, project)
+		n.byName[project.name] = project
+		if project.packageName != "" {
+			n.byPackageName[project.packageName] = project
+		}
+	}
+	return nil
+}
+
+func (n *nxImport) loadProject(root string) (*nxProject, error) {
+	project := &nxProject{root: root, targets: make(map[string]nxTarget), namedInputs: make(map[string][]json.RawMessage)}
+	directory := filepath.Join(n.workspace.root, filepath.FromSlash(root))
+
+	var configs []nxProjectConfig
+	var manifest packageJson
+	err := readJson(filepath.Join(directory, "package.json"), &manifest)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	hasManifest := err == nil
+	if hasManifest {
+		project.packageName = manifest.Name
+		var inline nxProjectConfig
+		if len(manifest.Nx) > 0 {
+			if err := json.Unmarshal(manifest.Nx, &inline); err != nil {
+				return nil, fmt.Errorf("%s/package.json: nx: %w", root, err)
+			}
+		}
+
+		// nx infers a target for each script
+		for _, script := range sortedKeys(manifest.Scripts) {
+			if inline.IncludedScripts != nil && !slices.Contains(*inline.IncludedScripts, script) {
+				continue
+			}
+			project.targets[script] = nxTarget{Executor: "nx:run-script", Options: map[string]any{"script": script}}
+		}
+		configs = append(configs, inline)
+	}
+
+	var projectJson nxProjectConfig
+	err = readJson(filepath.Join(directory, "project.json"), &projectJson)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		configs = append(configs, projectJson)
+	}
+
+	for _, config := range configs {
+		if config.Name != "" {
+			project.name = config.Name
+		}
+		for _, name := range sortedKeys(config.Targets) {
+			target, err := parseNxTarget(config.Targets[name])
+			if err != nil {
+				return nil, fmt.Errorf("project %s: target %s: %w", root, name, err)
+			}
+			project.targets[name] = project.targets[name].override(target)
+		}
+		project.dependencies = append(project.dependencies, config.ImplicitDependencies...)
+		for name, inputs := range config.NamedInputs {
+			project.namedInputs[name] = inputs
+		}
+	}
+
+	if project.name == "" {
+		project.name = project.packageName
+	}
+	if project.name == "" {
+		project.name = path.Base(root)
+	}
+
+	for name, target := range project.targets {
+		defaults, ok := n.targetDefaults[name]
+		if !ok && target.Executor != "" {
+			defaults, ok = n.targetDefaults[target.Executor]
+		}
+		if ok {
+			project.targets[name] = defaults.override(target)
+		}
+	}
+
+	if hasManifest {
+		project.dependencies = append(project.dependencies, (&jsPackage{Manifest: manifest}).dependencyNames()...)
+	}
+	return project, nil
+}
+
+// dependencyProjects returns the projects a project depends on through
+// package.json dependencies and implicitDependencies ("!name" removes one).
+func (n *nxImport) dependencyProjects(project *nxProject) []*nxProject {
+	var result []*nxProject
+	for _, name := range project.dependencies {
+		if strings.HasPrefix(name, "!") {
+			continue
+		}
+		dependency := n.byName[name]
+		if dependency == nil {
+			dependency = n.byPackageName[name]
+		}
+		if dependency == nil || dependency == project || slices.Contains(result, dependency) {
+			continue
+		}
+		if slices.Contains(project.dependencies, "!"+dependency.name) {
+			continue
+		}
+		result = append(result, dependency)
+	}
+	return result
+}
+
+// hasTarget reports whether a target is generated for the project target.
+func (n *nxImport) hasTarget(project *nxProject, name string) bool {
+	target, ok := project.targets[name]
+	return ok && !isTrue(target.Continuous)
+}
+
+func (n *nxImport) translate(project *nxProject, name string) *Target {
+	config := project.targets[name]
+	grogName := targetName(name)
+	report := func(format string, args ...any) {
+		n.result.report(project.root, grogName, format, args...)
+	}
+
+	for _, key := range config.untranslated {
+		report("target option %q is not translated", key)
+	}
+	if isTrue(config.Continuous) {
+		report("continuous targets (dev servers, watchers) are not translated")
+		return nil
+	}
+
+	target := &Target{Name: grogName, Command: n.command(project, name, config, report)}
+
+	for _, rawDependency := range config.DependsOn {
+		n.translateDependency(project, rawDependency, target, report)
+	}
+
+	inputs := config.Inputs
+	if inputs == nil {
+		inputs = []json.RawMessage{json.RawMessage(`"default"`)}
+	}
+	n.translateInputs(project, inputs, target, report, make(map[string]bool))
+	if len(target.Inputs) == 0 && len(target.ExcludeInputs) > 0 {
+		target.Inputs = defaultInputs
+	}
+
+	for _, output := range config.Outputs {
+		translated, ok := n.translateOutput(project, config, output)
+		if !ok {
+			report("output %q is not translated", output)
+			continue
+		}
+		target.Outputs = append(target.Outputs, translated)
+	}
+	var projectRoots []string
+	for _, other := range n.projects {
+		projectRoots = append(projectRoots, other.root)
+	}
+	excludeNestedPackages(target, project.root, projectRoots)
+
+	cached := slices.Contains(n.cacheableOperations, name)
+	if config.Cache != nil {
+		cached = *config.Cache
+	}
+	if !cached {
+		noCache(target)
+	}
+	return target
+}
+
+// command translates the executor of a target into a shell command.
+func (n *nxImport) command(project *nxProject, name string, config nxTarget, report func(string, ...any)) string {
+	executor := config.Executor
+	if executor == "" && config.Command != "" {
+		executor = "nx:run-commands"
+	}
+
+	switch executor {
+	case "nx:noop":
+		return ""
+	case "nx:run-script":
+		script, _ := config.Options["script"].(string)
+		if script == "" {
+			script = name
+		}
+		return n.workspace.runScript + " " + script
+	case "nx:run-commands":
+		var commands []string
+		if config.Command != "" {
+			commands = append(commands, config.Command)
+		}
+		if command, ok := config.Options["command"].(string); ok {
+			commands = append(commands, command)
+		}
+		if list, ok := config.Options["commands"].([]any); ok {
+			for _, item := range list {
+				switch typed := item.(type) {
+				case string:
+					commands = append(commands, typed)
+				case map[string]any:
+					if command, ok := typed["command"].(string); ok {
+						commands = append(commands, command)
+					}
+				}
+			}
+		}
+		for _, key := range sortedKeys(config.Options) {
+			if !slices.Contains(nxRunCommandsOptions, key) {
+				report("run-commands option %q is not translated", key)
+			}
+		}
+		if parallel, ok := config.Options["parallel"].(bool); len(commands) > 1 && (!ok || parallel) {
+			report("parallel commands are run sequentially")
+		}
+
+		// run-commands runs in the workspace root unless cwd is set
+		directory, _ := config.Options["cwd"].(string)
+		directory = path.Clean(strings.TrimPrefix(strings.ReplaceAll(directory, nxWorkspaceRoot, ""), "/"))
+		if directory == "." {
+			directory = ""
+		}
+		command := strings.Join(commands, " && ")
+		if directory == project.root {
+			return command
+		}
+		if directory == "" {
+			return `cd "$GROG_WORKSPACE_ROOT" && ` + command
+		}
+		return `cd "$GROG_WORKSPACE_ROOT/` + directory + `" && ` + command
+	}
+
+	report("executor %q is run through nx", executor)
+	return fmt.Sprintf("npx nx run %s:%s", project.name, name)
+}
+
+func (n *nxImport) translateDependency(project *nxProject, rawDependency json.RawMessage, target *Target, report func(string, ...any)) {
+	var dependency struct {
+		Target       string `json:"target"`
+		Projects     any    `json:"projects"`
+		Dependencies bool   `json:"dependencies"`
+	}
+
+	var spelled string
+	if err := json.Unmarshal(rawDependency, &spelled); err == nil {
+		switch {
+		case strings.HasPrefix(spelled, "^"):
+			dependency.Target = strings.TrimPrefix(spelled, "^")
+			dependency.Dependencies = true
+		case strings.Contains(spelled, ":"):
+			projectName, targetName, _ := strings.Cut(spelled, ":")
+			dependency.Target = targetName
+			dependency.Projects = projectName
+		default:
+			dependency.Target = spelled
+		}
+	} else if err := json.Unmarshal(rawDependency, &dependency); err != nil {
+		report("dependsOn entry %s is not translated", string(rawDependency))
+		return
+	}
+
+	var projects []*nxProject
+	switch value := dependency.Projects.(type) {
+	case nil:
+		if !dependency.Dependencies {
+			projects = []*nxProject{project}
+		}
+	case string:
+		projects = n.matchProjects(project, []string{value})
+	case []any:
+		var patterns []string
+		for _, item := range value {
+			if pattern, ok := item.(string); ok {
+				patterns = append(patterns, pattern)
+			}
+		}
+		projects = n.matchProjects(project, patterns)
+	}
+
+	var labels []label.TargetLabel
+	if dependency.Dependencies {
+		visited := make(map[string]bool)
+		for _, upstream := range n.dependencyProjects(project) {
+			labels = append(labels, n.upstreamTargets(upstream, dependency.Target, visited)...)
+		}
+	}
+	for _, other := range projects {
+		for _, name := range n.matchTargets(other, dependency.Target) {
+			if other == project && targetName(name) == target.Name {
+				continue
+			}
+			labels = append(labels, label.TL(other.root, targetName(name)))
+		}
+	}
+
+	if len(labels) == 0 && !dependency.Dependencies {
+		report("dependsOn entry %s does not match a translated target", string(rawDependency))
+	}
+	target.Dependencies = append(target.Dependencies, labels...)
+}
+
+// matchProjects resolves the project names or glob patterns of a dependsOn entry.
+func (n *nxImport) matchProjects(project *nxProject, patterns []string) []*nxProject {
+	var result []*nxProject
+	for _, pattern := range patterns {
+		switch pattern {
+		case "self", "{self}":
+			result = append(result, project)
+			continue
+		case "dependencies", "{dependencies}":
+			result = append(result, n.dependencyProjects(project)...)
+			continue
+		}
+		for _, candidate := range n.projects {
+			if matched, _ := doublestar.Match(pattern, candidate.name); matched {
+				result = append(result, candidate)
+			}
+		}
+	}
+	return result
+}
+
+// matchTargets returns the names of the targets of a project that match a
+// target name which may contain wildcards.
+func (n *nxImport) matchTargets(project *nxProject, pattern string) []string {
+	var names []string
+	for _, name := range sortedKeys(project.targets) {
+		if matched, _ := doublestar.Match(pattern, name); matched && n.hasTarget(project, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// upstreamTargets returns the targets of a dependency project. Projects
+// without the target are skipped over to their own dependencies.
+func (n *nxImport) upstreamTargets(project *nxProject, pattern string, visited map[string]bool) []label.TargetLabel {
+	if visited[project.name] {
+		return nil
+	}
+	visited[project.name] = true
+
+	if names := n.matchTargets(project, pattern); len(names) > 0 {
+		var labels []label.TargetLabel
+		for _, name := range names {
+			labels = append(labels, label.TL(project.root, targetName(name)))
+		}
+		return labels
+	}
+	var labels []label.TargetLabel
+	for _, upstream := range n.dependencyProjects(project) {
+		labels = append(labels, n.upstreamTargets(upstream, pattern, visited)...)
+	}
+	return labels
+}
+
+// translateInputs expands named inputs and converts file sets to package
+// relative inputs. Inputs of dependencies ("^production") are covered by the
+// dependencies of the target and skipped.
+func (n *nxImport) translateInputs(
+	project *nxProject,
+	inputs []json.RawMessage,
+	target *Target,
+	report func(string, ...any),
+	expanded map[string]bool,
+) {
+	for _, rawInput := range inputs {
+		var input string
+		if err := json.Unmarshal(rawInput, &input); err != nil {
+			var object map[string]any
+			if err := json.Unmarshal(rawInput, &object); err != nil {
+				report("input %s is not translated", string(rawInput))
+				continue
+			}
+			if fileset, ok := object["fileset"].(string); ok {
+				input = fileset
+			} else if name, ok := object["input"].(string); ok {
+				if dependencies, _ := object["dependencies"].(bool); dependencies {
+					continue
+				}
+				input = name
+			} else {
+				report("input %s is not translated", string(rawInput))
+				continue
+			}
+		}
+
+		if strings.HasPrefix(input, "^") {
+			continue
+		}
+		if !strings.Contains(input, "{") && !strings.Contains(input, "/") && !isGlob(input) {
+			n.expandNamedInput(project, input, target, report, expanded)
+			continue
+		}
+
+		negated := strings.HasPrefix(input, "!")
+		relativePath, ok := n.projectRelative(project, strings.TrimPrefix(input, "!"))
+		if negated {
+			relativePath = "!" + relativePath
+		}
+		if !ok || !translateInput(target, relativePath) {
+			report("input %q is not translated", input)
+		}
+	}
+}
+
+func (n *nxImport) expandNamedInput(
+	project *nxProject,
+	name string,
+	target *Target,
+	report func(string, ...any),
+	expanded map[string]bool,
+) {
+	if expanded[name] {
+		return
+	}
+	expanded[name] = true
+
+	inputs, ok := project.namedInputs[name]
+	if !ok {
+		inputs, ok = n.namedInputs[name]
+	}
+	if !ok {
+		if name == "default" {
+			// Implicit default of nx: all files of the project
+			target.Inputs = append(target.Inputs, defaultInputs...)
+			return
+		}
+		report("named input %q is not defined", name)
+		return
+	}
+	n.translateInputs(project, inputs, target, report, expanded)
+}
+
+// projectRelative converts a path with {projectRoot} or {workspaceRoot}
+// placeholders to a path relative to the project root.
+func (n *nxImport) projectRelative(project *nxProject, value string) (string, bool) {
+	workspacePath := value
+	if rest, ok := strings.CutPrefix(value, nxProjectRoot); ok {
+		workspacePath = path.Join(project.root, strings.TrimPrefix(rest, "/"))
+	} else if rest, ok := strings.CutPrefix(value, nxWorkspaceRoot); ok {
+		workspacePath = strings.TrimPrefix(rest, "/")
+	}
+	if strings.Contains(workspacePath, "{") {
+		return "", false
+	}
+
+	if project.root == "" {
+		return workspacePath, workspacePath != "" && !isOutsidePackage(workspacePath)
+	}
+	relativePath, ok := strings.CutPrefix(workspacePath, project.root+"/")
+	if !ok {
+		return "", false
+	}
+	return relativePath, true
+}
+
+// translateOutput interpolates {projectName}, {projectRoot} and
+// {options.*} placeholders. Literal paths without an extension are treated
+// as directories since nx outputs usually name the output folder.
+func (n *nxImport) translateOutput(project *nxProject, config nxTarget, output string) (string, bool) {
+	if strings.HasPrefix(output, "!") {
+		return "", false
+	}
+	output = strings.ReplaceAll(output, "{projectName}", project.name)
+	for key, value := range config.Options {
+		if text, ok := value.(string); ok {
+			output = strings.ReplaceAll(output, "{options."+key+"}", text)
+		}
+	}
+	if !strings.HasPrefix(output, nxProjectRoot) && !strings.HasPrefix(output, nxWorkspaceRoot) {
+		// Interpolated options are relative to the workspace root
+		output = nxWorkspaceRoot + "/" + output
+	}
+
+	relativePath, ok := n.projectRelative(project, output)
+	if !ok {
+		return "", false
+	}
+	return translateOutput(relativePath, func(literal string) bool {
+		return path.Ext(literal) == ""
+	})
+}
@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"testing"
+
+	"grog/internal/label"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportNx(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"package.json": `{"workspaces": ["packages/*"]}`,
+		"nx.json": `{
+  "namedInputs": {"default": ["{projectRoot}/**/*"], "production": ["default", "!{projectRoot}/**/*.spec.ts", {"env": "NODE_ENV"}]},
+  "targetDefaults": {"build": {"dependsOn": ["^build"], "inputs": ["production", "^production"], "cache": true}},
+  "plugins": ["@nx/eslint/plugin"]
+}`,
+		"libs/util/project.json": `{"name": "util", "targets": {
+  "build": {"executor": "nx:run-commands", "outputs": ["{projectRoot}/dist", "{workspaceRoot}/coverage/util"], "options": {"command": "tsc -p libs/util"}},
+  "test": {"executor": "@nx/vite:test"}
+}}`,
+		"packages/ui/package.json": `{"name": "@acme/ui", "scripts": {"build": "vite build", "lint": "eslint ."}, "nx": {"includedScripts": ["build"]}}`,
+		"apps/api/project.json": `{"name": "api", "implicitDependencies": ["util"], "targets": {
+  "build": {"command": "esbuild src/main.ts --outfile={options.outputPath}/main.js", "options": {"cwd": "apps/api", "outputPath": "apps/api/out"}, "outputs": ["{options.outputPath}"]},
+  "serve": {"continuous": true, "command": "node out/main.js"},
+  "e2e": {"command": "playwright test", "dependsOn": [{"target": "build", "projects": ["util", "@acme/*"]}, "serve"], "cache": true}
+}}`,
+		"apps/api/package.json": `{"name": "api", "dependencies": {"@acme/ui": "*"}}`,
+	})
+
+	result, err := ImportNx(root)
+	require.NoError(t, err)
+
+	api := result.Packages["apps/api"]
+	require.Len(t, api, 2)
+	assert.Equal(t, &Target{
+		Name:          "build",
+		Command:       "esbuild src/main.ts --outfile={options.outputPath}/main.js",
+		Dependencies:  []label.TargetLabel{label.TL("libs/util", "build"), label.TL("packages/ui", "build")},
+		Inputs:        []string{"**/*"},
+		ExcludeInputs: []string{"**/*.spec.ts", "node_modules/**", ".turbo/**", ".nx/**", "out/**"},
+		Outputs:       []string{"dir::out"},
+	}, api[0])
+	assert.Equal(t, "e2e", api[1].Name)
+	assert.Equal(t, `cd "$GROG_WORKSPACE_ROOT" && playwright test`, api[1].Command)
+	assert.Equal(t, []label.TargetLabel{label.TL("libs/util", "build"), label.TL("packages/ui", "build")}, api[1].Dependencies)
+	assert.Empty(t, api[1].Tags)
+
+	util := result.Packages["libs/util"]
+	require.Len(t, util, 2)
+	assert.Equal(t, `cd "$GROG_WORKSPACE_ROOT" && tsc -p libs/util`, util[0].Command)
+	assert.Equal(t, []string{"dir::dist"}, util[0].Outputs)
+	assert.Equal(t, "npx nx run util:test", util[1].Command)
+	assert.Equal(t, []string{"no-cache"}, util[1].Tags)
+
+	ui := result.Packages["packages/ui"]
+	require.Len(t, ui, 1)
+	assert.Equal(t, "npm run build", ui[0].Command)
+
+	assert.Equal(t, []string{
+		`//: targets inferred by nx plugins are not translated`,
+		`//apps/api:build: run-commands option "outputPath" is not translated`,
+		`//apps/api:build: input {"env": "NODE_ENV"} is not translated`,
+		`//apps/api:e2e: dependsOn entry "serve" does not match a translated target`,
+		`//apps/api:serve: continuous targets (dev servers, watchers) are not translated`,
+		`//libs/util:build: input {"env": "NODE_ENV"} is not translated`,
+		`//libs/util:build: output "{workspaceRoot}/coverage/util" is not translated`,
+		`//libs/util:test: executor "@nx/vite:test" is run through nx`,
+		`//packages/ui:build: input {"env": "NODE_ENV"} is not translated`,
+	}, issueStrings(result))
+}
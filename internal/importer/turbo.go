@@ -0,0 +1,371 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"grog/internal/label"
+)
+
+const (
+	turboDefaultInputs = "$TURBO_DEFAULT$"
+	turboRootPrefix    = "$TURBO_ROOT$"
+	turboExtends       = "$TURBO_EXTENDS$"
+	turboRootPackage   = "//"
+)
+
+// turboIgnoredKeys only affect how turbo itself runs (logging, caching
+// location, UI) and have no grog equivalent worth reporting.
+var turboIgnoredKeys = []string{
+	"$schema", "ui", "daemon", "cacheDir", "remoteCache", "concurrency", "noUpdateNotifier",
+	"dangerouslyDisablePackageManagerCheck", "extends", "tags", "boundaries", "futureFlags",
+}
+
+// turboTask is the configuration of a task in turbo.json. Nil fields are not
+// set so that package configurations can override single fields.
+type turboTask struct {
+	DependsOn  *[]string
+	Inputs     *[]string
+	Outputs    *[]string
+	Cache      *bool
+	Persistent *bool
+	// untranslated lists the keys that have no grog equivalent.
+	untranslated []string
+}
+
+// override returns the task with the fields set in other replacing its own.
+// $TURBO_EXTENDS$ in a list of other appends to the list of the task.
+func (t turboTask) override(other turboTask) turboTask {
+	extend := func(base *[]string, override *[]string) *[]string {
+		if override == nil {
+			return base
+		}
+		if !slices.Contains(*override, turboExtends) {
+			return override
+		}
+		var merged []string
+		if base != nil {
+			merged = append(merged, *base...)
+		}
+		for _, value := range *override {
+			if value != turboExtends {
+				merged = append(merged, value)
+			}
+		}
+		return &merged
+	}
+
+	result := t
+	result.DependsOn = extend(t.DependsOn, other.DependsOn)
+	result.Inputs = extend(t.Inputs, other.Inputs)
+	result.Outputs = extend(t.Outputs, other.Outputs)
+	if other.Cache != nil {
+		result.Cache = other.Cache
+	}
+	if other.Persistent != nil {
+		result.Persistent = other.Persistent
+	}
+	result.untranslated = slices.Concat(t.untranslated, other.untranslated)
+	return result
+}
+
+func parseTurboTask(raw json.RawMessage) (turboTask, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return turboTask{}, err
+	}
+
+	var task turboTask
+	for key, value := range fields {
+		var err error
+		switch key {
+		case "dependsOn":
+			err = json.Unmarshal(value, &task.DependsOn)
+		case "inputs":
+			err = json.Unmarshal(value, &task.Inputs)
+		case "outputs":
+			err = json.Unmarshal(value, &task.Outputs)
+		case "cache":
+			err = json.Unmarshal(value, &task.Cache)
+		case "persistent":
+			err = json.Unmarshal(value, &task.Persistent)
+		case "outputLogs", "outputMode", "interruptible":
+			// Only affect how turbo prints logs and restarts tasks
+		default:
+			task.untranslated = append(task.untranslated, key)
+		}
+		if err != nil {
+			return turboTask{}, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	slices.Sort(task.untranslated)
+	return task, nil
+}
+
+// turboConfig is a parsed turbo.json file.
+type turboConfig struct {
+	tasks map[string]turboTask
+}
+
+func readTurboConfig(filePath string, result *Result, packagePath string) (*turboConfig, error) {
+	var fields map[string]json.RawMessage
+	if err := readJson(filePath, &fields); err != nil {
+		return nil, err
+	}
+
+	config := &turboConfig{tasks: make(map[string]turboTask)}
+	for _, key := range sortedKeys(fields) {
+		switch {
+		case key == "tasks" || key == "pipeline":
+			var rawTasks map[string]json.RawMessage
+			if err := json.Unmarshal(fields[key], &rawTasks); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", filePath, key, err)
+			}
+			for name, rawTask := range rawTasks {
+				task, err := parseTurboTask(rawTask)
+				if err != nil {
+					return nil, fmt.Errorf("%s: task %s: %w", filePath, name, err)
+				}
+				config.tasks[name] = task
+			}
+		case slices.Contains(turboIgnoredKeys, key):
+		default:
+			result.report(packagePath, "", "turbo.json key %q is not translated", key)
+		}
+	}
+	return config, nil
+}
+
+type turboImport struct {
+	workspace *jsWorkspace
+	root      *turboConfig
+	// packageConfigs holds the turbo.json files of packages by directory.
+	packageConfigs map[string]*turboConfig
+	result         *Result
+}
+
+// ImportTurbo translates the turbo.json tasks of an npm, yarn or pnpm
+// workspace into targets that run the package.json scripts.
+func ImportTurbo(workspaceRoot string) (*Result, error) {
+	workspace, err := loadJsWorkspace(workspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newResult()
+	root, err := readTurboConfig(filepath.Join(workspaceRoot, "turbo.json"), result, "")
+	if err != nil {
+		return nil, err
+	}
+
+	importer := &turboImport{
+		workspace:      workspace,
+		root:           root,
+		packageConfigs: make(map[string]*turboConfig),
+		result:         result,
+	}
+	for _, jsPackage := range workspace.packages {
+		filePath := filepath.Join(workspaceRoot, filepath.FromSlash(jsPackage.Directory), "turbo.json")
+		packageConfig, err := readTurboConfig(filePath, result, jsPackage.Directory)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		importer.packageConfigs[jsPackage.Directory] = packageConfig
+	}
+
+	for _, name := range sortedKeys(root.tasks) {
+		packageName, _, found := strings.Cut(name, "#")
+		if found && packageName != turboRootPackage && workspace.byName[packageName] == nil {
+			result.report("", "", "turbo.json task %q refers to unknown package %q", name, packageName)
+		}
+	}
+
+	for _, jsPackage := range append([]*jsPackage{workspace.rootPackage()}, workspace.packages...) {
+		for _, script := range sortedKeys(jsPackage.Manifest.Scripts) {
+			task, ok := importer.task(jsPackage, script)
+			if !ok {
+				continue
+			}
+			if target := importer.translate(jsPackage, script, task); target != nil {
+				result.Packages[jsPackage.Directory] = append(result.Packages[jsPackage.Directory], target)
+			}
+		}
+	}
+
+	result.excludeOutputs()
+	result.sortIssues()
+	return result, nil
+}
+
+// task returns the configuration of a package script or false if turbo does
+// not run it. "package#task" replaces "task" in the root turbo.json and a
+// package turbo.json overrides single fields.
+func (t *turboImport) task(jsPackage *jsPackage, script string) (turboTask, bool) {
+	if _, ok := jsPackage.Manifest.Scripts[script]; !ok {
+		return turboTask{}, false
+	}
+
+	if jsPackage.Directory == "" {
+		task, ok := t.root.tasks[turboRootPackage+"#"+script]
+		return task, ok
+	}
+
+	task, ok := t.root.tasks[jsPackage.Manifest.Name+"#"+script]
+	if !ok {
+		task, ok = t.root.tasks[script]
+	}
+	if packageConfig := t.packageConfigs[jsPackage.Directory]; packageConfig != nil {
+		if packageTask, found := packageConfig.tasks[script]; found {
+			task = task.override(packageTask)
+			ok = true
+		}
+	}
+	return task, ok
+}
+
+// hasTarget reports whether a target is generated for the package script.
+func (t *turboImport) hasTarget(jsPackage *jsPackage, script string) bool {
+	task, ok := t.task(jsPackage, script)
+	return ok && !isTrue(task.Persistent)
+}
+
+func (t *turboImport) translate(jsPackage *jsPackage, script string, task turboTask) *Target {
+	packagePath := jsPackage.Directory
+	name := targetName(script)
+	for _, key := range task.untranslated {
+		t.result.report(packagePath, name, "task option %q is not translated", key)
+	}
+	if isTrue(task.Persistent) {
+		t.result.report(packagePath, name, "persistent tasks (dev servers, watchers) are not translated")
+		return nil
+	}
+
+	target := &Target{
+		Name:    name,
+		Command: t.workspace.runScript + " " + script,
+	}
+
+	if task.DependsOn != nil {
+		for _, dependency := range *task.DependsOn {
+			t.translateDependency(jsPackage, name, dependency, target)
+		}
+	}
+
+	if task.Outputs != nil {
+		for _, output := range *task.Outputs {
+			translated, ok := "", false
+			if !strings.HasPrefix(output, "!") && !strings.HasPrefix(output, turboRootPrefix) {
+				translated, ok = translateOutput(output, func(string) bool { return false })
+			}
+			if !ok {
+				t.result.report(packagePath, name, "output %q is not translated", output)
+				continue
+			}
+			target.Outputs = append(target.Outputs, translated)
+		}
+	}
+
+	inputs := defaultInputs
+	if task.Inputs != nil {
+		inputs = nil
+		for _, input := range *task.Inputs {
+			if input == turboDefaultInputs {
+				inputs = append(inputs, defaultInputs...)
+			} else {
+				inputs = append(inputs, input)
+			}
+		}
+	}
+	for _, input := range inputs {
+		if strings.Contains(input, turboRootPrefix) || !translateInput(target, input) {
+			t.result.report(packagePath, name, "input %q is not translated", input)
+		}
+	}
+	excludeNestedPackages(target, packagePath, t.packagePaths())
+
+	if task.Cache != nil && !*task.Cache {
+		noCache(target)
+	}
+	return target
+}
+
+// translateDependency adds the targets of a dependsOn entry: "^task" for the
+// task of all workspace dependencies, "package#task" and "task" of the same package.
+func (t *turboImport) translateDependency(jsPackage *jsPackage, name string, dependency string, target *Target) {
+	switch {
+	case strings.HasPrefix(dependency, "$"):
+		t.result.report(jsPackage.Directory, name, "environment variable dependency %q is not translated", dependency)
+	case strings.HasPrefix(dependency, "^"):
+		script := strings.TrimPrefix(dependency, "^")
+		visited := make(map[string]bool)
+		for _, dependencyName := range jsPackage.dependencyNames() {
+			if upstream := t.workspace.byName[dependencyName]; upstream != nil {
+				target.Dependencies = append(target.Dependencies, t.upstreamTargets(upstream, script, visited)...)
+			}
+		}
+	case strings.Contains(dependency, "#"):
+		packageName, script, _ := strings.Cut(dependency, "#")
+		other := t.workspace.byName[packageName]
+		if packageName == turboRootPackage {
+			other = t.workspace.rootPackage()
+		}
+		if other == nil || !t.hasTarget(other, script) {
+			t.result.report(jsPackage.Directory, name, "dependency %q does not match a translated task", dependency)
+			return
+		}
+		target.Dependencies = append(target.Dependencies, label.TL(other.Directory, targetName(script)))
+	default:
+		if t.hasTarget(jsPackage, dependency) {
+			target.Dependencies = append(target.Dependencies, label.TL(jsPackage.Directory, targetName(dependency)))
+		}
+	}
+}
+
+// upstreamTargets returns the target of a script in a workspace dependency.
+// Like turbo, packages without the script are skipped over to their own dependencies.
+func (t *turboImport) upstreamTargets(jsPackage *jsPackage, script string, visited map[string]bool) []label.TargetLabel {
+	if visited[jsPackage.Directory] {
+		return nil
+	}
+	visited[jsPackage.Directory] = true
+
+	if t.hasTarget(jsPackage, script) {
+		return []label.TargetLabel{label.TL(jsPackage.Directory, targetName(script))}
+	}
+	var labels []label.TargetLabel
+	for _, dependencyName := range jsPackage.dependencyNames() {
+		if upstream := t.workspace.byName[dependencyName]; upstream != nil {
+			labels = append(labels, t.upstreamTargets(upstream, script, visited)...)
+		}
+	}
+	return labels
+}
+
+func (t *turboImport) packagePaths() []string {
+	var packagePaths []string
+	for _, jsPackage := range t.workspace.packages {
+		packagePaths = append(packagePaths, jsPackage.Directory)
+	}
+	return packagePaths
+}
+
+func isTrue(value *bool) bool {
+	return value != nil && *value
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"grog/internal/label"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for filePath, content := range files {
+		absolutePath := filepath.Join(root, filepath.FromSlash(filePath))
+		require.NoError(t, os.MkdirAll(filepath.Dir(absolutePath), 0755))
+		require.NoError(t, os.WriteFile(absolutePath, []byte(content), 0644))
+	}
+	return root
+}
+
+func issueStrings(result *Result) []string {
+	var issues []string
+	for _, issue := range result.Issues {
+		issues = append(issues, issue.String())
+	}
+	return issues
+}
+
+func TestImportTurbo(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"pnpm-lock.yaml": "",
+		"package.json":   `{"workspaces": ["apps/*", "packages/*", "!packages/ignored"], "scripts": {"format": "prettier -w ."}}`,
+		"turbo.json": `{
+  "globalEnv": ["CI"],
+  "tasks": {
+    "build": {"dependsOn": ["^build"], "outputs": ["dist/**", "!dist/cache/**", "*.tsbuildinfo"], "env": ["API_URL"]},
+    "test": {"dependsOn": ["build"], "inputs": ["$TURBO_DEFAULT$", "!README.md"], "cache": false},
+    "dev": {"persistent": true},
+    "web#lint": {"dependsOn": ["//#format", "$TOKEN"]},
+    "ui#test:unit": {"cache": false},
+    "//#format": {}
+  }
+}`,
+		"packages/config/package.json":  `{"name": "config"}`,
+		"packages/ui/package.json":      `{"name": "ui", "scripts": {"build": "tsc", "test:unit": "vitest", "dev": "tsc -w"}, "dependencies": {"config": "*"}}`,
+		"packages/ignored/package.json": `{"name": "ignored", "scripts": {"build": "tsc"}}`,
+		"packages/base/package.json":    `{"name": "base", "scripts": {"build": "tsc"}}`,
+		"apps/web/package.json":         `{"name": "web", "scripts": {"build": "next build", "lint": "eslint ."}, "dependencies": {"ui": "workspace:*", "react": "18"}, "devDependencies": {"config": "*"}}`,
+		"apps/web/turbo.json":           `{"extends": ["//"], "tasks": {"build": {"outputs": ["$TURBO_EXTENDS$", ".next/**"]}}}`,
+	})
+	// ui depends on base through config, which has no build script
+	require.NoError(t, os.WriteFile(filepath.Join(root, "packages/config/package.json"), []byte(`{"name": "config", "dependencies": {"base": "*"}}`), 0644))
+
+	result, err := ImportTurbo(root)
+	require.NoError(t, err)
+
+	require.Len(t, result.Packages[""], 1)
+	assert.Equal(t, &Target{
+		Name:          "format",
+		Command:       "pnpm run format",
+		Inputs:        []string{"**/*"},
+		ExcludeInputs: []string{"apps/web/**", "packages/base/**", "packages/config/**", "packages/ui/**", "node_modules/**", ".turbo/**", ".nx/**"},
+	}, result.Packages[""][0])
+
+	web := result.Packages["apps/web"]
+	require.Len(t, web, 2)
+	assert.Equal(t, "pnpm run build", web[0].Command)
+	assert.ElementsMatch(t, []label.TargetLabel{label.TL("packages/ui", "build"), label.TL("packages/base", "build")}, web[0].Dependencies)
+	assert.Equal(t, []string{"dir::dist", "dir::.next"}, web[0].Outputs)
+	assert.Equal(t, []label.TargetLabel{label.TL("", "format")}, web[1].Dependencies)
+
+	ui := result.Packages["packages/ui"]
+	require.Len(t, ui, 2)
+	assert.Equal(t, []label.TargetLabel{label.TL("packages/base", "build")}, ui[0].Dependencies)
+	assert.Equal(t, "test_unit", ui[1].Name)
+	assert.Equal(t, "pnpm run test:unit", ui[1].Command)
+	assert.Equal(t, []string{"no-cache"}, ui[1].Tags)
+	assert.Empty(t, result.Packages["packages/ignored"])
+
+	assert.Equal(t, []string{
+		`//: turbo.json key "globalEnv" is not translated`,
+		`//apps/web:build: task option "env" is not translated`,
+		`//apps/web:build: output "!dist/cache/**" is not translated`,
+		`//apps/web:build: output "*.tsbuildinfo" is not translated`,
+		`//apps/web:lint: environment variable dependency "$TOKEN" is not translated`,
+		`//packages/base:build: task option "env" is not translated`,
+		`//packages/base:build: output "!dist/cache/**" is not translated`,
+		`//packages/base:build: output "*.tsbuildinfo" is not translated`,
+		`//packages/ui:build: task option "env" is not translated`,
+		`//packages/ui:build: output "!dist/cache/**" is not translated`,
+		`//packages/ui:build: output "*.tsbuildinfo" is not translated`,
+		`//packages/ui:dev: persistent tasks (dev servers, watchers) are not translated`,
+	}, issueStrings(result))
+}
+
+func TestTranslateOutput(t *testing.T) {
+	literalIsFile := func(string) bool { return false }
+	for pattern, expected := range map[string]string{
+		"dist/**":       "dir::dist",
+		"./out/**/*":    "dir::out",
+		"build/*":       "dir::build",
+		"index.js":      "file::index.js",
+		"dist/*.js":     "",
+		"../shared/out": "",
+		"**/*.map":      "",
+	} {
+		translated, ok := translateOutput(pattern, literalIsFile)
+		assert.Equal(t, expected != "", ok, pattern)
+		assert.Equal(t, expected, translated, pattern)
+	}
+}
@@ -0,0 +1,206 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// packageJson holds the parts of a package.json file that the importers use.
+type packageJson struct {
+	Name                 string            `json:"name"`
+	PackageManager       string            `json:"packageManager"`
+	Scripts              map[string]string `json:"scripts"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	// Workspaces is either a list of globs or {"packages": [...]} (yarn).
+	Workspaces json.RawMessage `json:"workspaces"`
+	// Nx holds inline nx project configuration.
+	Nx json.RawMessage `json:"nx"`
+}
+
+// jsPackage is a package of a JavaScript workspace.
+type jsPackage struct {
+	// Directory is the package path relative to the workspace root ("" for the root).
+	Directory string
+	Manifest  packageJson
+}
+
+func (p *jsPackage) dependencyNames() []string {
+	var names []string
+	for _, dependencies := range []map[string]string{
+		p.Manifest.Dependencies,
+		p.Manifest.DevDependencies,
+		p.Manifest.PeerDependencies,
+		p.Manifest.OptionalDependencies,
+	} {
+		for name := range dependencies {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// jsWorkspace is an npm, yarn or pnpm workspace.
+type jsWorkspace struct {
+	root     string
+	manifest packageJson
+	// packages are the workspace packages by directory in alphabetical order.
+	packages []*jsPackage
+	byName   map[string]*jsPackage
+	// runScript is the command prefix that runs a package.json script.
+	runScript string
+}
+
+// loadJsWorkspace reads the root package.json and the packages matched by its
+// workspaces field or by pnpm-workspace.yaml.
+func loadJsWorkspace(root string) (*jsWorkspace, error) {
+	workspace := &jsWorkspace{root: root, byName: make(map[string]*jsPackage)}
+	if err := readJson(filepath.Join(root, "package.json"), &workspace.manifest); err != nil {
+		return nil, err
+	}
+
+	patterns, err := workspace.workspacePatterns()
+	if err != nil {
+		return nil, err
+	}
+	directories, err := matchPackageDirectories(root, patterns)
+	if err != nil {
+		return nil, err
+	}
+	for _, directory := range directories {
+		jsPackage := &jsPackage{Directory: directory}
+		if err := readJson(filepath.Join(root, filepath.FromSlash(directory), "package.json"), &jsPackage.Manifest); err != nil {
+			return nil, err
+		}
+		workspace.packages = append(workspace.packages, jsPackage)
+		if jsPackage.Manifest.Name != "" {
+			workspace.byName[jsPackage.Manifest.Name] = jsPackage
+		}
+	}
+
+	workspace.runScript = detectRunScript(root, workspace.manifest.PackageManager)
+	return workspace, nil
+}
+
+// rootPackage returns the package.json at the workspace root as a package.
+func (w *jsWorkspace) rootPackage() *jsPackage {
+	return &jsPackage{Directory: "", Manifest: w.manifest}
+}
+
+func (w *jsWorkspace) workspacePatterns() ([]string, error) {
+	var pnpmWorkspace struct {
+		Packages []string `yaml:"packages"`
+	}
+	content, err := os.ReadFile(filepath.Join(w.root, "pnpm-workspace.yaml"))
+	if err == nil {
+		if err := yaml.Unmarshal(content, &pnpmWorkspace); err != nil {
+			return nil, fmt.Errorf("pnpm-workspace.yaml: %w", err)
+		}
+		return pnpmWorkspace.Packages, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if len(w.manifest.Workspaces) == 0 {
+		return nil, nil
+	}
+	var patterns []string
+	if err := json.Unmarshal(w.manifest.Workspaces, &patterns); err == nil {
+		return patterns, nil
+	}
+	var yarnWorkspaces struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(w.manifest.Workspaces, &yarnWorkspaces); err != nil {
+		return nil, fmt.Errorf("package.json: workspaces must be a list of globs or an object with packages")
+	}
+	return yarnWorkspaces.Packages, nil
+}
+
+// matchPackageDirectories returns the sorted directories containing a
+// package.json that are matched by the patterns. Patterns starting with "!"
+// exclude directories.
+func matchPackageDirectories(root string, patterns []string) ([]string, error) {
+	var included, excluded []string
+	for _, pattern := range patterns {
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			excluded = append(excluded, strings.TrimSuffix(path.Clean(negated), "/"))
+		} else {
+			included = append(included, strings.TrimSuffix(path.Clean(pattern), "/"))
+		}
+	}
+
+	var directories []string
+	fileSystem := os.DirFS(root)
+	for _, pattern := range included {
+		matches, err := doublestar.Glob(fileSystem, path.Join(pattern, "package.json"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace pattern %q: %w", pattern, err)
+		}
+		for _, match := range matches {
+			directory := path.Dir(match)
+			if directory == "." || slices.Contains(strings.Split(directory, "/"), "node_modules") {
+				continue
+			}
+			isExcluded := slices.ContainsFunc(excluded, func(exclude string) bool {
+				matched, _ := doublestar.Match(exclude, directory)
+				return matched
+			})
+			if !isExcluded && !slices.Contains(directories, directory) {
+				directories = append(directories, directory)
+			}
+		}
+	}
+	slices.Sort(directories)
+	return directories, nil
+}
+
+// detectRunScript returns the command that runs a package.json script using
+// the package manager of the workspace.
+func detectRunScript(root string, packageManager string) string {
+	name, _, _ := strings.Cut(packageManager, "@")
+	switch name {
+	case "pnpm", "yarn", "bun":
+		return name + " run"
+	case "npm":
+		return "npm run"
+	}
+
+	for _, lockFile := range []struct{ name, manager string }{
+		{"pnpm-lock.yaml", "pnpm"},
+		{"yarn.lock", "yarn"},
+		{"bun.lock", "bun"},
+		{"bun.lockb", "bun"},
+	} {
+		if _, err := os.Stat(filepath.Join(root, lockFile.name)); err == nil {
+			return lockFile.manager + " run"
+		}
+	}
+	return "npm run"
+}
+
+func readJson(filePath string, value any) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(content, value); err != nil {
+		return fmt.Errorf("%s: %w", filePath, err)
+	}
+	return nil
+}
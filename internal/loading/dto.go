@@ -73,6 +73,9 @@ type TargetDTO struct {
 	Timeout              string            `json:"timeout,omitempty" yaml:"timeout,omitempty" pkl:"timeout" starlark:"timeout"`
 
 	ConcurrencyGroup string `json:"concurrency_group,omitempty" yaml:"concurrency_group,omitempty" pkl:"concurrency_group" starlark:"concurrency_group"`
+	MissingInputs    string `json:"missing_inputs,omitempty" yaml:"missing_inputs,omitempty" pkl:"missing_inputs" starlark:"missing_inputs"`
+
+	Visibility []string `json:"visibility,omitempty" yaml:"visibility,omitempty" pkl:"visibility" starlark:"visibility"`
 }
 
 type AliasDTO struct {
@@ -105,6 +108,10 @@ type PackageDTO struct {
 	// Record the path to the source file that defines this package.
 	// Note that in the final model package this is stored on the target level not the package
 	SourceFilePath string
+	// LoaderDependencies lists the absolute paths of files other than the source
+	// file that were read while evaluating it (e.g. Starlark modules and files
+	// read through read_json). A change to any of them may change the package.
+	LoaderDependencies []string `json:"-" yaml:"-"`
 
 	Targets      []*TargetDTO      `json:"targets" yaml:"targets" pkl:"targets" starlark:"targets"`
 	Aliases      []*AliasDTO       `json:"aliases" yaml:"aliases" pkl:"aliases" starlark:"aliases"`
@@ -115,4 +122,8 @@ type PackageDTO struct {
 	// This serves as the default for target-level platform selectors.
 	// If a target specifies its own platform selectors, they override this default.
 	DefaultPlatforms []string `json:"default_platforms,omitempty" yaml:"default_platforms,omitempty" pkl:"default_platforms" starlark:"default_platforms"`
+
+	// DefaultVisibility is the visibility of all targets in the package that
+	// do not specify their own.
+	DefaultVisibility []string `json:"default_visibility,omitempty" yaml:"default_visibility,omitempty" pkl:"default_visibility" starlark:"default_visibility"`
 }
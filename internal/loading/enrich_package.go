@@ -3,6 +3,8 @@ package loading
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -20,10 +22,10 @@ import (
 // - resolves the globs in the inputs
 // - applies any defaults
 // - parses the deps into target labels.
-func getEnrichedPackage(logger *console.Logger, packagePath string, pkg PackageDTO) (*model.Package, error) {
+func getEnrichedPackage(logger *console.Logger, workspaceRoot string, packagePath string, pkg PackageDTO) (*model.Package, error) {
 	targets := make(map[label.TargetLabel]*model.Target)
 	aliases := make(map[label.TargetLabel]*model.Alias)
-	absolutePackagePath := config.GetPathAbsoluteToWorkspaceRoot(packagePath)
+	absolutePackagePath := filepath.Join(workspaceRoot, packagePath)
 
 	for _, target := range pkg.Targets {
 		var deps []label.TargetLabel
@@ -71,6 +73,10 @@ func getEnrichedPackage(logger *console.Logger, packagePath string, pkg PackageD
 			return nil, fmt.Errorf("duplicate target label: %s (package file %s)", target.Name, pkg.SourceFilePath)
 		}
 
+		if _, err := config.ParseSeverity("missing_inputs", target.MissingInputs, config.SeverityWarn); err != nil {
+			return nil, fmt.Errorf("target %s: %w", targetLabel, err)
+		}
+
 		var timeout time.Duration
 		if target.Timeout != "" {
 			timeout, err = time.ParseDuration(target.Timeout)
@@ -90,6 +96,15 @@ func getEnrichedPackage(logger *console.Logger, packagePath string, pkg PackageD
 			targetPlatforms = append([]string{}, pkg.DefaultPlatforms...)
 		}
 
+		visibilityValues := target.Visibility
+		if visibilityValues == nil {
+			visibilityValues = pkg.DefaultVisibility
+		}
+		visibility, err := parseVisibility(packagePath, visibilityValues)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse visibility for target %s: %w", targetLabel, err)
+		}
+
 		var ociPush map[string][]string
 		if len(target.OciPush) > 0 {
 			ociPush = make(map[string][]string, len(target.OciPush))
@@ -100,6 +115,7 @@ func getEnrichedPackage(logger *console.Logger, packagePath string, pkg PackageD
 
 		targets[targetLabel] = &model.Target{
 			SourceFilePath:       pkg.SourceFilePath,
+			LoaderDependencies:   pkg.LoaderDependencies,
 			Label:                targetLabel,
 			Command:              target.Command,
 			Dependencies:         deps,
@@ -117,6 +133,8 @@ func getEnrichedPackage(logger *console.Logger, packagePath string, pkg PackageD
 			EnvironmentVariables: target.EnvironmentVariables,
 			Timeout:              timeout,
 			ConcurrencyGroup:     target.ConcurrencyGroup,
+			MissingInputs:        target.MissingInputs,
+			Visibility:           visibility,
 		}
 	}
 
@@ -193,6 +211,39 @@ func getEnrichedPackage(logger *console.Logger, packagePath string, pkg PackageD
 	}, nil
 }
 
+const (
+	visibilityPublic  = "//visibility:public"
+	visibilityPrivate = "//visibility:private"
+)
+
+// parseVisibility parses the visibility of a target into the patterns of
+// the targets that may depend on it. It returns nil for public targets and
+// an empty list for targets that are private to their package.
+func parseVisibility(packagePath string, values []string) ([]label.TargetPattern, error) {
+	if values == nil || slices.Contains(values, visibilityPublic) {
+		return nil, nil
+	}
+
+	patterns := []label.TargetPattern{}
+	for _, value := range values {
+		switch {
+		case value == visibilityPrivate:
+			if len(values) > 1 {
+				return nil, fmt.Errorf("%s cannot be combined with other values", visibilityPrivate)
+			}
+		case strings.HasPrefix(value, "//visibility:"):
+			return nil, fmt.Errorf("unknown visibility %q: use %s, %s, a package pattern or a label", value, visibilityPublic, visibilityPrivate)
+		default:
+			pattern, err := label.ParseTargetPattern(packagePath, value)
+			if err != nil {
+				return nil, err
+			}
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns, nil
+}
+
 // resolveInputs resolves the glob patterns in the inputs and excludeInputs.
 func resolveInputs(
 	logger *console.Logger,
@@ -32,7 +32,7 @@ func TestGetEnrichedPackage_DefaultPlatforms(t *testing.T) {
 		},
 	}
 
-	enrichedPkg, err := getEnrichedPackage(logger, packagePath, pkgDTO)
+	enrichedPkg, err := getEnrichedPackage(logger, "", packagePath, pkgDTO)
 	if err != nil {
 		t.Fatalf("Failed to enrich package: %v", err)
 	}
@@ -81,7 +81,7 @@ func TestGetEnrichedPackage_FingerprintCopied(t *testing.T) {
 		},
 	}
 
-	enrichedPkg, err := getEnrichedPackage(logger, packagePath, pkgDTO)
+	enrichedPkg, err := getEnrichedPackage(logger, "", packagePath, pkgDTO)
 	if err != nil {
 		t.Fatalf("failed to enrich package: %v", err)
 	}
@@ -124,7 +124,7 @@ func TestGetEnrichedPackage_Resources(t *testing.T) {
 		},
 	}
 
-	enrichedPkg, err := getEnrichedPackage(logger, packagePath, pkgDTO)
+	enrichedPkg, err := getEnrichedPackage(logger, "", packagePath, pkgDTO)
 	if err != nil {
 		t.Fatalf("Failed to enrich package: %v", err)
 	}
@@ -155,7 +155,7 @@ func TestGetEnrichedPackage_ResourceRequiresUp(t *testing.T) {
 		Resources:      []*ResourceDTO{{Name: "postgres"}},
 	}
 
-	if _, err := getEnrichedPackage(logger, "test/package", pkgDTO); err == nil {
+	if _, err := getEnrichedPackage(logger, "", "test/package", pkgDTO); err == nil {
 		t.Fatal("expected error for resource without up command")
 	}
 }
@@ -169,7 +169,59 @@ func TestGetEnrichedPackage_ResourceDuplicateLabel(t *testing.T) {
 		Resources:      []*ResourceDTO{{Name: "postgres", Up: "true"}},
 	}
 
-	if _, err := getEnrichedPackage(logger, "test/package", pkgDTO); err == nil {
+	if _, err := getEnrichedPackage(logger, "", "test/package", pkgDTO); err == nil {
 		t.Fatal("expected duplicate label error")
 	}
 }
+
+func TestGetEnrichedPackage_Visibility(t *testing.T) {
+	logger := console.NewFromSugared(zaptest.NewLogger(t).Sugar(), zapcore.DebugLevel)
+	packagePath := "services/payments"
+
+	pkgDTO := PackageDTO{
+		SourceFilePath:    "services/payments/BUILD.yaml",
+		DefaultVisibility: []string{"//services/payments/...", ":api"},
+		Targets: []*TargetDTO{
+			{Name: "internal"},
+			{Name: "api", Visibility: []string{"//visibility:public"}},
+			{Name: "secret", Visibility: []string{"//visibility:private"}},
+		},
+	}
+
+	enrichedPkg, err := getEnrichedPackage(logger, "", packagePath, pkgDTO)
+	if err != nil {
+		t.Fatalf("Failed to enrich package: %v", err)
+	}
+
+	internal := enrichedPkg.Targets[label.TL(packagePath, "internal")]
+	if got := label.PatternSetToString(internal.Visibility); got != "//services/payments/... //services/payments:api" {
+		t.Errorf("internal should inherit the default visibility, got %q", got)
+	}
+	if !internal.IsVisibleTo(label.TL("services/payments/refunds", "worker")) || internal.IsVisibleTo(label.TL("billing", "worker")) {
+		t.Errorf("unexpected visibility of internal: %v", internal.Visibility)
+	}
+	if api := enrichedPkg.Targets[label.TL(packagePath, "api")]; api.Visibility != nil {
+		t.Errorf("api should be public, got %v", api.Visibility)
+	}
+	if secret := enrichedPkg.Targets[label.TL(packagePath, "secret")]; secret.Visibility == nil || len(secret.Visibility) != 0 {
+		t.Errorf("secret should be private, got %v", secret.Visibility)
+	}
+}
+
+func TestGetEnrichedPackage_InvalidVisibility(t *testing.T) {
+	logger := console.NewFromSugared(zaptest.NewLogger(t).Sugar(), zapcore.DebugLevel)
+
+	for _, visibility := range [][]string{
+		{"//visibility:internal"},
+		{"//visibility:private", "//app/..."},
+		{"app/..."},
+	} {
+		pkgDTO := PackageDTO{
+			SourceFilePath: "test/package/BUILD.yaml",
+			Targets:        []*TargetDTO{{Name: "target", Visibility: visibility}},
+		}
+		if _, err := getEnrichedPackage(logger, "", "test/package", pkgDTO); err == nil {
+			t.Errorf("expected an error for visibility %v", visibility)
+		}
+	}
+}
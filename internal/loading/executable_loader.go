@@ -0,0 +1,97 @@
+package loading
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"grog/internal/config"
+	"grog/internal/console"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExecutableLoader runs executable BUILD files (BUILD.gen by default, see
+// executable_build_files) in their package directory and reads the package
+// definition they print to stdout. JSON output is parsed as YAML, which is a
+// superset of it.
+type ExecutableLoader struct{}
+
+func (ExecutableLoader) Matches(ctx context.Context, fileName string) bool {
+	return slices.Contains(config.WorkspaceFromContext(ctx).GetExecutableBuildFiles(), fileName)
+}
+
+// Load runs the file at filePath and decodes its stdout into a PackageDTO.
+func (ExecutableLoader) Load(ctx context.Context, filePath string) (PackageDTO, bool, error) {
+	var pkg PackageDTO
+	workspace := config.WorkspaceFromContext(ctx)
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return pkg, false, err
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		return pkg, true, fmt.Errorf("%s is not executable: run chmod +x and add a shebang line", filePath)
+	}
+
+	timeout := workspace.GetExecutableBuildFileTimeout()
+	runContext, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(runContext, filePath)
+	cmd.Dir = filepath.Dir(filePath)
+	cmd.Env = executableLoaderEnv(workspace)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Do not wait forever on background processes that inherited the pipes
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if errors.Is(runContext.Err(), context.DeadlineExceeded) {
+		return pkg, true, fmt.Errorf("%s did not finish within %s%s", filePath, timeout, formatLoaderStderr(stderr))
+	}
+	if err != nil {
+		return pkg, true, fmt.Errorf("failed to run %s: %w%s", filePath, err, formatLoaderStderr(stderr))
+	}
+	if stderr.Len() > 0 {
+		console.GetLogger(ctx).Debugf("%s wrote to stderr:\n%s", filePath, stderr.String())
+	}
+
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return pkg, true, fmt.Errorf("%s did not print a package definition to stdout", filePath)
+	}
+	if err := yaml.Unmarshal(stdout.Bytes(), &pkg); err != nil {
+		return pkg, true, fmt.Errorf("failed to decode the output of %s as JSON or YAML: %w", filePath, err)
+	}
+
+	return pkg, true, nil
+}
+
+// executableLoaderEnv returns the process environment extended with the
+// configured environment variables and LoaderEnv, like the pkl loader.
+func executableLoaderEnv(workspace *config.WorkspaceConfig) []string {
+	env := os.Environ()
+	for key, value := range workspace.EnvironmentVariables {
+		env = append(env, key+"="+value)
+	}
+	for key, value := range LoaderEnv(workspace) {
+		env = append(env, key+"="+value)
+	}
+	return env
+}
+
+func formatLoaderStderr(stderr bytes.Buffer) string {
+	output := strings.TrimSpace(stderr.String())
+	if output == "" {
+		return ""
+	}
+	return "\nstderr:\n" + output
+}
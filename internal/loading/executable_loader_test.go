@@ -0,0 +1,149 @@
+package loading
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"grog/internal/config"
+)
+
+func TestExecutableLoaderLoad(t *testing.T) {
+	oldConfig := config.Global
+	defer func() { config.Global = oldConfig }()
+	config.Global.OS = "linux"
+	config.Global.Arch = "arm64"
+
+	dir := t.TempDir()
+	writeTempScript(t, dir, "pkg/names.txt", "api\nweb\n")
+	script := writeTempScript(t, dir, "pkg/BUILD.gen", `#!/bin/sh
+echo "generating" >&2
+echo '{"targets": ['
+first=1
+for name in $(cat names.txt); do
+  [ $first = 1 ] || echo ','
+  first=0
+  echo "{\"name\": \"$name\", \"command\": \"echo $GROG_OS-$GROG_ARCH\"}"
+done
+echo ']}'
+`)
+
+	pkg, matched, err := (ExecutableLoader{}).Load(context.Background(), script)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !matched {
+		t.Fatalf("expected the package to be matched")
+	}
+	if len(pkg.Targets) != 2 {
+		t.Fatalf("expected two targets, got %d", len(pkg.Targets))
+	}
+	if pkg.Targets[1].Name != "web" || pkg.Targets[1].Command != "echo linux-arm64" {
+		t.Fatalf("unexpected target %+v", pkg.Targets[1])
+	}
+}
+
+func TestExecutableLoaderLoad_Yaml(t *testing.T) {
+	dir := t.TempDir()
+	script := writeTempScript(t, dir, "BUILD.gen", `#!/bin/sh
+cat <<EOF
+targets:
+  - name: app
+    outputs: [dist/app]
+    oci_push:
+      app: registry.org/app:1.0.0
+EOF
+`)
+
+	pkg, _, err := (ExecutableLoader{}).Load(context.Background(), script)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(pkg.Targets) != 1 || pkg.Targets[0].OciPush["app"][0] != "registry.org/app:1.0.0" {
+		t.Fatalf("unexpected package %+v", pkg)
+	}
+}
+
+func TestExecutableLoaderLoad_Errors(t *testing.T) {
+	oldConfig := config.Global
+	defer func() { config.Global = oldConfig }()
+	config.Global.ExecutableBuildFileTimeout = 200 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		contents string
+		mode     os.FileMode
+		expected []string
+	}{
+		{
+			name:     "failing generator reports stderr",
+			contents: "#!/bin/sh\necho 'missing schema.json' >&2\nexit 3\n",
+			mode:     0755,
+			expected: []string{"exit status 3", "missing schema.json"},
+		},
+		{
+			name:     "timeout",
+			contents: "#!/bin/sh\necho 'still working' >&2\nsleep 5\n",
+			mode:     0755,
+			expected: []string{"did not finish within 200ms", "still working"},
+		},
+		{
+			name:     "empty output",
+			contents: "#!/bin/sh\n",
+			mode:     0755,
+			expected: []string{"did not print a package definition"},
+		},
+		{
+			name:     "invalid output",
+			contents: "#!/bin/sh\necho '{\"targets\": 3}'\n",
+			mode:     0755,
+			expected: []string{"failed to decode the output"},
+		},
+		{
+			name:     "not executable",
+			contents: "#!/bin/sh\n",
+			mode:     0644,
+			expected: []string{"is not executable"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := filepath.Join(t.TempDir(), "BUILD.gen")
+			if err := os.WriteFile(script, []byte(tt.contents), tt.mode); err != nil {
+				t.Fatal(err)
+			}
+
+			_, matched, err := (ExecutableLoader{}).Load(context.Background(), script)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if !matched {
+				t.Fatalf("expected the file to be matched")
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Fatalf("expected error to contain %q, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestExecutableLoaderMatches(t *testing.T) {
+	oldConfig := config.Global
+	defer func() { config.Global = oldConfig }()
+
+	loader := ExecutableLoader{}
+	if !loader.Matches(context.Background(), "BUILD.gen") || loader.Matches(context.Background(), "BUILD.py") {
+		t.Fatalf("expected only BUILD.gen to match by default")
+	}
+
+	config.Global.ExecutableBuildFiles = []string{"BUILD.py", "BUILD.ts"}
+	if loader.Matches(context.Background(), "BUILD.gen") || !loader.Matches(context.Background(), "BUILD.ts") {
+		t.Fatalf("expected the configured names to replace the default")
+	}
+}
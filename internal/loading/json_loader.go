@@ -5,29 +5,38 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+
+	"gopkg.in/yaml.v3"
 )
 
 // JsonLoader implements the Loader interface for JSON files.
 type JsonLoader struct{}
 
-func (j JsonLoader) Matches(fileName string) bool {
+func (j JsonLoader) Matches(_ context.Context, fileName string) bool {
 	return fileName == "BUILD.json"
 }
 
 // Load reads the file at the specified filePath and unmarshals its content into a model.Package.
-func (j JsonLoader) Load(_ context.Context, filePath string) (PackageDTO, bool, error) {
+func (j JsonLoader) Load(ctx context.Context, filePath string) (PackageDTO, bool, error) {
 	var pkg PackageDTO
 
-	// Open the file.
-	file, err := os.Open(filePath)
+	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return pkg, false, err
 	}
-	defer file.Close()
+
+	// JSON is parsed as YAML first to validate it with line and column
+	// information, which encoding/json does not provide. Documents that the
+	// YAML parser rejects are left to the JSON decoder to report.
+	var document yaml.Node
+	if yaml.Unmarshal(content, &document) == nil {
+		if err := reportBuildFileIssues(ctx, filePath, validateBuildFile(&document, true)); err != nil {
+			return pkg, true, err
+		}
+	}
 
 	// Decode JSON content.
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&pkg)
+	err = json.Unmarshal(content, &pkg)
 	if err != nil {
 		return pkg, true, fmt.Errorf(
 			"failed to decode JSON file %s: %w",
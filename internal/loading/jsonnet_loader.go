@@ -0,0 +1,227 @@
+package loading
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"grog/internal/config"
+
+	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+)
+
+// JsonnetLoader implements the Loader interface for Jsonnet files.
+// The GROG_* loader env and the configured environment variables are
+// available through std.extVar.
+type JsonnetLoader struct{}
+
+func (JsonnetLoader) Matches(_ context.Context, fileName string) bool {
+	return fileName == "BUILD.jsonnet"
+}
+
+// Load evaluates the file at the specified filePath and decodes the resulting
+// JSON manifest into a PackageDTO.
+func (JsonnetLoader) Load(ctx context.Context, filePath string) (PackageDTO, bool, error) {
+	var pkg PackageDTO
+	workspace := config.WorkspaceFromContext(ctx)
+
+	importer := newJsonnetImporter(workspace.WorkspaceRoot, filePath)
+	vm := jsonnet.MakeVM()
+	vm.Importer(importer)
+	for key, value := range LoaderEnv(workspace) {
+		vm.ExtVar(key, value)
+	}
+	for key, value := range workspace.EnvironmentVariables {
+		vm.ExtVar(key, value)
+	}
+
+	// Evaluation errors are formatted by go-jsonnet and already point at the
+	// offending file:line:column and include a stack trace.
+	manifest, err := vm.EvaluateFile(filePath)
+	if err != nil {
+		return pkg, true, fmt.Errorf("failed to evaluate Jsonnet file %s: %w", filePath, err)
+	}
+
+	if err := json.Unmarshal([]byte(manifest), &pkg); err != nil {
+		var typeError *json.UnmarshalTypeError
+		if errors.As(err, &typeError) && typeError.Field != "" {
+			return pkg, true, fmt.Errorf("failed to decode the manifest of Jsonnet file %s: %s%s must be %s, got %s",
+				filePath, locateManifestField(filePath, typeError.Field), describeManifestField(manifest, typeError.Field),
+				jsonTypeName(typeError.Type.Kind().String()), typeError.Value)
+		}
+		return pkg, true, fmt.Errorf("failed to decode the manifest of Jsonnet file %s: %w", filePath, err)
+	}
+
+	pkg.LoaderDependencies = importer.dependencies
+	return pkg, true, nil
+}
+
+// describeManifestField names the field of a decoding error together with
+// the name of the target, alias, resource or environment it belongs to.
+func describeManifestField(manifest, field string) string {
+	description := "field " + field
+	segments := strings.Split(field, ".")
+	if len(segments) < 2 {
+		return description
+	}
+	index, err := strconv.Atoi(segments[1])
+	if err != nil {
+		return description
+	}
+
+	var generic map[string]any
+	if err := json.Unmarshal([]byte(manifest), &generic); err != nil {
+		return description
+	}
+	entries, _ := generic[segments[0]].([]any)
+	if index >= len(entries) {
+		return description
+	}
+	entry, _ := entries[index].(map[string]any)
+	if name, ok := entry["name"].(string); ok && name != "" {
+		description += fmt.Sprintf(" of %s %q", strings.TrimSuffix(segments[0], "s"), name)
+	}
+	return description
+}
+
+// locateManifestField returns the file:line:column prefix of the field in the
+// Jsonnet source. The manifest has no source positions, so the field path is
+// followed through the syntax tree, which only works for fields that are
+// written out as literals. Otherwise the location of the closest enclosing
+// literal is used.
+func locateManifestField(filePath, field string) string {
+	source, err := os.ReadFile(filePath)
+	if err != nil {
+		return ""
+	}
+	node, err := jsonnet.SnippetToAST(filePath, string(source))
+	if err != nil {
+		return ""
+	}
+
+	location := node.Loc()
+	for _, segment := range strings.Split(field, ".") {
+		for {
+			local, ok := node.(*ast.Local)
+			if !ok {
+				break
+			}
+			node = local.Body
+		}
+
+		found := false
+		switch n := node.(type) {
+		case *ast.DesugaredObject:
+			for _, objectField := range n.Fields {
+				name, ok := objectField.Name.(*ast.LiteralString)
+				if ok && name.Value == segment {
+					location = &objectField.LocRange
+					node = objectField.Body
+					found = true
+					break
+				}
+			}
+		case *ast.Array:
+			if index, err := strconv.Atoi(segment); err == nil && index < len(n.Elements) {
+				node = n.Elements[index].Expr
+				location = node.Loc()
+				found = true
+			}
+		}
+		if !found {
+			break
+		}
+	}
+
+	if location == nil || !location.Begin.IsSet() {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d:%d: ", filePath, location.Begin.Line, location.Begin.Column)
+}
+
+// jsonTypeName translates Go kinds into the JSON type names users write.
+func jsonTypeName(kind string) string {
+	switch kind {
+	case "slice", "array":
+		return "an array"
+	case "map", "struct":
+		return "an object"
+	case "bool":
+		return "a boolean"
+	case "string":
+		return "a string"
+	default:
+		return "a number"
+	}
+}
+
+// jsonnetImporter resolves imports like load() in Starlark: paths starting
+// with // are relative to the workspace root. Other paths are looked up next
+// to the importing file first and then relative to the workspace root, like a
+// library path. Imports that escape the workspace are rejected.
+type jsonnetImporter struct {
+	workspaceRoot string
+	buildFile     string
+
+	mutex sync.Mutex
+	// contents caches files by their absolute path, as required by go-jsonnet.
+	contents map[string]jsonnet.Contents
+	// dependencies lists every imported file except the BUILD file itself.
+	dependencies []string
+}
+
+func newJsonnetImporter(workspaceRoot string, buildFile string) *jsonnetImporter {
+	return &jsonnetImporter{workspaceRoot: workspaceRoot, buildFile: buildFile, contents: make(map[string]jsonnet.Contents)}
+}
+
+func (i *jsonnetImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	workspaceRoot := i.workspaceRoot
+	var candidates []string
+	switch {
+	case strings.HasPrefix(importedPath, "//"):
+		candidates = []string{filepath.Join(workspaceRoot, importedPath[2:])}
+	case filepath.IsAbs(importedPath):
+		candidates = []string{filepath.Clean(importedPath)}
+	default:
+		if importedFrom != "" {
+			candidates = append(candidates, filepath.Join(filepath.Dir(importedFrom), importedPath))
+		}
+		candidates = append(candidates, filepath.Join(workspaceRoot, importedPath))
+	}
+
+	for _, candidate := range candidates {
+		if contents, ok := i.contents[candidate]; ok {
+			return contents, candidate, nil
+		}
+		if err := checkWithinWorkspace(workspaceRoot, importedPath, candidate); err != nil {
+			return jsonnet.Contents{}, "", err
+		}
+
+		data, err := os.ReadFile(candidate)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return jsonnet.Contents{}, "", err
+		}
+
+		contents := jsonnet.MakeContentsRaw(data)
+		i.contents[candidate] = contents
+		if candidate != i.buildFile {
+			i.dependencies = append(i.dependencies, candidate)
+		}
+		return contents, candidate, nil
+	}
+
+	return jsonnet.Contents{}, "", fmt.Errorf("%s not found (tried %s)", importedPath, strings.Join(candidates, ", "))
+}
@@ -0,0 +1,165 @@
+package loading
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"grog/internal/config"
+)
+
+func writeJsonnetWorkspace(t *testing.T, files map[string]string) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmpDir
+}
+
+func TestJsonnetLoader(t *testing.T) {
+	oldConfig := config.Global
+	defer func() { config.Global = oldConfig }()
+
+	tmpDir := writeJsonnetWorkspace(t, map[string]string{
+		"lib/node.libsonnet": `{
+  app(name, extra={}):: {
+    name: name,
+    command: "npm run build",
+    inputs: ["src/**"],
+    outputs: ["dir::dist"],
+  } + extra,
+}`,
+		"apps/web/overlay.libsonnet": `{ tags: ["web"] }`,
+		"apps/web/BUILD.jsonnet": `
+local node = import "lib/node.libsonnet";
+local shared = import "//lib/node.libsonnet";
+local overlay = import "overlay.libsonnet";
+{
+  targets: [
+    node.app("build", overlay),
+    shared.app("dist") { command: "echo " + std.extVar("GROG_OS") + " " + std.extVar("FOO") },
+  ],
+}`,
+	})
+	config.Global.WorkspaceRoot = tmpDir
+	config.Global.OS = "linux"
+	config.Global.EnvironmentVariables = map[string]string{"FOO": "bar"}
+
+	buildFile := filepath.Join(tmpDir, "apps/web/BUILD.jsonnet")
+	pkg, matched, err := (JsonnetLoader{}).Load(context.Background(), buildFile)
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if !matched {
+		t.Fatalf("expected the package to be matched")
+	}
+	if len(pkg.Targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(pkg.Targets))
+	}
+	if got := pkg.Targets[0]; got.Name != "build" || !reflect.DeepEqual(got.Tags, []string{"web"}) || !reflect.DeepEqual(got.Outputs, []string{"dir::dist"}) {
+		t.Errorf("unexpected target %+v", got)
+	}
+	if got := pkg.Targets[1].Command; got != "echo linux bar" {
+		t.Errorf("command = %q, want %q", got, "echo linux bar")
+	}
+
+	expectedDependencies := []string{
+		filepath.Join(tmpDir, "lib/node.libsonnet"),
+		filepath.Join(tmpDir, "apps/web/overlay.libsonnet"),
+	}
+	if !reflect.DeepEqual(pkg.LoaderDependencies, expectedDependencies) {
+		t.Errorf("LoaderDependencies = %v, want %v", pkg.LoaderDependencies, expectedDependencies)
+	}
+}
+
+func TestJsonnetLoader_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected []string
+	}{
+		{
+			name: "runtime error points at source location",
+			files: map[string]string{
+				"BUILD.jsonnet": "{\n  targets: [\n    { name: error \"name is required\" },\n  ],\n}\n",
+			},
+			expected: []string{"name is required", "BUILD.jsonnet:3:13-37"},
+		},
+		{
+			name: "error in imported file points at the import",
+			files: map[string]string{
+				"lib.libsonnet": "{\n  f(x):: x.missing,\n}\n",
+				"BUILD.jsonnet": "local lib = import 'lib.libsonnet';\n{ targets: [lib.f({})] }\n",
+			},
+			expected: []string{"Field does not exist: missing", "lib.libsonnet:2:10-19"},
+		},
+		{
+			name: "import outside the workspace",
+			files: map[string]string{
+				"BUILD.jsonnet": "import '../secret.libsonnet'",
+			},
+			expected: []string{"points outside the workspace root", "BUILD.jsonnet:1:1-29"},
+		},
+		{
+			name: "missing import",
+			files: map[string]string{
+				"BUILD.jsonnet": "import 'missing.libsonnet'",
+			},
+			expected: []string{"missing.libsonnet not found"},
+		},
+		{
+			name: "manifest with wrong type",
+			files: map[string]string{
+				"BUILD.jsonnet": `{ targets: [{ name: "a", inputs: "src/**" }] }`,
+			},
+			expected: []string{"BUILD.jsonnet:1:26: field targets.0.inputs of target \"a\" must be an array, got string"},
+		},
+		{
+			name: "wrongly typed field of a later target points at the field",
+			files: map[string]string{
+				"BUILD.jsonnet": "local cmd = 'echo';\n{\n  targets: [\n    { name: 'a', command: cmd },\n    {\n      name: 'b',\n      command: [cmd],\n    },\n  ],\n}\n",
+			},
+			expected: []string{"BUILD.jsonnet:7:7: field targets.1.command of target \"b\" must be a string, got array"},
+		},
+		{
+			name: "wrongly typed field of a generated target points at the closest literal",
+			files: map[string]string{
+				"BUILD.jsonnet": "{\n  targets: [{ name: n, timeout: 5 } for n in ['a']],\n}\n",
+			},
+			expected: []string{"BUILD.jsonnet:2:3: field targets.0.timeout of target \"a\" must be a string, got number"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldConfig := config.Global
+			defer func() { config.Global = oldConfig }()
+
+			tmpDir := writeJsonnetWorkspace(t, tt.files)
+			config.Global.WorkspaceRoot = tmpDir
+
+			_, matched, err := (JsonnetLoader{}).Load(context.Background(), filepath.Join(tmpDir, "BUILD.jsonnet"))
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if !matched {
+				t.Fatalf("expected the file to be matched")
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error to contain %q, got:\n%v", expected, err)
+				}
+			}
+		})
+	}
+}
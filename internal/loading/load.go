@@ -14,17 +14,24 @@ import (
 )
 
 func LoadAllPackages(ctx context.Context) ([]*model.Package, error) {
-	return LoadPackages(ctx, config.Global.WorkspaceRoot)
+	return LoadPackages(ctx, config.WorkspaceFromContext(ctx).WorkspaceRoot)
+}
+
+// LoadWorkspacePackages loads all packages of workspace, which does not have
+// to be the workspace of the running command.
+func LoadWorkspacePackages(ctx context.Context, workspace *config.WorkspaceConfig) ([]*model.Package, error) {
+	return LoadAllPackages(config.WithWorkspace(ctx, workspace))
 }
 
 // LoadPackages loads all packages in the given directory and its subdirectories.
 func LoadPackages(ctx context.Context, startDir string) ([]*model.Package, error) {
 	logger := console.GetLogger(ctx)
+	workspace := config.WorkspaceFromContext(ctx)
 
 	fileListQueue := make(chan *gocodewalker.File, 100)
 
 	fileWalker := gocodewalker.NewParallelFileWalker([]string{startDir}, fileListQueue)
-	fileWalker.IncludeHidden = config.Global.IncludeHidden
+	fileWalker.IncludeHidden = workspace.IncludeHidden
 	go fileWalker.Start()
 
 	packageLoader := NewPackageLoader(logger)
@@ -38,7 +45,7 @@ func LoadPackages(ctx context.Context, startDir string) ([]*model.Package, error
 	loadContext, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	workerCount := config.Global.NumWorkers
+	workerCount := workspace.NumWorkers
 	if workerCount < 1 {
 		workerCount = runtime.NumCPU()
 	}
@@ -77,13 +84,13 @@ func LoadPackages(ctx context.Context, startDir string) ([]*model.Package, error
 					continue
 				}
 
-				packagePath, err := config.GetPackagePath(fileEntry.Location)
+				packagePath, err := workspace.GetPackagePath(fileEntry.Location)
 				if err != nil {
 					setError(err)
 					continue
 				}
 
-				packageModel, err := getEnrichedPackage(logger, packagePath, packageDTO)
+				packageModel, err := getEnrichedPackage(logger, workspace.WorkspaceRoot, packagePath, packageDTO)
 				if err != nil {
 					setError(err)
 					continue
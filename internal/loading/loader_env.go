@@ -15,14 +15,14 @@ import (
 //
 // Per-target values (GROG_TARGET, GROG_PACKAGE) are intentionally excluded;
 // the loader does not know which target a value would belong to.
-func LoaderEnv() map[string]string {
+func LoaderEnv(workspace *config.WorkspaceConfig) map[string]string {
 	return map[string]string{
-		"GROG_OS":             config.Global.OS,
-		"GROG_ARCH":           config.Global.Arch,
-		"GROG_PLATFORM":       config.Global.GetPlatform(),
-		"GROG_PLATFORM_TAGS":  strings.Join(config.Global.PlatformTags, ","),
-		"GROG_ENV_FILE":       resolvedEnvironmentVariablesFilePath(),
-		"GROG_WORKSPACE_ROOT": config.Global.WorkspaceRoot,
+		"GROG_OS":             workspace.OS,
+		"GROG_ARCH":           workspace.Arch,
+		"GROG_PLATFORM":       workspace.GetPlatform(),
+		"GROG_PLATFORM_TAGS":  strings.Join(workspace.PlatformTags, ","),
+		"GROG_ENV_FILE":       resolvedEnvironmentVariablesFilePath(workspace),
+		"GROG_WORKSPACE_ROOT": workspace.WorkspaceRoot,
 		"GROG_GIT_HASH":       loaderGitHash(),
 	}
 }
@@ -37,12 +37,12 @@ func loaderGitHash() string {
 // addLoaderEnvToStarlark mirrors LoaderEnv into a starlark predeclared dict.
 // GROG_PLATFORM_TAGS becomes a starlark list rather than the comma-joined
 // string used by pkl, matching the existing per-language convention.
-func addLoaderEnvToStarlark(dict starlark.StringDict) {
-	dict["GROG_OS"] = starlark.String(config.Global.OS)
-	dict["GROG_ARCH"] = starlark.String(config.Global.Arch)
-	dict["GROG_PLATFORM"] = starlark.String(config.Global.GetPlatform())
-	dict["GROG_PLATFORM_TAGS"] = platformTagsStarlarkList()
-	dict["GROG_ENV_FILE"] = starlark.String(resolvedEnvironmentVariablesFilePath())
-	dict["GROG_WORKSPACE_ROOT"] = starlark.String(config.Global.WorkspaceRoot)
+func addLoaderEnvToStarlark(dict starlark.StringDict, workspace *config.WorkspaceConfig) {
+	dict["GROG_OS"] = starlark.String(workspace.OS)
+	dict["GROG_ARCH"] = starlark.String(workspace.Arch)
+	dict["GROG_PLATFORM"] = starlark.String(workspace.GetPlatform())
+	dict["GROG_PLATFORM_TAGS"] = platformTagsStarlarkList(workspace)
+	dict["GROG_ENV_FILE"] = starlark.String(resolvedEnvironmentVariablesFilePath(workspace))
+	dict["GROG_WORKSPACE_ROOT"] = starlark.String(workspace.WorkspaceRoot)
 	dict["GROG_GIT_HASH"] = starlark.String(loaderGitHash())
 }
@@ -13,7 +13,7 @@ import (
 // MakefileLoader implements the Loader interface for Makefiles.
 type MakefileLoader struct{}
 
-func (m MakefileLoader) Matches(fileName string) bool {
+func (m MakefileLoader) Matches(_ context.Context, fileName string) bool {
 	return fileName == "Makefile"
 }
 
@@ -10,7 +10,7 @@ import (
 // Load must be safe for concurrent use by multiple goroutines.
 type Loader interface {
 	// Matches indicates if the loader can load the specified file name
-	Matches(fileName string) bool
+	Matches(ctx context.Context, fileName string) bool
 	// Load reads the file at the specified filePath and unmarshals its content into a model.Package
 	// Returns true if the file contains a valid package definition (needed for Makefiles)
 	Load(ctx context.Context, filePath string) (PackageDTO, bool, error)
@@ -32,8 +32,10 @@ func NewPackageLoader(logger *console.Logger) *PackageLoader {
 			YamlLoader{},
 			MakefileLoader{},
 			&PklLoader{},
+			JsonnetLoader{},
 			StarlarkLoader{},
 			ScriptLoader{},
+			ExecutableLoader{},
 		},
 	}
 }
@@ -41,7 +43,7 @@ func NewPackageLoader(logger *console.Logger) *PackageLoader {
 // LoadIfMatched loads the package from the specified file name if it matches any of the supported file names.
 func (p *PackageLoader) LoadIfMatched(ctx context.Context, filePath string, fileName string) (PackageDTO, bool, error) {
 	for _, loader := range p.loaders {
-		if loader.Matches(fileName) {
+		if loader.Matches(ctx, fileName) {
 			p.logger.Debugf("Loading package from %s using loader %s", filePath, loader)
 			packageDTO, matched, err := loader.Load(ctx, filePath)
 			packageDTO.SourceFilePath = filePath
@@ -22,33 +22,34 @@ type PklLoader struct {
 	evaluatorOnce sync.Once
 }
 
-func (pl *PklLoader) Matches(fileName string) bool {
+func (pl *PklLoader) Matches(_ context.Context, fileName string) bool {
 	return fileName == "BUILD.pkl"
 }
 
 // getEvaluator lazily loads and caches the evaluator.
 func (pl *PklLoader) getEvaluator(ctx context.Context) (pkl.Evaluator, error) {
 	pl.evaluatorOnce.Do(func() {
-		if hasPklProjectFile() {
+		workspace := config.WorkspaceFromContext(ctx)
+		if hasPklProjectFile(workspace) {
 			pl.evaluator, pl.evaluatorErr = pkl.NewProjectEvaluator(ctx,
-				&url.URL{Scheme: "file", Path: config.Global.WorkspaceRoot},
+				&url.URL{Scheme: "file", Path: workspace.WorkspaceRoot},
 				pkl.PreconfiguredOptions,
-				withEnv(LoaderEnv()),
-				withEnv(config.Global.EnvironmentVariables),
+				withEnv(LoaderEnv(workspace)),
+				withEnv(workspace.EnvironmentVariables),
 			)
 		} else {
 			pl.evaluator, pl.evaluatorErr = pkl.NewEvaluator(ctx,
 				pkl.PreconfiguredOptions,
-				withEnv(LoaderEnv()),
-				withEnv(config.Global.EnvironmentVariables),
+				withEnv(LoaderEnv(workspace)),
+				withEnv(workspace.EnvironmentVariables),
 			)
 		}
 	})
 	return pl.evaluator, pl.evaluatorErr
 }
 
-func hasPklProjectFile() bool {
-	_, err := os.Stat(filepath.Join(config.Global.WorkspaceRoot, "PklProject"))
+func hasPklProjectFile(workspace *config.WorkspaceConfig) bool {
+	_, err := os.Stat(filepath.Join(workspace.WorkspaceRoot, "PklProject"))
 	return !errors.Is(err, os.ErrNotExist)
 }
 
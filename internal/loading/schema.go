@@ -0,0 +1,189 @@
+package loading
+
+import (
+	"reflect"
+	"strings"
+
+	"grog/internal/model"
+)
+
+// buildFileDefinitions names the object types that can appear in a BUILD
+// file. The names are used in the JSON Schema $defs and in validation messages.
+var buildFileDefinitions = map[reflect.Type]string{
+	reflect.TypeOf(PackageDTO{}):        "package",
+	reflect.TypeOf(TargetDTO{}):         "target",
+	reflect.TypeOf(AliasDTO{}):          "alias",
+	reflect.TypeOf(ResourceDTO{}):       "resource",
+	reflect.TypeOf(EnvironmentDTO{}):    "environment",
+	reflect.TypeOf(model.OutputCheck{}): "output_check",
+}
+
+// buildFileDescriptions documents the keys of each definition. They end up in
+// the JSON Schema so that editors can show them on hover.
+var buildFileDescriptions = map[string]map[string]string{
+	"package": {
+		"targets":            "Build targets defined in this package.",
+		"aliases":            "Alternative names for targets.",
+		"resources":          "Long running services (e.g. databases) that targets can depend on.",
+		"environments":       "Execution environments (e.g. docker images) that targets can run in.",
+		"default_platforms":  "Platform selectors applied to all targets of the package that do not set platforms.",
+		"default_visibility": "Visibility applied to all targets of the package that do not set visibility.",
+	},
+	"target": {
+		"name":                  "Unique identifier for the target within its package.",
+		"command":               "Shell command to execute in the target's package directory.",
+		"dependencies":          "Labels of other targets that must be built before this one.",
+		"inputs":                "File paths or glob patterns that, when changed, will trigger a rebuild.",
+		"exclude_inputs":        "File paths or glob patterns to exclude from inputs.",
+		"outputs":               "Files (file::), directories (dir::) or images (oci::) produced by the target.",
+		"oci_push":              "Maps the local name of an oci:: output to one or more remote destinations.",
+		"bin_output":            "Output that can be run with grog run.",
+		"binary_requires_push":  "When true, grog run of this binary fails unless --push is set.",
+		"output_checks":         "Commands that verify the outputs of the target.",
+		"tags":                  "Metadata tags that affect target behavior (e.g. no-cache).",
+		"fingerprint":           "Extra identity inputs used to invalidate the target's cache entry.",
+		"platforms":             "Platforms the target can run on (e.g. linux/amd64).",
+		"environment_variables": "Additional environment variables set when running the target.",
+		"timeout":               "Maximum time allowed for the command to run (e.g. 5m).",
+		"concurrency_group":     "Name of a concurrency group limiting how many members run in parallel.",
+		"missing_inputs":        "How literal inputs that do not exist are reported.",
+		"visibility":            "Targets that may depend on this target, e.g. //visibility:public, //services/payments/... or //app:server.",
+	},
+	"alias": {
+		"name":   "Name of the alias within its package.",
+		"actual": "Label of the target the alias points to.",
+	},
+	"resource": {
+		"name":         "Unique identifier for the resource within its package.",
+		"up":           "Command that starts the resource.",
+		"down":         "Command that stops the resource.",
+		"ready":        "Command that succeeds once the resource is ready.",
+		"timeout":      "Maximum time to wait for the resource to become ready.",
+		"exports":      "Environment variables exported to targets that depend on the resource.",
+		"dependencies": "Labels of targets or resources the resource depends on.",
+	},
+	"environment": {
+		"name":         "Unique identifier for the environment within its package.",
+		"type":         "Type of the environment (e.g. docker).",
+		"dependencies": "Labels of targets that must be built before the environment is used.",
+		"oci_image":    "Image the environment runs in.",
+	},
+	"output_check": {
+		"command":         "Command to run after the target.",
+		"expected_output": "Expected output of the command.",
+	},
+}
+
+// buildFileEnums lists the allowed values of string keys by definition and key.
+var buildFileEnums = map[string]map[string][]string{
+	"target": {"missing_inputs": {"ignore", "warn", "error"}},
+}
+
+// buildFileRequired lists the keys every definition must set.
+var buildFileRequired = map[string][]string{
+	"target":       {"name"},
+	"alias":        {"name", "actual"},
+	"resource":     {"name", "up"},
+	"environment":  {"name", "type"},
+	"output_check": {"command"},
+}
+
+// schemaKeyword is a key that editors use to associate a BUILD.json file
+// with a schema. It is accepted at the top level and otherwise ignored.
+const schemaKeyword = "$schema"
+
+// buildFileField is a key of a BUILD file object.
+type buildFileField struct {
+	key       string
+	fieldType reflect.Type
+}
+
+// buildFileFields returns the keys of a DTO in declaration order. Fields
+// without a yaml tag or tagged "-" cannot be set in BUILD files.
+func buildFileFields(structType reflect.Type) []buildFileField {
+	var fields []buildFileField
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		fields = append(fields, buildFileField{key: key, fieldType: field.Type})
+	}
+	return fields
+}
+
+// BuildFileSchema returns a JSON Schema (draft 2020-12) for BUILD.json and
+// BUILD.yaml files.
+func BuildFileSchema() map[string]any {
+	definitions := make(map[string]any)
+	root := objectSchema(reflect.TypeOf(PackageDTO{}), definitions)
+	root["properties"].(map[string]any)[schemaKeyword] = map[string]any{"type": "string"}
+
+	schema := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "grog BUILD file",
+		"$defs":   definitions,
+	}
+	for key, value := range root {
+		schema[key] = value
+	}
+	return schema
+}
+
+func objectSchema(structType reflect.Type, definitions map[string]any) map[string]any {
+	name := buildFileDefinitions[structType]
+	properties := make(map[string]any)
+	for _, field := range buildFileFields(structType) {
+		property := typeSchema(field.fieldType, definitions)
+		if description := buildFileDescriptions[name][field.key]; description != "" {
+			property["description"] = description
+		}
+		if values := buildFileEnums[name][field.key]; values != nil {
+			property["enum"] = values
+		}
+		properties[field.key] = property
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required := buildFileRequired[name]; required != nil {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func typeSchema(fieldType reflect.Type, definitions map[string]any) map[string]any {
+	if fieldType == reflect.TypeOf(ociPushDestinations{}) {
+		return map[string]any{"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		}}
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Pointer:
+		return typeSchema(fieldType.Elem(), definitions)
+	case reflect.Struct:
+		name := buildFileDefinitions[fieldType]
+		if _, ok := definitions[name]; !ok {
+			// Reserve the name before recursing in case of cycles
+			definitions[name] = nil
+			definitions[name] = objectSchema(fieldType, definitions)
+		}
+		return map[string]any{"$ref": "#/$defs/" + name}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(fieldType.Elem(), definitions)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(fieldType.Elem(), definitions)}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return map[string]any{"type": "integer"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
@@ -18,7 +18,7 @@ import (
 
 type ScriptLoader struct{}
 
-func (ScriptLoader) Matches(fileName string) bool {
+func (ScriptLoader) Matches(_ context.Context, fileName string) bool {
 	return strings.HasSuffix(fileName, ".grog.sh") || strings.HasSuffix(fileName, ".grog.py")
 }
 
@@ -170,7 +170,7 @@ func LoadScriptTarget(ctx context.Context, logger *console.Logger, filePath stri
 		return nil, fmt.Errorf("%s does not contain a # @grog annotation", filePath)
 	}
 
-	pkg, err := getEnrichedPackage(logger, packagePath, pkgDto)
+	pkg, err := getEnrichedPackage(logger, config.Global.WorkspaceRoot, packagePath, pkgDto)
 	if err != nil {
 		return nil, err
 	}
@@ -19,7 +19,7 @@ import (
 // StarlarkLoader implements the Loader interface for Starlark files.
 type StarlarkLoader struct{}
 
-func (sl StarlarkLoader) Matches(fileName string) bool {
+func (sl StarlarkLoader) Matches(_ context.Context, fileName string) bool {
 	return fileName == "BUILD.star" || fileName == "BUILD.bzl"
 }
 
@@ -30,6 +30,18 @@ type starlarkPackageCollector struct {
 	resources        []*ResourceDTO
 	environments     []*EnvironmentDTO
 	defaultPlatforms []string
+	// defaultVisibility is set through the package() builtin.
+	defaultVisibility []string
+
+	// workspace is the workspace the BUILD file belongs to.
+	workspace *config.WorkspaceConfig
+	// packageDirectory is the directory of the BUILD file being evaluated.
+	// Relative paths passed to the read_* builtins resolve against it.
+	packageDirectory string
+	// loaderDependencies records every file (loaded modules and files read
+	// through the read_* builtins) that the evaluation depended on.
+	loaderDependencies []string
+	seenDependencies   map[string]bool
 }
 
 // moduleLoadContext tracks loaded modules and in-progress loads for cycle detection.
@@ -43,10 +55,13 @@ type moduleLoadContext struct {
 // Load reads the file at the specified filePath and evaluates it as Starlark code.
 func (sl StarlarkLoader) Load(ctx context.Context, filePath string) (PackageDTO, bool, error) {
 	collector := &starlarkPackageCollector{
-		targets:      make([]*TargetDTO, 0),
-		aliases:      make([]*AliasDTO, 0),
-		resources:    make([]*ResourceDTO, 0),
-		environments: make([]*EnvironmentDTO, 0),
+		targets:          make([]*TargetDTO, 0),
+		aliases:          make([]*AliasDTO, 0),
+		resources:        make([]*ResourceDTO, 0),
+		environments:     make([]*EnvironmentDTO, 0),
+		workspace:        config.WorkspaceFromContext(ctx),
+		packageDirectory: filepath.Dir(filePath),
+		seenDependencies: make(map[string]bool),
 	}
 
 	// Create module load context for caching and cycle detection
@@ -55,20 +70,7 @@ func (sl StarlarkLoader) Load(ctx context.Context, filePath string) (PackageDTO,
 		loading: make(map[string]bool),
 	}
 
-	// Create predeclared functions and values
-	predeclared := starlark.StringDict{
-		"target":      starlark.NewBuiltin("target", collector.targetBuiltin),
-		"alias":       starlark.NewBuiltin("alias", collector.aliasBuiltin),
-		"resource":    starlark.NewBuiltin("resource", collector.resourceBuiltin),
-		"environment": starlark.NewBuiltin("environment", collector.environmentBuiltin),
-		"json":        json.Module,
-		"math":        math.Module,
-		"time":        time.Module,
-	}
-	addLoaderEnvToStarlark(predeclared)
-	for key, value := range config.Global.EnvironmentVariables {
-		predeclared[key] = starlark.String(value)
-	}
+	predeclared := collector.predeclared()
 
 	thread := &starlark.Thread{
 		Name: filePath,
@@ -84,16 +86,53 @@ func (sl StarlarkLoader) Load(ctx context.Context, filePath string) (PackageDTO,
 	}
 
 	packageDTO := PackageDTO{
-		Targets:          collector.targets,
-		Aliases:          collector.aliases,
-		Resources:        collector.resources,
-		Environments:     collector.environments,
-		DefaultPlatforms: collector.defaultPlatforms,
+		Targets:           collector.targets,
+		Aliases:           collector.aliases,
+		Resources:         collector.resources,
+		Environments:      collector.environments,
+		DefaultPlatforms:  collector.defaultPlatforms,
+		DefaultVisibility: collector.defaultVisibility,
+
+		LoaderDependencies: collector.loaderDependencies,
 	}
 
 	return packageDTO, true, nil
 }
 
+// predeclared returns the builtins and values available to BUILD files and
+// every module they load.
+func (c *starlarkPackageCollector) predeclared() starlark.StringDict {
+	predeclared := starlark.StringDict{
+		"target":      starlark.NewBuiltin("target", c.targetBuiltin),
+		"alias":       starlark.NewBuiltin("alias", c.aliasBuiltin),
+		"resource":    starlark.NewBuiltin("resource", c.resourceBuiltin),
+		"environment": starlark.NewBuiltin("environment", c.environmentBuiltin),
+		"package":     starlark.NewBuiltin("package", c.packageBuiltin),
+		"read_file":   starlark.NewBuiltin("read_file", c.readFileBuiltin),
+		"read_json":   starlark.NewBuiltin("read_json", c.readJsonBuiltin),
+		"read_yaml":   starlark.NewBuiltin("read_yaml", c.readYamlBuiltin),
+		"read_toml":   starlark.NewBuiltin("read_toml", c.readTomlBuiltin),
+		"json":        json.Module,
+		"math":        math.Module,
+		"time":        time.Module,
+	}
+	addLoaderEnvToStarlark(predeclared, c.workspace)
+	for key, value := range c.workspace.EnvironmentVariables {
+		predeclared[key] = starlark.String(value)
+	}
+	return predeclared
+}
+
+// addLoaderDependency records a file the evaluation depended on. Paths are
+// deduplicated so that a module loaded or read twice is only listed once.
+func (c *starlarkPackageCollector) addLoaderDependency(path string) {
+	if c.seenDependencies[path] {
+		return
+	}
+	c.seenDependencies[path] = true
+	c.loaderDependencies = append(c.loaderDependencies, path)
+}
+
 // loadModule implements the load() function for importing other Starlark files
 // with caching and cycle detection.
 func (sl StarlarkLoader) loadModule(thread *starlark.Thread, module string, currentFile string, collector *starlarkPackageCollector, loadContext *moduleLoadContext) (starlark.StringDict, error) {
@@ -103,7 +142,7 @@ func (sl StarlarkLoader) loadModule(thread *starlark.Thread, module string, curr
 	if len(module) > 2 && module[:2] == "//" {
 		// Absolute path from workspace root
 		relativePath := module[2:]
-		modulePath = filepath.Join(config.Global.WorkspaceRoot, relativePath)
+		modulePath = filepath.Join(collector.workspace.WorkspaceRoot, relativePath)
 	} else {
 		// Relative path from current file
 		currentDirectory := filepath.Dir(currentFile)
@@ -128,6 +167,8 @@ func (sl StarlarkLoader) loadModule(thread *starlark.Thread, module string, curr
 		return nil, fmt.Errorf("module not found: %s (resolved to %s)", module, modulePath)
 	}
 
+	collector.addLoaderDependency(modulePath)
+
 	// Mark as currently loading to detect cycles
 	loadContext.loading[modulePath] = true
 	defer func() {
@@ -136,19 +177,7 @@ func (sl StarlarkLoader) loadModule(thread *starlark.Thread, module string, curr
 	}()
 
 	// Create predeclared functions for the loaded module
-	predeclared := starlark.StringDict{
-		"target":      starlark.NewBuiltin("target", collector.targetBuiltin),
-		"alias":       starlark.NewBuiltin("alias", collector.aliasBuiltin),
-		"resource":    starlark.NewBuiltin("resource", collector.resourceBuiltin),
-		"environment": starlark.NewBuiltin("environment", collector.environmentBuiltin),
-		"json":        json.Module,
-		"math":        math.Module,
-		"time":        time.Module,
-	}
-	addLoaderEnvToStarlark(predeclared)
-	for key, value := range config.Global.EnvironmentVariables {
-		predeclared[key] = starlark.String(value)
-	}
+	predeclared := collector.predeclared()
 
 	// Create a new thread for the module with the same load function
 	moduleThread := &starlark.Thread{
@@ -187,7 +216,9 @@ func (c *starlarkPackageCollector) targetBuiltin(thread *starlark.Thread, fn *st
 	var envVars *starlark.Dict
 	var timeout string
 	var concurrencyGroup string
+	var missingInputs string
 	var ociPush *starlark.Dict
+	var visibility *starlark.List
 
 	// Parse keyword arguments
 	if err := starlark.UnpackArgs("target", args, kwargs,
@@ -206,7 +237,9 @@ func (c *starlarkPackageCollector) targetBuiltin(thread *starlark.Thread, fn *st
 		"environment_variables?", &envVars,
 		"timeout?", &timeout,
 		"concurrency_group?", &concurrencyGroup,
+		"missing_inputs?", &missingInputs,
 		"oci_push?", &ociPush,
+		"visibility?", &visibility,
 	); err != nil {
 		return nil, err
 	}
@@ -313,6 +346,10 @@ func (c *starlarkPackageCollector) targetBuiltin(thread *starlark.Thread, fn *st
 		target.ConcurrencyGroup = concurrencyGroup
 	}
 
+	if missingInputs != "" {
+		target.MissingInputs = missingInputs
+	}
+
 	if ociPush != nil {
 		push, err := starlarkDictToOciPush(ociPush)
 		if err != nil {
@@ -321,10 +358,50 @@ func (c *starlarkPackageCollector) targetBuiltin(thread *starlark.Thread, fn *st
 		target.OciPush = push
 	}
 
+	if visibility != nil {
+		vis, err := starlarkListToStringSlice(visibility)
+		if err != nil {
+			return nil, fmt.Errorf("visibility: %w", err)
+		}
+		target.Visibility = vis
+	}
+
 	c.targets = append(c.targets, target)
 	return starlark.None, nil
 }
 
+// packageBuiltin implements the package() function in Starlark which sets
+// package level defaults for the targets of the BUILD file.
+func (c *starlarkPackageCollector) packageBuiltin(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var defaultPlatforms *starlark.List
+	var defaultVisibility *starlark.List
+
+	if err := starlark.UnpackArgs("package", args, kwargs,
+		"default_platforms?", &defaultPlatforms,
+		"default_visibility?", &defaultVisibility,
+	); err != nil {
+		return nil, err
+	}
+
+	if defaultPlatforms != nil {
+		platforms, err := starlarkListToStringSlice(defaultPlatforms)
+		if err != nil {
+			return nil, fmt.Errorf("default_platforms: %w", err)
+		}
+		c.defaultPlatforms = platforms
+	}
+
+	if defaultVisibility != nil {
+		visibility, err := starlarkListToStringSlice(defaultVisibility)
+		if err != nil {
+			return nil, fmt.Errorf("default_visibility: %w", err)
+		}
+		c.defaultVisibility = visibility
+	}
+
+	return starlark.None, nil
+}
+
 // aliasBuiltin implements the alias() function in Starlark.
 func (c *starlarkPackageCollector) aliasBuiltin(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	var name string
@@ -433,9 +510,9 @@ func (c *starlarkPackageCollector) environmentBuiltin(thread *starlark.Thread, f
 
 // Helper functions to convert Starlark types to Go types
 
-func platformTagsStarlarkList() *starlark.List {
-	values := make([]starlark.Value, 0, len(config.Global.PlatformTags))
-	for _, tag := range config.Global.PlatformTags {
+func platformTagsStarlarkList(workspace *config.WorkspaceConfig) *starlark.List {
+	values := make([]starlark.Value, 0, len(workspace.PlatformTags))
+	for _, tag := range workspace.PlatformTags {
 		values = append(values, starlark.String(tag))
 	}
 	list := starlark.NewList(values)
@@ -563,13 +640,13 @@ func starlarkListToOutputChecks(list *starlark.List) ([]model.OutputCheck, error
 // resolvedEnvironmentVariablesFilePath returns the absolute path to the
 // configured environment variables file. If EnvironmentVariablesFile is empty,
 // it returns an empty string. Relative paths are resolved against WorkspaceRoot.
-func resolvedEnvironmentVariablesFilePath() string {
-	environmentVariablesFilePath := config.Global.EnvironmentVariablesFile
+func resolvedEnvironmentVariablesFilePath(workspace *config.WorkspaceConfig) string {
+	environmentVariablesFilePath := workspace.EnvironmentVariablesFile
 	if environmentVariablesFilePath == "" {
 		return ""
 	}
 	if !filepath.IsAbs(environmentVariablesFilePath) {
-		environmentVariablesFilePath = filepath.Join(config.Global.WorkspaceRoot, environmentVariablesFilePath)
+		environmentVariablesFilePath = filepath.Join(workspace.WorkspaceRoot, environmentVariablesFilePath)
 	}
 	return filepath.Clean(environmentVariablesFilePath)
 }
@@ -463,6 +463,41 @@ target(
 	}
 }
 
+func TestStarlarkLoader_PackageDefaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWorkspaceRoot := config.Global.WorkspaceRoot
+	config.Global.WorkspaceRoot = tmpDir
+	defer func() { config.Global.WorkspaceRoot = oldWorkspaceRoot }()
+
+	build := filepath.Join(tmpDir, "BUILD.star")
+	if err := os.WriteFile(build, []byte(`package(
+    default_platforms = ["linux/amd64"],
+    default_visibility = ["//services/..."],
+)
+target(
+    name = "api",
+    command = "echo build",
+    visibility = ["//visibility:public"],
+)
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, _, err := (StarlarkLoader{}).Load(context.Background(), build)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !reflect.DeepEqual(pkg.DefaultPlatforms, []string{"linux/amd64"}) {
+		t.Errorf("DefaultPlatforms = %v", pkg.DefaultPlatforms)
+	}
+	if !reflect.DeepEqual(pkg.DefaultVisibility, []string{"//services/..."}) {
+		t.Errorf("DefaultVisibility = %v", pkg.DefaultVisibility)
+	}
+	if !reflect.DeepEqual(pkg.Targets[0].Visibility, []string{"//visibility:public"}) {
+		t.Errorf("Visibility = %v", pkg.Targets[0].Visibility)
+	}
+}
+
 func TestStarlarkLoader_StdlibModules(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -528,3 +563,120 @@ target(name = "test", command = "echo " + str(d))
 		})
 	}
 }
+
+func TestStarlarkLoader_ReadBuiltins(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWorkspaceRoot := config.Global.WorkspaceRoot
+	config.Global.WorkspaceRoot = tmpDir
+	defer func() { config.Global.WorkspaceRoot = oldWorkspaceRoot }()
+
+	packageDir := filepath.Join(tmpDir, "app")
+	if err := os.MkdirAll(packageDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(packageDir, "package.json"):   `{"name": "web", "scripts": {"build": "vite build", "test": "vitest"}}`,
+		filepath.Join(packageDir, "pyproject.toml"): "[project]\nname = \"api\"\nversion = \"1.2.3\"\n",
+		filepath.Join(packageDir, "deps.yaml"):      "deps:\n  - //lib:a\n  - //lib:b\n",
+		filepath.Join(tmpDir, "VERSION"):            "0.1.0\n",
+		filepath.Join(tmpDir, "macros.star"): `def scripts_targets():
+    pkg = read_json("package.json")
+    for script in sorted(pkg["scripts"].keys()):
+        target(name = pkg["name"] + "_" + script, command = pkg["scripts"][script])
+`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buildFile := filepath.Join(packageDir, "BUILD.star")
+	script := `load("//macros.star", "scripts_targets")
+scripts_targets()
+project = read_toml("pyproject.toml")["project"]
+target(
+    name = project["name"],
+    command = "echo " + project["version"] + " " + read_file("//VERSION").strip(),
+    dependencies = read_yaml("deps.yaml")["deps"],
+)
+`
+	if err := os.WriteFile(buildFile, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, _, err := (StarlarkLoader{}).Load(context.Background(), buildFile)
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+
+	targetsByName := make(map[string]*TargetDTO)
+	for _, target := range pkg.Targets {
+		targetsByName[target.Name] = target
+	}
+	if got := targetsByName["web_build"]; got == nil || got.Command != "vite build" {
+		t.Errorf("web_build = %+v, want command %q", got, "vite build")
+	}
+	if got := targetsByName["web_test"]; got == nil || got.Command != "vitest" {
+		t.Errorf("web_test = %+v, want command %q", got, "vitest")
+	}
+	api := targetsByName["api"]
+	if api == nil {
+		t.Fatalf("target api not defined, got %+v", pkg.Targets)
+	}
+	if api.Command != "echo 1.2.3 0.1.0" {
+		t.Errorf("api command = %q, want %q", api.Command, "echo 1.2.3 0.1.0")
+	}
+	if !reflect.DeepEqual(api.Dependencies, []string{"//lib:a", "//lib:b"}) {
+		t.Errorf("api dependencies = %v", api.Dependencies)
+	}
+
+	expectedDependencies := []string{
+		filepath.Join(tmpDir, "macros.star"),
+		filepath.Join(packageDir, "package.json"),
+		filepath.Join(packageDir, "pyproject.toml"),
+		filepath.Join(tmpDir, "VERSION"),
+		filepath.Join(packageDir, "deps.yaml"),
+	}
+	if !reflect.DeepEqual(pkg.LoaderDependencies, expectedDependencies) {
+		t.Errorf("LoaderDependencies = %v, want %v", pkg.LoaderDependencies, expectedDependencies)
+	}
+}
+
+func TestStarlarkLoader_ReadBuiltinsConfinedToWorkspace(t *testing.T) {
+	outsideDir := t.TempDir()
+	tmpDir := t.TempDir()
+	oldWorkspaceRoot := config.Global.WorkspaceRoot
+	config.Global.WorkspaceRoot = tmpDir
+	defer func() { config.Global.WorkspaceRoot = oldWorkspaceRoot }()
+
+	secretPath := filepath.Join(outsideDir, "secret.json")
+	if err := os.WriteFile(secretPath, []byte(`{"token": "abc"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secretPath, filepath.Join(tmpDir, "link.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"relative escape": `read_file("../` + filepath.Base(outsideDir) + `/secret.json")`,
+		"absolute path":   `read_json("` + secretPath + `")`,
+		"symlink":         `read_json("link.json")`,
+	}
+	for name, statement := range cases {
+		t.Run(name, func(t *testing.T) {
+			buildFile := filepath.Join(tmpDir, "BUILD.star")
+			if err := os.WriteFile(buildFile, []byte(statement+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, _, err := (StarlarkLoader{}).Load(context.Background(), buildFile)
+			if err == nil {
+				t.Fatal("expected reading outside the workspace to fail")
+			}
+			if !strings.Contains(err.Error(), "outside the workspace root") {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
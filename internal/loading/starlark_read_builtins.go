@@ -0,0 +1,209 @@
+package loading
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	gotime "time"
+
+	"github.com/pelletier/go-toml/v2"
+	"go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"gopkg.in/yaml.v3"
+)
+
+// readFileBuiltin implements read_file(path) which returns the file content as a string.
+func (c *starlarkPackageCollector) readFileBuiltin(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	content, err := c.readWorkspaceFile(fn.Name(), args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.String(content), nil
+}
+
+// readJsonBuiltin implements read_json(path). Decoding is delegated to json.decode
+// so that values match what users get when decoding a string themselves.
+func (c *starlarkPackageCollector) readJsonBuiltin(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	content, err := c.readWorkspaceFile(fn.Name(), args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.Call(thread, json.Module.Members["decode"], starlark.Tuple{starlark.String(content)}, nil)
+}
+
+// readYamlBuiltin implements read_yaml(path).
+func (c *starlarkPackageCollector) readYamlBuiltin(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	content, err := c.readWorkspaceFile(fn.Name(), args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded any
+	if err := yaml.Unmarshal([]byte(content), &decoded); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return goValueToStarlark(decoded)
+}
+
+// readTomlBuiltin implements read_toml(path).
+func (c *starlarkPackageCollector) readTomlBuiltin(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	content, err := c.readWorkspaceFile(fn.Name(), args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded map[string]any
+	if err := toml.Unmarshal([]byte(content), &decoded); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+	}
+	return goValueToStarlark(decoded)
+}
+
+// readWorkspaceFile unpacks the path argument of a read_* builtin, confines it
+// to the workspace, records it as a loader dependency and returns its content.
+func (c *starlarkPackageCollector) readWorkspaceFile(builtinName string, args starlark.Tuple, kwargs []starlark.Tuple) (string, error) {
+	var path string
+	if err := starlark.UnpackArgs(builtinName, args, kwargs, "path", &path); err != nil {
+		return "", err
+	}
+
+	absolutePath, err := c.resolveWorkspacePath(path)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", builtinName, err)
+	}
+
+	// Record the dependency before reading so that a watcher also picks up
+	// files that do not exist yet.
+	c.addLoaderDependency(absolutePath)
+
+	content, err := os.ReadFile(absolutePath)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", builtinName, err)
+	}
+	return string(content), nil
+}
+
+// resolveWorkspacePath resolves a read_* path the same way load() does: paths
+// starting with // are relative to the workspace root, everything else is
+// relative to the package directory of the BUILD file being evaluated.
+// Paths that escape the workspace (including through symlinks) are rejected.
+func (c *starlarkPackageCollector) resolveWorkspacePath(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path must not be empty")
+	}
+
+	workspaceRoot := c.workspace.WorkspaceRoot
+	var absolutePath string
+	switch {
+	case strings.HasPrefix(path, "//"):
+		absolutePath = filepath.Join(workspaceRoot, path[2:])
+	case filepath.IsAbs(path):
+		absolutePath = filepath.Clean(path)
+	default:
+		absolutePath = filepath.Join(c.packageDirectory, path)
+	}
+
+	if err := checkWithinWorkspace(workspaceRoot, path, absolutePath); err != nil {
+		return "", err
+	}
+	return absolutePath, nil
+}
+
+// checkWithinWorkspace returns an error if absolutePath (requested as path)
+// lies outside workspaceRoot, including through symlinks.
+func checkWithinWorkspace(workspaceRoot string, path string, absolutePath string) error {
+	if !isWithinDirectory(workspaceRoot, absolutePath) {
+		return fmt.Errorf("%s points outside the workspace root %s", path, workspaceRoot)
+	}
+
+	// Resolve symlinks so that a link inside the workspace cannot be used to
+	// read files outside of it. Missing files are reported by the read itself.
+	resolvedRoot, err := filepath.EvalSymlinks(workspaceRoot)
+	if err != nil {
+		return err
+	}
+	if resolvedPath, err := filepath.EvalSymlinks(absolutePath); err == nil {
+		if !isWithinDirectory(resolvedRoot, resolvedPath) {
+			return fmt.Errorf("%s resolves to %s which is outside the workspace root %s", path, resolvedPath, workspaceRoot)
+		}
+	}
+	return nil
+}
+
+func isWithinDirectory(directory, path string) bool {
+	relativePath, err := filepath.Rel(directory, path)
+	if err != nil {
+		return false
+	}
+	return relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(filepath.Separator))
+}
+
+// goValueToStarlark converts the generic values produced by the yaml and toml
+// decoders into frozen Starlark values. Map keys are sorted so that iteration
+// order does not depend on Go's map ordering.
+func goValueToStarlark(value any) (starlark.Value, error) {
+	switch typed := value.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(typed), nil
+	case string:
+		return starlark.String(typed), nil
+	case int:
+		return starlark.MakeInt(typed), nil
+	case int64:
+		return starlark.MakeInt64(typed), nil
+	case uint64:
+		return starlark.MakeUint64(typed), nil
+	case *big.Int:
+		return starlark.MakeBigInt(typed), nil
+	case float64:
+		return starlark.Float(typed), nil
+	case gotime.Time:
+		return starlark.String(typed.Format(gotime.RFC3339Nano)), nil
+	case toml.LocalDate, toml.LocalTime, toml.LocalDateTime:
+		return starlark.String(fmt.Sprint(typed)), nil
+	case []any:
+		elements := make([]starlark.Value, 0, len(typed))
+		for _, element := range typed {
+			converted, err := goValueToStarlark(element)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, converted)
+		}
+		list := starlark.NewList(elements)
+		list.Freeze()
+		return list, nil
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		dict := starlark.NewDict(len(typed))
+		for _, key := range keys {
+			converted, err := goValueToStarlark(typed[key])
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(starlark.String(key), converted); err != nil {
+				return nil, err
+			}
+		}
+		dict.Freeze()
+		return dict, nil
+	case map[any]any:
+		stringKeyed := make(map[string]any, len(typed))
+		for key, element := range typed {
+			stringKeyed[fmt.Sprint(key)] = element
+		}
+		return goValueToStarlark(stringKeyed)
+	default:
+		return nil, fmt.Errorf("unsupported value of type %T", value)
+	}
+}
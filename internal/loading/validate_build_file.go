@@ -0,0 +1,269 @@
+package loading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"grog/internal/config"
+	"grog/internal/console"
+
+	"gopkg.in/yaml.v3"
+)
+
+// buildFileIssue is a problem found while validating a BUILD file against the
+// BUILD file schema.
+type buildFileIssue struct {
+	line   int
+	column int
+	// unknownKey is set for keys that the loaders would silently ignore.
+	// All other issues are type mismatches that fail decoding anyway.
+	unknownKey bool
+	message    string
+}
+
+// buildFileValidator checks a parsed BUILD.yaml or BUILD.json document
+// against the DTO types and records every problem with its location.
+type buildFileValidator struct {
+	// jsonScalars requires scalars to have the type JSON gives them, e.g. a
+	// number is not accepted as a string. YAML decoding converts scalars
+	// itself and reports the ones it cannot convert.
+	jsonScalars bool
+	issues      []buildFileIssue
+}
+
+// validateBuildFile returns the unknown keys and type mismatches in document.
+func validateBuildFile(document *yaml.Node, jsonScalars bool) []buildFileIssue {
+	validator := &buildFileValidator{jsonScalars: jsonScalars}
+	if document.Kind == yaml.DocumentNode {
+		if len(document.Content) == 0 {
+			return nil
+		}
+		document = document.Content[0]
+	}
+	validator.validate(document, reflect.TypeOf(PackageDTO{}), "", "package")
+	return validator.issues
+}
+
+// validate checks node against valueType. path is the location of the node
+// within owner, the innermost named object (e.g. `target "build"`).
+func (v *buildFileValidator) validate(node *yaml.Node, valueType reflect.Type, path string, owner string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+	if valueType.Kind() == reflect.Pointer {
+		valueType = valueType.Elem()
+	}
+
+	if valueType == reflect.TypeOf(ociPushDestinations{}) {
+		if node.Kind == yaml.ScalarNode {
+			v.validate(node, reflect.TypeOf(""), path, owner)
+			return
+		}
+		if node.Kind != yaml.SequenceNode {
+			v.typeMismatch(node, path, owner, "a string or an array of strings")
+			return
+		}
+	}
+
+	switch valueType.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			v.typeMismatch(node, path, owner, "an object")
+			return
+		}
+		v.validateObject(node, valueType, path, owner)
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			v.typeMismatch(node, path, owner, "an array")
+			return
+		}
+		for index, item := range node.Content {
+			v.validate(item, valueType.Elem(), fmt.Sprintf("%s[%d]", path, index), owner)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			v.typeMismatch(node, path, owner, "an object")
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.validate(node.Content[i+1], valueType.Elem(), path+"."+node.Content[i].Value, owner)
+		}
+	case reflect.Bool:
+		if node.Kind != yaml.ScalarNode || (v.jsonScalars && node.Tag != "!!bool") {
+			v.typeMismatch(node, path, owner, "a boolean")
+		}
+	case reflect.String:
+		if node.Kind != yaml.ScalarNode || (v.jsonScalars && node.Tag != "!!str") {
+			v.typeMismatch(node, path, owner, "a string")
+		}
+	}
+}
+
+func (v *buildFileValidator) validateObject(node *yaml.Node, structType reflect.Type, path string, owner string) {
+	definition := buildFileDefinitions[structType]
+	if definition != "package" {
+		owner = definition
+		if name := mappingValue(node, "name"); name != "" {
+			owner = fmt.Sprintf("%s %q", definition, name)
+		} else if path != "" {
+			owner = path
+		}
+		path = ""
+	}
+
+	fields := buildFileFields(structType)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+
+		if key == "<<" {
+			// YAML merge key: the merged mappings must be valid objects themselves
+			v.validateMerge(valueNode, structType, path, owner)
+			continue
+		}
+		if key == schemaKeyword && definition == "package" {
+			continue
+		}
+
+		index := -1
+		for fieldIndex, field := range fields {
+			if field.key == key {
+				index = fieldIndex
+				break
+			}
+		}
+		if index < 0 {
+			message := fmt.Sprintf("unknown key %q in %s", key, owner)
+			if suggestion := suggestKey(key, fields); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			v.issues = append(v.issues, buildFileIssue{
+				line:       keyNode.Line,
+				column:     keyNode.Column,
+				unknownKey: true,
+				message:    message,
+			})
+			continue
+		}
+
+		v.validate(valueNode, fields[index].fieldType, strings.TrimPrefix(path+"."+key, "."), owner)
+	}
+}
+
+func (v *buildFileValidator) validateMerge(node *yaml.Node, structType reflect.Type, path string, owner string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		v.validateObject(node, structType, path, owner)
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			v.validateMerge(item, structType, path, owner)
+		}
+	}
+}
+
+func (v *buildFileValidator) typeMismatch(node *yaml.Node, path string, owner string, expected string) {
+	subject := owner
+	if path != "" {
+		subject = fmt.Sprintf("%s of %s", path, owner)
+	}
+	v.issues = append(v.issues, buildFileIssue{
+		line:    node.Line,
+		column:  node.Column,
+		message: fmt.Sprintf("%s must be %s, got %s", subject, expected, describeNode(node)),
+	})
+}
+
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "an object"
+	case yaml.SequenceNode:
+		return "an array"
+	}
+	switch node.Tag {
+	case "!!int", "!!float":
+		return "a number"
+	case "!!bool":
+		return "a boolean"
+	default:
+		return "a string"
+	}
+}
+
+// mappingValue returns the scalar value of key in a mapping node.
+func mappingValue(node *yaml.Node, key string) string {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key && node.Content[i+1].Kind == yaml.ScalarNode {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// suggestKey returns the known key closest to an unknown one if it is likely
+// a typo, e.g. "dependency" for "dependencies", or a shortened key such as
+// "expected" for "expected_output".
+func suggestKey(key string, fields []buildFileField) string {
+	key = strings.ToLower(key)
+	for _, field := range fields {
+		if len(key) >= 3 && strings.HasPrefix(field.key, key+"_") {
+			return field.key
+		}
+	}
+
+	best, bestDistance := "", 4
+	for _, field := range fields {
+		distance := editDistance(key, field.key)
+		if distance < bestDistance && distance <= len(field.key)/2 {
+			best, bestDistance = field.key, distance
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// reportBuildFileIssues turns validation issues into an error or warnings.
+// Type mismatches are always errors since the file cannot be decoded. Unknown
+// keys are reported according to the build_file_validation setting.
+func reportBuildFileIssues(ctx context.Context, filePath string, issues []buildFileIssue) error {
+	severity := config.WorkspaceFromContext(ctx).GetBuildFileValidationSeverity()
+	var errs []error
+	for _, issue := range issues {
+		message := fmt.Sprintf("%s:%d:%d: %s", filePath, issue.line, issue.column, issue.message)
+		switch {
+		case !issue.unknownKey || severity == config.SeverityError:
+			errs = append(errs, errors.New(message))
+		case severity == config.SeverityWarn:
+			console.GetLogger(ctx).Warnf("%s", message)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,196 @@
+package loading
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"grog/internal/config"
+	"grog/internal/console"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestValidateBuildFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileName    string
+		content     string
+		expected    []string
+		unknownKeys int
+	}{
+		{
+			name:     "typo in yaml target",
+			fileName: "BUILD.yaml",
+			content: `targets:
+  - name: build
+    command: make
+    dependency:
+      - :gen
+`,
+			expected:    []string{`4:5: unknown key "dependency" in target "build" (did you mean "dependencies"?)`},
+			unknownKeys: 1,
+		},
+		{
+			name:     "unknown keys in nested objects",
+			fileName: "BUILD.yaml",
+			content: `target:
+  - name: build
+aliases:
+  - name: b
+    actual: :build
+    visibilty: public
+resources:
+  - up: start
+    downn: stop
+`,
+			expected: []string{
+				`1:1: unknown key "target" in package (did you mean "targets"?)`,
+				`6:5: unknown key "visibilty" in alias "b"`,
+				`9:5: unknown key "downn" in resources[0] (did you mean "down"?)`,
+			},
+			unknownKeys: 3,
+		},
+		{
+			name:     "yaml type mismatches",
+			fileName: "BUILD.yaml",
+			content: `targets:
+  - name: build
+    inputs: src/**
+    fingerprint:
+      version: [1]
+    output_checks:
+      - command: ./check
+        expected: ok
+`,
+			expected: []string{
+				`3:13: inputs of target "build" must be an array, got a string`,
+				`5:16: fingerprint.version of target "build" must be a string, got an array`,
+				`8:9: unknown key "expected" in output_checks[0] (did you mean "expected_output"?)`,
+			},
+			unknownKeys: 1,
+		},
+		{
+			name:     "yaml anchors and merge keys",
+			fileName: "BUILD.yaml",
+			content: `targets:
+  - &base
+    name: build
+    inputs: [src/**]
+  - <<: *base
+    name: test
+    tag: [slow]
+`,
+			expected:    []string{`7:5: unknown key "tag" in target "test" (did you mean "tags"?)`},
+			unknownKeys: 1,
+		},
+		{
+			name:     "json scalar types",
+			fileName: "BUILD.json",
+			content: `{
+  "$schema": "./build.schema.json",
+  "targets": [
+    {"name": "build", "timeout": 30, "binary_requires_push": "yes", "oci_push": {"app": ["a", 1]}}
+  ]
+}`,
+			expected: []string{
+				`4:34: timeout of target "build" must be a string, got a number`,
+				`4:62: binary_requires_push of target "build" must be a boolean, got a string`,
+				`4:95: oci_push.app[1] of target "build" must be a string, got a number`,
+			},
+		},
+		{
+			name:     "valid file",
+			fileName: "BUILD.json",
+			content:  `{"targets": [{"name": "app", "oci_push": {"app": "registry.org/app"}, "missing_inputs": "error"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.fileName)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var loader Loader = YamlLoader{}
+			if tt.fileName == "BUILD.json" {
+				loader = JsonLoader{}
+			}
+
+			for _, severity := range []string{"ignore", "warn", "error"} {
+				oldConfig := config.Global
+				config.Global.BuildFileValidation = severity
+
+				observedCore, observedLogs := observer.New(zap.WarnLevel)
+				ctx := console.WithLogger(context.Background(), console.NewFromSugared(zap.New(observedCore).Sugar(), zapcore.WarnLevel))
+				_, _, err := loader.Load(ctx, path)
+				config.Global = oldConfig
+
+				var reported []string
+				if err != nil {
+					reported = strings.Split(err.Error(), "\n")
+				}
+				for _, entry := range observedLogs.All() {
+					reported = append(reported, entry.Message)
+				}
+
+				expectedCount := len(tt.expected)
+				if severity == "ignore" {
+					expectedCount -= tt.unknownKeys
+				}
+				if len(reported) != expectedCount {
+					t.Fatalf("%s: expected %d issues, got %d:\n%s", severity, expectedCount, len(reported), strings.Join(reported, "\n"))
+				}
+				if severity == "ignore" {
+					continue
+				}
+				for i, expected := range tt.expected {
+					if reported[i] != path+":"+expected {
+						t.Errorf("%s: issue %d = %q, want %q", severity, i, reported[i], path+":"+expected)
+					}
+				}
+				if severity == "warn" && observedLogs.Len() != tt.unknownKeys {
+					t.Errorf("expected %d warnings, got %d", tt.unknownKeys, observedLogs.Len())
+				}
+			}
+		})
+	}
+}
+
+func TestBuildFileSchema(t *testing.T) {
+	schema := BuildFileSchema()
+	if _, err := json.Marshal(schema); err != nil {
+		t.Fatalf("schema is not serializable: %v", err)
+	}
+
+	definitions := schema["$defs"].(map[string]any)
+	target := definitions["target"].(map[string]any)
+	if target["additionalProperties"] != false {
+		t.Errorf("expected unknown target keys to be rejected")
+	}
+	properties := target["properties"].(map[string]any)
+	for _, field := range buildFileFields(reflect.TypeOf(TargetDTO{})) {
+		property, ok := properties[field.key].(map[string]any)
+		if !ok {
+			t.Fatalf("missing property %s", field.key)
+		}
+		if property["description"] == nil {
+			t.Errorf("property %s has no description", field.key)
+		}
+	}
+
+	ociPush := properties["oci_push"].(map[string]any)["additionalProperties"].(map[string]any)
+	if ociPush["oneOf"] == nil {
+		t.Errorf("expected oci_push destinations to accept a string or a list, got %v", ociPush)
+	}
+	if _, ok := schema["properties"].(map[string]any)["$schema"]; !ok {
+		t.Errorf("expected the $schema key to be allowed")
+	}
+}
@@ -11,12 +11,12 @@ import (
 // YamlLoader implements the Loader interface for JSON files.
 type YamlLoader struct{}
 
-func (j YamlLoader) Matches(fileName string) bool {
+func (j YamlLoader) Matches(_ context.Context, fileName string) bool {
 	return fileName == "BUILD.yaml" || fileName == "BUILD.yml"
 }
 
 // Load reads the file at the specified filePath and unmarshals its content into a model.Package.
-func (j YamlLoader) Load(_ context.Context, filePath string) (PackageDTO, bool, error) {
+func (j YamlLoader) Load(ctx context.Context, filePath string) (PackageDTO, bool, error) {
 	var pkg PackageDTO
 
 	// Open the file.
@@ -26,9 +26,16 @@ func (j YamlLoader) Load(_ context.Context, filePath string) (PackageDTO, bool,
 	}
 	defer file.Close()
 
-	// Decode JSON content.
+	// Decode into a node first to validate it with line and column information.
+	var document yaml.Node
 	decoder := yaml.NewDecoder(file)
-	err = decoder.Decode(&pkg)
+	err = decoder.Decode(&document)
+	if err == nil {
+		if err := reportBuildFileIssues(ctx, filePath, validateBuildFile(&document, false)); err != nil {
+			return pkg, true, err
+		}
+		err = document.Decode(&pkg)
+	}
 	if err != nil {
 		return pkg, true, fmt.Errorf(
 			"failed to decode JSON file %s: %w",
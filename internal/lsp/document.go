@@ -0,0 +1,71 @@
+package lsp
+
+import (
+	"regexp"
+	"strings"
+)
+
+// The helpers in this file work on the raw text of BUILD.yaml, BUILD.json
+// and BUILD.star files. All three write labels as plain or quoted strings and
+// keys as `key:`, `"key":` or `key =`, which is enough to find labels and
+// the key they belong to without parsing each format.
+
+// keyPattern matches a YAML/JSON key or a Starlark keyword argument.
+var keyPattern = regexp.MustCompile(`(?m)(?:^|[\s{,(])"?([A-Za-z_][A-Za-z0-9_]*)"?\s*(?::\s|:$|=[^=])`)
+
+// labelPattern matches fully qualified labels in messages, e.g. //pkg:name.
+var labelPattern = regexp.MustCompile(`//[A-Za-z0-9_\-./]*:[A-Za-z0-9_\-.]+`)
+
+func isLabelChar(char byte) bool {
+	switch {
+	case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z', char >= '0' && char <= '9':
+		return true
+	}
+	return strings.IndexByte("_-./:", char) >= 0
+}
+
+// tokenAt returns the byte range of the label-like token that contains offset.
+func tokenAt(text string, offset int) (int, int) {
+	start, end := offset, offset
+	for start > 0 && isLabelChar(text[start-1]) {
+		start--
+	}
+	for end < len(text) && isLabelChar(text[end]) {
+		end++
+	}
+	return start, end
+}
+
+// inDependencies reports whether offset is within the value of a
+// dependencies key, i.e. where a label is expected.
+func inDependencies(text string, offset int) bool {
+	before := text[:offset]
+	matches := keyPattern.FindAllStringSubmatchIndex(before, -1)
+	if len(matches) == 0 {
+		return false
+	}
+	last := matches[len(matches)-1]
+	if before[last[2]:last[3]] != "dependencies" {
+		return false
+	}
+
+	// Flow style lists (JSON, Starlark, YAML [...]) must still be open.
+	value := before[last[3]:]
+	opened := strings.Count(value, "[")
+	return opened == 0 || opened > strings.Count(value, "]")
+}
+
+// findNameRange returns the byte range of the value of the name key that
+// defines name, e.g. `name: build` or `name = "build"`.
+func findNameRange(text string, name string) (int, int, bool) {
+	pattern := regexp.MustCompile(`\bname"?\s*[:=]\s*["']?(` + regexp.QuoteMeta(name) + `)`)
+	for _, match := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		end := match[3]
+		if end < len(text) && isLabelChar(text[end]) {
+			// Only a prefix of a longer name
+			continue
+		}
+		return match[2], end, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,135 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"grog/internal/completions"
+	"grog/internal/label"
+	"grog/internal/model"
+	"grog/internal/selection"
+)
+
+// completion offers target labels while typing the dependencies of a target,
+// alias or resource.
+func (s *Server) completion(document documentContext) any {
+	result := completionList{Items: []completionItem{}}
+	if !inDependencies(document.text, document.offset) {
+		return result
+	}
+
+	start, _ := tokenAt(document.text, document.offset)
+	toComplete := document.text[start:document.offset]
+	labels, _, err := completions.TargetLabelCompletions(s.ctx, document.packagePath, toComplete, selection.AllTargets)
+	if err != nil {
+		return result
+	}
+
+	replaceRange := rangeAt(document.text, start, document.offset)
+	for _, completion := range labels {
+		kind := completionKindReference
+		if strings.HasSuffix(completion, "/") || strings.HasSuffix(completion, ":") {
+			kind = completionKindFolder
+		}
+		result.Items = append(result.Items, completionItem{
+			Label:    completion,
+			Kind:     kind,
+			TextEdit: &textEdit{Range: replaceRange, NewText: completion},
+		})
+	}
+	return result
+}
+
+// definition jumps from a label to the name of the node in the BUILD file
+// that defines it.
+func (s *Server) definition(document documentContext) any {
+	node, _, _ := s.nodeAt(document)
+	if node == nil {
+		return nil
+	}
+
+	path := sourceFilePath(node)
+	if path == "" {
+		return nil
+	}
+	target := location{URI: pathToURI(path)}
+	if text, err := s.readDocument(path); err == nil {
+		if start, end, found := findNameRange(text, node.GetLabel().Name); found {
+			target.Range = rangeAt(text, start, end)
+		}
+	}
+	return []location{target}
+}
+
+// hover shows the inputs, outputs and direct dependants of the node a label
+// refers to.
+func (s *Server) hover(document documentContext) any {
+	node, nodes, tokenRange := s.nodeAt(document)
+	if node == nil {
+		return nil
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "**%s** (%s)\n", node.GetLabel(), node.GetType())
+	switch typed := node.(type) {
+	case *model.Target:
+		if typed.Command != "" {
+			fmt.Fprintf(&builder, "\n```sh\n%s\n```\n", typed.Command)
+		}
+		writeList(&builder, "Inputs", typed.UnresolvedInputs)
+		var outputs []string
+		for _, output := range typed.AllOutputs() {
+			outputs = append(outputs, output.String())
+		}
+		writeList(&builder, "Outputs", outputs)
+	case *model.Alias:
+		fmt.Fprintf(&builder, "\nAlias for `%s`\n", typed.Actual)
+	case *model.Resource:
+		fmt.Fprintf(&builder, "\n```sh\n%s\n```\n", typed.Up)
+	}
+
+	var dependants []string
+	for _, candidate := range nodes {
+		for _, dependency := range candidate.GetDependencies() {
+			if dependency == node.GetLabel() {
+				dependants = append(dependants, candidate.GetLabel().String())
+				break
+			}
+		}
+	}
+	sort.Strings(dependants)
+	writeList(&builder, "Dependants", dependants)
+
+	return hover{
+		Contents: markupContent{Kind: "markdown", Value: builder.String()},
+		Range:    &tokenRange,
+	}
+}
+
+func writeList(builder *strings.Builder, title string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(builder, "\n**%s**\n", title)
+	for _, value := range values {
+		fmt.Fprintf(builder, "- `%s`\n", value)
+	}
+}
+
+// nodeAt returns the node referenced by the label under the cursor.
+func (s *Server) nodeAt(document documentContext) (model.BuildNode, model.BuildNodeMap, textRange) {
+	start, end := tokenAt(document.text, document.offset)
+	nodeLabel, err := label.ParseTargetLabel(document.packagePath, document.text[start:end])
+	if err != nil {
+		return nil, nil, textRange{}
+	}
+
+	// Stale nodes are still useful while a file fails to load
+	nodes, _ := s.getNodes()
+	node, ok := nodes[nodeLabel]
+	if !ok {
+		return nil, nil, textRange{}
+	}
+	return node, nodes, rangeAt(document.text, start, end)
+}
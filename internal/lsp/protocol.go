@@ -0,0 +1,230 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// This file contains the subset of the Language Server Protocol (3.17) and
+// its JSON-RPC 2.0 framing that grog lsp uses.
+
+const (
+	errorMethodNotFound = -32601
+	errorInvalidParams  = -32602
+)
+
+const (
+	diagnosticError   = 1
+	diagnosticWarning = 2
+)
+
+const (
+	completionKindFolder    = 19
+	completionKindReference = 18
+)
+
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type textRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type location struct {
+	URI   string    `json:"uri"`
+	Range textRange `json:"range"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didSaveParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     position               `json:"position"`
+}
+
+type diagnostic struct {
+	Range    textRange `json:"range"`
+	Severity int       `json:"severity"`
+	Source   string    `json:"source"`
+	Message  string    `json:"message"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+}
+
+type textEdit struct {
+	Range   textRange `json:"range"`
+	NewText string    `json:"newText"`
+}
+
+type completionItem struct {
+	Label    string    `json:"label"`
+	Kind     int       `json:"kind"`
+	TextEdit *textEdit `json:"textEdit,omitempty"`
+}
+
+type completionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []completionItem `json:"items"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    *textRange    `json:"range,omitempty"`
+}
+
+// readMessage reads a single message framed by a Content-Length header.
+func readMessage(reader *bufio.Reader) (*message, error) {
+	header, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header %q", header.Get("Content-Length"))
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, err
+	}
+
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	return &msg, nil
+}
+
+// writeMessage writes msg framed by a Content-Length header.
+func writeMessage(writer io.Writer, msg *message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(writer, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = writer.Write(body)
+	return err
+}
+
+// uriToPath converts a file:// URI into an absolute path.
+func uriToPath(uri string) (string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI scheme %q", parsed.Scheme)
+	}
+	return filepath.FromSlash(parsed.Path), nil
+}
+
+// pathToURI converts an absolute path into a file:// URI.
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// offsetAt returns the byte offset of pos in text. LSP counts characters
+// in UTF-16 code units.
+func offsetAt(text string, pos position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		newline := strings.IndexByte(text[offset:], '\n')
+		if newline < 0 {
+			return len(text)
+		}
+		offset += newline + 1
+	}
+
+	units := 0
+	for index, char := range text[offset:] {
+		if units >= pos.Character || char == '\n' {
+			return offset + index
+		}
+		units++
+		if char >= 0x10000 {
+			units++
+		}
+	}
+	return len(text)
+}
+
+// positionAt is the inverse of offsetAt.
+func positionAt(text string, offset int) position {
+	offset = min(offset, len(text))
+	lineStart := strings.LastIndexByte(text[:offset], '\n') + 1
+	units := 0
+	for _, char := range text[lineStart:offset] {
+		units++
+		if char >= 0x10000 {
+			units++
+		}
+	}
+	return position{Line: strings.Count(text[:offset], "\n"), Character: units}
+}
+
+func rangeAt(text string, start int, end int) textRange {
+	return textRange{Start: positionAt(text, start), End: positionAt(text, end)}
+}
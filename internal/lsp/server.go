@@ -0,0 +1,197 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"grog/internal/config"
+	"grog/internal/console"
+)
+
+// Server is a language server for BUILD files that talks LSP over a reader
+// and a writer, typically stdin and stdout.
+//
+// Completion, go-to-definition and hover work on the open documents.
+// Diagnostics and target information come from the packages on disk, so they
+// are refreshed whenever a document is opened or saved. Unsaved changes are
+// not reflected in diagnostics until the document is saved.
+type Server struct {
+	ctx    context.Context
+	reader *bufio.Reader
+	writer io.Writer
+
+	// documents holds the text of open documents by absolute path.
+	documents map[string]string
+
+	workspace *workspace
+	// published holds the URIs that currently have diagnostics so that they
+	// can be cleared once fixed.
+	published map[string]bool
+}
+
+func NewServer(ctx context.Context, reader io.Reader, writer io.Writer) *Server {
+	return &Server{
+		ctx:       ctx,
+		reader:    bufio.NewReader(reader),
+		writer:    writer,
+		documents: make(map[string]string),
+		workspace: &workspace{},
+		published: make(map[string]bool),
+	}
+}
+
+// Run handles messages until the client sends exit or closes the input.
+func (s *Server) Run() error {
+	logger := console.GetLogger(s.ctx)
+	for {
+		msg, err := readMessage(s.reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		result, handleErr := s.handle(msg)
+		if msg.ID == nil {
+			if handleErr != nil {
+				logger.Warnf("%s: %v", msg.Method, handleErr.Message)
+			}
+			continue
+		}
+
+		response := &message{ID: msg.ID, Error: handleErr}
+		if handleErr == nil {
+			response.Result, err = json.Marshal(result)
+			if err != nil {
+				return err
+			}
+		}
+		if err := writeMessage(s.writer, response); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *message) (any, *responseError) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync": map[string]any{
+					"openClose": true,
+					"change":    1, // full document sync
+					"save":      true,
+				},
+				"completionProvider": map[string]any{"triggerCharacters": []string{":", "/"}},
+				"definitionProvider": true,
+				"hoverProvider":      true,
+			},
+			"serverInfo": map[string]any{"name": "grog"},
+		}, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		path, err := uriToPath(params.TextDocument.URI)
+		if err != nil {
+			return nil, invalidParams(err)
+		}
+		s.documents[path] = params.TextDocument.Text
+		return nil, s.publishDiagnostics()
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		path, err := uriToPath(params.TextDocument.URI)
+		if err != nil {
+			return nil, invalidParams(err)
+		}
+		if len(params.ContentChanges) > 0 {
+			s.documents[path] = params.ContentChanges[len(params.ContentChanges)-1].Text
+		}
+		return nil, nil
+	case "textDocument/didSave":
+		s.workspace.invalidate()
+		return nil, s.publishDiagnostics()
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		path, err := uriToPath(params.TextDocument.URI)
+		if err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(s.documents, path)
+		return nil, nil
+	case "textDocument/completion":
+		return s.withPosition(msg, s.completion)
+	case "textDocument/definition":
+		return s.withPosition(msg, s.definition)
+	case "textDocument/hover":
+		return s.withPosition(msg, s.hover)
+	}
+
+	if msg.ID == nil {
+		// Notifications such as initialized or $/cancelRequest
+		return nil, nil
+	}
+	return nil, &responseError{Code: errorMethodNotFound, Message: fmt.Sprintf("method %s is not supported", msg.Method)}
+}
+
+// documentContext is the open document and cursor position of a request.
+type documentContext struct {
+	path   string
+	text   string
+	offset int
+	// packagePath is the package of the document, e.g. "" for the root.
+	packagePath string
+}
+
+func (s *Server) withPosition(msg *message, handler func(documentContext) any) (any, *responseError) {
+	var params textDocumentPositionParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return nil, invalidParams(err)
+	}
+	path, err := uriToPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	text, err := s.readDocument(path)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+
+	return handler(documentContext{
+		path:        path,
+		text:        text,
+		offset:      offsetAt(text, params.Position),
+		packagePath: packagePathOf(path),
+	}), nil
+}
+
+// packagePathOf returns the package of a BUILD file, e.g. "" for the root.
+func packagePathOf(path string) string {
+	packagePath, err := filepath.Rel(config.Global.WorkspaceRoot, filepath.Dir(path))
+	if err != nil || packagePath == "." {
+		return ""
+	}
+	return filepath.ToSlash(packagePath)
+}
+
+func invalidParams(err error) *responseError {
+	return &responseError{Code: errorInvalidParams, Message: err.Error()}
+}
@@ -0,0 +1,315 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"grog/internal/config"
+	"grog/internal/console"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const yamlBuildFile = `targets:
+  - name: app
+    command: go build ./...
+    inputs: ["**/*.go"]
+    outputs: [file::app]
+    dependencies:
+      - //lib:lib
+`
+
+const jsonBuildFile = `{
+  "targets": [
+    {"name": "lib", "command": "echo lib", "inputs": ["lib.go"], "dependencies": ["//tools:gen"]}
+  ]
+}
+`
+
+const starlarkBuildFile = `target(
+    name = "gen",
+    command = "echo gen",
+    inputs = ["gen.txt"],
+    dependencies = ["//lib:lib"],
+)
+`
+
+// session runs the server on the given client messages and returns the
+// responses by request id and the published diagnostics by URI.
+type session struct {
+	responses   map[int]json.RawMessage
+	diagnostics map[string][]diagnostic
+}
+
+func runSession(t *testing.T, messages ...map[string]any) session {
+	t.Helper()
+	var input bytes.Buffer
+	for _, msg := range append(messages, map[string]any{"method": "exit"}) {
+		msg["jsonrpc"] = "2.0"
+		body, err := json.Marshal(msg)
+		require.NoError(t, err)
+		input.WriteString("Content-Length: " + itoa(len(body)) + "\r\n\r\n")
+		input.Write(body)
+	}
+
+	var output bytes.Buffer
+	logger := console.NewFromSugared(zap.NewNop().Sugar(), zapcore.WarnLevel)
+	ctx := console.WithLogger(context.Background(), logger)
+	require.NoError(t, NewServer(ctx, &input, &output).Run())
+
+	result := session{responses: make(map[int]json.RawMessage), diagnostics: make(map[string][]diagnostic)}
+	reader := bufio.NewReader(&output)
+	for {
+		msg, err := readMessage(reader)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if msg.ID != nil {
+			var id int
+			require.NoError(t, json.Unmarshal(*msg.ID, &id))
+			require.Nil(t, msg.Error, "request %d failed", id)
+			result.responses[id] = msg.Result
+			continue
+		}
+		require.Equal(t, "textDocument/publishDiagnostics", msg.Method)
+		var params publishDiagnosticsParams
+		require.NoError(t, json.Unmarshal(msg.Params, &params))
+		result.diagnostics[params.URI] = params.Diagnostics
+	}
+	return result
+}
+
+func itoa(value int) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func setupWorkspace(t *testing.T, files map[string]string) string {
+	t.Helper()
+	oldConfig := config.Global
+	t.Cleanup(func() { config.Global = oldConfig })
+
+	root := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	}
+	config.Global.WorkspaceRoot = root
+	return root
+}
+
+func didOpen(path string, text string) map[string]any {
+	return map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+		"textDocument": map[string]any{"uri": pathToURI(path), "languageId": "yaml", "version": 1, "text": text},
+	}}
+}
+
+// request builds a position request at the end of the first occurrence of
+// marker in text.
+func request(id int, method string, path string, text string, marker string) map[string]any {
+	offset := strings.Index(text, marker) + len(marker)
+	return map[string]any{"id": id, "method": method, "params": map[string]any{
+		"textDocument": map[string]any{"uri": pathToURI(path)},
+		"position":     positionAt(text, offset),
+	}}
+}
+
+func TestServer(t *testing.T) {
+	root := setupWorkspace(t, map[string]string{
+		"grog.toml":        "",
+		"app/BUILD.yaml":   yamlBuildFile,
+		"app/main.go":      "",
+		"lib/BUILD.json":   jsonBuildFile,
+		"lib/lib.go":       "",
+		"tools/BUILD.star": starlarkBuildFile,
+		"tools/gen.txt":    "",
+	})
+	yamlPath := filepath.Join(root, "app/BUILD.yaml")
+	jsonPath := filepath.Join(root, "lib/BUILD.json")
+	starlarkPath := filepath.Join(root, "tools/BUILD.star")
+
+	result := runSession(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{}},
+		map[string]any{"method": "initialized", "params": map[string]any{}},
+		didOpen(yamlPath, yamlBuildFile),
+		request(2, "textDocument/completion", yamlPath, yamlBuildFile, "- //li"),
+		request(3, "textDocument/completion", yamlPath, yamlBuildFile, "command: go"),
+		request(4, "textDocument/definition", yamlPath, yamlBuildFile, "- //lib:l"),
+		request(5, "textDocument/definition", jsonPath, jsonBuildFile, `"//tools:g`),
+		request(6, "textDocument/hover", starlarkPath, starlarkBuildFile, `"//lib`),
+		request(7, "textDocument/completion", starlarkPath, starlarkBuildFile, `dependencies = ["//lib:`),
+		map[string]any{"id": 8, "method": "shutdown"},
+	)
+
+	var capabilities struct {
+		Capabilities map[string]any `json:"capabilities"`
+	}
+	require.NoError(t, json.Unmarshal(result.responses[1], &capabilities))
+	assert.Equal(t, true, capabilities.Capabilities["hoverProvider"])
+
+	var completion completionList
+	require.NoError(t, json.Unmarshal(result.responses[2], &completion))
+	require.Len(t, completion.Items, 1)
+	assert.Equal(t, "//lib", completion.Items[0].Label)
+	assert.Equal(t, textRange{Start: position{Line: 6, Character: 8}, End: position{Line: 6, Character: 12}}, completion.Items[0].TextEdit.Range)
+
+	require.NoError(t, json.Unmarshal(result.responses[3], &completion))
+	assert.Empty(t, completion.Items, "no completion outside of dependencies")
+
+	require.NoError(t, json.Unmarshal(result.responses[7], &completion))
+	require.NotEmpty(t, completion.Items)
+	assert.Equal(t, "//lib:lib", completion.Items[len(completion.Items)-1].Label)
+
+	var locations []location
+	require.NoError(t, json.Unmarshal(result.responses[4], &locations))
+	assert.Equal(t, []location{{
+		URI:   pathToURI(jsonPath),
+		Range: textRange{Start: position{Line: 2, Character: 14}, End: position{Line: 2, Character: 17}},
+	}}, locations)
+
+	require.NoError(t, json.Unmarshal(result.responses[5], &locations))
+	assert.Equal(t, []location{{
+		URI:   pathToURI(starlarkPath),
+		Range: textRange{Start: position{Line: 1, Character: 12}, End: position{Line: 1, Character: 15}},
+	}}, locations)
+
+	var hoverResult hover
+	require.NoError(t, json.Unmarshal(result.responses[6], &hoverResult))
+	assert.Contains(t, hoverResult.Contents.Value, "**//lib:lib** (target)")
+	assert.Contains(t, hoverResult.Contents.Value, "**Inputs**\n- `lib.go`")
+	assert.Contains(t, hoverResult.Contents.Value, "**Dependants**\n- `//app:app`\n- `//tools:gen`")
+
+	assert.Equal(t, json.RawMessage("null"), result.responses[8])
+}
+
+func TestServerDiagnostics(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected map[string][]string
+	}{
+		{
+			name: "dependency cycle across formats",
+			files: map[string]string{
+				"lib/BUILD.json":   jsonBuildFile,
+				"tools/BUILD.star": starlarkBuildFile,
+			},
+			expected: map[string][]string{
+				"lib/BUILD.json":   {"2:14: cycle detected"},
+				"tools/BUILD.star": {"1:12: cycle detected"},
+			},
+		},
+		{
+			name: "target constraints",
+			files: map[string]string{
+				"app/BUILD.yaml": "targets:\n  - name: build\n    command: ''\n    inputs: [../outside.txt]\n  - name: app_test\n    inputs: [main.go]\n  - name: noop\n    command: 'true'\n",
+			},
+			expected: map[string][]string{
+				"app/BUILD.yaml": {
+					"4:10: target //app:app_test is a test target but has no command",
+					"1:10: input ../outside.txt for target //app:build points outside the package",
+					"6:10: target //app:noop has no inputs",
+				},
+			},
+		},
+		{
+			name: "loading error",
+			files: map[string]string{
+				"app/BUILD.yaml": "targets:\n  - name: app\n    dependency: [\":lib\"]\n",
+			},
+			expected: map[string][]string{
+				"app/BUILD.yaml": {`2:4: unknown key "dependency" in target "app"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.files["grog.toml"] = ""
+			root := setupWorkspace(t, tt.files)
+			config.Global.BuildFileValidation = "error"
+
+			var opened string
+			for name := range tt.expected {
+				opened = name
+			}
+			openedPath := filepath.Join(root, opened)
+			result := runSession(t, didOpen(openedPath, tt.files[opened]))
+
+			require.Len(t, result.diagnostics, len(tt.expected))
+			for name, expected := range tt.expected {
+				diagnostics := result.diagnostics[pathToURI(filepath.Join(root, name))]
+				require.Len(t, diagnostics, len(expected), "%s: %v", name, diagnostics)
+				for i, prefix := range expected {
+					location, message, _ := strings.Cut(prefix, " ")
+					got := diagnostics[i]
+					assert.Equal(t, location, itoa(got.Range.Start.Line)+":"+itoa(got.Range.Start.Character)+":")
+					assert.Contains(t, got.Message, message)
+				}
+			}
+		})
+	}
+}
+
+func TestServerDiagnosticsIgnoreUnsavedChanges(t *testing.T) {
+	root := setupWorkspace(t, map[string]string{
+		"grog.toml":      "",
+		"app/BUILD.yaml": yamlBuildFile,
+		"lib/BUILD.json": strings.ReplaceAll(jsonBuildFile, `, "dependencies": ["//tools:gen"]`, ""),
+	})
+	path := filepath.Join(root, "app", "BUILD.yaml")
+	broken := "targets:\n  - name: app\n    dependency: [\":lib\"]\n"
+
+	result := runSession(t,
+		didOpen(path, yamlBuildFile),
+		map[string]any{"method": "textDocument/didChange", "params": map[string]any{
+			"textDocument":   map[string]any{"uri": pathToURI(path), "version": 2},
+			"contentChanges": []map[string]any{{"text": broken}},
+		}},
+		map[string]any{"method": "textDocument/didSave", "params": map[string]any{
+			"textDocument": map[string]any{"uri": pathToURI(path)},
+		}},
+	)
+
+	// The broken buffer was never written to disk
+	assert.Empty(t, result.diagnostics)
+}
+
+func TestInDependencies(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected bool
+	}{
+		{"dependencies:\n  - :a\n  - :|", true},
+		{"dependencies: [\":a\", \"|", true},
+		{"{\"dependencies\": [\":a\"], \"inputs\": [\"|", false},
+		{"{\"dependencies\": [\":a\"]|", false},
+		{"target(name = \"a\", dependencies=[\"//x:|", true},
+		{"dependencies = [\":a\"],\n    inputs = [\"|", false},
+		{"dependencies:\n  - :a\noutputs:\n  - |", false},
+	}
+	for _, tt := range tests {
+		offset := strings.Index(tt.text, "|")
+		assert.Equal(t, tt.expected, inDependencies(tt.text[:offset], offset), tt.text)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	text := "a: é\nb: 😀x\n"
+	for _, offset := range []int{0, 3, 5, 6, 9, 13, len(text)} {
+		assert.Equal(t, offset, offsetAt(text, positionAt(text, offset)))
+	}
+	assert.Equal(t, position{Line: 1, Character: 5}, positionAt(text, strings.Index(text, "x")))
+}
@@ -0,0 +1,236 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"grog/internal/analysis"
+	"grog/internal/console"
+	"grog/internal/label"
+	"grog/internal/loading"
+	"grog/internal/model"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// workspace caches the packages on disk between document saves. The text of
+// open documents is not overlaid onto the load since loaders such as Pkl and
+// executable BUILD files can only read from disk.
+type workspace struct {
+	loaded bool
+	// nodes keeps the last successfully loaded nodes when loading fails so
+	// that completion and hover keep working while a file is broken.
+	nodes   model.BuildNodeMap
+	loadErr error
+}
+
+func (w *workspace) invalidate() {
+	w.loaded = false
+}
+
+func (s *Server) getNodes() (model.BuildNodeMap, error) {
+	w := s.workspace
+	if w.loaded {
+		return w.nodes, w.loadErr
+	}
+	w.loaded = true
+
+	packages, err := loading.LoadAllPackages(s.ctx)
+	if err == nil {
+		var nodes model.BuildNodeMap
+		nodes, err = model.BuildNodeMapFromPackages(packages)
+		if err == nil {
+			w.nodes = nodes
+		}
+	}
+	w.loadErr = err
+	return w.nodes, w.loadErr
+}
+
+// locationPattern matches file:line:column prefixes in loading errors.
+var locationPattern = regexp.MustCompile(`(/[^\s:]+):(\d+):(\d+)`)
+
+// fileDiagnostics collects diagnostics by absolute file path.
+type fileDiagnostics map[string][]diagnostic
+
+// publishDiagnostics loads the workspace and publishes the loading errors,
+// target constraint violations and graph errors (e.g. cycles) for every
+// affected BUILD file. Files whose problems were fixed get an empty list.
+func (s *Server) publishDiagnostics() *responseError {
+	diagnostics := make(fileDiagnostics)
+
+	nodes, loadErr := s.getNodes()
+	if loadErr != nil {
+		s.addLoadingDiagnostics(diagnostics, loadErr)
+	} else {
+		var warnings []string
+		core := zapcore.RegisterHooks(
+			zapcore.NewCore(zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()), zapcore.AddSync(io.Discard), zapcore.WarnLevel),
+			func(entry zapcore.Entry) error {
+				warnings = append(warnings, entry.Message)
+				return nil
+			})
+		logger := console.NewFromSugared(zap.New(core).Sugar(), zapcore.WarnLevel)
+
+		for _, err := range analysis.CheckTargetConstraints(logger, nodes) {
+			s.addNodeDiagnostic(diagnostics, nodes, err.Error(), diagnosticError, false)
+		}
+		for _, warning := range warnings {
+			s.addNodeDiagnostic(diagnostics, nodes, warning, diagnosticWarning, false)
+		}
+		if _, err := analysis.BuildGraph(nodes); err != nil {
+			isCycle := strings.HasPrefix(err.Error(), "cycle detected")
+			s.addNodeDiagnostic(diagnostics, nodes, err.Error(), diagnosticError, isCycle)
+		}
+	}
+
+	published := make(map[string]bool)
+	paths := make([]string, 0, len(diagnostics))
+	for path := range diagnostics {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		uri := pathToURI(path)
+		published[uri] = true
+		if err := s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics[path]}); err != nil {
+			return &responseError{Code: errorInvalidParams, Message: err.Error()}
+		}
+	}
+	for uri := range s.published {
+		if published[uri] {
+			continue
+		}
+		if err := s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: []diagnostic{}}); err != nil {
+			return &responseError{Code: errorInvalidParams, Message: err.Error()}
+		}
+	}
+	s.published = published
+	return nil
+}
+
+// addLoadingDiagnostics places each loading error at the location it
+// mentions. Errors without a location are shown at the top of every open
+// document since they prevent the whole workspace from loading.
+func (s *Server) addLoadingDiagnostics(diagnostics fileDiagnostics, loadErr error) {
+	for _, line := range strings.Split(loadErr.Error(), "\n") {
+		if match := locationPattern.FindStringSubmatchIndex(line); match != nil {
+			path := line[match[2]:match[3]]
+			if _, err := os.Stat(path); err == nil {
+				lineNumber, _ := strconv.Atoi(line[match[4]:match[5]])
+				column, _ := strconv.Atoi(line[match[6]:match[7]])
+				start := position{Line: max(lineNumber-1, 0), Character: max(column-1, 0)}
+				diagnostics[path] = append(diagnostics[path], diagnostic{
+					Range:    textRange{Start: start, End: start},
+					Severity: diagnosticError,
+					Source:   "grog",
+					Message:  strings.TrimPrefix(strings.TrimSpace(line[match[1]:]), ": "),
+				})
+				continue
+			}
+		}
+
+		for path := range s.documents {
+			diagnostics[path] = append(diagnostics[path], diagnostic{
+				Severity: diagnosticError,
+				Source:   "grog",
+				Message:  line,
+			})
+		}
+	}
+}
+
+// addNodeDiagnostic attaches message to the definition of the first node
+// it mentions, or to every mentioned node when allNodes is set (e.g. for all
+// members of a cycle).
+func (s *Server) addNodeDiagnostic(diagnostics fileDiagnostics, nodes model.BuildNodeMap, message string, severity int, allNodes bool) {
+	seen := make(map[string]bool)
+	for _, mentioned := range labelPattern.FindAllString(message, -1) {
+		if seen[mentioned] {
+			continue
+		}
+		seen[mentioned] = true
+
+		node := findNode(nodes, mentioned)
+		if node == nil {
+			continue
+		}
+		path := sourceFilePath(node)
+		if path == "" {
+			continue
+		}
+
+		var nameRange textRange
+		if text, err := s.readDocument(path); err == nil {
+			if start, end, found := findNameRange(text, node.GetLabel().Name); found {
+				nameRange = rangeAt(text, start, end)
+			}
+		}
+		diagnostics[path] = append(diagnostics[path], diagnostic{
+			Range:    nameRange,
+			Severity: severity,
+			Source:   "grog",
+			Message:  message,
+		})
+		if !allNodes {
+			return
+		}
+	}
+}
+
+// readDocument returns the text of an open document or reads it from disk.
+func (s *Server) readDocument(path string) (string, error) {
+	if text, isOpen := s.documents[path]; isOpen {
+		return text, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (s *Server) notify(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return writeMessage(s.writer, &message{Method: method, Params: data})
+}
+
+// findNode returns the node for a fully qualified label.
+func findNode(nodes model.BuildNodeMap, fullLabel string) model.BuildNode {
+	nodeLabel, err := label.ParseTargetLabel("", fullLabel)
+	if err != nil {
+		return nil
+	}
+	return nodes[nodeLabel]
+}
+
+// sourceFilePath returns the BUILD file that defines node.
+func sourceFilePath(node model.BuildNode) string {
+	var path string
+	switch typed := node.(type) {
+	case *model.Target:
+		path = typed.SourceFilePath
+	case *model.Alias:
+		path = typed.SourceFilePath
+	case *model.Resource:
+		path = typed.SourceFilePath
+	}
+	if path == "" {
+		return ""
+	}
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return absolutePath
+}
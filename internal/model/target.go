@@ -16,6 +16,7 @@ const (
 	TagNoCache            = "no-cache"
 	TagMultiplatformCache = "multiplatform-cache"
 	TagTestOnly           = "testonly"
+	TagRemote             = "remote"
 )
 
 // Target defines a build step that depends on Dependencies (other targets)
@@ -24,6 +25,9 @@ type Target struct {
 	Label label.TargetLabel `json:"label"`
 	// The file in which this target was defined
 	SourceFilePath string `json:"-"`
+	// LoaderDependencies are the absolute paths of the files besides
+	// SourceFilePath that were read while loading it (e.g. Starlark modules)
+	LoaderDependencies []string `json:"-"`
 
 	Command              string              `json:"command"`
 	Dependencies         []label.TargetLabel `json:"dependencies,omitempty"`
@@ -43,6 +47,14 @@ type Target struct {
 	// means fully serialized). Group capacities are configured in grog.toml.
 	ConcurrencyGroup string `json:"concurrency_group,omitempty"`
 
+	// MissingInputs overrides how literal inputs that do not exist are
+	// reported ("ignore", "warn" or "error"). Falls back to grog.toml.
+	MissingInputs string `json:"missing_inputs,omitempty"`
+
+	// Visibility lists the targets that may depend on this target.
+	// nil means public and an empty list means private to the package.
+	Visibility []label.TargetPattern `json:"-"`
+
 	// UnresolvedInputs are the inputs as specified by the user (no glob resolving)
 	UnresolvedInputs []string `json:"-"`
 	// BinOutput is always a path to a binary file
@@ -74,6 +86,22 @@ type Target struct {
 	OutputLoadTime  time.Duration `json:"-"`
 	CacheWriteTime  time.Duration `json:"-"`
 	DepLoadTime     time.Duration `json:"-"`
+
+	// ResourceUsage of the target command for trace collection.
+	ResourceUsage ResourceUsage `json:"-"`
+}
+
+// ResourceUsage is the resource consumption reported by the operating system
+// for a target command including all of its child processes.
+// Fields that the platform does not report are zero.
+type ResourceUsage struct {
+	UserCPU                    time.Duration
+	SystemCPU                  time.Duration
+	MaxRSSBytes                int64
+	BlockInputOps              int64
+	BlockOutputOps             int64
+	VoluntaryContextSwitches   int64
+	InvoluntaryContextSwitches int64
 }
 
 type OutputCheck struct {
@@ -88,6 +116,34 @@ func (t *Target) AllOutputs() []Output {
 	return t.Outputs
 }
 
+// GetMissingInputsSeverity returns the severity for reporting missing input
+// files of this target, falling back to the workspace configuration.
+func (t *Target) GetMissingInputsSeverity() config.Severity {
+	if t.MissingInputs == "" {
+		return config.Global.GetMissingInputsSeverity()
+	}
+	severity, err := config.ParseSeverity("missing_inputs", t.MissingInputs, config.SeverityWarn)
+	if err != nil {
+		// Validated when loading the package
+		return config.SeverityWarn
+	}
+	return severity
+}
+
+// IsVisibleTo reports whether dependant may depend on this target.
+// Targets are always visible within their own package.
+func (t *Target) IsVisibleTo(dependant label.TargetLabel) bool {
+	if t.Visibility == nil || dependant.Package == t.Label.Package {
+		return true
+	}
+	for _, pattern := range t.Visibility {
+		if pattern.Matches(dependant) {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *Target) HasBinOutput() bool {
 	return t.BinOutput.IsSet()
 }
@@ -104,6 +160,12 @@ func (t *Target) IsMultiplatformCache() bool {
 	return t.HasTag(TagMultiplatformCache)
 }
 
+// IsRemote reports whether the target should run on the remote execution
+// server if one is configured.
+func (t *Target) IsRemote() bool {
+	return t.HasTag(TagRemote)
+}
+
 func (t *Target) IsTestOnly() bool {
 	return t.HasTag(TagTestOnly)
 }
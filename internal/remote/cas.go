@@ -0,0 +1,231 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	repb "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/bytestream"
+	"google.golang.org/grpc/codes"
+)
+
+// maxBatchBytes bounds the size of a single batch request so that it stays
+// below the default gRPC message limit of 4 MiB. Larger blobs are
+// transferred with the ByteStream API instead.
+const maxBatchBytes = 4*1024*1024 - 64*1024
+
+// byteStreamChunkBytes is the size of each message when writing a blob with
+// the ByteStream API.
+const byteStreamChunkBytes = 1024 * 1024
+
+// uploadMissing uploads the blobs that the CAS does not have yet.
+func (c *Client) uploadMissing(ctx context.Context, blobs map[string]blob) error {
+	digests := make([]*repb.Digest, 0, len(blobs))
+	for _, key := range sortedKeys(blobs) {
+		digests = append(digests, blobs[key].digest)
+	}
+
+	response, err := c.cas.FindMissingBlobs(ctx, &repb.FindMissingBlobsRequest{
+		InstanceName: c.instanceName,
+		BlobDigests:  digests,
+	})
+	if err != nil {
+		return fmt.Errorf("could not find missing blobs: %w", err)
+	}
+
+	var batch []*repb.BatchUpdateBlobsRequest_Request
+	batchBytes := int64(0)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := c.batchUpdate(ctx, batch)
+		batch, batchBytes = nil, 0
+		return err
+	}
+
+	for _, digest := range response.GetMissingBlobDigests() {
+		missing, ok := blobs[digestKey(digest)]
+		if !ok {
+			return fmt.Errorf("server reported unknown blob %s as missing", digestKey(digest))
+		}
+		data, err := missing.read()
+		if err != nil {
+			return err
+		}
+
+		if digest.GetSizeBytes() > c.maxBatchBytes {
+			if err := c.writeByteStream(ctx, digest, data); err != nil {
+				return err
+			}
+			continue
+		}
+		if batchBytes+digest.GetSizeBytes() > c.maxBatchBytes {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		batch = append(batch, &repb.BatchUpdateBlobsRequest_Request{Digest: digest, Data: data})
+		batchBytes += digest.GetSizeBytes()
+	}
+	return flush()
+}
+
+func (c *Client) batchUpdate(ctx context.Context, requests []*repb.BatchUpdateBlobsRequest_Request) error {
+	response, err := c.cas.BatchUpdateBlobs(ctx, &repb.BatchUpdateBlobsRequest{
+		InstanceName: c.instanceName,
+		Requests:     requests,
+	})
+	if err != nil {
+		return fmt.Errorf("could not upload blobs: %w", err)
+	}
+	for _, blobResponse := range response.GetResponses() {
+		if code := codes.Code(blobResponse.GetStatus().GetCode()); code != codes.OK {
+			return fmt.Errorf("could not upload blob %s: %s: %s",
+				digestKey(blobResponse.GetDigest()), code, blobResponse.GetStatus().GetMessage())
+		}
+	}
+	return nil
+}
+
+func (c *Client) writeByteStream(ctx context.Context, digest *repb.Digest, data []byte) error {
+	stream, err := c.byteStream.Write(ctx)
+	if err != nil {
+		return fmt.Errorf("could not upload blob %s: %w", digestKey(digest), err)
+	}
+
+	resourceName := fmt.Sprintf("%suploads/%s/blobs/%s/%d", c.resourcePrefix(), uuid.NewString(), digest.GetHash(), digest.GetSizeBytes())
+	for offset := 0; ; offset += byteStreamChunkBytes {
+		end := min(offset+byteStreamChunkBytes, len(data))
+		request := &bytestream.WriteRequest{
+			WriteOffset: int64(offset),
+			Data:        data[offset:end],
+			FinishWrite: end == len(data),
+		}
+		if offset == 0 {
+			request.ResourceName = resourceName
+		}
+		if err := stream.Send(request); err != nil {
+			if errors.Is(err, io.EOF) {
+				// The server already has the blob
+				break
+			}
+			return fmt.Errorf("could not upload blob %s: %w", digestKey(digest), err)
+		}
+		if end == len(data) {
+			break
+		}
+	}
+
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return fmt.Errorf("could not upload blob %s: %w", digestKey(digest), err)
+	}
+	return nil
+}
+
+// readBlobs downloads the given blobs and returns their content by digest
+// key.
+func (c *Client) readBlobs(ctx context.Context, digests []*repb.Digest) (map[string][]byte, error) {
+	contents := make(map[string][]byte, len(digests))
+
+	var batch []*repb.Digest
+	batchBytes := int64(0)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := c.batchRead(ctx, batch, contents)
+		batch, batchBytes = nil, 0
+		return err
+	}
+
+	for _, digest := range digests {
+		key := digestKey(digest)
+		if _, done := contents[key]; done {
+			continue
+		}
+		if digest.GetSizeBytes() == 0 {
+			contents[key] = nil
+			continue
+		}
+
+		if digest.GetSizeBytes() > c.maxBatchBytes {
+			data, err := c.readByteStream(ctx, digest)
+			if err != nil {
+				return nil, err
+			}
+			contents[key] = data
+			continue
+		}
+		if batchBytes+digest.GetSizeBytes() > c.maxBatchBytes {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		// Reserve the key so that duplicates are only requested once
+		contents[key] = nil
+		batch = append(batch, digest)
+		batchBytes += digest.GetSizeBytes()
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+func (c *Client) batchRead(ctx context.Context, digests []*repb.Digest, contents map[string][]byte) error {
+	response, err := c.cas.BatchReadBlobs(ctx, &repb.BatchReadBlobsRequest{
+		InstanceName: c.instanceName,
+		Digests:      digests,
+	})
+	if err != nil {
+		return fmt.Errorf("could not download blobs: %w", err)
+	}
+	for _, blobResponse := range response.GetResponses() {
+		if code := codes.Code(blobResponse.GetStatus().GetCode()); code != codes.OK {
+			return fmt.Errorf("could not download blob %s: %s: %s",
+				digestKey(blobResponse.GetDigest()), code, blobResponse.GetStatus().GetMessage())
+		}
+		if digestKey(digestData(blobResponse.GetData())) != digestKey(blobResponse.GetDigest()) {
+			return fmt.Errorf("downloaded blob %s does not match its digest", digestKey(blobResponse.GetDigest()))
+		}
+		contents[digestKey(blobResponse.GetDigest())] = blobResponse.GetData()
+	}
+	return nil
+}
+
+func (c *Client) readByteStream(ctx context.Context, digest *repb.Digest) ([]byte, error) {
+	stream, err := c.byteStream.Read(ctx, &bytestream.ReadRequest{
+		ResourceName: fmt.Sprintf("%sblobs/%s/%d", c.resourcePrefix(), digest.GetHash(), digest.GetSizeBytes()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not download blob %s: %w", digestKey(digest), err)
+	}
+
+	data := make([]byte, 0, digest.GetSizeBytes())
+	for {
+		response, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not download blob %s: %w", digestKey(digest), err)
+		}
+		data = append(data, response.GetData()...)
+	}
+
+	if digestKey(digestData(data)) != digestKey(digest) {
+		return nil, fmt.Errorf("downloaded blob %s does not match its digest", digestKey(digest))
+	}
+	return data, nil
+}
+
+func (c *Client) resourcePrefix() string {
+	if c.instanceName == "" {
+		return ""
+	}
+	return c.instanceName + "/"
+}
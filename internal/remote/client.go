@@ -0,0 +1,291 @@
+// Package remote runs commands on a server implementing the Remote Execution
+// API (REAPI). Inputs are uploaded to the server's content addressable
+// storage (CAS) as a Merkle tree and outputs are downloaded into the local
+// input root once the action completed.
+package remote
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"grog/internal/config"
+
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+	repb "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"google.golang.org/genproto/googleapis/bytestream"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Action is a command to run remotely. All paths are relative to
+// InputRoot.
+type Action struct {
+	// InputRoot is the local directory that is mirrored on the server.
+	// Outputs are downloaded into it as well.
+	InputRoot string
+	// Inputs are files or directories below InputRoot that are uploaded.
+	// Inputs that do not exist are skipped.
+	Inputs []string
+	// Files are additional inputs with the given content that are not read
+	// from disk, e.g. a generated script.
+	Files map[string][]byte
+	// WorkingDirectory is the directory in which the command runs.
+	WorkingDirectory string
+	Arguments        []string
+	Environment      map[string]string
+	// OutputPaths are the files and directories to download, relative to
+	// the working directory.
+	OutputPaths []string
+	Timeout     time.Duration
+	// DoNotCache prevents the server from caching the result.
+	DoNotCache bool
+	// SkipCacheLookup forces the server to run the action again.
+	SkipCacheLookup bool
+}
+
+// Result is the outcome of a remotely executed action.
+type Result struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+	// Cached is true if the server returned a cached result instead of
+	// running the action.
+	Cached bool
+	// TimedOut is true if the action exceeded its timeout.
+	TimedOut bool
+}
+
+// Client talks to a remote execution server.
+type Client struct {
+	conn          *grpc.ClientConn
+	instanceName  string
+	platform      *repb.Platform
+	execution     repb.ExecutionClient
+	cas           repb.ContentAddressableStorageClient
+	byteStream    bytestream.ByteStreamClient
+	maxBatchBytes int64
+}
+
+// Dial creates a client for the configured server. The connection is only
+// established on the first request.
+func Dial(remoteConfig config.RemoteExecutionConfig) (*Client, error) {
+	var transportCredentials credentials.TransportCredentials
+	var target string
+	switch {
+	case strings.HasPrefix(remoteConfig.Address, "grpcs://"):
+		transportCredentials = credentials.NewTLS(&tls.Config{})
+		target = strings.TrimPrefix(remoteConfig.Address, "grpcs://")
+	case strings.HasPrefix(remoteConfig.Address, "grpc://"):
+		transportCredentials = insecure.NewCredentials()
+		target = strings.TrimPrefix(remoteConfig.Address, "grpc://")
+	default:
+		return nil, fmt.Errorf("invalid remote execution address %q: must start with grpc:// or grpcs://", remoteConfig.Address)
+	}
+
+	options := []grpc.DialOption{grpc.WithTransportCredentials(transportCredentials)}
+	if len(remoteConfig.Headers) > 0 {
+		unaryInterceptor, streamInterceptor := headerInterceptors(remoteConfig.Headers)
+		options = append(options,
+			grpc.WithChainUnaryInterceptor(unaryInterceptor),
+			grpc.WithChainStreamInterceptor(streamInterceptor),
+		)
+	}
+
+	conn, err := grpc.NewClient(target, options...)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %w", remoteConfig.Address, err)
+	}
+	return NewClient(conn, remoteConfig.InstanceName, remoteConfig.GetPlatformProperties()), nil
+}
+
+// headerInterceptors add headers to the outgoing metadata of every call
+// while keeping the metadata that is already set, e.g. RequestMetadata.
+func headerInterceptors(headers map[string]string) (grpc.UnaryClientInterceptor, grpc.StreamClientInterceptor) {
+	var pairs []string
+	for name, value := range headers {
+		pairs = append(pairs, name, value)
+	}
+	unary := func(ctx context.Context, method string, request, reply any, conn *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(metadata.AppendToOutgoingContext(ctx, pairs...), method, request, reply, conn, opts...)
+	}
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, conn *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(metadata.AppendToOutgoingContext(ctx, pairs...), desc, conn, method, opts...)
+	}
+	return unary, stream
+}
+
+// NewClient creates a client using an existing connection.
+func NewClient(conn *grpc.ClientConn, instanceName string, platformProperties map[string]string) *Client {
+	platform := &repb.Platform{}
+	for _, name := range sortedKeys(platformProperties) {
+		platform.Properties = append(platform.Properties, &repb.Platform_Property{Name: name, Value: platformProperties[name]})
+	}
+
+	return &Client{
+		conn:          conn,
+		instanceName:  instanceName,
+		platform:      platform,
+		execution:     repb.NewExecutionClient(conn),
+		cas:           repb.NewContentAddressableStorageClient(conn),
+		byteStream:    bytestream.NewByteStreamClient(conn),
+		maxBatchBytes: maxBatchBytes,
+	}
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Execute uploads the inputs of action, runs it on the server and downloads
+// its outputs into the input root. A non-zero exit code is not an error and
+// reported in the result instead.
+func (c *Client) Execute(ctx context.Context, action Action) (*Result, error) {
+	tree := newInputTree()
+	for _, input := range action.Inputs {
+		if err := tree.addPath(action.InputRoot, input); err != nil {
+			return nil, fmt.Errorf("could not add input %s: %w", input, err)
+		}
+	}
+	for _, path := range sortedKeys(action.Files) {
+		if err := tree.addContent(path, action.Files[path], false); err != nil {
+			return nil, err
+		}
+	}
+	inputRootDigest, err := tree.computeRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	command := &repb.Command{
+		Arguments:        action.Arguments,
+		OutputPaths:      action.OutputPaths,
+		WorkingDirectory: action.WorkingDirectory,
+		Platform:         c.platform,
+	}
+	for _, name := range sortedKeys(action.Environment) {
+		command.EnvironmentVariables = append(command.EnvironmentVariables,
+			&repb.Command_EnvironmentVariable{Name: name, Value: action.Environment[name]})
+	}
+	commandBlob, err := marshalBlob(command)
+	if err != nil {
+		return nil, err
+	}
+	tree.blobs[digestKey(commandBlob.digest)] = commandBlob
+
+	actionMessage := &repb.Action{
+		CommandDigest:   commandBlob.digest,
+		InputRootDigest: inputRootDigest,
+		DoNotCache:      action.DoNotCache,
+		Platform:        c.platform,
+	}
+	if action.Timeout > 0 {
+		actionMessage.Timeout = durationpb.New(action.Timeout)
+	}
+	actionBlob, err := marshalBlob(actionMessage)
+	if err != nil {
+		return nil, err
+	}
+	tree.blobs[digestKey(actionBlob.digest)] = actionBlob
+
+	if err := c.uploadMissing(ctx, tree.blobs); err != nil {
+		return nil, err
+	}
+
+	response, err := c.execute(ctx, &repb.ExecuteRequest{
+		InstanceName:    c.instanceName,
+		ActionDigest:    actionBlob.digest,
+		SkipCacheLookup: action.SkipCacheLookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Cached: response.GetCachedResult()}
+	if code := codes.Code(response.GetStatus().GetCode()); code != codes.OK {
+		if code != codes.DeadlineExceeded {
+			return nil, fmt.Errorf("remote execution failed: %s: %s", code, response.GetStatus().GetMessage())
+		}
+		result.TimedOut = true
+	}
+
+	actionResult := response.GetResult()
+	if actionResult == nil {
+		if result.TimedOut {
+			return result, nil
+		}
+		return nil, errors.New("remote execution returned no result")
+	}
+	result.ExitCode = int(actionResult.GetExitCode())
+
+	result.Stdout, result.Stderr, err = c.downloadLogs(ctx, actionResult)
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode == 0 && !result.TimedOut {
+		if err := c.downloadOutputs(ctx, action.InputRoot, action.WorkingDirectory, actionResult); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// operationStream is implemented by the streams of Execute and WaitExecution.
+type operationStream interface {
+	Recv() (*longrunningpb.Operation, error)
+}
+
+// execute runs the Execute call and waits for the operation to complete.
+func (c *Client) execute(ctx context.Context, request *repb.ExecuteRequest) (*repb.ExecuteResponse, error) {
+	var stream operationStream
+	stream, err := c.execution.Execute(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute action: %w", err)
+	}
+
+	var operation *longrunningpb.Operation
+	for {
+		next, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if operation == nil || status.Code(err) != codes.Unavailable {
+				return nil, fmt.Errorf("could not execute action: %w", err)
+			}
+			// The stream broke but the operation continues on the server
+			stream, err = c.execution.WaitExecution(ctx, &repb.WaitExecutionRequest{Name: operation.GetName()})
+			if err != nil {
+				return nil, fmt.Errorf("could not wait for action: %w", err)
+			}
+			continue
+		}
+		operation = next
+		if operation.GetDone() {
+			break
+		}
+	}
+
+	if operation == nil || !operation.GetDone() {
+		return nil, errors.New("remote execution stream ended before the action completed")
+	}
+	if operationError := operation.GetError(); operationError != nil {
+		return nil, fmt.Errorf("remote execution failed: %s: %s", codes.Code(operationError.GetCode()), operationError.GetMessage())
+	}
+
+	response := &repb.ExecuteResponse{}
+	if err := operation.GetResponse().UnmarshalTo(response); err != nil {
+		return nil, fmt.Errorf("could not read execute response: %w", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,289 @@
+package remote
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	repb "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	return string(data)
+}
+
+// newTestAction returns an action that runs script in pkg below a new
+// workspace with an input file and a dependency output.
+func newTestAction(t *testing.T, script string) Action {
+	t.Helper()
+	workspace := t.TempDir()
+	writeTestFile(t, filepath.Join(workspace, "pkg", "input.txt"), "input\n")
+	writeTestFile(t, filepath.Join(workspace, "dep", "out", "dep.txt"), "dep\n")
+	writeTestFile(t, filepath.Join(workspace, "unrelated.txt"), "unrelated\n")
+
+	return Action{
+		InputRoot:        workspace,
+		Inputs:           []string{"pkg/input.txt", "dep/out", "pkg/missing.txt"},
+		Files:            map[string][]byte{".grog/command.sh": []byte(script)},
+		WorkingDirectory: "pkg",
+		Arguments:        []string{"sh", "../.grog/command.sh"},
+		Environment:      map[string]string{"PATH": os.Getenv("PATH"), "GREETING": "hello"},
+		OutputPaths:      []string{"result.txt", "dist"},
+	}
+}
+
+func TestClient_Execute(t *testing.T) {
+	server, client := startFakeServer(t)
+	ctx := context.Background()
+
+	action := newTestAction(t, `
+cat input.txt ../dep/out/dep.txt > result.txt
+test ! -e ../unrelated.txt
+mkdir -p dist/nested dist/empty
+echo "$GREETING" > dist/nested/greeting.txt
+echo stdout
+echo stderr >&2
+`)
+	// A stale output directory is replaced
+	writeTestFile(t, filepath.Join(action.InputRoot, "pkg", "dist", "stale.txt"), "stale")
+
+	result, err := client.Execute(ctx, action)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.ExitCode != 0 || result.Cached || result.TimedOut {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if string(result.Stdout) != "stdout\n" || string(result.Stderr) != "stderr\n" {
+		t.Errorf("unexpected logs: stdout %q stderr %q", result.Stdout, result.Stderr)
+	}
+
+	if got := readTestFile(t, filepath.Join(action.InputRoot, "pkg", "result.txt")); got != "input\ndep\n" {
+		t.Errorf("unexpected result.txt %q", got)
+	}
+	if got := readTestFile(t, filepath.Join(action.InputRoot, "pkg", "dist", "nested", "greeting.txt")); got != "hello\n" {
+		t.Errorf("unexpected greeting.txt %q", got)
+	}
+	if info, err := os.Stat(filepath.Join(action.InputRoot, "pkg", "dist", "empty")); err != nil || !info.IsDir() {
+		t.Errorf("expected empty output directory to be created: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(action.InputRoot, "pkg", "dist", "stale.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected stale output to be removed, got %v", err)
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		result, err := client.Execute(ctx, action)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if !result.Cached {
+			t.Errorf("expected a cached result")
+		}
+		if server.executions != 1 {
+			t.Errorf("expected 1 execution, got %d", server.executions)
+		}
+	})
+
+	t.Run("skip cache lookup", func(t *testing.T) {
+		action := action
+		action.SkipCacheLookup = true
+		result, err := client.Execute(ctx, action)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result.Cached {
+			t.Errorf("expected the action to run again")
+		}
+		if server.executions != 2 {
+			t.Errorf("expected 2 executions, got %d", server.executions)
+		}
+	})
+}
+
+func TestClient_ExecuteFailure(t *testing.T) {
+	_, client := startFakeServer(t)
+
+	action := newTestAction(t, "echo partial > result.txt\necho failed >&2\nexit 3\n")
+	result, err := client.Execute(context.Background(), action)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.ExitCode != 3 {
+		t.Errorf("expected exit code 3, got %d", result.ExitCode)
+	}
+	if string(result.Stderr) != "failed\n" {
+		t.Errorf("unexpected stderr %q", result.Stderr)
+	}
+	if _, err := os.Stat(filepath.Join(action.InputRoot, "pkg", "result.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected outputs of failed actions not to be downloaded, got %v", err)
+	}
+}
+
+func TestClient_ExecuteTimeout(t *testing.T) {
+	_, client := startFakeServer(t)
+
+	action := newTestAction(t, "sleep 5\n")
+	action.Timeout = 100 * time.Millisecond
+	result, err := client.Execute(context.Background(), action)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !result.TimedOut {
+		t.Errorf("expected the action to time out, got %+v", result)
+	}
+}
+
+func TestClient_ExecuteLargeBlobs(t *testing.T) {
+	server, client := startFakeServer(t)
+	// Transfer every blob larger than 16 bytes with the ByteStream API
+	client.maxBatchBytes = 16
+
+	action := newTestAction(t, "cp large.txt result.txt\n")
+	large := strings.Repeat("0123456789", 10)
+	writeTestFile(t, filepath.Join(action.InputRoot, "pkg", "large.txt"), large)
+	action.Inputs = append(action.Inputs, "pkg/large.txt")
+
+	result, err := client.Execute(context.Background(), action)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", result.ExitCode, result.Stderr)
+	}
+	if server.byteStreamWrites == 0 {
+		t.Errorf("expected blobs to be uploaded with the ByteStream API")
+	}
+	if got := readTestFile(t, filepath.Join(action.InputRoot, "pkg", "result.txt")); got != large {
+		t.Errorf("unexpected result.txt %q", got)
+	}
+}
+
+func TestInputTree(t *testing.T) {
+	t.Run("rejects paths outside the input root", func(t *testing.T) {
+		tree := newInputTree()
+		if err := tree.addContent("../outside.txt", nil, false); err == nil {
+			t.Errorf("expected an error for a path outside the input root")
+		}
+	})
+
+	t.Run("rejects conflicting files and directories", func(t *testing.T) {
+		tree := newInputTree()
+		if err := tree.addContent("a", []byte("a"), false); err != nil {
+			t.Fatalf("addContent failed: %v", err)
+		}
+		if err := tree.addContent("a/b", []byte("b"), false); err == nil {
+			t.Errorf("expected an error for a file below a file")
+		}
+	})
+
+	t.Run("digest does not depend on insertion order", func(t *testing.T) {
+		first, second := newInputTree(), newInputTree()
+		for _, path := range []string{"a/x", "b/y", "a/z"} {
+			if err := first.addContent(path, []byte(path), false); err != nil {
+				t.Fatalf("addContent failed: %v", err)
+			}
+		}
+		for _, path := range []string{"a/z", "b/y", "a/x"} {
+			if err := second.addContent(path, []byte(path), false); err != nil {
+				t.Fatalf("addContent failed: %v", err)
+			}
+		}
+		firstDigest, err := first.computeRoot()
+		if err != nil {
+			t.Fatalf("computeRoot failed: %v", err)
+		}
+		secondDigest, err := second.computeRoot()
+		if err != nil {
+			t.Fatalf("computeRoot failed: %v", err)
+		}
+		if digestKey(firstDigest) != digestKey(secondDigest) {
+			t.Errorf("expected equal digests, got %s and %s", digestKey(firstDigest), digestKey(secondDigest))
+		}
+	})
+}
+
+func TestDownloadOutputs(t *testing.T) {
+	t.Run("rejects paths outside the input root", func(t *testing.T) {
+		workspace := t.TempDir()
+		inputRoot := filepath.Join(workspace, "root")
+		client := &Client{}
+		for _, result := range []*repb.ActionResult{
+			{OutputFiles: []*repb.OutputFile{{Path: "../../outside.txt", Digest: digestData([]byte("outside"))}}},
+			{OutputFiles: []*repb.OutputFile{{Path: filepath.Join(workspace, "outside.txt"), Digest: digestData([]byte("outside"))}}},
+			{OutputSymlinks: []*repb.OutputSymlink{{Path: "../../outside.txt", Target: "target"}}},
+			{OutputDirectorySymlinks: []*repb.OutputSymlink{{Path: "..", Target: "target"}}},
+		} {
+			if err := client.downloadOutputs(context.Background(), inputRoot, "pkg", result); err == nil {
+				t.Errorf("expected an error for %v", result)
+			}
+		}
+		if _, err := os.Lstat(filepath.Join(workspace, "outside.txt")); !os.IsNotExist(err) {
+			t.Errorf("expected no file outside the input root, got %v", err)
+		}
+	})
+
+	t.Run("rejects tree entries that are not a single name", func(t *testing.T) {
+		for _, name := range []string{"", ".", "..", "../outside.txt", "nested/file.txt"} {
+			tree := &repb.Tree{Root: &repb.Directory{Files: []*repb.FileNode{{Name: name, Digest: digestData(nil)}}}}
+			if _, err := collectTree(tree, t.TempDir(), make(map[string]*repb.FileNode)); err == nil {
+				t.Errorf("expected an error for file name %q", name)
+			}
+		}
+
+		tree := &repb.Tree{Root: &repb.Directory{Directories: []*repb.DirectoryNode{{Name: "..", Digest: digestData(nil)}}}}
+		if _, err := collectTree(tree, t.TempDir(), make(map[string]*repb.FileNode)); err == nil {
+			t.Errorf("expected an error for directory name ..")
+		}
+	})
+}
+
+func TestHeaderInterceptors(t *testing.T) {
+	unary, stream := headerInterceptors(map[string]string{"Authorization": "Bearer token"})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "42")
+
+	expectMetadata := func(ctx context.Context) {
+		t.Helper()
+		md, _ := metadata.FromOutgoingContext(ctx)
+		if got := md.Get("authorization"); len(got) != 1 || got[0] != "Bearer token" {
+			t.Errorf("expected the configured header, got %v", md)
+		}
+		if got := md.Get("x-request-id"); len(got) != 1 || got[0] != "42" {
+			t.Errorf("expected the metadata of the call to be kept, got %v", md)
+		}
+	}
+
+	err := unary(ctx, "/method", nil, nil, nil, func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		expectMetadata(ctx)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unary interceptor failed: %v", err)
+	}
+	_, err = stream(ctx, &grpc.StreamDesc{}, nil, "/method", func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+		expectMetadata(ctx)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("stream interceptor failed: %v", err)
+	}
+}
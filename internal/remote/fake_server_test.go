@@ -0,0 +1,400 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+	repb "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"google.golang.org/genproto/googleapis/bytestream"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// fakeServer is an in-process implementation of the Execution, CAS and
+// ByteStream services that runs actions in a temporary directory on the
+// local machine.
+type fakeServer struct {
+	repb.UnimplementedExecutionServer
+	repb.UnimplementedContentAddressableStorageServer
+	bytestream.UnimplementedByteStreamServer
+
+	t           *testing.T
+	mu          sync.Mutex
+	blobs       map[string][]byte
+	actionCache map[string]*repb.ActionResult
+	// executions counts the actions that were actually run.
+	executions int
+	// byteStreamWrites counts the blobs uploaded with the ByteStream API.
+	byteStreamWrites int
+}
+
+// startFakeServer starts a fake server and returns a client connected to it.
+func startFakeServer(t *testing.T) (*fakeServer, *Client) {
+	t.Helper()
+	server := &fakeServer{
+		t:           t,
+		blobs:       make(map[string][]byte),
+		actionCache: make(map[string]*repb.ActionResult),
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	repb.RegisterExecutionServer(grpcServer, server)
+	repb.RegisterContentAddressableStorageServer(grpcServer, server)
+	bytestream.RegisterByteStreamServer(grpcServer, server)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///fake",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("could not connect to fake server: %v", err)
+	}
+	client := NewClient(conn, "", map[string]string{"OSFamily": "Linux"})
+	t.Cleanup(func() { _ = client.Close() })
+	return server, client
+}
+
+func (s *fakeServer) put(data []byte) *repb.Digest {
+	digest := digestData(data)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[digestKey(digest)] = data
+	return digest
+}
+
+func (s *fakeServer) get(digest *repb.Digest) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.blobs[digestKey(digest)]
+	return data, ok
+}
+
+func (s *fakeServer) getMessage(digest *repb.Digest, message proto.Message) error {
+	data, ok := s.get(digest)
+	if !ok {
+		return status.Errorf(codes.FailedPrecondition, "missing blob %s", digestKey(digest))
+	}
+	return proto.Unmarshal(data, message)
+}
+
+func (s *fakeServer) FindMissingBlobs(_ context.Context, request *repb.FindMissingBlobsRequest) (*repb.FindMissingBlobsResponse, error) {
+	response := &repb.FindMissingBlobsResponse{}
+	for _, digest := range request.GetBlobDigests() {
+		if _, ok := s.get(digest); !ok {
+			response.MissingBlobDigests = append(response.MissingBlobDigests, digest)
+		}
+	}
+	return response, nil
+}
+
+func (s *fakeServer) BatchUpdateBlobs(_ context.Context, request *repb.BatchUpdateBlobsRequest) (*repb.BatchUpdateBlobsResponse, error) {
+	response := &repb.BatchUpdateBlobsResponse{}
+	for _, blobRequest := range request.GetRequests() {
+		code := codes.OK
+		if digestKey(digestData(blobRequest.GetData())) != digestKey(blobRequest.GetDigest()) {
+			code = codes.InvalidArgument
+		} else {
+			s.put(blobRequest.GetData())
+		}
+		response.Responses = append(response.Responses, &repb.BatchUpdateBlobsResponse_Response{
+			Digest: blobRequest.GetDigest(),
+			Status: &rpcstatus.Status{Code: int32(code)},
+		})
+	}
+	return response, nil
+}
+
+func (s *fakeServer) BatchReadBlobs(_ context.Context, request *repb.BatchReadBlobsRequest) (*repb.BatchReadBlobsResponse, error) {
+	response := &repb.BatchReadBlobsResponse{}
+	for _, digest := range request.GetDigests() {
+		data, ok := s.get(digest)
+		code := codes.OK
+		if !ok {
+			code = codes.NotFound
+		}
+		response.Responses = append(response.Responses, &repb.BatchReadBlobsResponse_Response{
+			Digest: digest,
+			Data:   data,
+			Status: &rpcstatus.Status{Code: int32(code)},
+		})
+	}
+	return response, nil
+}
+
+func (s *fakeServer) Write(stream bytestream.ByteStream_WriteServer) error {
+	var resourceName string
+	var data []byte
+	for {
+		request, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if request.GetResourceName() != "" {
+			resourceName = request.GetResourceName()
+		}
+		data = append(data, request.GetData()...)
+		if request.GetFinishWrite() {
+			break
+		}
+	}
+
+	digest, err := parseResourceDigest(resourceName)
+	if err != nil {
+		return err
+	}
+	if digestKey(digestData(data)) != digestKey(digest) {
+		return status.Errorf(codes.InvalidArgument, "data does not match %s", resourceName)
+	}
+	s.put(data)
+	s.mu.Lock()
+	s.byteStreamWrites++
+	s.mu.Unlock()
+	return stream.SendAndClose(&bytestream.WriteResponse{CommittedSize: int64(len(data))})
+}
+
+func (s *fakeServer) Read(request *bytestream.ReadRequest, stream bytestream.ByteStream_ReadServer) error {
+	digest, err := parseResourceDigest(request.GetResourceName())
+	if err != nil {
+		return err
+	}
+	data, ok := s.get(digest)
+	if !ok {
+		return status.Errorf(codes.NotFound, "missing blob %s", request.GetResourceName())
+	}
+	for offset := 0; offset < len(data); offset += 10 {
+		if err := stream.Send(&bytestream.ReadResponse{Data: data[offset:min(offset+10, len(data))]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseResourceDigest parses the digest at the end of a ByteStream resource
+// name, e.g. "uploads/<uuid>/blobs/<hash>/<size>".
+func parseResourceDigest(resourceName string) (*repb.Digest, error) {
+	parts := strings.Split(resourceName, "/")
+	if len(parts) < 3 || parts[len(parts)-3] != "blobs" {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid resource name %s", resourceName)
+	}
+	var size int64
+	if _, err := fmt.Sscan(parts[len(parts)-1], &size); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid resource name %s", resourceName)
+	}
+	return &repb.Digest{Hash: parts[len(parts)-2], SizeBytes: size}, nil
+}
+
+func (s *fakeServer) Execute(request *repb.ExecuteRequest, stream repb.Execution_ExecuteServer) error {
+	response, err := s.execute(stream.Context(), request)
+	if err != nil {
+		return err
+	}
+	packedResponse, err := anypb.New(response)
+	if err != nil {
+		return err
+	}
+	return stream.Send(&longrunningpb.Operation{
+		Name:   "operations/" + request.GetActionDigest().GetHash(),
+		Done:   true,
+		Result: &longrunningpb.Operation_Response{Response: packedResponse},
+	})
+}
+
+func (s *fakeServer) execute(ctx context.Context, request *repb.ExecuteRequest) (*repb.ExecuteResponse, error) {
+	actionKey := digestKey(request.GetActionDigest())
+	if !request.GetSkipCacheLookup() {
+		s.mu.Lock()
+		cached, ok := s.actionCache[actionKey]
+		s.mu.Unlock()
+		if ok {
+			return &repb.ExecuteResponse{Result: cached, CachedResult: true}, nil
+		}
+	}
+
+	action := &repb.Action{}
+	if err := s.getMessage(request.GetActionDigest(), action); err != nil {
+		return nil, err
+	}
+	command := &repb.Command{}
+	if err := s.getMessage(action.GetCommandDigest(), command); err != nil {
+		return nil, err
+	}
+
+	inputRoot := s.t.TempDir()
+	if err := s.materialize(action.GetInputRootDigest(), inputRoot); err != nil {
+		return nil, err
+	}
+
+	if action.GetTimeout() != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, action.GetTimeout().AsDuration())
+		defer cancel()
+	}
+
+	arguments := command.GetArguments()
+	cmd := exec.CommandContext(ctx, arguments[0], arguments[1:]...)
+	cmd.Dir = filepath.Join(inputRoot, command.GetWorkingDirectory())
+	cmd.Env = []string{}
+	for _, variable := range command.GetEnvironmentVariables() {
+		cmd.Env = append(cmd.Env, variable.GetName()+"="+variable.GetValue())
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	s.mu.Lock()
+	s.executions++
+	s.mu.Unlock()
+
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return &repb.ExecuteResponse{
+			Status: &rpcstatus.Status{Code: int32(codes.DeadlineExceeded), Message: "action timed out"},
+		}, nil
+	}
+
+	result := &repb.ActionResult{StdoutRaw: stdout.Bytes(), StderrDigest: s.put(stderr.Bytes())}
+	var exitError *exec.ExitError
+	if errors.As(runErr, &exitError) {
+		result.ExitCode = int32(exitError.ExitCode())
+	} else if runErr != nil {
+		return nil, runErr
+	}
+
+	for _, outputPath := range command.GetOutputPaths() {
+		absolutePath := filepath.Join(cmd.Dir, outputPath)
+		info, err := os.Stat(absolutePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			data, err := os.ReadFile(absolutePath)
+			if err != nil {
+				return nil, err
+			}
+			result.OutputFiles = append(result.OutputFiles, &repb.OutputFile{
+				Path:         outputPath,
+				Digest:       s.put(data),
+				IsExecutable: info.Mode()&0o111 != 0,
+			})
+			continue
+		}
+
+		treeDigest, err := s.putTree(absolutePath)
+		if err != nil {
+			return nil, err
+		}
+		result.OutputDirectories = append(result.OutputDirectories, &repb.OutputDirectory{Path: outputPath, TreeDigest: treeDigest})
+	}
+
+	if result.ExitCode == 0 && !action.GetDoNotCache() {
+		s.mu.Lock()
+		s.actionCache[actionKey] = result
+		s.mu.Unlock()
+	}
+	return &repb.ExecuteResponse{Result: result}, nil
+}
+
+// materialize writes the directory with the given digest to path.
+func (s *fakeServer) materialize(digest *repb.Digest, path string) error {
+	directory := &repb.Directory{}
+	if err := s.getMessage(digest, directory); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return err
+	}
+	for _, file := range directory.GetFiles() {
+		data, ok := s.get(file.GetDigest())
+		if !ok {
+			return status.Errorf(codes.FailedPrecondition, "missing blob for %s", file.GetName())
+		}
+		mode := os.FileMode(0o644)
+		if file.GetIsExecutable() {
+			mode = 0o755
+		}
+		if err := os.WriteFile(filepath.Join(path, file.GetName()), data, mode); err != nil {
+			return err
+		}
+	}
+	for _, subdirectory := range directory.GetDirectories() {
+		if err := s.materialize(subdirectory.GetDigest(), filepath.Join(path, subdirectory.GetName())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putTree stores the directory at path and its files as a Tree.
+func (s *fakeServer) putTree(path string) (*repb.Digest, error) {
+	tree := &repb.Tree{}
+	var build func(path string) (*repb.Directory, error)
+	build = func(path string) (*repb.Directory, error) {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		directory := &repb.Directory{}
+		for _, entry := range entries {
+			entryPath := filepath.Join(path, entry.Name())
+			if entry.IsDir() {
+				child, err := build(entryPath)
+				if err != nil {
+					return nil, err
+				}
+				childBlob, err := marshalBlob(child)
+				if err != nil {
+					return nil, err
+				}
+				tree.Children = append(tree.Children, child)
+				directory.Directories = append(directory.Directories, &repb.DirectoryNode{Name: entry.Name(), Digest: childBlob.digest})
+				continue
+			}
+			data, err := os.ReadFile(entryPath)
+			if err != nil {
+				return nil, err
+			}
+			directory.Files = append(directory.Files, &repb.FileNode{Name: entry.Name(), Digest: s.put(data)})
+		}
+		return directory, nil
+	}
+
+	root, err := build(path)
+	if err != nil {
+		return nil, err
+	}
+	tree.Root = root
+	treeBlob, err := marshalBlob(tree)
+	if err != nil {
+		return nil, err
+	}
+	return s.put(treeBlob.data), nil
+}
@@ -0,0 +1,211 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	repb "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// downloadLogs returns the stdout and stderr of an action result which are
+// either inlined or stored in the CAS.
+func (c *Client) downloadLogs(ctx context.Context, result *repb.ActionResult) ([]byte, []byte, error) {
+	stdout, stderr := result.GetStdoutRaw(), result.GetStderrRaw()
+
+	var digests []*repb.Digest
+	if len(stdout) == 0 && result.GetStdoutDigest() != nil {
+		digests = append(digests, result.GetStdoutDigest())
+	}
+	if len(stderr) == 0 && result.GetStderrDigest() != nil {
+		digests = append(digests, result.GetStderrDigest())
+	}
+	if len(digests) == 0 {
+		return stdout, stderr, nil
+	}
+
+	contents, err := c.readBlobs(ctx, digests)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(stdout) == 0 && result.GetStdoutDigest() != nil {
+		stdout = contents[digestKey(result.GetStdoutDigest())]
+	}
+	if len(stderr) == 0 && result.GetStderrDigest() != nil {
+		stderr = contents[digestKey(result.GetStderrDigest())]
+	}
+	return stdout, stderr, nil
+}
+
+// downloadOutputs writes the output files, directories and symlinks of
+// result to inputRoot/workingDirectory replacing existing ones.
+func (c *Client) downloadOutputs(ctx context.Context, inputRoot string, workingDirectory string, result *repb.ActionResult) error {
+	outputRoot := filepath.Join(inputRoot, workingDirectory)
+
+	var treeDigests []*repb.Digest
+	for _, directory := range result.GetOutputDirectories() {
+		treeDigests = append(treeDigests, directory.GetTreeDigest())
+	}
+	treeContents, err := c.readBlobs(ctx, treeDigests)
+	if err != nil {
+		return err
+	}
+
+	// Collect every file of the output directories so that all blobs are
+	// downloaded together
+	files := make(map[string]*repb.FileNode)
+	var outputDirectories, directories []string
+	for _, directory := range result.GetOutputDirectories() {
+		tree := &repb.Tree{}
+		if err := proto.Unmarshal(treeContents[digestKey(directory.GetTreeDigest())], tree); err != nil {
+			return fmt.Errorf("could not read output directory %s: %w", directory.GetPath(), err)
+		}
+		directoryPath, err := outputPath(inputRoot, outputRoot, directory.GetPath())
+		if err != nil {
+			return err
+		}
+		outputDirectories = append(outputDirectories, directoryPath)
+		treeDirectories, err := collectTree(tree, directoryPath, files)
+		if err != nil {
+			return fmt.Errorf("could not read output directory %s: %w", directory.GetPath(), err)
+		}
+		directories = append(directories, treeDirectories...)
+	}
+	for _, file := range result.GetOutputFiles() {
+		path, err := outputPath(inputRoot, outputRoot, file.GetPath())
+		if err != nil {
+			return err
+		}
+		files[path] = &repb.FileNode{
+			Digest:       file.GetDigest(),
+			IsExecutable: file.GetIsExecutable(),
+		}
+	}
+
+	var digests []*repb.Digest
+	for _, path := range sortedKeys(files) {
+		digests = append(digests, files[path].GetDigest())
+	}
+	contents, err := c.readBlobs(ctx, digests)
+	if err != nil {
+		return err
+	}
+
+	for _, directory := range outputDirectories {
+		if err := os.RemoveAll(directory); err != nil {
+			return err
+		}
+	}
+	for _, directory := range directories {
+		if err := os.MkdirAll(directory, 0o755); err != nil {
+			return err
+		}
+	}
+	for _, path := range sortedKeys(files) {
+		if err := writeOutputFile(path, contents[digestKey(files[path].GetDigest())], files[path].GetIsExecutable()); err != nil {
+			return err
+		}
+	}
+
+	symlinks := append(append(result.GetOutputSymlinks(), result.GetOutputFileSymlinks()...), result.GetOutputDirectorySymlinks()...)
+	for _, symlink := range symlinks {
+		path, err := outputPath(inputRoot, outputRoot, symlink.GetPath())
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		if err := os.Symlink(symlink.GetTarget(), path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectTree adds the files of tree below directoryPath to files and
+// returns the paths of all directories in the tree including empty ones.
+func collectTree(tree *repb.Tree, directoryPath string, files map[string]*repb.FileNode) ([]string, error) {
+	children := make(map[string]*repb.Directory, len(tree.GetChildren()))
+	for _, child := range tree.GetChildren() {
+		b, err := marshalBlob(child)
+		if err != nil {
+			return nil, err
+		}
+		children[digestKey(b.digest)] = child
+	}
+
+	var directories []string
+	var collect func(directory *repb.Directory, path string) error
+	collect = func(directory *repb.Directory, path string) error {
+		directories = append(directories, path)
+		for _, file := range directory.GetFiles() {
+			if err := checkEntryName(file.GetName()); err != nil {
+				return err
+			}
+			files[filepath.Join(path, file.GetName())] = file
+		}
+		for _, subdirectory := range directory.GetDirectories() {
+			if err := checkEntryName(subdirectory.GetName()); err != nil {
+				return err
+			}
+			child, ok := children[digestKey(subdirectory.GetDigest())]
+			if !ok {
+				return fmt.Errorf("directory %s is missing from the tree", subdirectory.GetName())
+			}
+			if err := collect(child, filepath.Join(path, subdirectory.GetName())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := collect(tree.GetRoot(), directoryPath); err != nil {
+		return nil, err
+	}
+	return directories, nil
+}
+
+// outputPath resolves the path of an output reported by the server against
+// outputRoot. Like inputs, outputs must stay inside the input root so that
+// the server cannot overwrite files outside of the workspace.
+func outputPath(inputRoot string, outputRoot string, relativePath string) (string, error) {
+	if relativePath == "" || filepath.IsAbs(relativePath) {
+		return "", fmt.Errorf("output %q is not inside the input root", relativePath)
+	}
+	path := filepath.Join(outputRoot, relativePath)
+	inputRelativePath, err := filepath.Rel(inputRoot, path)
+	if err != nil || inputRelativePath == "." || !filepath.IsLocal(inputRelativePath) {
+		return "", fmt.Errorf("output %q is not inside the input root", relativePath)
+	}
+	return path, nil
+}
+
+// checkEntryName returns an error unless name is a single path component as
+// required for the files and directories of a tree.
+func checkEntryName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+		return fmt.Errorf("invalid file or directory name %q", name)
+	}
+	return nil
+}
+
+func writeOutputFile(path string, data []byte, executable bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	mode := os.FileMode(0o644)
+	if executable {
+		mode = 0o755
+	}
+	// Remove first so that read-only or differently typed files are replaced
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, mode)
+}
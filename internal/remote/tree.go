@@ -0,0 +1,201 @@
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	repb "github.com/bazelbuild/remote-apis/build/bazel/remote/execution/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// blob is a piece of content addressed by its digest. Files are read from
+// path when they are uploaded instead of being kept in memory.
+type blob struct {
+	digest *repb.Digest
+	data   []byte
+	path   string
+}
+
+func (b blob) read() ([]byte, error) {
+	if b.path == "" {
+		return b.data, nil
+	}
+	return os.ReadFile(b.path)
+}
+
+// digestKey identifies a digest in maps.
+func digestKey(digest *repb.Digest) string {
+	return fmt.Sprintf("%s/%d", digest.GetHash(), digest.GetSizeBytes())
+}
+
+func digestData(data []byte) *repb.Digest {
+	sum := sha256.Sum256(data)
+	return &repb.Digest{Hash: hex.EncodeToString(sum[:]), SizeBytes: int64(len(data))}
+}
+
+func digestFile(path string) (*repb.Digest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return nil, err
+	}
+	return &repb.Digest{Hash: hex.EncodeToString(hash.Sum(nil)), SizeBytes: size}, nil
+}
+
+// marshalBlob serializes message deterministically so that equal messages
+// always have the same digest.
+func marshalBlob(message proto.Message) (blob, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		return blob{}, err
+	}
+	return blob{digest: digestData(data), data: data}, nil
+}
+
+// inputTree is the Merkle tree of an action's input root.
+type inputTree struct {
+	root  *directoryNode
+	blobs map[string]blob
+}
+
+type directoryNode struct {
+	files       map[string]*repb.FileNode
+	directories map[string]*directoryNode
+}
+
+func newDirectoryNode() *directoryNode {
+	return &directoryNode{files: make(map[string]*repb.FileNode), directories: make(map[string]*directoryNode)}
+}
+
+func newInputTree() *inputTree {
+	return &inputTree{root: newDirectoryNode(), blobs: make(map[string]blob)}
+}
+
+// addPath adds the file or directory at rootDirectory/relativePath to the
+// tree. Symlinks are followed and paths that do not exist are skipped like
+// they would be missing for a local run.
+func (t *inputTree) addPath(rootDirectory string, relativePath string) error {
+	absolutePath := filepath.Join(rootDirectory, relativePath)
+	info, err := os.Stat(absolutePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return t.addFile(relativePath, absolutePath, info)
+	}
+
+	return filepath.WalkDir(absolutePath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relativeFilePath, err := filepath.Rel(rootDirectory, path)
+		if err != nil {
+			return err
+		}
+		return t.addFile(relativeFilePath, path, info)
+	})
+}
+
+func (t *inputTree) addFile(relativePath string, absolutePath string, info fs.FileInfo) error {
+	digest, err := digestFile(absolutePath)
+	if err != nil {
+		return err
+	}
+	t.blobs[digestKey(digest)] = blob{digest: digest, path: absolutePath}
+	return t.insert(relativePath, &repb.FileNode{Digest: digest, IsExecutable: info.Mode()&0o111 != 0})
+}
+
+// addContent adds a file with the given content that does not exist on disk.
+func (t *inputTree) addContent(relativePath string, data []byte, executable bool) error {
+	b := blob{digest: digestData(data), data: data}
+	t.blobs[digestKey(b.digest)] = b
+	return t.insert(relativePath, &repb.FileNode{Digest: b.digest, IsExecutable: executable})
+}
+
+func (t *inputTree) insert(relativePath string, file *repb.FileNode) error {
+	cleanPath := filepath.ToSlash(filepath.Clean(relativePath))
+	if cleanPath == "." || strings.HasPrefix(cleanPath, "../") || filepath.IsAbs(relativePath) {
+		return fmt.Errorf("input %s is not inside the input root", relativePath)
+	}
+
+	parts := strings.Split(cleanPath, "/")
+	directory := t.root
+	for _, name := range parts[:len(parts)-1] {
+		if _, isFile := directory.files[name]; isFile {
+			return fmt.Errorf("input %s conflicts with file %s", relativePath, name)
+		}
+		child, ok := directory.directories[name]
+		if !ok {
+			child = newDirectoryNode()
+			directory.directories[name] = child
+		}
+		directory = child
+	}
+
+	name := parts[len(parts)-1]
+	if _, isDirectory := directory.directories[name]; isDirectory {
+		return fmt.Errorf("input %s conflicts with a directory", relativePath)
+	}
+	file.Name = name
+	directory.files[name] = file
+	return nil
+}
+
+// computeRoot serializes every directory of the tree, adds them to the
+// blobs and returns the digest of the root directory.
+func (t *inputTree) computeRoot() (*repb.Digest, error) {
+	return t.computeDirectory(t.root)
+}
+
+func (t *inputTree) computeDirectory(node *directoryNode) (*repb.Digest, error) {
+	directory := &repb.Directory{}
+	for _, name := range sortedKeys(node.files) {
+		directory.Files = append(directory.Files, node.files[name])
+	}
+	for _, name := range sortedKeys(node.directories) {
+		digest, err := t.computeDirectory(node.directories[name])
+		if err != nil {
+			return nil, err
+		}
+		directory.Directories = append(directory.Directories, &repb.DirectoryNode{Name: name, Digest: digest})
+	}
+
+	b, err := marshalBlob(directory)
+	if err != nil {
+		return nil, err
+	}
+	t.blobs[digestKey(b.digest)] = b
+	return b.digest, nil
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
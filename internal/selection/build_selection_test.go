@@ -357,4 +357,56 @@ func TestSelectTargetsForBuild(t *testing.T) {
 			t.Errorf("Expected target8 to be excluded")
 		}
 	})
+
+	t.Run("restricting to labels", func(t *testing.T) {
+		graph := dag.NewDirectedGraph()
+
+		// affected is in the label set while unaffected only matches the pattern.
+		affected := &model.Target{
+			Label: label.TargetLabel{
+				Name:    "affected",
+				Package: "pkg",
+			},
+			Tags: []string{testTag},
+		}
+		unaffected := &model.Target{
+			Label: label.TargetLabel{
+				Name:    "unaffected",
+				Package: "pkg",
+			},
+			Tags: []string{testTag},
+		}
+		// dependency is outside the label set but still selected as a dependency.
+		dependency := &model.Target{
+			Label: label.TargetLabel{
+				Name:    "dependency",
+				Package: "other",
+			},
+		}
+
+		graph.AddNode(affected)
+		graph.AddNode(unaffected)
+		graph.AddNode(dependency)
+		if err := graph.AddEdge(dependency, affected); err != nil {
+			t.Fatalf("failed to add edge: %v", err)
+		}
+
+		selector := New([]label.TargetPattern{pattern}, []string{testTag}, []string{}, AllTargets)
+		selector.Labels = map[label.TargetLabel]bool{affected.Label: true}
+
+		selected, _, err := selector.SelectTargetsForBuild(graph)
+		if err != nil {
+			t.Fatalf("SelectTargetsForBuild returned unexpected error: %v", err)
+		}
+		// The count includes the dependency
+		if selected != 2 {
+			t.Errorf("Expected 2 selected targets, got %d", selected)
+		}
+		if !affected.IsSelected || !dependency.IsSelected {
+			t.Errorf("Expected the affected target and its dependency to be selected")
+		}
+		if unaffected.IsSelected {
+			t.Errorf("Expected the unaffected target not to be selected")
+		}
+	})
 }
@@ -29,3 +29,9 @@ func (s *Selector) FilterNodes(nodes []model.BuildNode) []model.BuildNode {
 func (s *Selector) Match(node model.BuildNode) bool {
 	return s.nodeMatchesFilters(node) && nodeMatchesPlatform(node)
 }
+
+// MatchFilters reports whether node matches the patterns, tags and target
+// type of the selector regardless of the host platform.
+func (s *Selector) MatchFilters(node model.BuildNode) bool {
+	return s.nodeMatchesFilters(node)
+}
@@ -38,6 +38,9 @@ type Selector struct {
 	Tags        []string
 	ExcludeTags []string
 	TargetType  TargetTypeSelection
+	// Labels optionally restricts the selection to the given labels, e.g. to
+	// the targets affected by changes since a revision. nil selects all.
+	Labels map[label.TargetLabel]bool
 }
 
 func New(
@@ -52,6 +55,10 @@ func New(
 func (s *Selector) nodeMatchesFilters(
 	node model.BuildNode,
 ) bool {
+	if s.Labels != nil && !s.Labels[node.GetLabel()] {
+		return false
+	}
+
 	target, ok := node.(*model.Target)
 	if !ok {
 		// For non-Target nodes (like Alias, Environment), still check pattern matching
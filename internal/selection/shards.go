@@ -0,0 +1,174 @@
+package selection
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"grog/internal/dag"
+	"grog/internal/label"
+	"grog/internal/model"
+)
+
+// Shard is one partition of the targets returned by PlanShards.
+type Shard struct {
+	// Index is the 1-based index of the shard.
+	Index int
+	// Targets are the selected targets assigned to the shard in alphabetical
+	// order. Their dependencies are built as part of the shard as usual.
+	Targets []label.TargetLabel
+	// EstimatedMillis is the estimated cost of the targets and of all
+	// dependencies that the shard has to build for them.
+	EstimatedMillis float64
+}
+
+// ParseShard parses a shard reference of the form "i/N" with 1 <= i <= N.
+func ParseShard(value string) (int, int, error) {
+	indexString, countString, found := strings.Cut(value, "/")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid shard %q: expected the form i/N, e.g. 1/4", value)
+	}
+	index, err := strconv.Atoi(indexString)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid shard %q: %w", value, err)
+	}
+	count, err := strconv.Atoi(countString)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid shard %q: %w", value, err)
+	}
+	if count < 1 || index < 1 || index > count {
+		return 0, 0, fmt.Errorf("invalid shard %q: index must be between 1 and the shard count", value)
+	}
+	return index, count, nil
+}
+
+// PlanShards partitions targets into count shards of similar estimated cost.
+//
+// Only targets that no other of the given targets depends on are
+// distributed. Each of them is assigned, most expensive first, to the shard
+// whose load plus the cost of the dependencies it does not build yet is the
+// lowest so that targets sharing dependencies tend to end up in the same
+// shard. Targets that are dependencies of other given targets go to the
+// first shard that builds them anyway. The result only depends on the graph
+// and the costs so every CI job computes the same plan.
+func PlanShards(
+	graph *dag.DirectedTargetGraph,
+	targets []*model.Target,
+	count int,
+	cost func(target *model.Target) float64,
+) []Shard {
+	closures := make(map[label.TargetLabel][]*model.Target, len(targets))
+	isDependency := make(map[label.TargetLabel]bool)
+	for _, target := range targets {
+		closures[target.Label] = targetClosure(graph, target)
+		for _, dependency := range closures[target.Label][1:] {
+			isDependency[dependency.Label] = true
+		}
+	}
+
+	var roots, dependencies []*model.Target
+	for _, target := range targets {
+		if isDependency[target.Label] {
+			dependencies = append(dependencies, target)
+		} else {
+			roots = append(roots, target)
+		}
+	}
+
+	closureCost := func(target *model.Target) float64 {
+		total := 0.0
+		for _, node := range closures[target.Label] {
+			total += cost(node)
+		}
+		return total
+	}
+	slices.SortStableFunc(roots, func(a, b *model.Target) int {
+		if costA, costB := closureCost(a), closureCost(b); costA != costB {
+			if costA > costB {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Label.String(), b.Label.String())
+	})
+
+	shards := make([]Shard, count)
+	built := make([]map[label.TargetLabel]bool, count)
+	for i := range shards {
+		shards[i].Index = i + 1
+		built[i] = make(map[label.TargetLabel]bool)
+	}
+
+	for _, root := range roots {
+		best, bestLoad := 0, 0.0
+		for i := range shards {
+			load := shards[i].EstimatedMillis
+			for _, node := range closures[root.Label] {
+				if !built[i][node.Label] {
+					load += cost(node)
+				}
+			}
+			if i == 0 || load < bestLoad {
+				best, bestLoad = i, load
+			}
+		}
+
+		shards[best].Targets = append(shards[best].Targets, root.Label)
+		shards[best].EstimatedMillis = bestLoad
+		for _, node := range closures[root.Label] {
+			built[best][node.Label] = true
+		}
+	}
+
+	for _, dependency := range dependencies {
+		for i := range shards {
+			if built[i][dependency.Label] {
+				shards[i].Targets = append(shards[i].Targets, dependency.Label)
+				break
+			}
+		}
+	}
+
+	for i := range shards {
+		slices.SortFunc(shards[i].Targets, func(a, b label.TargetLabel) int {
+			return strings.Compare(a.String(), b.String())
+		})
+	}
+	return shards
+}
+
+// targetClosure returns target followed by all of its transitive target
+// dependencies, each once.
+func targetClosure(graph *dag.DirectedTargetGraph, target *model.Target) []*model.Target {
+	closure := []*model.Target{target}
+	visited := map[label.TargetLabel]bool{target.Label: true}
+	queue := []model.BuildNode{target}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, dependency := range graph.GetDependencies(node) {
+			if visited[dependency.GetLabel()] {
+				continue
+			}
+			visited[dependency.GetLabel()] = true
+			queue = append(queue, dependency)
+			if dependencyTarget, ok := dependency.(*model.Target); ok {
+				closure = append(closure, dependencyTarget)
+			}
+		}
+	}
+	return closure
+}
+
+// MatchingTargets returns the targets of the graph that match the selector
+// including the host platform in alphabetical order.
+func (s *Selector) MatchingTargets(graph *dag.DirectedTargetGraph) []*model.Target {
+	var targets []*model.Target
+	for _, node := range graph.GetNodes().NodesAlphabetically() {
+		if target, ok := node.(*model.Target); ok && s.Match(target) {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
@@ -0,0 +1,82 @@
+package selection
+
+import (
+	"reflect"
+	"testing"
+
+	"grog/internal/dag"
+	"grog/internal/label"
+	"grog/internal/model"
+)
+
+func TestParseShard(t *testing.T) {
+	tests := []struct {
+		value         string
+		expectedIndex int
+		expectedCount int
+		expectError   bool
+	}{
+		{value: "1/4", expectedIndex: 1, expectedCount: 4},
+		{value: "4/4", expectedIndex: 4, expectedCount: 4},
+		{value: "0/4", expectError: true},
+		{value: "5/4", expectError: true},
+		{value: "1", expectError: true},
+		{value: "a/4", expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			index, count, err := ParseShard(test.value)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error for %q", test.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if index != test.expectedIndex || count != test.expectedCount {
+				t.Errorf("expected %d/%d, got %d/%d", test.expectedIndex, test.expectedCount, index, count)
+			}
+		})
+	}
+}
+
+func TestPlanShards(t *testing.T) {
+	newTarget := func(name string) *model.Target {
+		return &model.Target{Label: label.TargetLabel{Package: "pkg", Name: name}}
+	}
+	// shared is an expensive dependency of a and b, c is independent and
+	// b_test depends on b.
+	shared, a, b, c, bTest := newTarget("shared"), newTarget("a"), newTarget("b"), newTarget("c"), newTarget("b_test")
+	graph := dag.NewDirectedGraphFromTargets(shared, a, b, c, bTest)
+	for _, edge := range [][2]*model.Target{{shared, a}, {shared, b}, {b, bTest}} {
+		if err := graph.AddEdge(edge[0], edge[1]); err != nil {
+			t.Fatalf("AddEdge failed: %v", err)
+		}
+	}
+
+	costs := map[string]float64{"shared": 100, "a": 10, "b": 10, "c": 120, "b_test": 5}
+	cost := func(target *model.Target) float64 { return costs[target.Label.Name] }
+
+	shards := PlanShards(graph, []*model.Target{a, b, c, bTest}, 2, cost)
+
+	expected := []Shard{
+		{Index: 1, Targets: []label.TargetLabel{c.Label}, EstimatedMillis: 120},
+		{Index: 2, Targets: []label.TargetLabel{a.Label, b.Label, bTest.Label}, EstimatedMillis: 125},
+	}
+	if !reflect.DeepEqual(shards, expected) {
+		t.Errorf("expected %+v, got %+v", expected, shards)
+	}
+
+	t.Run("more shards than targets", func(t *testing.T) {
+		shards := PlanShards(graph, []*model.Target{c}, 3, cost)
+		if len(shards) != 3 {
+			t.Fatalf("expected 3 shards, got %d", len(shards))
+		}
+		if len(shards[0].Targets) != 1 || len(shards[1].Targets) != 0 || len(shards[2].Targets) != 0 {
+			t.Errorf("expected only the first shard to have targets, got %+v", shards)
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// chromeEvent is a single event of the Trace Event Format understood by
+// chrome://tracing and ui.perfetto.dev. Timestamps are in microseconds.
+type chromeEvent struct {
+	Name     string         `json:"name"`
+	Category string         `json:"cat,omitempty"`
+	Phase    string         `json:"ph"`
+	Ts       int64          `json:"ts"`
+	Dur      int64          `json:"dur,omitempty"`
+	Pid      int            `json:"pid"`
+	Tid      int            `json:"tid"`
+	ID       string         `json:"id,omitempty"`
+	Args     map[string]any `json:"args,omitempty"`
+}
+
+// chromeBuildTid is the lane of the build itself. Worker lanes start at 1.
+const chromeBuildTid = 0
+
+// ExportChrome streams traces as a Trace Event Format JSON document. Every
+// build becomes a process whose timestamps start at zero. Targets are placed
+// on one lane per worker slot with their phases nested below them, while
+// hashing and queue wait, which happen before a worker picks up a target,
+// are shown as async slices.
+func ExportChrome(ctx context.Context, loader SpanLoader, builds []BuildRow, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	if _, err := bw.WriteString("{\"displayTimeUnit\":\"ms\",\"traceEvents\":["); err != nil {
+		return err
+	}
+
+	first := true
+	pid := 0
+	err := forEachChunk(ctx, loader, builds, func(b BuildRow, spans []SpanRow) error {
+		pid++
+		for _, event := range buildChromeEvents(pid, &BuildTrace{Build: b, Spans: spans}) {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("encode trace %s: %w", b.TraceID, err)
+			}
+			if !first {
+				if err := bw.WriteByte(','); err != nil {
+					return err
+				}
+			}
+			first = false
+			if _, err := bw.WriteString("\n"); err != nil {
+				return err
+			}
+			if _, err := bw.Write(data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = bw.WriteString("\n]}\n")
+	return err
+}
+
+// spanPhase is a timed part of a span.
+type spanPhase struct {
+	name   string
+	millis int64
+}
+
+// workerPhases returns the phases of a span in the order in which they run
+// on the worker.
+func workerPhases(s SpanRow) []spanPhase {
+	return []spanPhase{
+		{"cache check", s.CacheCheckMillis},
+		{"dependency load", s.DepLoadMillis},
+		{"output load", s.OutputLoadMillis},
+		{"command", s.CommandDurationMillis},
+		{"output write", s.OutputWriteMillis},
+		{"cache write", s.CacheWriteMillis},
+	}
+}
+
+// workerMillis returns how long a span occupied its worker.
+func workerMillis(s SpanRow) int64 {
+	var total int64
+	for _, phase := range workerPhases(s) {
+		total += phase.millis
+	}
+	return total
+}
+
+// assignWorkerLanes assigns each span with a start time to the lowest lane
+// that is free when the span starts and returns the lanes by span index.
+// Since a worker runs one target at a time this reconstructs the worker
+// slots.
+func assignWorkerLanes(spans []SpanRow) map[int]int {
+	var order []int
+	for i, s := range spans {
+		if s.StartTimeUnixMillis > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		sa, sb := spans[order[a]], spans[order[b]]
+		if sa.StartTimeUnixMillis != sb.StartTimeUnixMillis {
+			return sa.StartTimeUnixMillis < sb.StartTimeUnixMillis
+		}
+		return sa.Label < sb.Label
+	})
+
+	lanes := make(map[int]int, len(order))
+	var laneEnds []int64
+	for _, i := range order {
+		start := spans[i].StartTimeUnixMillis
+		end := start + workerMillis(spans[i])
+		lane := -1
+		for l, laneEnd := range laneEnds {
+			if laneEnd <= start {
+				lane = l
+				break
+			}
+		}
+		if lane == -1 {
+			lane = len(laneEnds)
+			laneEnds = append(laneEnds, 0)
+		}
+		laneEnds[lane] = end
+		lanes[i] = lane + 1
+	}
+	return lanes
+}
+
+func buildChromeEvents(pid int, trace *BuildTrace) []chromeEvent {
+	origin := trace.Build.StartTimeUnixMillis
+	micros := func(unixMillis int64) int64 { return (unixMillis - origin) * 1000 }
+	// Complete events need a duration to be rendered
+	duration := func(millis int64) int64 { return max(millis*1000, 1) }
+
+	events := []chromeEvent{
+		{Name: "process_name", Phase: "M", Pid: pid, Args: map[string]any{
+			"name": fmt.Sprintf("grog %s (%s)", trace.Build.Command, trace.Build.TraceID),
+		}},
+		{Name: "process_sort_index", Phase: "M", Pid: pid, Args: map[string]any{"sort_index": pid}},
+		{Name: "thread_name", Phase: "M", Pid: pid, Tid: chromeBuildTid, Args: map[string]any{"name": "build"}},
+		{
+			Name:     fmt.Sprintf("grog %s", trace.Build.Command),
+			Category: "build",
+			Phase:    "X",
+			Ts:       0,
+			Dur:      duration(trace.Build.TotalDurationMillis),
+			Pid:      pid,
+			Tid:      chromeBuildTid,
+			Args: map[string]any{
+				"git_commit":         trace.Build.GitCommit,
+				"git_branch":         trace.Build.GitBranch,
+				"total_targets":      trace.Build.TotalTargets,
+				"cache_hit_count":    trace.Build.CacheHitCount,
+				"failure_count":      trace.Build.FailureCount,
+				"requested_patterns": trace.Build.RequestedPatterns,
+			},
+		},
+	}
+
+	if trace.Build.AsyncCacheWaitMillis > 0 {
+		end := origin + trace.Build.TotalDurationMillis
+		events = append(events, chromeEvent{
+			Name:     "async cache writes",
+			Category: "cache",
+			Phase:    "X",
+			Ts:       micros(end - trace.Build.AsyncCacheWaitMillis),
+			Dur:      duration(trace.Build.AsyncCacheWaitMillis),
+			Pid:      pid,
+			Tid:      chromeBuildTid,
+		})
+	}
+
+	lanes := assignWorkerLanes(trace.Spans)
+	laneCount := 0
+	for _, lane := range lanes {
+		laneCount = max(laneCount, lane)
+	}
+	for lane := 1; lane <= laneCount; lane++ {
+		events = append(events, chromeEvent{
+			Name: "thread_name", Phase: "M", Pid: pid, Tid: lane,
+			Args: map[string]any{"name": fmt.Sprintf("worker %d", lane)},
+		})
+	}
+
+	for i, s := range trace.Spans {
+		lane, ok := lanes[i]
+		if !ok {
+			continue
+		}
+
+		// Hashing and queue wait precede the start on the worker
+		asyncID := fmt.Sprintf("%d-%d", pid, i)
+		schedulingEnd := s.StartTimeUnixMillis
+		for _, phase := range []spanPhase{{"queue wait", s.QueueWaitMillis}, {"hashing", s.HashDurationMillis}} {
+			if phase.millis <= 0 {
+				continue
+			}
+			schedulingStart := schedulingEnd - phase.millis
+			events = append(events,
+				chromeEvent{Name: phase.name, Category: "scheduling", Phase: "b", Ts: micros(schedulingStart), Pid: pid, Tid: lane, ID: asyncID, Args: map[string]any{"label": s.Label}},
+				chromeEvent{Name: phase.name, Category: "scheduling", Phase: "e", Ts: micros(schedulingEnd), Pid: pid, Tid: lane, ID: asyncID},
+			)
+			schedulingEnd = schedulingStart
+		}
+
+		events = append(events, chromeEvent{
+			Name:     s.Label,
+			Category: "target",
+			Phase:    "X",
+			Ts:       micros(s.StartTimeUnixMillis),
+			Dur:      duration(workerMillis(s)),
+			Pid:      pid,
+			Tid:      lane,
+			Args: map[string]any{
+				"status":       s.Status,
+				"cache_result": s.CacheResult,
+				"change_hash":  s.ChangeHash,
+				"exit_code":    s.ExitCode,
+				"is_test":      s.IsTest,
+			},
+		})
+
+		phaseStart := s.StartTimeUnixMillis
+		for _, phase := range workerPhases(s) {
+			if phase.millis <= 0 {
+				continue
+			}
+			events = append(events, chromeEvent{
+				Name:     phase.name,
+				Category: "phase",
+				Phase:    "X",
+				Ts:       micros(phaseStart),
+				Dur:      duration(phase.millis),
+				Pid:      pid,
+				Tid:      lane,
+			})
+			phaseStart += phase.millis
+		}
+	}
+	return events
+}
@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+type staticSpanLoader map[string][]SpanRow
+
+func (l staticSpanLoader) LoadSpansForTraces(_ context.Context, traceIDs []string) (map[string][]SpanRow, error) {
+	result := make(map[string][]SpanRow, len(traceIDs))
+	for _, id := range traceIDs {
+		result[id] = l[id]
+	}
+	return result, nil
+}
+
+func TestAssignWorkerLanes(t *testing.T) {
+	spans := []SpanRow{
+		{Label: "//:a", StartTimeUnixMillis: 1000, CommandDurationMillis: 500},
+		{Label: "//:b", StartTimeUnixMillis: 1000, CommandDurationMillis: 200},
+		{Label: "//:c", StartTimeUnixMillis: 1200, CommandDurationMillis: 100},
+		{Label: "//:d", StartTimeUnixMillis: 1300, CommandDurationMillis: 100},
+		{Label: "//:skipped"},
+	}
+
+	lanes := assignWorkerLanes(spans)
+
+	expected := map[int]int{0: 1, 1: 2, 2: 2, 3: 2}
+	if len(lanes) != len(expected) {
+		t.Fatalf("expected %d lanes, got %v", len(expected), lanes)
+	}
+	for i, lane := range expected {
+		if lanes[i] != lane {
+			t.Errorf("expected %s on lane %d, got %d", spans[i].Label, lane, lanes[i])
+		}
+	}
+}
+
+func TestExportChrome(t *testing.T) {
+	trace := makeTestTrace("trace-1", 10_000, "build")
+	trace.Build.AsyncCacheWaitMillis = 300
+	trace.Spans[0].CacheCheckMillis = 10
+	trace.Spans[0].OutputWriteMillis = 40
+
+	var buf bytes.Buffer
+	loader := staticSpanLoader{"trace-1": trace.Spans}
+	if err := ExportChrome(context.Background(), loader, []BuildRow{trace.Build}, &buf); err != nil {
+		t.Fatalf("ExportChrome failed: %v", err)
+	}
+
+	var document struct {
+		TraceEvents []chromeEvent `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &document); err != nil {
+		t.Fatalf("export is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	events := make(map[string]chromeEvent)
+	for _, event := range document.TraceEvents {
+		if event.Phase != "M" && event.Phase != "e" {
+			events[event.Name] = event
+		}
+	}
+
+	expected := map[string]struct {
+		phase string
+		tid   int
+		ts    int64
+		dur   int64
+	}{
+		"grog build":         {"X", chromeBuildTid, 0, 5_000_000},
+		"async cache writes": {"X", chromeBuildTid, 4_700_000, 300_000},
+		"//pkg:target":       {"X", 1, 100_000, 1_550_000},
+		"cache check":        {"X", 1, 100_000, 10_000},
+		"command":            {"X", 1, 110_000, 1_500_000},
+		"output write":       {"X", 1, 1_610_000, 40_000},
+		"queue wait":         {"b", 1, -100_000, 0},
+		"hashing":            {"b", 1, -150_000, 0},
+	}
+	for name, want := range expected {
+		event, ok := events[name]
+		if !ok {
+			t.Errorf("missing event %q", name)
+			continue
+		}
+		if event.Phase != want.phase || event.Tid != want.tid || event.Ts != want.ts || event.Dur != want.dur {
+			t.Errorf("unexpected event %q: %+v", name, event)
+		}
+	}
+}
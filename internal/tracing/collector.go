@@ -184,5 +184,15 @@ func (c *TraceCollector) buildSpan(target *model.Target, completion *dag.Complet
 	span.CacheWriteMillis = target.CacheWriteTime.Milliseconds()
 	span.DepLoadMillis = target.DepLoadTime.Milliseconds()
 
+	// Resource usage
+	usage := target.ResourceUsage
+	span.UserCPUMillis = usage.UserCPU.Milliseconds()
+	span.SystemCPUMillis = usage.SystemCPU.Milliseconds()
+	span.MaxRSSBytes = usage.MaxRSSBytes
+	span.BlockInputOps = usage.BlockInputOps
+	span.BlockOutputOps = usage.BlockOutputOps
+	span.VoluntaryContextSwitches = usage.VoluntaryContextSwitches
+	span.InvoluntaryContextSwitches = usage.InvoluntaryContextSwitches
+
 	return span
 }
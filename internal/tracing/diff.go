@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"sort"
+)
+
+// DiffOptions configures which command duration changes count as a
+// regression.
+type DiffOptions struct {
+	// Threshold is the minimum relative increase, e.g. 0.2 for 20%.
+	Threshold float64
+	// MinDeltaMillis is the minimum absolute increase which filters out
+	// noise of fast targets.
+	MinDeltaMillis int64
+}
+
+// MetricDelta is a build level metric of two traces.
+type MetricDelta struct {
+	Base  int64 `json:"base"`
+	Head  int64 `json:"head"`
+	Delta int64 `json:"delta"`
+}
+
+func newMetricDelta(base, head int64) MetricDelta {
+	return MetricDelta{Base: base, Head: head, Delta: head - base}
+}
+
+// CacheMissDiff is a target that was a cache hit in the base trace and a
+// cache miss in the head trace.
+type CacheMissDiff struct {
+	Label          string `json:"label"`
+	BaseChangeHash string `json:"base_change_hash"`
+	HeadChangeHash string `json:"head_change_hash"`
+	// HeadCommandMillis is the command duration of the head trace which
+	// the cache hit would have saved.
+	HeadCommandMillis int64 `json:"head_command_millis"`
+}
+
+// ChangeHashChanged reports whether the target definition or inputs changed.
+// Otherwise the cache entry was missing, e.g. because it was evicted.
+func (d CacheMissDiff) ChangeHashChanged() bool {
+	return d.BaseChangeHash != d.HeadChangeHash
+}
+
+// DurationDiff is a target whose command got slower.
+type DurationDiff struct {
+	Label      string  `json:"label"`
+	BaseMillis int64   `json:"base_millis"`
+	HeadMillis int64   `json:"head_millis"`
+	Delta      int64   `json:"delta_millis"`
+	Ratio      float64 `json:"ratio"`
+}
+
+// TraceDiff compares a head trace with a base trace target by target.
+type TraceDiff struct {
+	BaseTraceID       string          `json:"base_trace_id"`
+	HeadTraceID       string          `json:"head_trace_id"`
+	TotalDuration     MetricDelta     `json:"total_duration_millis"`
+	CriticalPathExec  MetricDelta     `json:"critical_path_exec_millis"`
+	CriticalPathCache MetricDelta     `json:"critical_path_cache_millis"`
+	AsyncCacheWait    MetricDelta     `json:"async_cache_wait_millis"`
+	CacheHits         MetricDelta     `json:"cache_hit_count"`
+	CacheMisses       []CacheMissDiff `json:"cache_misses"`
+	Regressions       []DurationDiff  `json:"regressions"`
+	Added             []string        `json:"added"`
+	Removed           []string        `json:"removed"`
+}
+
+// DiffTraces joins the spans of two traces by label. It reports targets
+// that switched from cache hit to miss, targets whose command duration
+// regressed according to opts and targets that only exist in one trace.
+func DiffTraces(base, head *BuildTrace, opts DiffOptions) *TraceDiff {
+	diff := &TraceDiff{
+		BaseTraceID:       base.Build.TraceID,
+		HeadTraceID:       head.Build.TraceID,
+		TotalDuration:     newMetricDelta(base.Build.TotalDurationMillis, head.Build.TotalDurationMillis),
+		CriticalPathExec:  newMetricDelta(base.Build.CriticalPathExecMillis, head.Build.CriticalPathExecMillis),
+		CriticalPathCache: newMetricDelta(base.Build.CriticalPathCacheMillis, head.Build.CriticalPathCacheMillis),
+		AsyncCacheWait:    newMetricDelta(base.Build.AsyncCacheWaitMillis, head.Build.AsyncCacheWaitMillis),
+		CacheHits:         newMetricDelta(int64(base.Build.CacheHitCount), int64(head.Build.CacheHitCount)),
+		CacheMisses:       []CacheMissDiff{},
+		Regressions:       []DurationDiff{},
+		Added:             []string{},
+		Removed:           []string{},
+	}
+
+	baseSpans := make(map[string]SpanRow, len(base.Spans))
+	for _, s := range base.Spans {
+		baseSpans[s.Label] = s
+	}
+	headSpans := make(map[string]SpanRow, len(head.Spans))
+	for _, s := range head.Spans {
+		headSpans[s.Label] = s
+	}
+
+	for label, headSpan := range headSpans {
+		baseSpan, ok := baseSpans[label]
+		if !ok {
+			diff.Added = append(diff.Added, label)
+			continue
+		}
+
+		if baseSpan.CacheResult == "CACHE_HIT" && headSpan.CacheResult == "CACHE_MISS" {
+			diff.CacheMisses = append(diff.CacheMisses, CacheMissDiff{
+				Label:             label,
+				BaseChangeHash:    baseSpan.ChangeHash,
+				HeadChangeHash:    headSpan.ChangeHash,
+				HeadCommandMillis: headSpan.CommandDurationMillis,
+			})
+		}
+
+		// Only compare targets whose command ran in both traces
+		baseMillis, headMillis := baseSpan.CommandDurationMillis, headSpan.CommandDurationMillis
+		if baseMillis <= 0 || headMillis <= 0 {
+			continue
+		}
+		delta := headMillis - baseMillis
+		ratio := float64(headMillis) / float64(baseMillis)
+		if delta > opts.MinDeltaMillis && ratio > 1+opts.Threshold {
+			diff.Regressions = append(diff.Regressions, DurationDiff{
+				Label:      label,
+				BaseMillis: baseMillis,
+				HeadMillis: headMillis,
+				Delta:      delta,
+				Ratio:      ratio,
+			})
+		}
+	}
+	for label := range baseSpans {
+		if _, ok := headSpans[label]; !ok {
+			diff.Removed = append(diff.Removed, label)
+		}
+	}
+
+	sort.Slice(diff.CacheMisses, func(i, j int) bool {
+		if diff.CacheMisses[i].HeadCommandMillis != diff.CacheMisses[j].HeadCommandMillis {
+			return diff.CacheMisses[i].HeadCommandMillis > diff.CacheMisses[j].HeadCommandMillis
+		}
+		return diff.CacheMisses[i].Label < diff.CacheMisses[j].Label
+	})
+	sort.Slice(diff.Regressions, func(i, j int) bool {
+		if diff.Regressions[i].Delta != diff.Regressions[j].Delta {
+			return diff.Regressions[i].Delta > diff.Regressions[j].Delta
+		}
+		return diff.Regressions[i].Label < diff.Regressions[j].Label
+	})
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff
+}
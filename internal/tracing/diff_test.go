@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffTraces(t *testing.T) {
+	base := &BuildTrace{
+		Build: BuildRow{TraceID: "base", TotalDurationMillis: 10_000, CriticalPathExecMillis: 4000, AsyncCacheWaitMillis: 100, CacheHitCount: 3},
+		Spans: []SpanRow{
+			{Label: "//:hit", CacheResult: "CACHE_HIT", ChangeHash: "a1"},
+			{Label: "//:evicted", CacheResult: "CACHE_HIT", ChangeHash: "b1"},
+			{Label: "//:slower", CacheResult: "CACHE_MISS", CommandDurationMillis: 1000},
+			{Label: "//:noise", CacheResult: "CACHE_MISS", CommandDurationMillis: 10},
+			{Label: "//:faster", CacheResult: "CACHE_MISS", CommandDurationMillis: 3000},
+			{Label: "//:removed", CacheResult: "CACHE_HIT"},
+		},
+	}
+	head := &BuildTrace{
+		Build: BuildRow{TraceID: "head", TotalDurationMillis: 25_000, CriticalPathExecMillis: 9000, AsyncCacheWaitMillis: 50, CacheHitCount: 1},
+		Spans: []SpanRow{
+			{Label: "//:hit", CacheResult: "CACHE_MISS", ChangeHash: "a2", CommandDurationMillis: 5000},
+			{Label: "//:evicted", CacheResult: "CACHE_MISS", ChangeHash: "b1", CommandDurationMillis: 200},
+			{Label: "//:slower", CacheResult: "CACHE_MISS", CommandDurationMillis: 2500},
+			{Label: "//:noise", CacheResult: "CACHE_MISS", CommandDurationMillis: 40},
+			{Label: "//:faster", CacheResult: "CACHE_MISS", CommandDurationMillis: 1000},
+			{Label: "//:added", CacheResult: "CACHE_MISS", CommandDurationMillis: 100},
+		},
+	}
+
+	diff := DiffTraces(base, head, DiffOptions{Threshold: 0.2, MinDeltaMillis: 100})
+
+	if diff.TotalDuration != (MetricDelta{Base: 10_000, Head: 25_000, Delta: 15_000}) {
+		t.Errorf("unexpected total duration delta %+v", diff.TotalDuration)
+	}
+	if diff.CriticalPathExec.Delta != 5000 || diff.AsyncCacheWait.Delta != -50 || diff.CacheHits.Delta != -2 {
+		t.Errorf("unexpected build deltas %+v %+v %+v", diff.CriticalPathExec, diff.AsyncCacheWait, diff.CacheHits)
+	}
+
+	if len(diff.CacheMisses) != 2 {
+		t.Fatalf("expected 2 cache misses, got %+v", diff.CacheMisses)
+	}
+	if diff.CacheMisses[0].Label != "//:hit" || !diff.CacheMisses[0].ChangeHashChanged() {
+		t.Errorf("expected //:hit first with a changed hash, got %+v", diff.CacheMisses[0])
+	}
+	if diff.CacheMisses[1].Label != "//:evicted" || diff.CacheMisses[1].ChangeHashChanged() {
+		t.Errorf("expected //:evicted second with an unchanged hash, got %+v", diff.CacheMisses[1])
+	}
+
+	if len(diff.Regressions) != 1 || diff.Regressions[0].Label != "//:slower" || diff.Regressions[0].Delta != 1500 {
+		t.Errorf("expected only //:slower to regress by 1500ms, got %+v", diff.Regressions)
+	}
+	if !slices.Equal(diff.Added, []string{"//:added"}) {
+		t.Errorf("unexpected added targets %v", diff.Added)
+	}
+	if !slices.Equal(diff.Removed, []string{"//:removed"}) {
+		t.Errorf("unexpected removed targets %v", diff.Removed)
+	}
+}
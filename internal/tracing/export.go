@@ -240,6 +240,9 @@ func buildOTLPResourceSpans(trace *BuildTrace) otlpResourceSpans {
 				{Key: "grog.output_write_ms", Value: intVal(s.OutputWriteMillis)},
 				{Key: "grog.output_load_ms", Value: intVal(s.OutputLoadMillis)},
 				{Key: "grog.cache_write_ms", Value: intVal(s.CacheWriteMillis)},
+				{Key: "grog.user_cpu_ms", Value: intVal(s.UserCPUMillis)},
+				{Key: "grog.system_cpu_ms", Value: intVal(s.SystemCPUMillis)},
+				{Key: "grog.max_rss_bytes", Value: intVal(s.MaxRSSBytes)},
 			},
 			Status: status,
 		}
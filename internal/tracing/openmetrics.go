@@ -0,0 +1,229 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// targetDurationBuckets are the upper bounds in seconds of the target
+// duration histogram.
+var targetDurationBuckets = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 900}
+
+var (
+	spanStatuses     = []string{"SUCCESS", "FAILURE", "CANCELLED"}
+	spanCacheResults = []string{"CACHE_HIT", "CACHE_MISS", "CACHE_SKIP"}
+)
+
+// buildMetrics is the summary of a build that is exposed as metrics.
+// It is small enough to keep in memory for every exported build.
+type buildMetrics struct {
+	build BuildRow
+	// labels identify the series of the build
+	labels string
+	// targets counts the spans by status and cache result
+	targets map[[2]string]int
+	// durations holds a histogram of span durations per cache result
+	durations map[string]*durationHistogram
+}
+
+type durationHistogram struct {
+	// buckets are the cumulative counts of targetDurationBuckets
+	buckets    []int
+	count      int
+	sumSeconds float64
+}
+
+func (h *durationHistogram) observe(seconds float64) {
+	for i, bound := range targetDurationBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sumSeconds += seconds
+}
+
+func newBuildMetrics(b BuildRow, spans []SpanRow) buildMetrics {
+	m := buildMetrics{
+		build: b,
+		labels: formatMetricLabels(
+			"command", b.Command,
+			"branch", b.GitBranch,
+			"ci", strconv.FormatBool(b.IsCI),
+			"platform", b.Platform,
+		),
+		targets:   make(map[[2]string]int),
+		durations: make(map[string]*durationHistogram),
+	}
+	for _, cacheResult := range spanCacheResults {
+		m.durations[cacheResult] = &durationHistogram{buckets: make([]int, len(targetDurationBuckets))}
+	}
+	for _, s := range spans {
+		m.targets[[2]string{s.Status, s.CacheResult}]++
+		if h, ok := m.durations[s.CacheResult]; ok {
+			h.observe(millisToSeconds(s.TotalDurationMillis))
+		}
+	}
+	return m
+}
+
+// ExportOpenMetrics writes the metrics of every build in the OpenMetrics
+// text format with the build start as the sample timestamp, e.g. to backfill
+// Prometheus with promtool.
+func ExportOpenMetrics(ctx context.Context, loader SpanLoader, builds []BuildRow, w io.Writer) error {
+	var metrics []buildMetrics
+	err := forEachChunk(ctx, loader, builds, func(b BuildRow, spans []SpanRow) error {
+		metrics = append(metrics, newBuildMetrics(b, spans))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Samples of a series must have increasing timestamps
+	sort.SliceStable(metrics, func(i, j int) bool {
+		return metrics[i].build.StartTimeUnixMillis < metrics[j].build.StartTimeUnixMillis
+	})
+	lastMillis := make(map[string]int64)
+	deduplicated := metrics[:0]
+	for _, m := range metrics {
+		if last, ok := lastMillis[m.labels]; ok && m.build.StartTimeUnixMillis <= last {
+			continue
+		}
+		lastMillis[m.labels] = m.build.StartTimeUnixMillis
+		deduplicated = append(deduplicated, m)
+	}
+
+	return writeOpenMetrics(w, deduplicated, true)
+}
+
+// WriteMetricsTextfile atomically replaces path with the metrics of trace for
+// the textfile collector of the Prometheus node exporter. The file has no
+// timestamps since the collector rejects them.
+func WriteMetricsTextfile(path string, trace *BuildTrace) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create textfile directory: %w", err)
+	}
+
+	// The collector only reads *.prom files so the temp file is never
+	// picked up half-written
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create textfile: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeOpenMetrics(tmp, []buildMetrics{newBuildMetrics(trace.Build, trace.Spans)}, false); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write textfile: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write textfile: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write textfile: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeOpenMetrics writes all metric families. Each family lists the samples
+// of all builds since families must not be interleaved.
+func writeOpenMetrics(w io.Writer, metrics []buildMetrics, withTimestamps bool) error {
+	bw := bufio.NewWriter(w)
+
+	sample := func(name, labels string, value float64, b BuildRow) {
+		fmt.Fprintf(bw, "%s{%s} %s", name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+		if withTimestamps {
+			fmt.Fprintf(bw, " %s", strconv.FormatFloat(millisToSeconds(b.StartTimeUnixMillis), 'f', 3, 64))
+		}
+		bw.WriteString("\n")
+	}
+	family := func(name, metricType, unit, help string) {
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, metricType)
+		if unit != "" {
+			fmt.Fprintf(bw, "# UNIT %s %s\n", name, unit)
+		}
+		fmt.Fprintf(bw, "# HELP %s %s\n", name, help)
+	}
+
+	family("grog_build_start_timestamp_seconds", "gauge", "seconds", "Start time of the build.")
+	for _, m := range metrics {
+		sample("grog_build_start_timestamp_seconds", m.labels, millisToSeconds(m.build.StartTimeUnixMillis), m.build)
+	}
+
+	family("grog_build_duration_seconds", "gauge", "seconds", "Total duration of the build.")
+	for _, m := range metrics {
+		sample("grog_build_duration_seconds", m.labels, millisToSeconds(m.build.TotalDurationMillis), m.build)
+	}
+
+	family("grog_build_critical_path_seconds", "gauge", "seconds", "Execution and cache time on the critical path of the build.")
+	for _, m := range metrics {
+		sample("grog_build_critical_path_seconds", m.labels+`,kind="exec"`, millisToSeconds(m.build.CriticalPathExecMillis), m.build)
+		sample("grog_build_critical_path_seconds", m.labels+`,kind="cache"`, millisToSeconds(m.build.CriticalPathCacheMillis), m.build)
+	}
+
+	family("grog_build_async_cache_wait_seconds", "gauge", "seconds", "Time spent waiting for async cache writes at the end of the build.")
+	for _, m := range metrics {
+		sample("grog_build_async_cache_wait_seconds", m.labels, millisToSeconds(m.build.AsyncCacheWaitMillis), m.build)
+	}
+
+	family("grog_build_targets", "gauge", "", "Number of targets in the build by status and cache result.")
+	for _, m := range metrics {
+		for _, status := range spanStatuses {
+			for _, cacheResult := range spanCacheResults {
+				labels := m.labels + "," + formatMetricLabels("status", strings.ToLower(status), "cache_result", cacheResultName(cacheResult))
+				sample("grog_build_targets", labels, float64(m.targets[[2]string{status, cacheResult}]), m.build)
+			}
+		}
+	}
+
+	family("grog_target_duration_seconds", "histogram", "seconds", "Total duration of the targets in the build by cache result.")
+	for _, m := range metrics {
+		for _, cacheResult := range spanCacheResults {
+			h := m.durations[cacheResult]
+			labels := m.labels + "," + formatMetricLabels("cache_result", cacheResultName(cacheResult))
+			for i, bound := range targetDurationBuckets {
+				sample("grog_target_duration_seconds_bucket", labels+","+formatMetricLabels("le", strconv.FormatFloat(bound, 'f', -1, 64)), float64(h.buckets[i]), m.build)
+			}
+			sample("grog_target_duration_seconds_bucket", labels+`,le="+Inf"`, float64(h.count), m.build)
+			sample("grog_target_duration_seconds_sum", labels, h.sumSeconds, m.build)
+			sample("grog_target_duration_seconds_count", labels, float64(h.count), m.build)
+		}
+	}
+
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
+
+// formatMetricLabels formats alternating label names and values.
+func formatMetricLabels(namesAndValues ...string) string {
+	var pairs []string
+	for i := 0; i+1 < len(namesAndValues); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, namesAndValues[i], escapeLabelValue(namesAndValues[i+1])))
+	}
+	return strings.Join(pairs, ",")
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+// cacheResultName turns CACHE_HIT into hit.
+func cacheResultName(cacheResult string) string {
+	return strings.ToLower(strings.TrimPrefix(cacheResult, "CACHE_"))
+}
+
+func millisToSeconds(millis int64) float64 {
+	return float64(millis) / 1000
+}
@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func makeMetricsTestBuilds() ([]BuildRow, staticSpanLoader) {
+	builds := []BuildRow{
+		// Newest first like TraceStore.List
+		{TraceID: "trace-2", Command: "test", GitBranch: "main", IsCI: true, Platform: "linux/amd64", StartTimeUnixMillis: 5000, TotalDurationMillis: 2500},
+		{TraceID: "trace-1", Command: "build", GitBranch: "main", IsCI: true, Platform: "linux/amd64", StartTimeUnixMillis: 1000, TotalDurationMillis: 4000,
+			CriticalPathExecMillis: 3000, CriticalPathCacheMillis: 250, AsyncCacheWaitMillis: 100},
+		{TraceID: "trace-dup", Command: "build", GitBranch: "main", IsCI: true, Platform: "linux/amd64", StartTimeUnixMillis: 1000, TotalDurationMillis: 9000},
+	}
+	loader := staticSpanLoader{
+		"trace-1": {
+			{Label: "//:a", Status: "SUCCESS", CacheResult: "CACHE_MISS", TotalDurationMillis: 2000},
+			{Label: "//:b", Status: "SUCCESS", CacheResult: "CACHE_HIT", TotalDurationMillis: 50},
+			{Label: "//:c", Status: "FAILURE", CacheResult: "CACHE_MISS", TotalDurationMillis: 400},
+		},
+		"trace-2": {
+			{Label: "//:a_test", Status: "SUCCESS", CacheResult: "CACHE_MISS", TotalDurationMillis: 1000},
+		},
+	}
+	return builds, loader
+}
+
+func TestExportOpenMetrics(t *testing.T) {
+	builds, loader := makeMetricsTestBuilds()
+	var buf bytes.Buffer
+	if err := ExportOpenMetrics(context.Background(), loader, builds, &buf); err != nil {
+		t.Fatalf("ExportOpenMetrics failed: %v", err)
+	}
+	out := buf.String()
+
+	buildLabels := `command="build",branch="main",ci="true",platform="linux/amd64"`
+	for _, want := range []string{
+		"# TYPE grog_build_duration_seconds gauge\n# UNIT grog_build_duration_seconds seconds\n",
+		`grog_build_duration_seconds{` + buildLabels + `} 4 1.000`,
+		`grog_build_critical_path_seconds{` + buildLabels + `,kind="exec"} 3 1.000`,
+		`grog_build_async_cache_wait_seconds{` + buildLabels + `} 0.1 1.000`,
+		`grog_build_targets{` + buildLabels + `,status="success",cache_result="miss"} 1 1.000`,
+		`grog_build_targets{` + buildLabels + `,status="failure",cache_result="miss"} 1 1.000`,
+		`grog_build_targets{` + buildLabels + `,status="success",cache_result="hit"} 1 1.000`,
+		`grog_target_duration_seconds_bucket{` + buildLabels + `,cache_result="miss",le="0.5"} 1 1.000`,
+		`grog_target_duration_seconds_bucket{` + buildLabels + `,cache_result="miss",le="+Inf"} 2 1.000`,
+		`grog_target_duration_seconds_sum{` + buildLabels + `,cache_result="miss"} 2.4 1.000`,
+		`grog_build_duration_seconds{command="test",branch="main",ci="true",platform="linux/amd64"} 2.5 5.000`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q", want)
+		}
+	}
+	if !strings.HasSuffix(out, "# EOF\n") {
+		t.Errorf("expected output to end with # EOF")
+	}
+	// The second build of a series with the same timestamp is dropped
+	if strings.Contains(out, "} 9 1.000") {
+		t.Errorf("expected duplicate sample to be dropped")
+	}
+	// Families must not be interleaved
+	if strings.Count(out, "# TYPE grog_build_duration_seconds ") != 1 {
+		t.Errorf("expected a single grog_build_duration_seconds family")
+	}
+}
+
+func TestWriteMetricsTextfile(t *testing.T) {
+	builds, loader := makeMetricsTestBuilds()
+	path := filepath.Join(t.TempDir(), "textfile", "grog.prom")
+	trace := &BuildTrace{Build: builds[1], Spans: loader["trace-1"]}
+
+	if err := WriteMetricsTextfile(path, trace); err != nil {
+		t.Fatalf("WriteMetricsTextfile failed: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read textfile: %v", err)
+	}
+	if !strings.Contains(string(content), `grog_build_duration_seconds{command="build",branch="main",ci="true",platform="linux/amd64"} 4`+"\n") {
+		t.Errorf("expected textfile to contain the build duration without timestamp, got:\n%s", content)
+	}
+
+	// The file is replaced by the next build and no temp files remain
+	trace.Build.TotalDurationMillis = 6000
+	if err := WriteMetricsTextfile(path, trace); err != nil {
+		t.Fatalf("WriteMetricsTextfile failed: %v", err)
+	}
+	content, _ = os.ReadFile(path)
+	if !strings.Contains(string(content), "} 6\n") {
+		t.Errorf("expected textfile to be replaced, got:\n%s", content)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected only the textfile in its directory, got %d entries", len(entries))
+	}
+}
@@ -1,6 +1,10 @@
 package tracing
 
 import (
+	"os"
+	"path/filepath"
+	"time"
+
 	"grog/internal/config"
 )
 
@@ -33,3 +37,56 @@ func (p *PathResolver) BuildsGlob() string {
 func (p *PathResolver) SpansGlob() string {
 	return p.spansBase + "/**/*.parquet"
 }
+
+// BuildsGlobsBetween returns a glob per date partition of the build files
+// that may contain builds started between since and until. Zero times are
+// unbounded. Partitions that do not exist locally or hold no files are
+// skipped.
+func (p *PathResolver) BuildsGlobsBetween(since, until time.Time) ([]string, error) {
+	return partitionGlobs(p.buildsBase, since, until)
+}
+
+// SpansGlobsBetween is BuildsGlobsBetween for span files.
+func (p *PathResolver) SpansGlobsBetween(since, until time.Time) ([]string, error) {
+	return partitionGlobs(p.spansBase, since, until)
+}
+
+// partitionGlobs lists the date partitions under base. Partitions are named
+// after the UTC date of the build start, see dateStr.
+func partitionGlobs(base string, since, until time.Time) ([]string, error) {
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var globs []string
+	for _, entry := range entries {
+		date := entry.Name()
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			continue
+		}
+		if !since.IsZero() && date < dateStr(since) {
+			continue
+		}
+		if !until.IsZero() && date > dateStr(until) {
+			continue
+		}
+		// Prune leaves empty partitions behind which DuckDB cannot read
+		glob := base + "/" + date + "/*.parquet"
+		matches, err := filepath.Glob(glob)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		globs = append(globs, glob)
+	}
+	return globs, nil
+}
@@ -0,0 +1,157 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/duckdb/duckdb-go/v2"
+)
+
+// QueryOptions restricts the traces that are visible to an ad-hoc query.
+type QueryOptions struct {
+	// Since and Until bound the build start time, Until is exclusive. Zero
+	// values are unbounded. Only the date partitions within the range are
+	// read so that narrow ranges stay fast on a large trace history.
+	Since time.Time
+	Until time.Time
+}
+
+// QueryResult holds the rows of an ad-hoc query in column order.
+type QueryResult struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// Query runs an ad-hoc SQL query against the builds and spans views over
+// the local trace files. Returns nil if there are no traces in the range.
+func (s *TraceStore) Query(ctx context.Context, query string, opts QueryOptions) (*QueryResult, error) {
+	buildsGlobs, err := s.resolver.BuildsGlobsBetween(opts.Since, opts.Until)
+	if err != nil {
+		return nil, fmt.Errorf("list build partitions: %w", err)
+	}
+	if len(buildsGlobs) == 0 {
+		return nil, nil
+	}
+	spansGlobs, err := s.resolver.SpansGlobsBetween(opts.Since, opts.Until)
+	if err != nil {
+		return nil, fmt.Errorf("list span partitions: %w", err)
+	}
+	if len(spansGlobs) == 0 {
+		// Builds without targets have no span file, read an empty file
+		// instead so that the spans view still has the schema
+		emptySpans, err := writeEmptySpansFile()
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(emptySpans)
+		spansGlobs = []string{emptySpans}
+	}
+
+	// Temporary views only exist on the connection that created them
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("open duckdb connection: %w", err)
+	}
+	defer conn.Close()
+
+	var conditions []string
+	if !opts.Since.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("start_time_unix_millis >= %d", opts.Since.UnixMilli()))
+	}
+	if !opts.Until.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("start_time_unix_millis < %d", opts.Until.UnixMilli()))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	views := []string{
+		fmt.Sprintf(`CREATE OR REPLACE TEMP VIEW builds AS
+			SELECT * FROM read_parquet(%s, union_by_name=true) %s`,
+			sqlStringList(buildsGlobs), where),
+		// Partitions only bound the range to whole days, so spans are
+		// restricted to the builds in range
+		fmt.Sprintf(`CREATE OR REPLACE TEMP VIEW spans AS
+			SELECT * FROM %s WHERE trace_id IN (SELECT trace_id FROM builds)`,
+			spansSourceFrom(sqlStringList(spansGlobs))),
+	}
+	for _, view := range views {
+		if _, err := conn.ExecContext(ctx, view); err != nil {
+			return nil, fmt.Errorf("create trace views: %w", err)
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &QueryResult{Columns: columns, Rows: [][]any{}}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		for i, value := range values {
+			values[i] = normalizeQueryValue(value)
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, rows.Err()
+}
+
+// writeEmptySpansFile writes a span Parquet file without rows to a
+// temporary file and returns its path.
+func writeEmptySpansFile() (string, error) {
+	buf, err := writeParquet([]SpanRow{})
+	if err != nil {
+		return "", fmt.Errorf("write empty spans parquet: %w", err)
+	}
+	f, err := os.CreateTemp("", "grog-spans-*.parquet")
+	if err != nil {
+		return "", fmt.Errorf("write empty spans parquet: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(buf); err != nil {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("write empty spans parquet: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// sqlStringList formats values as a DuckDB list of string literals.
+func sqlStringList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// normalizeQueryValue converts DuckDB specific types into plain Go values
+// that format and encode naturally.
+func normalizeQueryValue(value any) any {
+	switch v := value.(type) {
+	case duckdb.Decimal:
+		return v.Float64()
+	case duckdb.UUID:
+		return v.String()
+	case []byte:
+		return string(v)
+	default:
+		return value
+	}
+}
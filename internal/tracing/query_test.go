@@ -0,0 +1,157 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"grog/internal/caching/backends"
+)
+
+func TestTraceStore_Query(t *testing.T) {
+	dir := t.TempDir()
+	fs := backends.NewFileSystemCacheForTest(dir, t.TempDir())
+	writer := NewTraceWriter(fs)
+	ctx := context.Background()
+
+	day := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	traces := []*BuildTrace{
+		makeTestTrace("trace-old", day.AddDate(0, 0, -5).UnixMilli(), "build"),
+		makeTestTrace("trace-a", day.UnixMilli(), "build"),
+		makeTestTrace("trace-b", day.Add(time.Hour).UnixMilli(), "test"),
+	}
+	for _, trace := range traces {
+		if err := writer.Write(ctx, trace); err != nil {
+			t.Fatalf("Write %s failed: %v", trace.Build.TraceID, err)
+		}
+	}
+
+	store, err := NewTraceStore(fs, &PathResolver{
+		buildsBase: dir + "/traces/builds",
+		spansBase:  dir + "/traces/spans",
+	})
+	if err != nil {
+		t.Fatalf("NewTraceStore failed: %v", err)
+	}
+	defer store.Close()
+
+	query := `SELECT b.trace_id, b.command, count(*) AS spans
+		FROM builds b JOIN spans s USING (trace_id)
+		GROUP BY ALL ORDER BY b.trace_id`
+
+	result, err := store.Query(ctx, query, QueryOptions{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if got := len(result.Rows); got != 3 {
+		t.Fatalf("expected 3 rows without a range, got %d", got)
+	}
+
+	result, err = store.Query(ctx, query, QueryOptions{
+		Since: day.Add(-time.Hour),
+		Until: day.Add(30 * time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("Query with range failed: %v", err)
+	}
+	if got := strings.Join(result.Columns, ","); got != "trace_id,command,spans" {
+		t.Fatalf("columns = %s, want trace_id,command,spans", got)
+	}
+	if len(result.Rows) != 1 {
+		t.Fatalf("expected 1 row within the range, got %v", result.Rows)
+	}
+	if result.Rows[0][0] != "trace-a" || result.Rows[0][2] != int64(1) {
+		t.Errorf("row = %v, want [trace-a build 1]", result.Rows[0])
+	}
+
+	// Partitions outside of the range are never read
+	result, err = store.Query(ctx, "SELECT * FROM builds", QueryOptions{Since: day.AddDate(0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Query after the last trace failed: %v", err)
+	}
+	if result != nil {
+		t.Errorf("expected no result without partitions in range, got %v", result.Rows)
+	}
+
+	if _, err := store.Query(ctx, "SELECT * FROM missing", QueryOptions{}); err == nil {
+		t.Error("expected an error for an unknown table")
+	}
+}
+
+func TestTraceStore_QueryAfterPrune(t *testing.T) {
+	dir := t.TempDir()
+	fs := backends.NewFileSystemCacheForTest(dir, t.TempDir())
+	writer := NewTraceWriter(fs)
+	ctx := context.Background()
+
+	now := time.Now()
+	if err := writer.Write(ctx, makeTestTrace("old-trace", now.Add(-48*time.Hour).UnixMilli(), "build")); err != nil {
+		t.Fatalf("Write old trace failed: %v", err)
+	}
+	if err := writer.Write(ctx, makeTestTrace("new-trace", now.UnixMilli(), "build")); err != nil {
+		t.Fatalf("Write new trace failed: %v", err)
+	}
+
+	store, err := NewTraceStore(fs, &PathResolver{
+		buildsBase: dir + "/traces/builds",
+		spansBase:  dir + "/traces/spans",
+	})
+	if err != nil {
+		t.Fatalf("NewTraceStore failed: %v", err)
+	}
+	defer store.Close()
+
+	if _, err := store.Prune(ctx, now.Add(-24*time.Hour)); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+
+	// The emptied partition of the old trace is skipped
+	result, err := store.Query(ctx, "SELECT trace_id FROM builds", QueryOptions{})
+	if err != nil {
+		t.Fatalf("Query after prune failed: %v", err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0][0] != "new-trace" {
+		t.Errorf("expected only new-trace, got %v", result.Rows)
+	}
+
+	result, err = store.Query(ctx, "SELECT trace_id FROM builds", QueryOptions{
+		Since: now.Add(-72 * time.Hour),
+		Until: now.Add(-36 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Query of the pruned range failed: %v", err)
+	}
+	if result != nil {
+		t.Errorf("expected no result for the pruned range, got %v", result.Rows)
+	}
+}
+
+func TestTraceStore_QueryWithoutSpans(t *testing.T) {
+	dir := t.TempDir()
+	fs := backends.NewFileSystemCacheForTest(dir, t.TempDir())
+	ctx := context.Background()
+
+	trace := makeTestTrace("trace-empty", time.Now().UnixMilli(), "build")
+	trace.Spans = nil
+	if err := NewTraceWriter(fs).Write(ctx, trace); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	store, err := NewTraceStore(fs, &PathResolver{
+		buildsBase: dir + "/traces/builds",
+		spansBase:  dir + "/traces/spans",
+	})
+	if err != nil {
+		t.Fatalf("NewTraceStore failed: %v", err)
+	}
+	defer store.Close()
+
+	result, err := store.Query(ctx, "SELECT count(*) AS n, sum(user_cpu_millis) AS cpu FROM spans", QueryOptions{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0][0] != int64(0) {
+		t.Errorf("expected an empty spans view, got %v", result.Rows)
+	}
+}
@@ -0,0 +1,153 @@
+package tracing
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// RegressionOptions configures which changes between the baseline and the
+// window count as a regression.
+type RegressionOptions struct {
+	// Threshold is the minimum relative increase of the median or p90
+	// command duration, e.g. 0.2 for 20%.
+	Threshold float64
+	// MinDeltaMillis is the minimum absolute increase of the median or p90
+	// command duration which filters out noise of fast targets.
+	MinDeltaMillis int64
+	// HitRateDrop is the decrease of the cache hit rate that has to be
+	// exceeded, e.g. 0.2 for 20 percentage points.
+	HitRateDrop float64
+	// MinSamples is the minimum number of runs a target needs in both
+	// periods to be compared.
+	MinSamples int
+}
+
+// TargetDistribution summarizes the runs of a target within a period.
+// Command durations only include successful cache misses since other runs
+// do not execute the full command.
+type TargetDistribution struct {
+	Runs         int     `json:"runs"`
+	Misses       int     `json:"misses"`
+	MedianMillis float64 `json:"median_millis"`
+	P90Millis    float64 `json:"p90_millis"`
+	CacheHitRate float64 `json:"cache_hit_rate"` // 0-1
+}
+
+// ExpectedMillis weights the median command duration by the chance of a
+// cache miss.
+func (d TargetDistribution) ExpectedMillis() float64 {
+	return d.MedianMillis * (1 - d.CacheHitRate)
+}
+
+// TargetRegression is a target that got slower or lost cache hits in the
+// window compared to the baseline.
+type TargetRegression struct {
+	Label             string             `json:"label"`
+	Baseline          TargetDistribution `json:"baseline"`
+	Window            TargetDistribution `json:"window"`
+	DurationRegressed bool               `json:"duration_regressed"`
+	CacheRegressed    bool               `json:"cache_regressed"`
+	// ImpactMillis is the increase of the expected time spent on the target
+	// per build, which combines slower commands and additional cache misses.
+	ImpactMillis float64 `json:"impact_millis"`
+}
+
+// RegressionReport compares the targets of the builds in a window with the
+// builds of the baseline period before it.
+type RegressionReport struct {
+	BaselineStartUnixMillis int64              `json:"baseline_start_unix_millis"`
+	WindowStartUnixMillis   int64              `json:"window_start_unix_millis"`
+	EndUnixMillis           int64              `json:"end_unix_millis"`
+	BaselineBuilds          int                `json:"baseline_builds"`
+	WindowBuilds            int                `json:"window_builds"`
+	Regressions             []TargetRegression `json:"regressions"`
+}
+
+// RegressionPeriods are the two adjacent periods that are compared: the
+// baseline from BaselineStart to WindowStart and the window from
+// WindowStart to End.
+type RegressionPeriods struct {
+	BaselineStart time.Time
+	WindowStart   time.Time
+	End           time.Time
+}
+
+// Regressions compares the targets of the builds in the window with those of
+// the baseline and returns the significant regressions.
+func (s *TraceStore) Regressions(ctx context.Context, periods RegressionPeriods, filters StatsOptions, opts RegressionOptions) (*RegressionReport, error) {
+	baseline, baselineBuilds, err := s.TargetDistributions(ctx, periods.BaselineStart, periods.WindowStart, filters)
+	if err != nil {
+		return nil, err
+	}
+	window, windowBuilds, err := s.TargetDistributions(ctx, periods.WindowStart, periods.End, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegressionReport{
+		BaselineStartUnixMillis: periods.BaselineStart.UnixMilli(),
+		WindowStartUnixMillis:   periods.WindowStart.UnixMilli(),
+		EndUnixMillis:           periods.End.UnixMilli(),
+		BaselineBuilds:          baselineBuilds,
+		WindowBuilds:            windowBuilds,
+		Regressions:             DetectRegressions(baseline, window, opts),
+	}, nil
+}
+
+// DetectRegressions compares the target distributions of two periods keyed
+// by label and returns the significant regressions ranked by impact.
+// Targets that only exist in one period are ignored.
+func DetectRegressions(baseline, window map[string]TargetDistribution, opts RegressionOptions) []TargetRegression {
+	minSamples := max(opts.MinSamples, 1)
+
+	regressions := []TargetRegression{}
+	for label, head := range window {
+		base, ok := baseline[label]
+		if !ok {
+			continue
+		}
+
+		durationRegressed := false
+		if base.Misses >= minSamples && head.Misses >= minSamples {
+			durationRegressed = isDurationRegression(base.MedianMillis, head.MedianMillis, opts) ||
+				isDurationRegression(base.P90Millis, head.P90Millis, opts)
+		}
+		hitRateDrop := base.CacheHitRate - head.CacheHitRate
+		cacheRegressed := base.Runs >= minSamples && head.Runs >= minSamples &&
+			hitRateDrop > 0 && hitRateDrop > opts.HitRateDrop
+		if !durationRegressed && !cacheRegressed {
+			continue
+		}
+
+		// Without baseline misses the baseline is priced with the window's
+		// median so that the impact reflects the lost cache hits
+		baseMedian := base.MedianMillis
+		if base.Misses == 0 {
+			baseMedian = head.MedianMillis
+		}
+		regressions = append(regressions, TargetRegression{
+			Label:             label,
+			Baseline:          base,
+			Window:            head,
+			DurationRegressed: durationRegressed,
+			CacheRegressed:    cacheRegressed,
+			ImpactMillis:      head.ExpectedMillis() - baseMedian*(1-base.CacheHitRate),
+		})
+	}
+
+	sort.Slice(regressions, func(i, j int) bool {
+		if regressions[i].ImpactMillis != regressions[j].ImpactMillis {
+			return regressions[i].ImpactMillis > regressions[j].ImpactMillis
+		}
+		return regressions[i].Label < regressions[j].Label
+	})
+	return regressions
+}
+
+func isDurationRegression(baseMillis, headMillis float64, opts RegressionOptions) bool {
+	if baseMillis <= 0 {
+		return false
+	}
+	return headMillis-baseMillis > float64(opts.MinDeltaMillis) && headMillis/baseMillis > 1+opts.Threshold
+}
@@ -0,0 +1,172 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"grog/internal/caching/backends"
+)
+
+func TestDetectRegressions(t *testing.T) {
+	baseline := map[string]TargetDistribution{
+		"//:slower":     {Runs: 10, Misses: 10, MedianMillis: 10_000, P90Millis: 12_000},
+		"//:tail":       {Runs: 10, Misses: 10, MedianMillis: 10_000, P90Millis: 11_000},
+		"//:noise":      {Runs: 10, Misses: 10, MedianMillis: 100, P90Millis: 120},
+		"//:evicted":    {Runs: 10, Misses: 1, MedianMillis: 5000, P90Millis: 5000, CacheHitRate: 0.9},
+		"//:always-hit": {Runs: 10, CacheHitRate: 1},
+		"//:few":        {Runs: 2, Misses: 2, MedianMillis: 1000, P90Millis: 1000},
+		"//:faster":     {Runs: 10, Misses: 10, MedianMillis: 8000, P90Millis: 9000},
+		"//:removed":    {Runs: 10, Misses: 10, MedianMillis: 1000, P90Millis: 1000},
+	}
+	window := map[string]TargetDistribution{
+		"//:slower":     {Runs: 5, Misses: 5, MedianMillis: 13_000, P90Millis: 14_000},
+		"//:tail":       {Runs: 5, Misses: 5, MedianMillis: 10_100, P90Millis: 20_000},
+		"//:noise":      {Runs: 5, Misses: 5, MedianMillis: 300, P90Millis: 400},
+		"//:evicted":    {Runs: 5, Misses: 3, MedianMillis: 5000, P90Millis: 5000, CacheHitRate: 0.4},
+		"//:always-hit": {Runs: 5, Misses: 5, MedianMillis: 2000, P90Millis: 2000},
+		"//:few":        {Runs: 5, Misses: 5, MedianMillis: 9000, P90Millis: 9000},
+		"//:faster":     {Runs: 5, Misses: 5, MedianMillis: 4000, P90Millis: 5000},
+		"//:added":      {Runs: 5, Misses: 5, MedianMillis: 60_000, P90Millis: 60_000},
+	}
+
+	regressions := DetectRegressions(baseline, window, RegressionOptions{
+		Threshold:      0.2,
+		MinDeltaMillis: 1000,
+		HitRateDrop:    0.2,
+		MinSamples:     3,
+	})
+
+	var labels []string
+	for _, r := range regressions {
+		labels = append(labels, r.Label)
+	}
+	// Ranked by impact: slower adds 3000ms per build, evicted 2500ms,
+	// always-hit 2000ms and tail 100ms
+	expected := []string{"//:slower", "//:evicted", "//:always-hit", "//:tail"}
+	if fmt.Sprint(labels) != fmt.Sprint(expected) {
+		t.Fatalf("expected regressions %v, got %v", expected, labels)
+	}
+
+	byLabel := make(map[string]TargetRegression)
+	for _, r := range regressions {
+		byLabel[r.Label] = r
+	}
+	if r := byLabel["//:slower"]; !r.DurationRegressed || r.CacheRegressed || r.ImpactMillis != 3000 {
+		t.Errorf("unexpected regression for //:slower: %+v", r)
+	}
+	if r := byLabel["//:evicted"]; r.DurationRegressed || !r.CacheRegressed || r.ImpactMillis != 2500 {
+		t.Errorf("unexpected regression for //:evicted: %+v", r)
+	}
+	// The baseline has no misses so only the lost cache hits count
+	if r := byLabel["//:always-hit"]; !r.CacheRegressed || r.ImpactMillis != 2000 {
+		t.Errorf("unexpected regression for //:always-hit: %+v", r)
+	}
+	if r := byLabel["//:tail"]; !r.DurationRegressed {
+		t.Errorf("expected p90 regression for //:tail: %+v", r)
+	}
+}
+
+func TestDetectRegressions_ZeroHitRateDrop(t *testing.T) {
+	baseline := map[string]TargetDistribution{
+		"//:stable":   {Runs: 10, Misses: 5, MedianMillis: 1000, P90Millis: 1000, CacheHitRate: 0.5},
+		"//:improved": {Runs: 10, Misses: 5, MedianMillis: 1000, P90Millis: 1000, CacheHitRate: 0.5},
+		"//:dropped":  {Runs: 10, Misses: 5, MedianMillis: 1000, P90Millis: 1000, CacheHitRate: 0.5},
+	}
+	window := map[string]TargetDistribution{
+		"//:stable":   {Runs: 10, Misses: 5, MedianMillis: 1000, P90Millis: 1000, CacheHitRate: 0.5},
+		"//:improved": {Runs: 10, Misses: 3, MedianMillis: 1000, P90Millis: 1000, CacheHitRate: 0.7},
+		"//:dropped":  {Runs: 10, Misses: 6, MedianMillis: 1000, P90Millis: 1000, CacheHitRate: 0.4},
+	}
+
+	// Any decrease counts but an unchanged hit rate is not a regression
+	regressions := DetectRegressions(baseline, window, RegressionOptions{
+		Threshold:      0.2,
+		MinDeltaMillis: 1000,
+		HitRateDrop:    0,
+		MinSamples:     3,
+	})
+	if len(regressions) != 1 || regressions[0].Label != "//:dropped" || !regressions[0].CacheRegressed {
+		t.Fatalf("expected only //:dropped to regress, got %+v", regressions)
+	}
+}
+
+func TestTraceStore_Regressions(t *testing.T) {
+	dir := t.TempDir()
+	fs := backends.NewFileSystemCacheForTest(dir, t.TempDir())
+	writer := NewTraceWriter(fs)
+	ctx := context.Background()
+
+	end := time.Now()
+	periods := RegressionPeriods{
+		BaselineStart: end.Add(-10 * 24 * time.Hour),
+		WindowStart:   end.Add(-2 * 24 * time.Hour),
+		End:           end,
+	}
+
+	write := func(id string, start time.Time, commandMillis int64, cacheResult string) {
+		trace := makeTestTrace(id, start.UnixMilli(), "build")
+		trace.Spans[0].CommandDurationMillis = commandMillis
+		trace.Spans[0].CacheResult = cacheResult
+		if err := writer.Write(ctx, trace); err != nil {
+			t.Fatalf("Write %s failed: %v", id, err)
+		}
+	}
+	for i, millis := range []int64{1000, 1100, 900, 1000, 1050} {
+		write(fmt.Sprintf("baseline-%d", i), periods.BaselineStart.Add(time.Duration(i+1)*time.Hour), millis, "CACHE_MISS")
+	}
+	write("baseline-hit", periods.BaselineStart.Add(10*time.Hour), 0, "CACHE_HIT")
+	for i, millis := range []int64{3000, 3200, 2900} {
+		write(fmt.Sprintf("window-%d", i), periods.WindowStart.Add(time.Duration(i+1)*time.Hour), millis, "CACHE_MISS")
+	}
+	// Outside of both periods
+	write("too-old", periods.BaselineStart.Add(-time.Hour), 100_000, "CACHE_MISS")
+
+	resolver := &PathResolver{
+		buildsBase: dir + "/traces/builds",
+		spansBase:  dir + "/traces/spans",
+	}
+	store, err := NewTraceStore(fs, resolver)
+	if err != nil {
+		t.Fatalf("NewTraceStore failed: %v", err)
+	}
+	defer store.Close()
+
+	report, err := store.Regressions(ctx, periods, StatsOptions{}, RegressionOptions{
+		Threshold:      0.2,
+		MinDeltaMillis: 1000,
+		HitRateDrop:    0.2,
+		MinSamples:     3,
+	})
+	if err != nil {
+		t.Fatalf("Regressions failed: %v", err)
+	}
+
+	if report.BaselineBuilds != 6 || report.WindowBuilds != 3 {
+		t.Errorf("expected 6 baseline and 3 window builds, got %d and %d", report.BaselineBuilds, report.WindowBuilds)
+	}
+	if len(report.Regressions) != 1 {
+		t.Fatalf("expected 1 regression, got %+v", report.Regressions)
+	}
+	r := report.Regressions[0]
+	if r.Label != "//pkg:target" || r.Baseline.MedianMillis != 1000 || r.Window.MedianMillis != 3000 {
+		t.Errorf("unexpected regression %+v", r)
+	}
+	if r.Baseline.Misses != 5 || r.Baseline.Runs != 6 {
+		t.Errorf("expected 5 misses out of 6 baseline runs, got %+v", r.Baseline)
+	}
+
+	// An empty store has nothing to compare
+	empty, err := NewTraceStore(fs, &PathResolver{
+		buildsBase: t.TempDir() + "/traces/builds",
+		spansBase:  t.TempDir() + "/traces/spans",
+	})
+	if err != nil {
+		t.Fatalf("NewTraceStore failed: %v", err)
+	}
+	defer empty.Close()
+	if report, err := empty.Regressions(ctx, periods, StatsOptions{}, RegressionOptions{}); err != nil || len(report.Regressions) != 0 {
+		t.Errorf("expected no regressions without traces, got %+v, %v", report, err)
+	}
+}
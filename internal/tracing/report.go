@@ -0,0 +1,280 @@
+package tracing
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed report.html.tmpl
+var reportTemplate string
+
+// reportData is the data expected by report.html.tmpl.
+type reportData struct {
+	Build        BuildRow
+	Date         string
+	Duration     string
+	Patterns     []string
+	Ticks        []reportTick
+	Rows         []reportRow
+	Phases       []reportPhase
+	CriticalPath []reportCriticalTarget
+	Failures     []reportFailure
+	GeneratedAt  string
+}
+
+type reportTick struct {
+	Left  string
+	Label string
+}
+
+type reportRow struct {
+	Label    string
+	Left     string
+	Width    string
+	Class    string
+	Critical bool
+	Title    string
+}
+
+type reportPhase struct {
+	Name     string
+	Duration string
+	Width    string
+}
+
+type reportCriticalTarget struct {
+	Label    string
+	Duration string
+}
+
+type reportFailure struct {
+	Label    string
+	ExitCode int32
+	Command  string
+	Log      string
+}
+
+// spanPathMillis is the weight of a span on the critical path, i.e. its
+// execution and cache time like the critical path of the build graph.
+func spanPathMillis(s SpanRow) int64 {
+	return s.CommandDurationMillis + s.OutputLoadMillis + s.OutputWriteMillis + s.CacheWriteMillis
+}
+
+// CriticalPath returns the labels of the longest chain of dependent spans
+// weighted by execution and cache time, starting with the first target to
+// run. Dependencies that are not part of the trace are ignored.
+func CriticalPath(spans []SpanRow) []string {
+	byLabel := make(map[string]SpanRow, len(spans))
+	for _, s := range spans {
+		byLabel[s.Label] = s
+	}
+
+	type pathInfo struct {
+		millis int64
+		// next is the dependency on the longest path
+		next string
+	}
+	best := make(map[string]pathInfo, len(spans))
+	var visit func(label string) int64
+	visit = func(label string) int64 {
+		if info, ok := best[label]; ok {
+			return info.millis
+		}
+		// Guard against cycles in corrupt traces
+		best[label] = pathInfo{}
+
+		info := pathInfo{}
+		for _, dep := range splitList(byLabel[label].Dependencies) {
+			if _, ok := byLabel[dep]; !ok {
+				continue
+			}
+			depMillis := visit(dep)
+			if info.next == "" || depMillis > info.millis || (depMillis == info.millis && dep < info.next) {
+				info = pathInfo{millis: depMillis, next: dep}
+			}
+		}
+		info.millis += spanPathMillis(byLabel[label])
+		best[label] = info
+		return info.millis
+	}
+
+	labels := make([]string, 0, len(byLabel))
+	for label := range byLabel {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	var last string
+	var lastMillis int64 = -1
+	for _, label := range labels {
+		if millis := visit(label); millis > lastMillis {
+			last, lastMillis = label, millis
+		}
+	}
+	if last == "" {
+		return nil
+	}
+
+	var path []string
+	for label := last; label != ""; label = best[label].next {
+		path = append(path, label)
+	}
+	// Reverse so that the path starts with the first target to run
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// WriteHTMLReport writes a self-contained HTML report of trace with a Gantt
+// chart of all spans, the critical path, a per-phase breakdown and the
+// failing targets. logExcerpts maps the labels of failing targets to their
+// log output and may be nil.
+func WriteHTMLReport(w io.Writer, trace *BuildTrace, logExcerpts map[string]string) error {
+	tmpl, err := template.New("report").Parse(reportTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse report template: %w", err)
+	}
+	return tmpl.Execute(w, newReportData(trace, logExcerpts))
+}
+
+func newReportData(trace *BuildTrace, logExcerpts map[string]string) reportData {
+	build := trace.Build
+	data := reportData{
+		Build:       build,
+		Date:        time.UnixMilli(build.StartTimeUnixMillis).Format("2006-01-02 15:04:05"),
+		Duration:    formatReportMillis(build.TotalDurationMillis),
+		Patterns:    splitList(build.RequestedPatterns),
+		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	criticalPath := CriticalPath(trace.Spans)
+	critical := make(map[string]bool, len(criticalPath))
+	spansByLabel := make(map[string]SpanRow, len(trace.Spans))
+	for _, s := range trace.Spans {
+		spansByLabel[s.Label] = s
+	}
+	for _, label := range criticalPath {
+		critical[label] = true
+		data.CriticalPath = append(data.CriticalPath, reportCriticalTarget{
+			Label:    label,
+			Duration: formatReportMillis(spanPathMillis(spansByLabel[label])),
+		})
+	}
+
+	// The timeline covers the whole build and every span in it
+	timelineMillis := build.TotalDurationMillis
+	for _, s := range trace.Spans {
+		if s.StartTimeUnixMillis > 0 {
+			timelineMillis = max(timelineMillis, s.StartTimeUnixMillis+workerMillis(s)-build.StartTimeUnixMillis)
+		}
+	}
+	timelineMillis = max(timelineMillis, 1)
+	percent := func(millis int64) string {
+		return fmt.Sprintf("%.3f", float64(millis)*100/float64(timelineMillis))
+	}
+
+	const tickCount = 5
+	for i := 0; i <= tickCount; i++ {
+		millis := timelineMillis * int64(i) / tickCount
+		data.Ticks = append(data.Ticks, reportTick{Left: percent(millis), Label: formatReportMillis(millis)})
+	}
+
+	spans := append([]SpanRow{}, trace.Spans...)
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].StartTimeUnixMillis != spans[j].StartTimeUnixMillis {
+			return spans[i].StartTimeUnixMillis < spans[j].StartTimeUnixMillis
+		}
+		return spans[i].Label < spans[j].Label
+	})
+	for _, s := range spans {
+		if s.StartTimeUnixMillis == 0 {
+			continue
+		}
+		class := "miss"
+		switch {
+		case s.Status == "FAILURE":
+			class = "failed"
+		case s.CacheResult == "CACHE_HIT":
+			class = "hit"
+		case s.CacheResult == "CACHE_SKIP":
+			class = "skip"
+		}
+
+		title := []string{s.Label, fmt.Sprintf("%s, %s", strings.ToLower(s.Status), strings.ToLower(strings.TrimPrefix(s.CacheResult, "CACHE_")))}
+		for _, phase := range reportPhases(s) {
+			if phase.millis > 0 {
+				title = append(title, fmt.Sprintf("%s: %s", phase.name, formatReportMillis(phase.millis)))
+			}
+		}
+		data.Rows = append(data.Rows, reportRow{
+			Label:    s.Label,
+			Left:     percent(s.StartTimeUnixMillis - build.StartTimeUnixMillis),
+			Width:    percent(max(workerMillis(s), 1)),
+			Class:    class,
+			Critical: critical[s.Label],
+			Title:    strings.Join(title, "\n"),
+		})
+	}
+
+	phaseTotals := make(map[string]int64)
+	var phaseNames []string
+	var maxPhaseMillis int64 = 1
+	for _, s := range trace.Spans {
+		for _, phase := range reportPhases(s) {
+			if _, ok := phaseTotals[phase.name]; !ok {
+				phaseNames = append(phaseNames, phase.name)
+			}
+			phaseTotals[phase.name] += phase.millis
+			maxPhaseMillis = max(maxPhaseMillis, phaseTotals[phase.name])
+		}
+	}
+	for _, name := range phaseNames {
+		data.Phases = append(data.Phases, reportPhase{
+			Name:     name,
+			Duration: formatReportMillis(phaseTotals[name]),
+			Width:    fmt.Sprintf("%.3f", float64(phaseTotals[name])*100/float64(maxPhaseMillis)),
+		})
+	}
+
+	for _, s := range spans {
+		if s.Status != "FAILURE" {
+			continue
+		}
+		data.Failures = append(data.Failures, reportFailure{
+			Label:    s.Label,
+			ExitCode: s.ExitCode,
+			Command:  s.Command,
+			Log:      logExcerpts[s.Label],
+		})
+	}
+	return data
+}
+
+// reportPhases returns all timed phases of a span in execution order.
+func reportPhases(s SpanRow) []spanPhase {
+	return append([]spanPhase{
+		{"hashing", s.HashDurationMillis},
+		{"queue wait", s.QueueWaitMillis},
+	}, workerPhases(s)...)
+}
+
+func formatReportMillis(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	if d < time.Second {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func makeReportTestTrace() *BuildTrace {
+	return &BuildTrace{
+		Build: BuildRow{
+			TraceID:             "trace-1",
+			Command:             "build",
+			GitCommit:           "abc1234",
+			GitBranch:           "main",
+			Platform:            "linux/amd64",
+			RequestedPatterns:   "//apps/...,//libs/...",
+			StartTimeUnixMillis: 1000,
+			TotalDurationMillis: 4000,
+			TotalTargets:        4,
+			FailureCount:        1,
+		},
+		Spans: []SpanRow{
+			{Label: "//libs:a", Status: "SUCCESS", CacheResult: "CACHE_MISS", StartTimeUnixMillis: 1000, CommandDurationMillis: 1000},
+			{Label: "//libs:b", Status: "SUCCESS", CacheResult: "CACHE_HIT", StartTimeUnixMillis: 1000, OutputLoadMillis: 100},
+			{Label: "//apps:app", Status: "SUCCESS", CacheResult: "CACHE_MISS", StartTimeUnixMillis: 2000, CommandDurationMillis: 500, Dependencies: "//libs:a,//libs:b"},
+			{Label: "//apps:test", Status: "FAILURE", CacheResult: "CACHE_MISS", StartTimeUnixMillis: 2500, CommandDurationMillis: 800, ExitCode: 2, Command: "go test ./...", Dependencies: "//apps:app,//:alias"},
+		},
+	}
+}
+
+func TestCriticalPath(t *testing.T) {
+	path := CriticalPath(makeReportTestTrace().Spans)
+
+	expected := []string{"//libs:a", "//apps:app", "//apps:test"}
+	if !slices.Equal(path, expected) {
+		t.Errorf("expected critical path %v, got %v", expected, path)
+	}
+	if path := CriticalPath(nil); path != nil {
+		t.Errorf("expected no critical path without spans, got %v", path)
+	}
+}
+
+func TestWriteHTMLReport(t *testing.T) {
+	var buf bytes.Buffer
+	logExcerpts := map[string]string{"//apps:test": "--- FAIL: TestApp <expected>"}
+	if err := WriteHTMLReport(&buf, makeReportTestTrace(), logExcerpts); err != nil {
+		t.Fatalf("WriteHTMLReport failed: %v", err)
+	}
+	report := buf.String()
+
+	for _, want := range []string{
+		"abc1234",
+		"linux/amd64",
+		"<code>//apps/...</code>, <code>//libs/...</code>",
+		`class="bar hit"`,
+		`class="bar failed critical"`,
+		`style="left: 25.000%; width: 12.500%"`,
+		"failed with exit code 2",
+		"--- FAIL: TestApp &lt;expected&gt;",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected report to contain %q", want)
+		}
+	}
+
+	// The report must work offline
+	for _, external := range []string{"<script src", "<link", "http://", "https://"} {
+		if strings.Contains(report, external) {
+			t.Errorf("expected report to be self-contained, found %q", external)
+		}
+	}
+}
@@ -47,6 +47,21 @@ type SpanRow struct {
 	DepLoadMillis         int64  `parquet:"dep_load_millis" json:"dep_load_millis"`
 	Tags                  string `parquet:"tags" json:"tags"`
 	Dependencies          string `parquet:"dependencies" json:"dependencies"`
+
+	// Resource usage of the target command. Zero for targets that did not run
+	// a command locally and in traces written before these columns existed.
+	UserCPUMillis              int64 `parquet:"user_cpu_millis" json:"user_cpu_millis"`
+	SystemCPUMillis            int64 `parquet:"system_cpu_millis" json:"system_cpu_millis"`
+	MaxRSSBytes                int64 `parquet:"max_rss_bytes" json:"max_rss_bytes"`
+	BlockInputOps              int64 `parquet:"block_input_ops" json:"block_input_ops"`
+	BlockOutputOps             int64 `parquet:"block_output_ops" json:"block_output_ops"`
+	VoluntaryContextSwitches   int64 `parquet:"voluntary_context_switches" json:"voluntary_context_switches"`
+	InvoluntaryContextSwitches int64 `parquet:"involuntary_context_switches" json:"involuntary_context_switches"`
+}
+
+// CPUMillis is the user plus system CPU time of the target command.
+func (s SpanRow) CPUMillis() int64 {
+	return s.UserCPUMillis + s.SystemCPUMillis
 }
 
 // BuildTrace is the in-memory representation of a complete trace.
@@ -264,18 +264,58 @@ func (s *TraceStore) LoadBuild(ctx context.Context, traceIDPrefix string) (*Buil
 	return &builds[0], nil
 }
 
+// spanColumns selects the columns of SpanRow in the order of scanSpanRows.
+// Resource usage columns are NULL in traces written before they existed.
+const spanColumns = `trace_id, label, package, change_hash, output_hash,
+	status, cache_result, command, exit_code, is_test,
+	start_time_unix_millis, end_time_unix_millis, total_duration_millis,
+	queue_wait_millis, hash_duration_millis, cache_check_millis,
+	command_duration_millis, output_write_millis, output_load_millis,
+	cache_write_millis, dep_load_millis, tags, dependencies,
+	COALESCE(user_cpu_millis, 0), COALESCE(system_cpu_millis, 0),
+	COALESCE(max_rss_bytes, 0), COALESCE(block_input_ops, 0),
+	COALESCE(block_output_ops, 0), COALESCE(voluntary_context_switches, 0),
+	COALESCE(involuntary_context_switches, 0)`
+
+// addedSpanColumns are the span columns that were added after the initial
+// schema together with their types.
+var addedSpanColumns = []string{
+	"user_cpu_millis BIGINT",
+	"system_cpu_millis BIGINT",
+	"max_rss_bytes BIGINT",
+	"block_input_ops BIGINT",
+	"block_output_ops BIGINT",
+	"voluntary_context_switches BIGINT",
+	"involuntary_context_switches BIGINT",
+}
+
+// spansSource returns a relation over all span files that always contains
+// the added span columns, even if none of the files has them yet.
+func (s *TraceStore) spansSource() string {
+	return spansSourceFrom(fmt.Sprintf("'%s'", s.resolver.SpansGlob()))
+}
+
+// spansSourceFrom is spansSource for the span files matched by files, a
+// DuckDB string or list of strings.
+func spansSourceFrom(files string) string {
+	var nullColumns []string
+	for _, column := range addedSpanColumns {
+		name, columnType, _ := strings.Cut(column, " ")
+		nullColumns = append(nullColumns, fmt.Sprintf("NULL::%s AS %s", columnType, name))
+	}
+	return fmt.Sprintf(`(SELECT * FROM read_parquet(%s, union_by_name=true)
+		UNION ALL BY NAME
+		SELECT %s WHERE false)`,
+		files, strings.Join(nullColumns, ", "))
+}
+
 // LoadSpans retrieves all spans for a given trace ID.
 func (s *TraceStore) LoadSpans(ctx context.Context, traceID string) ([]SpanRow, error) {
-	query := fmt.Sprintf(`SELECT trace_id, label, package, change_hash, output_hash,
-		status, cache_result, command, exit_code, is_test,
-		start_time_unix_millis, end_time_unix_millis, total_duration_millis,
-		queue_wait_millis, hash_duration_millis, cache_check_millis,
-		command_duration_millis, output_write_millis, output_load_millis,
-		cache_write_millis, dep_load_millis, tags, dependencies
-		FROM read_parquet('%s', union_by_name=true)
+	query := fmt.Sprintf(`SELECT %s
+		FROM %s
 		WHERE trace_id = '%s'
 		ORDER BY total_duration_millis DESC`,
-		s.resolver.SpansGlob(), sanitize(traceID))
+		spanColumns, s.spansSource(), sanitize(traceID))
 
 	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
@@ -308,15 +348,10 @@ func (s *TraceStore) LoadSpansForTraces(ctx context.Context, traceIDs []string)
 			quoted = append(quoted, "'"+sanitize(id)+"'")
 		}
 
-		query := fmt.Sprintf(`SELECT trace_id, label, package, change_hash, output_hash,
-			status, cache_result, command, exit_code, is_test,
-			start_time_unix_millis, end_time_unix_millis, total_duration_millis,
-			queue_wait_millis, hash_duration_millis, cache_check_millis,
-			command_duration_millis, output_write_millis, output_load_millis,
-			cache_write_millis, dep_load_millis, tags, dependencies
-			FROM read_parquet('%s', union_by_name=true)
+		query := fmt.Sprintf(`SELECT %s
+			FROM %s
 			WHERE trace_id IN (%s)`,
-			s.resolver.SpansGlob(), strings.Join(quoted, ","))
+			spanColumns, s.spansSource(), strings.Join(quoted, ","))
 
 		rows, err := s.db.QueryContext(ctx, query)
 		if err != nil {
@@ -425,6 +460,9 @@ type TargetBottleneck struct {
 	AvgOutputWrite float64
 	AvgOutputLoad  float64
 	AvgCacheWrite  float64
+	// AvgCPU is the average user plus system CPU time of cache misses.
+	AvgCPU  float64
+	PeakRSS int64
 }
 
 // BottleneckReport categorizes targets by bottleneck type.
@@ -435,6 +473,7 @@ type BottleneckReport struct {
 	SlowHashing    []TargetBottleneck
 	FrequentMisses []TargetBottleneck
 	FlakyTargets   []TargetBottleneck
+	ResourceHeavy  []TargetBottleneck
 
 	OverallCacheMissRate float64
 }
@@ -487,13 +526,15 @@ func (s *TraceStore) Bottlenecks(ctx context.Context, opts StatsOptions) (*Bottl
 			SUM(CASE WHEN status = 'FAILURE' THEN 1 ELSE 0 END) as failures,
 			AVG(output_write_millis) as avg_output_write,
 			AVG(output_load_millis) as avg_output_load,
-			AVG(cache_write_millis) as avg_cache_write
-		FROM read_parquet('%s', union_by_name=true)
+			AVG(cache_write_millis) as avg_cache_write,
+			COALESCE(AVG(CASE WHEN cache_result = 'CACHE_MISS' THEN user_cpu_millis + system_cpu_millis END), 0) as avg_cpu,
+			COALESCE(MAX(max_rss_bytes), 0) as peak_rss
+		FROM %s
 		WHERE trace_id IN (SELECT trace_id FROM recent_builds)
 		GROUP BY label
 		HAVING n > 1
 		ORDER BY impact DESC`,
-		s.resolver.BuildsGlob(), where, limit, s.resolver.SpansGlob())
+		s.resolver.BuildsGlob(), where, limit, s.spansSource())
 
 	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
@@ -510,7 +551,8 @@ func (s *TraceStore) Bottlenecks(ctx context.Context, opts StatsOptions) (*Bottl
 		var t TargetBottleneck
 		if err := rows.Scan(&t.Label, &t.Count, &t.Frequency, &t.Impact,
 			&t.AvgCmd, &t.AvgQueue, &t.AvgIO, &t.AvgHash, &t.MissRate, &t.Failures,
-			&t.AvgOutputWrite, &t.AvgOutputLoad, &t.AvgCacheWrite); err != nil {
+			&t.AvgOutputWrite, &t.AvgOutputLoad, &t.AvgCacheWrite,
+			&t.AvgCPU, &t.PeakRSS); err != nil {
 			return nil, err
 		}
 		all = append(all, t)
@@ -546,6 +588,9 @@ func (s *TraceStore) Bottlenecks(ctx context.Context, opts StatsOptions) (*Bottl
 		if t.Failures > 0 && len(report.FlakyTargets) < maxBottlenecksPerCategory {
 			report.FlakyTargets = append(report.FlakyTargets, t)
 		}
+		if t.AvgCPU > 0 || t.PeakRSS > 0 {
+			report.ResourceHeavy = append(report.ResourceHeavy, t)
+		}
 	}
 
 	// Sort secondary categories by their primary metric (query already sorts by avg_cmd)
@@ -571,9 +616,151 @@ func (s *TraceStore) Bottlenecks(ctx context.Context, opts StatsOptions) (*Bottl
 	sortByMissRate(report.FrequentMisses)
 	sortByFailures(report.FlakyTargets)
 
+	// Unlike the other categories every target qualifies, so only keep the top
+	sort.Slice(report.ResourceHeavy, func(i, j int) bool {
+		return report.ResourceHeavy[i].AvgCPU > report.ResourceHeavy[j].AvgCPU
+	})
+	if len(report.ResourceHeavy) > maxBottlenecksPerCategory {
+		report.ResourceHeavy = report.ResourceHeavy[:maxBottlenecksPerCategory]
+	}
+
 	return report, nil
 }
 
+// TargetCost holds the historical cost of a target used to estimate how long
+// it will take in a future build.
+type TargetCost struct {
+	Label string
+	Count int
+	// AvgMissCmd is the average command duration of runs that missed the cache.
+	AvgMissCmd   float64
+	CacheHitRate float64 // 0-1
+}
+
+// ExpectedMillis weights the command duration by the chance of a cache miss.
+func (c TargetCost) ExpectedMillis() float64 {
+	return c.AvgMissCmd * (1 - c.CacheHitRate)
+}
+
+// TargetCosts aggregates the command durations and cache hit rates of every
+// target over the most recent builds keyed by label.
+func (s *TraceStore) TargetCosts(ctx context.Context, opts StatsOptions) (map[string]TargetCost, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var conditions []string
+	if opts.Command != "" {
+		conditions = append(conditions, fmt.Sprintf("command = '%s'", sanitize(opts.Command)))
+	}
+	if opts.IsCI != nil {
+		conditions = append(conditions, fmt.Sprintf("is_ci = %t", *opts.IsCI))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`WITH recent_builds AS (
+			SELECT trace_id FROM read_parquet('%s', union_by_name=true)
+			%s
+			ORDER BY start_time_unix_millis DESC LIMIT %d
+		)
+		SELECT
+			label,
+			COUNT(*) as n,
+			COALESCE(AVG(CASE WHEN cache_result = 'CACHE_MISS' THEN command_duration_millis END), 0) as avg_miss_cmd,
+			SUM(CASE WHEN cache_result = 'CACHE_HIT' THEN 1 ELSE 0 END)::FLOAT / COUNT(*) as hit_rate
+		FROM read_parquet('%s', union_by_name=true)
+		WHERE trace_id IN (SELECT trace_id FROM recent_builds)
+		GROUP BY label`,
+		s.resolver.BuildsGlob(), where, limit, s.resolver.SpansGlob())
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		if isNoFilesError(err) {
+			return map[string]TargetCost{}, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	costs := make(map[string]TargetCost)
+	for rows.Next() {
+		var c TargetCost
+		if err := rows.Scan(&c.Label, &c.Count, &c.AvgMissCmd, &c.CacheHitRate); err != nil {
+			return nil, err
+		}
+		costs[c.Label] = c
+	}
+	return costs, rows.Err()
+}
+
+// TargetDistributions aggregates the runs of every target in the builds that
+// started within [start, end) keyed by label. The filters' Limit is ignored.
+// It also returns the number of matching builds.
+func (s *TraceStore) TargetDistributions(ctx context.Context, start, end time.Time, filters StatsOptions) (map[string]TargetDistribution, int, error) {
+	conditions := []string{
+		fmt.Sprintf("start_time_unix_millis >= %d", start.UnixMilli()),
+		fmt.Sprintf("start_time_unix_millis < %d", end.UnixMilli()),
+	}
+	if filters.Command != "" {
+		conditions = append(conditions, fmt.Sprintf("command = '%s'", sanitize(filters.Command)))
+	}
+	if filters.IsCI != nil {
+		conditions = append(conditions, fmt.Sprintf("is_ci = %t", *filters.IsCI))
+	}
+	where := "WHERE " + strings.Join(conditions, " AND ")
+
+	var buildCount int
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM read_parquet('%s', union_by_name=true) %s`,
+		s.resolver.BuildsGlob(), where)
+	if err := s.db.QueryRowContext(ctx, countQuery).Scan(&buildCount); err != nil {
+		if isNoFilesError(err) {
+			return map[string]TargetDistribution{}, 0, nil
+		}
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`WITH period_builds AS (
+			SELECT trace_id FROM read_parquet('%s', union_by_name=true)
+			%s
+		)
+		SELECT
+			label,
+			COUNT(*) as runs,
+			COUNT(CASE WHEN status = 'SUCCESS' AND cache_result = 'CACHE_MISS' THEN 1 END) as misses,
+			COALESCE(MEDIAN(CASE WHEN status = 'SUCCESS' AND cache_result = 'CACHE_MISS' THEN command_duration_millis END), 0) as median_cmd,
+			COALESCE(QUANTILE_CONT(CASE WHEN status = 'SUCCESS' AND cache_result = 'CACHE_MISS' THEN command_duration_millis END, 0.9), 0) as p90_cmd,
+			SUM(CASE WHEN cache_result = 'CACHE_HIT' THEN 1 ELSE 0 END)::FLOAT / COUNT(*) as hit_rate
+		FROM read_parquet('%s', union_by_name=true)
+		WHERE trace_id IN (SELECT trace_id FROM period_builds)
+		GROUP BY label`,
+		s.resolver.BuildsGlob(), where, s.resolver.SpansGlob())
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		if isNoFilesError(err) {
+			return map[string]TargetDistribution{}, buildCount, nil
+		}
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	distributions := make(map[string]TargetDistribution)
+	for rows.Next() {
+		var label string
+		var d TargetDistribution
+		if err := rows.Scan(&label, &d.Runs, &d.Misses, &d.MedianMillis, &d.P90Millis, &d.CacheHitRate); err != nil {
+			return nil, 0, err
+		}
+		distributions[label] = d
+	}
+	return distributions, buildCount, rows.Err()
+}
+
 // Prune deletes traces older than the given time.
 func (s *TraceStore) Prune(ctx context.Context, olderThan time.Time) (int, error) {
 	cutoffMillis := olderThan.UnixMilli()
@@ -641,6 +828,9 @@ func scanSpanRows(rows *sql.Rows) ([]SpanRow, error) {
 			&s.QueueWaitMillis, &s.HashDurationMillis, &s.CacheCheckMillis,
 			&s.CommandDurationMillis, &s.OutputWriteMillis, &s.OutputLoadMillis,
 			&s.CacheWriteMillis, &s.DepLoadMillis, &s.Tags, &s.Dependencies,
+			&s.UserCPUMillis, &s.SystemCPUMillis, &s.MaxRSSBytes,
+			&s.BlockInputOps, &s.BlockOutputOps,
+			&s.VoluntaryContextSwitches, &s.InvoluntaryContextSwitches,
 		); err != nil {
 			return nil, err
 		}
@@ -1,6 +1,7 @@
 package tracing
 
 import (
+	"bytes"
 	"context"
 	"testing"
 	"time"
@@ -364,6 +365,55 @@ func TestTraceStore_StatsFiltersByCI(t *testing.T) {
 	}
 }
 
+func TestTraceStore_TargetCosts(t *testing.T) {
+	dir := t.TempDir()
+	fs := backends.NewFileSystemCacheForTest(dir, t.TempDir())
+	writer := NewTraceWriter(fs)
+	ctx := context.Background()
+
+	now := time.Now()
+	miss := makeTestTrace("miss", now.UnixMilli(), "build")
+	hit := makeTestTrace("hit", now.Add(time.Minute).UnixMilli(), "build")
+	hit.Spans[0].CacheResult = "CACHE_HIT"
+	hit.Spans[0].CommandDurationMillis = 0
+	for _, trace := range []*BuildTrace{miss, hit} {
+		if err := writer.Write(ctx, trace); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	resolver := &PathResolver{
+		buildsBase: dir + "/traces/builds",
+		spansBase:  dir + "/traces/spans",
+	}
+	store, err := NewTraceStore(fs, resolver)
+	if err != nil {
+		t.Fatalf("NewTraceStore failed: %v", err)
+	}
+	defer store.Close()
+
+	costs, err := store.TargetCosts(ctx, StatsOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("TargetCosts failed: %v", err)
+	}
+	cost, ok := costs["//pkg:target"]
+	if !ok {
+		t.Fatalf("expected cost for //pkg:target, got %v", costs)
+	}
+	if cost.Count != 2 {
+		t.Errorf("expected count 2, got %d", cost.Count)
+	}
+	if cost.AvgMissCmd != 1500 {
+		t.Errorf("expected avg miss command 1500, got %f", cost.AvgMissCmd)
+	}
+	if cost.CacheHitRate != 0.5 {
+		t.Errorf("expected cache hit rate 0.5, got %f", cost.CacheHitRate)
+	}
+	if cost.ExpectedMillis() != 750 {
+		t.Errorf("expected 750 expected millis, got %f", cost.ExpectedMillis())
+	}
+}
+
 func TestTraceStore_Prune(t *testing.T) {
 	dir := t.TempDir()
 	fs := backends.NewFileSystemCacheForTest(dir, t.TempDir())
@@ -411,3 +461,122 @@ func TestTraceStore_Prune(t *testing.T) {
 		t.Errorf("expected new-trace, got %s", entries[0].TraceID)
 	}
 }
+
+// legacySpanRow is the span schema before resource usage columns existed.
+type legacySpanRow struct {
+	TraceID               string `parquet:"trace_id"`
+	Label                 string `parquet:"label"`
+	Package               string `parquet:"package"`
+	ChangeHash            string `parquet:"change_hash"`
+	OutputHash            string `parquet:"output_hash"`
+	Status                string `parquet:"status"`
+	CacheResult           string `parquet:"cache_result"`
+	Command               string `parquet:"command"`
+	ExitCode              int32  `parquet:"exit_code"`
+	IsTest                bool   `parquet:"is_test"`
+	StartTimeUnixMillis   int64  `parquet:"start_time_unix_millis"`
+	EndTimeUnixMillis     int64  `parquet:"end_time_unix_millis"`
+	TotalDurationMillis   int64  `parquet:"total_duration_millis"`
+	QueueWaitMillis       int64  `parquet:"queue_wait_millis"`
+	HashDurationMillis    int64  `parquet:"hash_duration_millis"`
+	CacheCheckMillis      int64  `parquet:"cache_check_millis"`
+	CommandDurationMillis int64  `parquet:"command_duration_millis"`
+	OutputWriteMillis     int64  `parquet:"output_write_millis"`
+	OutputLoadMillis      int64  `parquet:"output_load_millis"`
+	CacheWriteMillis      int64  `parquet:"cache_write_millis"`
+	DepLoadMillis         int64  `parquet:"dep_load_millis"`
+	Tags                  string `parquet:"tags"`
+	Dependencies          string `parquet:"dependencies"`
+}
+
+func writeLegacyTrace(t *testing.T, fs backends.CacheBackend, id string, startMillis int64) {
+	t.Helper()
+	ctx := context.Background()
+	date := dateStr(time.UnixMilli(startMillis))
+
+	buildsBuf, err := writeParquet([]BuildRow{makeTestTrace(id, startMillis, "build").Build})
+	if err != nil {
+		t.Fatalf("write legacy builds parquet: %v", err)
+	}
+	spansBuf, err := writeParquet([]legacySpanRow{{
+		TraceID:               id,
+		Label:                 "//pkg:target",
+		Status:                "SUCCESS",
+		CacheResult:           "CACHE_MISS",
+		StartTimeUnixMillis:   startMillis,
+		TotalDurationMillis:   2000,
+		CommandDurationMillis: 1500,
+	}})
+	if err != nil {
+		t.Fatalf("write legacy spans parquet: %v", err)
+	}
+	if err := fs.Set(ctx, tracesBuildsPath+"/"+date, id+".parquet", bytes.NewReader(buildsBuf)); err != nil {
+		t.Fatalf("store legacy builds parquet: %v", err)
+	}
+	if err := fs.Set(ctx, tracesSpansPath+"/"+date, id+".parquet", bytes.NewReader(spansBuf)); err != nil {
+		t.Fatalf("store legacy spans parquet: %v", err)
+	}
+}
+
+func TestTraceStore_ResourceUsageSchemaEvolution(t *testing.T) {
+	dir := t.TempDir()
+	fs := backends.NewFileSystemCacheForTest(dir, t.TempDir())
+	writer := NewTraceWriter(fs)
+	ctx := context.Background()
+
+	resolver := &PathResolver{
+		buildsBase: dir + "/traces/builds",
+		spansBase:  dir + "/traces/spans",
+	}
+	store, err := NewTraceStore(fs, resolver)
+	if err != nil {
+		t.Fatalf("NewTraceStore failed: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	writeLegacyTrace(t, fs, "legacy-trace", now.Add(-time.Minute).UnixMilli())
+
+	// Only legacy files: the resource columns do not exist in any file
+	legacy, err := store.FindAndLoad(ctx, "legacy-trace")
+	if err != nil {
+		t.Fatalf("FindAndLoad of legacy trace failed: %v", err)
+	}
+	if len(legacy.Spans) != 1 || legacy.Spans[0].CommandDurationMillis != 1500 {
+		t.Fatalf("expected the legacy span to load, got %+v", legacy.Spans)
+	}
+	if legacy.Spans[0].CPUMillis() != 0 || legacy.Spans[0].MaxRSSBytes != 0 {
+		t.Errorf("expected zero resource usage for legacy span, got %+v", legacy.Spans[0])
+	}
+
+	trace := makeTestTrace("new-trace", now.UnixMilli(), "build")
+	trace.Spans[0].UserCPUMillis = 1200
+	trace.Spans[0].SystemCPUMillis = 300
+	trace.Spans[0].MaxRSSBytes = 64 << 20
+	trace.Spans[0].VoluntaryContextSwitches = 42
+	if err := writer.Write(ctx, trace); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// Mixed files
+	loaded, err := store.FindAndLoad(ctx, "new-trace")
+	if err != nil {
+		t.Fatalf("FindAndLoad failed: %v", err)
+	}
+	span := loaded.Spans[0]
+	if span.CPUMillis() != 1500 || span.MaxRSSBytes != 64<<20 || span.VoluntaryContextSwitches != 42 {
+		t.Errorf("expected resource usage to round trip, got %+v", span)
+	}
+
+	report, err := store.Bottlenecks(ctx, StatsOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("Bottlenecks failed: %v", err)
+	}
+	if len(report.ResourceHeavy) != 1 {
+		t.Fatalf("expected 1 resource heavy target, got %d", len(report.ResourceHeavy))
+	}
+	// Legacy spans without resource usage do not lower the average
+	if heavy := report.ResourceHeavy[0]; heavy.AvgCPU != 1500 || heavy.PeakRSS != 64<<20 {
+		t.Errorf("expected avg cpu 1500ms and peak rss 64MB, got %+v", heavy)
+	}
+}